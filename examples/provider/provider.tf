@@ -2,3 +2,9 @@ provider "azurepim" {
   # The provider uses the DefaultAzureCredential in azidentity for authentication.
   # See docs: https://pkg.go.dev/github.com/Azure/azure-sdk-for-go/sdk/azidentity#DefaultAzureCredential
 }
+
+# Manage PIM in a sandbox tenant with the same configuration.
+provider "azurepim" {
+  alias     = "sandbox"
+  tenant_id = "00000000-0000-0000-0000-000000000000"
+}
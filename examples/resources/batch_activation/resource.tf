@@ -0,0 +1,27 @@
+terraform {
+  required_providers {
+    azurepim = {
+      source = "telenornorway/azurepim"
+    }
+  }
+}
+
+provider "azurepim" {}
+
+variable "incident_group_ids" {
+  type        = list(string)
+  description = "The object IDs of the PIM enabled groups to activate membership of during an incident."
+}
+
+resource "azurepim_batch_activation" "incident" {
+  justification = "Incident response"
+  duration      = "PT2H"
+
+  targets = concat(
+    [for id in var.incident_group_ids : { group_id = id }],
+    [
+      # Global Reader
+      { role_definition_id = "f2ef992c-3afb-46b9-b7cf-a126ee74c451" },
+    ],
+  )
+}
@@ -1,2 +1,5 @@
 # The resource can be imported using the scope and principal: `{scope}||{principal_id}`.
 terraform import azurepim_group_eligible_assignment.example "00000000-0000-0000-0000-000000000000|00000000-0000-0000-0000-000000000000"
+
+# It can also be imported using the ID of the eligibility schedule request or instance, as shown in the portal.
+terraform import azurepim_group_eligible_assignment.example "00000000-0000-0000-0000-000000000000"
@@ -0,0 +1,19 @@
+terraform {
+  required_providers {
+    azurepim = {
+      source = "telenornorway/azurepim"
+    }
+  }
+}
+
+provider "azurepim" {}
+
+variable "legacy_group_id" {
+  type        = string
+  description = "The object ID of the always-on group to migrate to PIM."
+}
+
+resource "azurepim_group_member_migration" "main" {
+  scope         = var.legacy_group_id
+  justification = "Migrated from permanent membership"
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package clients bundles the clients of the areas of Microsoft Graph and Azure Resource Manager the provider calls.
+// All of them share one credential, one HTTP pipeline per API and one set of options, so calls made by a resource
+// spanning several areas are authenticated, paced, correlated and audited the same way.
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/armpim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// Options are shared by the clients of all areas.
+type Options struct {
+	// Credential gets the tokens of all calls.
+	Credential azcore.TokenCredential
+
+	// GraphHTTPClient sends all Graph calls, whether made through the SDK or raw.
+	GraphHTTPClient *http.Client
+
+	// ARMHTTPClient sends all Azure Resource Manager calls.
+	ARMHTTPClient *http.Client
+
+	// GraphEndpoint is the Microsoft Graph endpoint, without version.
+	GraphEndpoint string
+
+	// GraphBaseURL is the versioned Microsoft Graph endpoint the calls are sent to.
+	GraphBaseURL string
+
+	// ARMEndpoint is the Azure Resource Manager endpoint.
+	ARMEndpoint string
+
+	// ARMScope is the scope of the tokens for Azure Resource Manager.
+	ARMScope string
+
+	// MaxRetries is how often a throttled or conflicting write is retried by the clients themselves.
+	MaxRetries int
+
+	// CorrelationID is sent as client-request-id on calls not sent through the Graph pipeline, empty when not
+	// configured.
+	CorrelationID string
+}
+
+// GraphScope returns the scope of the tokens for Microsoft Graph.
+func (o Options) GraphScope() string {
+	return o.GraphEndpoint + "/.default"
+}
+
+// validate returns an error naming the first option required by all clients which is missing.
+func (o Options) validate() error {
+	switch {
+	case o.Credential == nil:
+		return errors.New("no credential")
+	case o.GraphHTTPClient == nil:
+		return errors.New("no Graph HTTP client")
+	case o.ARMHTTPClient == nil:
+		return errors.New("no Azure Resource Manager HTTP client")
+	case o.GraphEndpoint == "" || o.GraphBaseURL == "":
+		return errors.New("no Graph endpoint")
+	case o.ARMEndpoint == "" || o.ARMScope == "":
+		return errors.New("no Azure Resource Manager endpoint")
+	}
+
+	return nil
+}
+
+// Graph is the client of all Graph areas. A single client serves them, as they share the SDK client.
+type Graph interface {
+	grouppim.Client
+	rolepim.Client
+	directory.Client
+	pimpolicy.Client
+}
+
+// GraphFactory creates the Graph client from the shared options.
+type GraphFactory func(Options) (Graph, error)
+
+// ARMFactory creates the Azure Resource Manager client from the shared options.
+type ARMFactory func(Options) (armpim.Client, error)
+
+// Clients are the clients of the areas the provider calls. A zero Clients has no clients, which is used while the
+// provider configuration is not known and no calls are made.
+type Clients struct {
+	// Options are the options all clients were created with.
+	Options Options
+
+	// GroupPIM performs the calls of PIM for groups.
+	GroupPIM grouppim.Client
+
+	// DirectoryRolePIM performs the calls of PIM for Microsoft Entra roles.
+	DirectoryRolePIM rolepim.Client
+
+	// Directory looks up the directory objects referenced by resources.
+	Directory directory.Client
+
+	// Policies performs the calls of role management policies.
+	Policies pimpolicy.Client
+
+	// ARMPIM performs the calls of PIM for Azure resources.
+	ARMPIM armpim.Client
+}
+
+// New creates the clients of all areas from opts.
+func New(opts Options, newGraph GraphFactory, newARM ARMFactory) (*Clients, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("invalid client options: %w", err)
+	}
+
+	graph, err := newGraph(opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create graph client: %w", err)
+	}
+
+	arm, err := newARM(opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Azure Resource Manager client: %w", err)
+	}
+
+	return &Clients{
+		Options:          opts,
+		GroupPIM:         graph,
+		DirectoryRolePIM: graph,
+		Directory:        graph,
+		Policies:         graph,
+		ARMPIM:           arm,
+	}, nil
+}
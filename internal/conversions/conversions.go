@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package conversions converts between Terraform attribute values and Microsoft Graph SDK values.
+package conversions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+)
+
+// idSeparator separates the parts of composite resource IDs.
+const idSeparator = "|"
+
+// RoleToAccessID converts a role attribute value to the accessId used by Graph.
+func RoleToAccessID(role string) (graphmodels.PrivilegedAccessGroupRelationships, error) {
+	switch role {
+	case "owner":
+		return graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, nil
+	case "member":
+		return graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, nil
+	default:
+		return 0, fmt.Errorf("invalid role: %s", role)
+	}
+}
+
+// AccessIDToRole converts an accessId returned by Graph to a role attribute value.
+func AccessIDToRole(accessId graphmodels.PrivilegedAccessGroupRelationships) (string, error) {
+	switch accessId {
+	case graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS:
+		return "owner", nil
+	case graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS:
+		return "member", nil
+	default:
+		return "", fmt.Errorf("invalid accessId: %d", accessId)
+	}
+}
+
+// GroupAssignmentID returns the ID of a group assignment, '{scope}|{principal_id}' for the member role and
+// '{scope}|{principal_id}|{role}' for other roles, so a principal eligible for both roles of a group gets two IDs.
+func GroupAssignmentID(scope, principalID, role string) string {
+	if role == "" || role == "member" {
+		return scope + idSeparator + principalID
+	}
+
+	return scope + idSeparator + principalID + idSeparator + role
+}
+
+// ParseGroupAssignmentID splits a '{scope}|{principal_id}' or '{scope}|{principal_id}|{role}' ID into its parts. The
+// role is empty when it is left out, and validated otherwise.
+func ParseGroupAssignmentID(id string) (scope, principalID, role string, err error) {
+	parts := strings.Split(id, idSeparator)
+	if len(parts) == 3 {
+		if _, err := RoleToAccessID(parts[2]); err != nil {
+			return "", "", "", err
+		}
+		role = parts[2]
+		parts = parts[:2]
+	}
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("ID must be in the format '{scope}|{principal_id}' or '{scope}|{principal_id}|{role}', got %q", id)
+	}
+
+	return parts[0], parts[1], role, nil
+}
+
+// String dereferences a string returned by the SDK, returning an empty string for nil.
+func String(v *string) string {
+	if v == nil {
+		return ""
+	}
+
+	return *v
+}
+
+// Bool dereferences a bool returned by the SDK, returning false for nil.
+func Bool(v *bool) bool {
+	return v != nil && *v
+}
+
+// Time formats a time returned by the SDK as RFC 3339, returning an empty string for nil.
+func Time(v *time.Time) string {
+	if v == nil {
+		return ""
+	}
+
+	return v.Format(time.RFC3339)
+}
+
+// IdentityID returns the object ID of the user, application or device of an identity set, in that order,
+// returning an empty string if none is set.
+func IdentityID(v graphmodels.IdentitySetable) string {
+	if v == nil {
+		return ""
+	}
+
+	for _, identity := range []graphmodels.Identityable{v.GetUser(), v.GetApplication(), v.GetDevice()} {
+		if identity != nil && identity.GetId() != nil {
+			return *identity.GetId()
+		}
+	}
+
+	return ""
+}
+
+// DurationSchedule returns a schedule starting at start and lasting duration, an ISO 8601 duration such as PT8H, as
+// requested by activations. The start is formatted as RFC 3339, the schedule starts now when it is empty.
+func DurationSchedule(start, duration string) (graphmodels.RequestScheduleable, error) {
+	d, err := serialization.ParseISODuration(duration)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse duration %q: %w", duration, err)
+	}
+
+	startDateTime := time.Now()
+	if start != "" {
+		startDateTime, err = time.Parse(time.RFC3339, start)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse start %q: %w", start, err)
+		}
+	}
+
+	expiration := graphmodels.NewExpirationPattern()
+	typ := graphmodels.AFTERDURATION_EXPIRATIONPATTERNTYPE
+	expiration.SetTypeEscaped(&typ)
+	expiration.SetDuration(d)
+
+	schedule := graphmodels.NewRequestSchedule()
+	schedule.SetStartDateTime(&startDateTime)
+	schedule.SetExpiration(expiration)
+
+	return schedule, nil
+}
+
+// ISODuration returns the length of an ISO 8601 duration such as PT8H.
+func ISODuration(duration string) (time.Duration, error) {
+	d, err := serialization.ParseISODuration(duration)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse duration %q: %w", duration, err)
+	}
+
+	return d.ToDuration()
+}
+
+// ScheduleEnd returns the end of a schedule returned by Graph, formatted as RFC 3339. Graph only returns the duration
+// of schedules expiring after a duration, so their end is computed from the start. It returns an empty string for
+// schedules without expiration.
+func ScheduleEnd(v graphmodels.RequestScheduleable) string {
+	if v == nil || v.GetExpiration() == nil {
+		return ""
+	}
+
+	expiration := v.GetExpiration()
+	if end := expiration.GetEndDateTime(); end != nil {
+		return Time(end)
+	}
+
+	start, duration := v.GetStartDateTime(), expiration.GetDuration()
+	if start == nil || duration == nil {
+		return ""
+	}
+
+	d, err := duration.ToDuration()
+	if err != nil {
+		return ""
+	}
+
+	end := start.Add(d)
+	return Time(&end)
+}
@@ -0,0 +1,243 @@
+package conversions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+)
+
+func TestRoleToAccessID(t *testing.T) {
+	tests := map[string]struct {
+		role    string
+		want    graphmodels.PrivilegedAccessGroupRelationships
+		wantErr bool
+	}{
+		"owner":   {role: "owner", want: graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS},
+		"member":  {role: "member", want: graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS},
+		"unknown": {role: "admin", wantErr: true},
+		"casing":  {role: "Member", wantErr: true},
+		"empty":   {role: "", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := RoleToAccessID(tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RoleToAccessID() error = %v, wantErr %t", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("RoleToAccessID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessIDToRole(t *testing.T) {
+	tests := map[string]struct {
+		accessId graphmodels.PrivilegedAccessGroupRelationships
+		want     string
+		wantErr  bool
+	}{
+		"owner":   {accessId: graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, want: "owner"},
+		"member":  {accessId: graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, want: "member"},
+		"unknown": {accessId: graphmodels.UNKNOWNFUTUREVALUE_PRIVILEGEDACCESSGROUPRELATIONSHIPS, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := AccessIDToRole(tt.accessId)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AccessIDToRole() error = %v, wantErr %t", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("AccessIDToRole() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGroupAssignmentID(t *testing.T) {
+	tests := map[string]struct {
+		id              string
+		wantScope       string
+		wantPrincipalID string
+		wantRole        string
+		wantErr         bool
+	}{
+		"without role":    {id: "group|principal", wantScope: "group", wantPrincipalID: "principal"},
+		"with role":       {id: "group|principal|owner", wantScope: "group", wantPrincipalID: "principal", wantRole: "owner"},
+		"missing part":    {id: "group", wantErr: true},
+		"invalid role":    {id: "group|principal|admin", wantErr: true},
+		"empty role":      {id: "group|principal|", wantErr: true},
+		"too many parts":  {id: "group|principal|member|extra", wantErr: true},
+		"empty scope":     {id: "|principal|member", wantErr: true},
+		"empty principal": {id: "group|", wantErr: true},
+		"empty":           {id: "", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			scope, principalID, role, err := ParseGroupAssignmentID(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGroupAssignmentID() error = %v, wantErr %t", err, tt.wantErr)
+			}
+
+			if scope != tt.wantScope || principalID != tt.wantPrincipalID || role != tt.wantRole {
+				t.Errorf("ParseGroupAssignmentID() = %q, %q, %q, want %q, %q, %q", scope, principalID, role, tt.wantScope, tt.wantPrincipalID, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestGroupAssignmentID(t *testing.T) {
+	if got := GroupAssignmentID("group", "principal", "member"); got != "group|principal" {
+		t.Errorf("GroupAssignmentID() = %q for the member role, want %q", got, "group|principal")
+	}
+
+	if got := GroupAssignmentID("group", "principal", "owner"); got != "group|principal|owner" {
+		t.Errorf("GroupAssignmentID() = %q for the owner role, want %q", got, "group|principal|owner")
+	}
+}
+
+func TestStringAndTime(t *testing.T) {
+	if got := String(nil); got != "" {
+		t.Errorf("String(nil) = %q", got)
+	}
+
+	v := "value"
+	if got := String(&v); got != "value" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if got := Time(nil); got != "" {
+		t.Errorf("Time(nil) = %q", got)
+	}
+
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if got := Time(&ts); got != "2024-05-01T12:00:00Z" {
+		t.Errorf("Time() = %q", got)
+	}
+}
+
+func TestIdentityID(t *testing.T) {
+	identity := func(id string) graphmodels.Identityable {
+		i := graphmodels.NewIdentity()
+		i.SetId(&id)
+		return i
+	}
+
+	user := graphmodels.NewIdentitySet()
+	user.SetUser(identity("user-id"))
+	user.SetApplication(identity("application-id"))
+
+	application := graphmodels.NewIdentitySet()
+	application.SetApplication(identity("application-id"))
+
+	tests := []struct {
+		name string
+		v    graphmodels.IdentitySetable
+		want string
+	}{
+		{name: "nil", v: nil, want: ""},
+		{name: "empty", v: graphmodels.NewIdentitySet(), want: ""},
+		{name: "user", v: user, want: "user-id"},
+		{name: "application", v: application, want: "application-id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IdentityID(tt.v); got != tt.want {
+				t.Errorf("IdentityID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestISODuration(t *testing.T) {
+	if got, err := ISODuration("P1DT2H"); err != nil || got != 26*time.Hour {
+		t.Errorf("ISODuration() = %v, %v, want 26h", got, err)
+	}
+
+	if _, err := ISODuration("8 hours"); err == nil {
+		t.Error("ISODuration() accepted a duration which is not ISO 8601")
+	}
+}
+
+func TestDurationScheduleEnd(t *testing.T) {
+	if _, err := DurationSchedule("", "8 hours"); err == nil {
+		t.Error("DurationSchedule() accepted a duration which is not ISO 8601")
+	}
+
+	if schedule, err := DurationSchedule("", "PT8H"); err != nil || time.Since(*schedule.GetStartDateTime()) > time.Minute {
+		t.Errorf("DurationSchedule() = %v, want a schedule starting now", err)
+	}
+
+	schedule, err := DurationSchedule("2024-05-01T12:00:00Z", "PT8H")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ScheduleEnd(schedule); got != "2024-05-01T20:00:00Z" {
+		t.Errorf("ScheduleEnd() = %q for a duration, want the start plus 8 hours", got)
+	}
+
+	end := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+	schedule.GetExpiration().SetEndDateTime(&end)
+	if got := ScheduleEnd(schedule); got != "2024-05-01T14:00:00Z" {
+		t.Errorf("ScheduleEnd() = %q, want the end returned by Graph", got)
+	}
+
+	if got := ScheduleEnd(graphmodels.NewRequestSchedule()); got != "" {
+		t.Errorf("ScheduleEnd() = %q for a schedule without expiration", got)
+	}
+}
+
+func FuzzGroupAssignmentID(f *testing.F) {
+	f.Add("00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000002", "owner")
+	f.Add("group", "", "member")
+	f.Add("a|b", "c", "")
+
+	f.Fuzz(func(t *testing.T, scope, principalID, role string) {
+		gotScope, gotPrincipalID, gotRole, err := ParseGroupAssignmentID(GroupAssignmentID(scope, principalID, role))
+
+		valid := scope != "" && principalID != "" && !strings.Contains(scope, idSeparator) && !strings.Contains(principalID, idSeparator) &&
+			(role == "" || role == "member" || role == "owner")
+		if valid != (err == nil) {
+			t.Fatalf("ParseGroupAssignmentID() error = %v for scope %q, principal %q and role %q", err, scope, principalID, role)
+		}
+
+		wantRole := role
+		if role == "member" {
+			wantRole = ""
+		}
+		if valid && (gotScope != scope || gotPrincipalID != principalID || gotRole != wantRole) {
+			t.Errorf("round trip = %q, %q, %q, want %q, %q, %q", gotScope, gotPrincipalID, gotRole, scope, principalID, wantRole)
+		}
+	})
+}
+
+func FuzzRoleToAccessID(f *testing.F) {
+	f.Add("owner")
+	f.Add("member")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, role string) {
+		accessId, err := RoleToAccessID(role)
+		if err != nil {
+			return
+		}
+
+		got, err := AccessIDToRole(accessId)
+		if err != nil {
+			t.Fatalf("AccessIDToRole() error = %v", err)
+		}
+
+		if got != role {
+			t.Errorf("round trip = %q, want %q", got, role)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package customtypes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestGUIDSemanticEquals(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     bool
+	}{
+		{name: "equal", old: "00000000-0000-0000-0000-00000000abcd", new: "00000000-0000-0000-0000-00000000abcd", want: true},
+		{name: "casing", old: "00000000-0000-0000-0000-00000000ABCD", new: "00000000-0000-0000-0000-00000000abcd", want: true},
+		{name: "different", old: "00000000-0000-0000-0000-00000000abcd", new: "00000000-0000-0000-0000-00000000abce", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := NewGUIDValue(tt.old).StringSemanticEquals(context.Background(), NewGUIDValue(tt.new))
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got != tt.want {
+				t.Errorf("StringSemanticEquals(%q, %q) = %t, want %t", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRFC3339SemanticEquals(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     bool
+	}{
+		{name: "equal", old: "2024-05-01T12:00:00Z", new: "2024-05-01T12:00:00Z", want: true},
+		{name: "fractional seconds", old: "2024-05-01T12:00:00Z", new: "2024-05-01T12:00:00.000Z", want: true},
+		{name: "offset", old: "2024-05-01T14:00:00+02:00", new: "2024-05-01T12:00:00Z", want: true},
+		{name: "different", old: "2024-05-01T12:00:00Z", new: "2024-05-01T12:00:01Z", want: false},
+		{name: "empty", old: "", new: "", want: true},
+		{name: "empty and time", old: "", new: "2024-05-01T12:00:00Z", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := NewRFC3339Value(tt.old).StringSemanticEquals(context.Background(), NewRFC3339Value(tt.new))
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if got != tt.want {
+				t.Errorf("StringSemanticEquals(%q, %q) = %t, want %t", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeMismatch(t *testing.T) {
+	if _, diags := NewGUIDValue("a").StringSemanticEquals(context.Background(), NewRFC3339Value("a")); !diags.HasError() {
+		t.Errorf("expected an error comparing a GUID with an RFC3339 value")
+	}
+}
+
+func TestRequiresReplaceUnlessSemanticEqual(t *testing.T) {
+	tests := []struct {
+		name        string
+		state, plan types.String
+		want        bool
+	}{
+		{name: "casing", state: types.StringValue("abcd"), plan: types.StringValue("ABCD"), want: false},
+		{name: "different", state: types.StringValue("abcd"), plan: types.StringValue("abce"), want: true},
+		{name: "unknown", state: types.StringValue("abcd"), plan: types.StringUnknown(), want: true},
+	}
+
+	// Only the nullness of the raw values matters to the plan modifier.
+	raw := tftypes.NewValue(tftypes.Object{}, map[string]tftypes.Value{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := planmodifier.StringRequest{
+				State:      tfsdk.State{Raw: raw},
+				Plan:       tfsdk.Plan{Raw: raw},
+				StateValue: tt.state,
+				PlanValue:  tt.plan,
+			}
+
+			resp := &planmodifier.StringResponse{PlanValue: tt.plan}
+			RequiresReplaceUnlessSemanticEqual(GUIDType{}).PlanModifyString(context.Background(), req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			if resp.RequiresReplace != tt.want {
+				t.Errorf("RequiresReplace = %t, want %t", resp.RequiresReplace, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package customtypes provides Terraform attribute types whose values are compared semantically, so formatting
+// differences between Terraform configuration and Microsoft Graph responses do not show up as diffs.
+package customtypes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var (
+	_ basetypes.StringTypable                    = GUIDType{}
+	_ basetypes.StringValuableWithSemanticEquals = GUID{}
+)
+
+// GUIDType is a string type for object IDs, which Graph may return in a different casing than configured.
+type GUIDType struct {
+	basetypes.StringType
+}
+
+func (t GUIDType) String() string {
+	return "customtypes.GUIDType"
+}
+
+func (t GUIDType) ValueType(ctx context.Context) attr.Value {
+	return GUID{}
+}
+
+func (t GUIDType) Equal(o attr.Type) bool {
+	other, ok := o.(GUIDType)
+	if !ok {
+		return false
+	}
+
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t GUIDType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return GUID{StringValue: in}, nil
+}
+
+func (t GUIDType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	return GUID{StringValue: stringValue}, nil
+}
+
+// GUID is an object ID. Values which only differ in casing are semantically equal.
+type GUID struct {
+	basetypes.StringValue
+}
+
+func NewGUIDNull() GUID {
+	return GUID{StringValue: basetypes.NewStringNull()}
+}
+
+func NewGUIDUnknown() GUID {
+	return GUID{StringValue: basetypes.NewStringUnknown()}
+}
+
+func NewGUIDValue(value string) GUID {
+	return GUID{StringValue: basetypes.NewStringValue(value)}
+}
+
+func (v GUID) Type(ctx context.Context) attr.Type {
+	return GUIDType{}
+}
+
+func (v GUID) Equal(o attr.Value) bool {
+	other, ok := o.(GUID)
+	if !ok {
+		return false
+	}
+
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v GUID) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(GUID)
+	if !ok {
+		diags.AddError("Semantic Equality Check Error", fmt.Sprintf("Expected value type %T, got %T.", v, newValuable))
+		return false, diags
+	}
+
+	return strings.EqualFold(v.ValueString(), newValue.ValueString()), diags
+}
@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package customtypes
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// RequiresReplaceUnlessSemanticEqual is stringplanmodifier.RequiresReplace, except that values which are semantically
+// equal according to typ do not require replacement. The framework only applies semantic equality to values returned
+// by the provider, so e.g. a configured GUID in upper case would otherwise replace a resource imported in lower case.
+func RequiresReplaceUnlessSemanticEqual(typ basetypes.StringTypable) planmodifier.String {
+	description := "If the value of this attribute changes to a semantically different value, Terraform will destroy and recreate the resource."
+
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			if req.StateValue.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() {
+				resp.RequiresReplace = true
+				return
+			}
+
+			stateValue, diags := typ.ValueFromString(ctx, req.StateValue)
+			resp.Diagnostics.Append(diags...)
+
+			planValue, diags := typ.ValueFromString(ctx, req.PlanValue)
+			resp.Diagnostics.Append(diags...)
+
+			if resp.Diagnostics.HasError() {
+				return
+			}
+
+			semanticValue, ok := stateValue.(basetypes.StringValuableWithSemanticEquals)
+			if !ok {
+				resp.RequiresReplace = true
+				return
+			}
+
+			equal, diags := semanticValue.StringSemanticEquals(ctx, planValue)
+			resp.Diagnostics.Append(diags...)
+
+			resp.RequiresReplace = !equal
+		},
+		description,
+		description,
+	)
+}
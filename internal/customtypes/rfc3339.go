@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package customtypes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var (
+	_ basetypes.StringTypable                    = RFC3339Type{}
+	_ basetypes.StringValuableWithSemanticEquals = RFC3339{}
+)
+
+// RFC3339Type is a string type for timestamps, which Graph may return with a different precision or offset.
+type RFC3339Type struct {
+	basetypes.StringType
+}
+
+func (t RFC3339Type) String() string {
+	return "customtypes.RFC3339Type"
+}
+
+func (t RFC3339Type) ValueType(ctx context.Context) attr.Value {
+	return RFC3339{}
+}
+
+func (t RFC3339Type) Equal(o attr.Type) bool {
+	other, ok := o.(RFC3339Type)
+	if !ok {
+		return false
+	}
+
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t RFC3339Type) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return RFC3339{StringValue: in}, nil
+}
+
+func (t RFC3339Type) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	return RFC3339{StringValue: stringValue}, nil
+}
+
+// RFC3339 is a timestamp formatted as RFC 3339. Values which represent the same instant are semantically equal,
+// other values, such as empty strings, are compared as strings.
+type RFC3339 struct {
+	basetypes.StringValue
+}
+
+func NewRFC3339Null() RFC3339 {
+	return RFC3339{StringValue: basetypes.NewStringNull()}
+}
+
+func NewRFC3339Unknown() RFC3339 {
+	return RFC3339{StringValue: basetypes.NewStringUnknown()}
+}
+
+func NewRFC3339Value(value string) RFC3339 {
+	return RFC3339{StringValue: basetypes.NewStringValue(value)}
+}
+
+func (v RFC3339) Type(ctx context.Context) attr.Type {
+	return RFC3339Type{}
+}
+
+func (v RFC3339) Equal(o attr.Value) bool {
+	other, ok := o.(RFC3339)
+	if !ok {
+		return false
+	}
+
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v RFC3339) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(RFC3339)
+	if !ok {
+		diags.AddError("Semantic Equality Check Error", fmt.Sprintf("Expected value type %T, got %T.", v, newValuable))
+		return false, diags
+	}
+
+	oldTime, oldErr := time.Parse(time.RFC3339Nano, v.ValueString())
+	newTime, newErr := time.Parse(time.RFC3339Nano, newValue.ValueString())
+	if oldErr != nil || newErr != nil {
+		return v.ValueString() == newValue.ValueString(), diags
+	}
+
+	return oldTime.Equal(newTime), diags
+}
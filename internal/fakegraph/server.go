@@ -0,0 +1,505 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package fakegraph provides an in-memory Microsoft Graph server implementing the subset of endpoints used by the provider,
+// so resources can be exercised end to end without a tenant or credentials.
+package fakegraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	eligibilityScheduleRequestsPath     = "/beta/identityGovernance/privilegedAccess/group/eligibilityScheduleRequests"
+	eligibilityScheduleInstancesPath    = "/beta/identityGovernance/privilegedAccess/group/eligibilityScheduleInstances"
+	assignmentScheduleInstancesPath     = "/beta/identityGovernance/privilegedAccess/group/assignmentScheduleInstances"
+	assignmentScheduleRequestsPath      = "/beta/identityGovernance/privilegedAccess/group/assignmentScheduleRequests"
+	roleManagementPolicyAssignmentsPath = "/beta/policies/roleManagementPolicyAssignments"
+	roleManagementPoliciesPath          = "/beta/policies/roleManagementPolicies/"
+	directoryObjectsPath                = "/beta/directoryObjects/"
+)
+
+// filterClauseRegex matches a single "property eq 'value'" clause of an OData filter.
+var filterClauseRegex = regexp.MustCompile(`(\w+) eq '([^']*)'`)
+
+// Server is a fake Microsoft Graph server. All state is kept in memory and is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	nextID      int
+	requests    []map[string]any
+	activations []map[string]any
+	deleted     map[string]bool
+	policyRules map[string]map[string]any
+	// policyRuleVersions is the version of each policy rule, which is its ETag.
+	policyRuleVersions map[string]int
+}
+
+// NewServer starts a fake Graph server. It is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		policyRules:        map[string]map[string]any{},
+		policyRuleVersions: map[string]int{},
+		deleted:            map[string]bool{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(eligibilityScheduleRequestsPath, s.handleEligibilityScheduleRequests)
+	mux.HandleFunc(eligibilityScheduleRequestsPath+"/", s.handleEligibilityScheduleRequestAction)
+	mux.HandleFunc(eligibilityScheduleInstancesPath, s.handleEligibilityScheduleInstances)
+	mux.HandleFunc(assignmentScheduleInstancesPath, s.handleAssignmentScheduleInstances)
+	mux.HandleFunc(assignmentScheduleRequestsPath, s.handleAssignmentScheduleRequests)
+	mux.HandleFunc(roleManagementPolicyAssignmentsPath, s.handleRoleManagementPolicyAssignments)
+	mux.HandleFunc(roleManagementPoliciesPath, s.handleRoleManagementPolicyRule)
+	mux.HandleFunc(directoryObjectsPath, s.handleDirectoryObject)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// BaseURL is the Graph beta base URL served by the fake, e.g. for the SDK request adapter.
+func (s *Server) BaseURL() string {
+	return s.URL + "/beta"
+}
+
+// PolicyID returns the ID of the role management policy the fake assigns to the role of a group.
+func PolicyID(groupID, roleDefinitionID string) string {
+	return fmt.Sprintf("Group_%s_%s", groupID, roleDefinitionID)
+}
+
+// EligibilityScheduleRequests returns a copy of all eligibility schedule requests created so far.
+func (s *Server) EligibilityScheduleRequests() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]map[string]any, 0, len(s.requests))
+	for _, r := range s.requests {
+		result = append(result, copyMap(r))
+	}
+
+	return result
+}
+
+// Activate adds an activated assignment of principalID to groupID, as if the principal activated its eligibility.
+// accessId is member or owner.
+func (s *Server) Activate(groupID, principalID, accessId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	s.activations = append(s.activations, map[string]any{
+		"@odata.type":    "#microsoft.graph.privilegedAccessGroupAssignmentScheduleInstance",
+		"id":             fmt.Sprintf("%08d-0000-0000-0000-000000000000", s.nextID),
+		"groupId":        groupID,
+		"principalId":    principalID,
+		"accessId":       accessId,
+		"assignmentType": "activated",
+		"memberType":     "direct",
+	})
+}
+
+// Activations returns a copy of all active assignments.
+func (s *Server) Activations() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]map[string]any, 0, len(s.activations))
+	for _, a := range s.activations {
+		result = append(result, copyMap(a))
+	}
+
+	return result
+}
+
+// DeleteDirectoryObject deletes the directory object with the given ID. All other directory objects exist.
+func (s *Server) DeleteDirectoryObject(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleted[id] = true
+}
+
+// PolicyRule returns the last body patched to the rule of a policy, or nil if it was never patched.
+func (s *Server) PolicyRule(policyID, ruleID string) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.policyRules[policyID+"/"+ruleID]; ok {
+		return copyMap(r)
+	}
+
+	return nil
+}
+
+// SetPolicyRule replaces the rule of a policy, like an administrator editing it in the portal.
+func (s *Server) SetPolicyRule(policyID, ruleID string, rule map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setPolicyRuleLocked(policyID+"/"+ruleID, rule)
+}
+
+func (s *Server) setPolicyRuleLocked(key string, rule map[string]any) {
+	s.policyRules[key] = copyMap(rule)
+	s.policyRuleVersions[key]++
+}
+
+// policyRuleLocked returns the rule of a policy with its ETag. Expiration is required until the expiration rule is
+// patched, like in new groups.
+func (s *Server) policyRuleLocked(policyID, ruleID string) map[string]any {
+	key := policyID + "/" + ruleID
+
+	rule, ok := s.policyRules[key]
+	if ok {
+		rule = copyMap(rule)
+	} else {
+		rule = map[string]any{
+			"@odata.type":          "#microsoft.graph.unifiedRoleManagementPolicyExpirationRule",
+			"id":                   ruleID,
+			"isExpirationRequired": true,
+			"maximumDuration":      "P365D",
+		}
+	}
+	rule["@odata.etag"] = s.policyRuleETagLocked(key)
+
+	return rule
+}
+
+func (s *Server) policyRuleETagLocked(key string) string {
+	return fmt.Sprintf(`W/"%d"`, s.policyRuleVersions[key])
+}
+
+func (s *Server) handleEligibilityScheduleRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		filter := parseFilter(r.URL.Query().Get("$filter"))
+
+		s.mu.Lock()
+		var value []map[string]any
+		for _, req := range s.requests {
+			if matches(req, filter) {
+				value = append(value, copyMap(req))
+			}
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, map[string]any{"value": value})
+	case http.MethodPost:
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		created := s.createEligibilityScheduleRequest(body)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// handleEligibilityScheduleRequestAction handles actions on a single request. Only cancel is supported.
+func (s *Server) handleEligibilityScheduleRequestAction(w http.ResponseWriter, r *http.Request) {
+	// The path is /beta/identityGovernance/privilegedAccess/group/eligibilityScheduleRequests/{id}/cancel
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, eligibilityScheduleRequestsPath+"/"), "/")
+	if len(parts) != 2 || parts[1] != "cancel" {
+		writeError(w, http.StatusNotFound, "NotFound", r.URL.Path)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, req := range s.requests {
+		if req["id"] == parts[0] {
+			req["status"] = "Canceled"
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "NotFound", parts[0])
+}
+
+// createEligibilityScheduleRequest stores body the way Graph does: requests are provisioned immediately, and replace
+// the matching provisioned requests.
+func (s *Server) createEligibilityScheduleRequest(body map[string]any) map[string]any {
+	s.nextID++
+
+	created := copyMap(body)
+	created["@odata.type"] = "#microsoft.graph.privilegedAccessGroupEligibilityScheduleRequest"
+	created["id"] = fmt.Sprintf("%08d-0000-0000-0000-000000000000", s.nextID)
+	created["createdDateTime"] = time.Now().UTC().Format(time.RFC3339)
+	created["status"] = "Provisioned"
+	created["targetScheduleId"] = created["id"]
+
+	// Removals revoke the eligibility, and updates and extensions replace its schedule, so the requests which made the
+	// principal eligible before are no longer in effect.
+	switch body["action"] {
+	case "adminRemove", "adminUpdate", "adminExtend":
+		for _, req := range s.requests {
+			if req["groupId"] == body["groupId"] && req["principalId"] == body["principalId"] && req["accessId"] == body["accessId"] {
+				req["status"] = "Revoked"
+			}
+		}
+	}
+	if body["action"] == "adminRemove" {
+		created["status"] = "Revoked"
+	}
+
+	s.requests = append(s.requests, created)
+
+	return copyMap(created)
+}
+
+// handleEligibilityScheduleInstances lists an instance for every provisioned request. All instances are direct.
+func (s *Server) handleEligibilityScheduleInstances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	filter := parseFilter(r.URL.Query().Get("$filter"))
+
+	s.mu.Lock()
+	var value []map[string]any
+	for _, req := range s.requests {
+		if req["status"] != "Provisioned" {
+			continue
+		}
+
+		instance := map[string]any{
+			"@odata.type":           "#microsoft.graph.privilegedAccessGroupEligibilityScheduleInstance",
+			"id":                    req["targetScheduleId"],
+			"eligibilityScheduleId": req["targetScheduleId"],
+			"groupId":               req["groupId"],
+			"principalId":           req["principalId"],
+			"accessId":              req["accessId"],
+			"memberType":            "direct",
+		}
+		if matches(instance, filter) {
+			value = append(value, instance)
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"value": value})
+}
+
+func (s *Server) handleAssignmentScheduleInstances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	filter := parseFilter(r.URL.Query().Get("$filter"))
+
+	s.mu.Lock()
+	var value []map[string]any
+	for _, a := range s.activations {
+		if matches(a, filter) {
+			value = append(value, copyMap(a))
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"value": value})
+}
+
+// handleAssignmentScheduleRequests only supports adminRemove, which ends the matching activations.
+func (s *Server) handleAssignmentScheduleRequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	if body["action"] != "adminRemove" {
+		writeError(w, http.StatusBadRequest, "BadRequest", fmt.Sprintf("unsupported action %v", body["action"]))
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	var remaining []map[string]any
+	for _, a := range s.activations {
+		if a["groupId"] != body["groupId"] || a["principalId"] != body["principalId"] || a["accessId"] != body["accessId"] {
+			remaining = append(remaining, a)
+		}
+	}
+	s.activations = remaining
+
+	created := copyMap(body)
+	created["@odata.type"] = "#microsoft.graph.privilegedAccessGroupAssignmentScheduleRequest"
+	created["id"] = fmt.Sprintf("%08d-0000-0000-0000-000000000000", s.nextID)
+	created["status"] = "Revoked"
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleRoleManagementPolicyAssignments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	filter := parseFilter(r.URL.Query().Get("$filter"))
+	if filter["scopeId"] == "" || filter["roleDefinitionId"] == "" {
+		writeError(w, http.StatusBadRequest, "BadRequest", "scopeId and roleDefinitionId must be filtered on")
+		return
+	}
+
+	policyID := PolicyID(filter["scopeId"], filter["roleDefinitionId"])
+
+	s.mu.Lock()
+	expirationRule := s.policyRuleLocked(policyID, "Expiration_Admin_Eligibility")
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"value": []map[string]any{{
+			"id":               policyID + "_" + filter["roleDefinitionId"],
+			"policyId":         policyID,
+			"scopeId":          filter["scopeId"],
+			"scopeType":        "Group",
+			"roleDefinitionId": filter["roleDefinitionId"],
+			"policy": map[string]any{
+				"id":    policyID,
+				"rules": []map[string]any{expirationRule},
+			},
+		}},
+	})
+}
+
+func (s *Server) handleRoleManagementPolicyRule(w http.ResponseWriter, r *http.Request) {
+	// The path is /beta/policies/roleManagementPolicies/{policyId}/rules/{ruleId}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, roleManagementPoliciesPath), "/")
+	if len(parts) != 3 || parts[1] != "rules" {
+		writeError(w, http.StatusNotFound, "NotFound", r.URL.Path)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s.mu.Lock()
+		rule := s.policyRuleLocked(parts[0], parts[2])
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, rule)
+		return
+	}
+
+	if r.Method != http.MethodPatch {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	key := parts[0] + "/" + parts[2]
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != s.policyRuleETagLocked(key) {
+		s.mu.Unlock()
+		writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "the rule was changed")
+		return
+	}
+	s.setPolicyRuleLocked(key, body)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) handleDirectoryObject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, directoryObjectsPath)
+
+	s.mu.Lock()
+	deleted := s.deleted[id]
+	s.mu.Unlock()
+
+	if deleted {
+		writeError(w, http.StatusNotFound, "Request_ResourceNotFound", fmt.Sprintf("Resource '%s' does not exist.", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"@odata.type": "#microsoft.graph.user",
+		"id":          id,
+	})
+}
+
+// parseFilter returns the values of all "property eq 'value'" clauses in filter.
+func parseFilter(filter string) map[string]string {
+	result := map[string]string{}
+	for _, m := range filterClauseRegex.FindAllStringSubmatch(filter, -1) {
+		result[m[1]] = m[2]
+	}
+
+	return result
+}
+
+func matches(v map[string]any, filter map[string]string) bool {
+	for k, want := range filter {
+		if got, _ := v[k].(string); !strings.EqualFold(got, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func copyMap(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format used by Graph.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
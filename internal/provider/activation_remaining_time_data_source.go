@@ -0,0 +1,231 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ActivationRemainingTime{}
+
+func NewActivationRemainingTime() datasource.DataSource {
+	return &ActivationRemainingTime{}
+}
+
+// ActivationRemainingTime defines the data source implementation.
+type ActivationRemainingTime struct {
+	groups    *grouppim.Service
+	roles     *rolepim.Service
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// ActivationRemainingTimeModel describes the data source data model.
+type ActivationRemainingTimeModel struct {
+	PrincipalID      customtypes.GUID    `tfsdk:"principal_id"`
+	Scope            customtypes.GUID    `tfsdk:"scope"`
+	Role             types.String        `tfsdk:"role"`
+	RoleDefinitionID types.String        `tfsdk:"role_definition_id"`
+	Active           types.Bool          `tfsdk:"active"`
+	EndDateTime      customtypes.RFC3339 `tfsdk:"end_date_time"`
+	RemainingSeconds types.Int64         `tfsdk:"remaining_seconds"`
+}
+
+func (d *ActivationRemainingTime) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_activation_remaining_time"
+}
+
+func (d *ActivationRemainingTime) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Returns when the role the user or service principal the provider authenticates as holds in a PIM enabled group or as
+Microsoft Entra role ends, so pipelines can decide whether to activate the role again or extend it before using it.
+
+When the role is held through several assignments, e.g. an activation and an assignment, the one ending last is
+reported. Directory roles are only considered when they apply to the whole tenant.
+
+It requires the following graph permissions:
+- PrivilegedAssignmentSchedule.Read.AzureADGroup for groups
+- RoleAssignmentSchedule.Read.Directory for directory roles
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "The object ID of the user or service principal the provider authenticates as.",
+				Computed:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "The group to check the role in.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("role_definition_id")),
+				},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "The role in the group, either `member` or `owner`. Defaults to `member`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("owner", "member"),
+					stringvalidator.AlsoRequires(path.MatchRoot("scope")),
+				},
+			},
+			"role_definition_id": schema.StringAttribute{
+				MarkdownDescription: "The Microsoft Entra role to check.",
+				Optional:            true,
+			},
+			"active": schema.BoolAttribute{
+				MarkdownDescription: "Whether the caller currently holds the role.",
+				Computed:            true,
+			},
+			"end_date_time": schema.StringAttribute{
+				MarkdownDescription: "When the role ends, formatted as RFC 3339. Null when the role is not active or does not expire.",
+				Computed:            true,
+				CustomType:          customtypes.RFC3339Type{},
+			},
+			"remaining_seconds": schema.Int64Attribute{
+				MarkdownDescription: "How many seconds are left until `end_date_time` when the data source is read. `0` when the role is not active, null when it does not expire.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *ActivationRemainingTime) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.groups = grouppim.NewService(pd.clients.GroupPIM)
+	d.roles = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *ActivationRemainingTime) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "remaining activation time") }()
+
+	var data ActivationRemainingTimeModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	principalID, err := d.directory.CallerObjectID(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get the object ID of the caller: "+sanitizeError(err))
+		return
+	}
+	data.PrincipalID = customtypes.NewGUIDValue(principalID)
+
+	// The ends of the active assignments of the role, empty for assignments which do not expire.
+	var ends []string
+	if groupID := data.Scope.ValueString(); groupID != "" {
+		role := data.Role.ValueString()
+		if role == "" {
+			role = "member"
+		}
+
+		assignments, err := d.groups.ListActiveAssignments(ctx, groupID, principalID)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list active assignments in the group: "+sanitizeError(err))
+			return
+		}
+
+		for _, a := range assignments {
+			if a.Role == role {
+				ends = append(ends, a.EndDateTime)
+			}
+		}
+	} else {
+		assignments, err := d.roles.ListActiveAssignments(ctx, principalID)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list active assignments of directory roles: "+sanitizeError(err))
+			return
+		}
+
+		for _, a := range assignments {
+			if strings.EqualFold(a.RoleDefinitionID, data.RoleDefinitionID.ValueString()) && a.DirectoryScopeID == "/" {
+				ends = append(ends, a.EndDateTime)
+			}
+		}
+	}
+
+	end, permanent, err := latestEnd(ends)
+	if err != nil {
+		resp.Diagnostics.AddError("Unexpected end of assignment", err.Error())
+		return
+	}
+
+	data.Active = types.BoolValue(len(ends) > 0)
+	switch {
+	case len(ends) == 0:
+		data.EndDateTime = customtypes.NewRFC3339Null()
+		data.RemainingSeconds = types.Int64Value(0)
+	case permanent:
+		data.EndDateTime = customtypes.NewRFC3339Null()
+		data.RemainingSeconds = types.Int64Null()
+	default:
+		data.EndDateTime = customtypes.NewRFC3339Value(end.Format(time.RFC3339))
+		data.RemainingSeconds = types.Int64Value(int64(max(time.Until(end), 0) / time.Second))
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// latestEnd returns the latest of ends, formatted as RFC 3339, and whether one of them is empty, i.e. does not expire.
+func latestEnd(ends []string) (time.Time, bool, error) {
+	var latest time.Time
+	for _, e := range ends {
+		if e == "" {
+			return time.Time{}, true, nil
+		}
+
+		end, err := time.Parse(time.RFC3339, e)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unable to parse end %q: %w", e, err)
+		}
+		if end.After(latest) {
+			latest = end
+		}
+	}
+
+	return latest, false, nil
+}
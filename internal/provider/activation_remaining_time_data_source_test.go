@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+func TestActivationRemainingTimeRead(t *testing.T) {
+	ctx := context.Background()
+	end := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+
+	groups := newFakeGroupEligibilityClient()
+	groups.activate("group-a", "caller-id", "instance-1")
+	groups.activations[0].SetEndDateTime(&end)
+
+	instance := graphmodels.NewUnifiedRoleAssignmentScheduleInstance()
+	instance.SetPrincipalId(toPtr("caller-id"))
+	instance.SetRoleDefinitionId(toPtr("role-1"))
+	instance.SetDirectoryScopeId(toPtr("/"))
+	instance.SetAssignmentType(toPtr("Assigned"))
+	roles := &fakeRolePIMClient{assignmentInstances: []graphmodels.UnifiedRoleAssignmentScheduleInstanceable{instance}}
+
+	d := &ActivationRemainingTime{
+		groups:    grouppim.NewService(groups),
+		roles:     rolepim.NewService(roles),
+		directory: directory.NewService(fakeDirectoryClient{caller: {"caller-id"}}),
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	tests := []struct {
+		name       string
+		config     ActivationRemainingTimeModel
+		wantActive bool
+		wantEnd    string
+		// wantRemaining is nil when remaining_seconds is null.
+		wantRemaining *int64
+	}{
+		{
+			name:          "activated member",
+			config:        ActivationRemainingTimeModel{Scope: customtypes.NewGUIDValue("group-a")},
+			wantActive:    true,
+			wantEnd:       end.Format(time.RFC3339),
+			wantRemaining: toPtr(int64(2 * time.Hour / time.Second)),
+		},
+		{
+			name:          "owner",
+			config:        ActivationRemainingTimeModel{Scope: customtypes.NewGUIDValue("group-a"), Role: types.StringValue("owner")},
+			wantRemaining: toPtr(int64(0)),
+		},
+		{
+			name:       "permanent directory role",
+			config:     ActivationRemainingTimeModel{RoleDefinitionID: types.StringValue("role-1")},
+			wantActive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configState := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			if diags := configState.Set(ctx, tt.config); diags.HasError() {
+				t.Fatalf("unable to set config: %v", diags)
+			}
+
+			resp := &datasource.ReadResponse{State: tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}}
+			d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+
+			var read ActivationRemainingTimeModel
+			resp.State.Get(ctx, &read)
+
+			if read.Active.ValueBool() != tt.wantActive {
+				t.Errorf("got active %t, want %t", read.Active.ValueBool(), tt.wantActive)
+			}
+			if read.EndDateTime.ValueString() != tt.wantEnd {
+				t.Errorf("got end %q, want %q", read.EndDateTime.ValueString(), tt.wantEnd)
+			}
+
+			switch {
+			case tt.wantRemaining == nil:
+				if !read.RemainingSeconds.IsNull() {
+					t.Errorf("got remaining_seconds %d, want null", read.RemainingSeconds.ValueInt64())
+				}
+			// Reading takes a moment, so up to a minute less than wanted is left.
+			case read.RemainingSeconds.ValueInt64() > *tt.wantRemaining || read.RemainingSeconds.ValueInt64() < *tt.wantRemaining-60:
+				t.Errorf("got remaining_seconds %d, want about %d", read.RemainingSeconds.ValueInt64(), *tt.wantRemaining)
+			}
+		})
+	}
+}
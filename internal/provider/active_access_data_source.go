@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ActiveAccess{}
+
+func NewActiveAccess() datasource.DataSource {
+	return &ActiveAccess{}
+}
+
+// ActiveAccess defines the data source implementation.
+type ActiveAccess struct {
+	groups    *grouppim.Service
+	roles     *rolepim.Service
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// ActiveAccessModel describes the data source data model.
+type ActiveAccessModel struct {
+	PrincipalID      customtypes.GUID `tfsdk:"principal_id"`
+	Scope            customtypes.GUID `tfsdk:"scope"`
+	Role             types.String     `tfsdk:"role"`
+	RoleDefinitionID types.String     `tfsdk:"role_definition_id"`
+	Active           types.Bool       `tfsdk:"active"`
+	AssignmentType   types.String     `tfsdk:"assignment_type"`
+}
+
+func (d *ActiveAccess) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_active_access"
+}
+
+func (d *ActiveAccess) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Reports whether a principal currently holds a role in a PIM enabled group or a Microsoft Entra role, either activated
+or assigned, e.g. to gate resources which need the access with a precondition.
+
+Directory roles are only considered active when they apply to the whole tenant.
+
+It requires the following graph permissions:
+- PrivilegedAssignmentSchedule.Read.AzureADGroup for groups
+- RoleAssignmentSchedule.Read.Directory for directory roles
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "The principal to check. Defaults to the user or service principal the provider authenticates as.",
+				Optional:            true,
+				Computed:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "The group to check the role in.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("role_definition_id")),
+				},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "The role in the group, either `member` or `owner`. Defaults to `member`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("owner", "member"),
+					stringvalidator.AlsoRequires(path.MatchRoot("scope")),
+				},
+			},
+			"role_definition_id": schema.StringAttribute{
+				MarkdownDescription: "The Microsoft Entra role to check.",
+				Optional:            true,
+			},
+			"active": schema.BoolAttribute{
+				MarkdownDescription: "Whether the principal currently holds the role.",
+				Computed:            true,
+			},
+			"assignment_type": schema.StringAttribute{
+				MarkdownDescription: "`Activated` when the role was activated through an eligibility, `Assigned` when it is assigned outside of PIM activation. Null when the role is not active.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *ActiveAccess) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.groups = grouppim.NewService(pd.clients.GroupPIM)
+	d.roles = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *ActiveAccess) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "check of active access") }()
+
+	var data ActiveAccessModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	principalID := data.PrincipalID.ValueString()
+	if principalID == "" {
+		var err error
+		principalID, err = d.directory.CallerObjectID(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to get the object ID of the caller: "+sanitizeError(err))
+			return
+		}
+		data.PrincipalID = customtypes.NewGUIDValue(principalID)
+	}
+
+	// The assignment types of the active assignments of the role, spelled like Graph does for directory roles. PIM for
+	// Groups spells them in lower case.
+	var assignmentTypes []string
+	if groupID := data.Scope.ValueString(); groupID != "" {
+		role := data.Role.ValueString()
+		if role == "" {
+			role = "member"
+		}
+
+		assignments, err := d.groups.ListActiveAssignments(ctx, groupID, principalID)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list active assignments in the group: "+sanitizeError(err))
+			return
+		}
+
+		for _, a := range assignments {
+			if a.Role == role && a.AssignmentType != "" {
+				assignmentTypes = append(assignmentTypes, strings.ToUpper(a.AssignmentType[:1])+a.AssignmentType[1:])
+			}
+		}
+	} else {
+		assignments, err := d.roles.ListActiveAssignments(ctx, principalID)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list active assignments of directory roles: "+sanitizeError(err))
+			return
+		}
+
+		for _, a := range assignments {
+			if strings.EqualFold(a.RoleDefinitionID, data.RoleDefinitionID.ValueString()) && a.DirectoryScopeID == "/" {
+				assignmentTypes = append(assignmentTypes, a.AssignmentType)
+			}
+		}
+	}
+
+	data.Active = types.BoolValue(len(assignmentTypes) > 0)
+	data.AssignmentType = types.StringNull()
+	if len(assignmentTypes) > 0 {
+		data.AssignmentType = types.StringValue(assignmentTypes[0])
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+func TestActiveAccessRead(t *testing.T) {
+	ctx := context.Background()
+
+	groups := newFakeGroupEligibilityClient()
+	groups.activate("group-a", "caller-id", "instance-1")
+	groups.activate("group-a", "principal-1", "instance-2")
+
+	instance := graphmodels.NewUnifiedRoleAssignmentScheduleInstance()
+	instance.SetPrincipalId(toPtr("caller-id"))
+	instance.SetRoleDefinitionId(toPtr("role-1"))
+	instance.SetDirectoryScopeId(toPtr("/"))
+	instance.SetAssignmentType(toPtr("Assigned"))
+	roles := &fakeRolePIMClient{assignmentInstances: []graphmodels.UnifiedRoleAssignmentScheduleInstanceable{instance}}
+
+	d := &ActiveAccess{
+		groups:    grouppim.NewService(groups),
+		roles:     rolepim.NewService(roles),
+		directory: directory.NewService(fakeDirectoryClient{caller: {"caller-id"}}),
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	tests := []struct {
+		name               string
+		config             ActiveAccessModel
+		wantActive         bool
+		wantAssignmentType string
+	}{
+		{
+			name:               "activated member",
+			config:             ActiveAccessModel{Scope: customtypes.NewGUIDValue("group-a")},
+			wantActive:         true,
+			wantAssignmentType: "Activated",
+		},
+		{
+			name:       "owner",
+			config:     ActiveAccessModel{Scope: customtypes.NewGUIDValue("group-a"), Role: types.StringValue("owner")},
+			wantActive: false,
+		},
+		{
+			name:               "assigned directory role",
+			config:             ActiveAccessModel{RoleDefinitionID: types.StringValue("role-1")},
+			wantActive:         true,
+			wantAssignmentType: "Assigned",
+		},
+		{
+			name:       "other directory role",
+			config:     ActiveAccessModel{RoleDefinitionID: types.StringValue("role-2")},
+			wantActive: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configState := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			if diags := configState.Set(ctx, tt.config); diags.HasError() {
+				t.Fatalf("unable to set config: %v", diags)
+			}
+
+			resp := &datasource.ReadResponse{State: tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}}
+			d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+
+			var read ActiveAccessModel
+			resp.State.Get(ctx, &read)
+
+			if read.PrincipalID.ValueString() != "caller-id" {
+				t.Errorf("got principal %s, want caller-id", read.PrincipalID.ValueString())
+			}
+			if read.Active.ValueBool() != tt.wantActive {
+				t.Errorf("got active %t, want %t", read.Active.ValueBool(), tt.wantActive)
+			}
+			if read.AssignmentType.ValueString() != tt.wantAssignmentType {
+				t.Errorf("got assignment type %q, want %q", read.AssignmentType.ValueString(), tt.wantAssignmentType)
+			}
+		})
+	}
+}
+
+func TestActiveAccessReadPages(t *testing.T) {
+	ctx := context.Background()
+
+	// The member activation of the principal is on the second page, after its owner assignments.
+	server := newTestPagedServer(t, map[string][]map[string]any{
+		"/beta/identityGovernance/privilegedAccess/group/assignmentScheduleInstances": {
+			{"id": "instance-1", "groupId": "group-a", "principalId": "caller-id", "accessId": "owner", "assignmentType": "assigned"},
+			{"id": "instance-2", "groupId": "group-a", "principalId": "caller-id", "accessId": "owner", "assignmentType": "activated"},
+			{"id": "instance-3", "groupId": "group-a", "principalId": "caller-id", "accessId": "member", "assignmentType": "activated"},
+		},
+	})
+	d := &ActiveAccess{
+		groups:    grouppim.NewService(testGraphClient(t, server, 0)),
+		directory: directory.NewService(fakeDirectoryClient{caller: {"caller-id"}}),
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, ActiveAccessModel{Scope: customtypes.NewGUIDValue("group-a")}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var read ActiveAccessModel
+	resp.State.Get(ctx, &read)
+	if !read.Active.ValueBool() || read.AssignmentType.ValueString() != "Activated" {
+		t.Errorf("got active %t with assignment type %q, want the activation on the second page", read.Active.ValueBool(), read.AssignmentType.ValueString())
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	khttp "github.com/microsoft/kiota-http-go"
+)
+
+const (
+	// minThrottleInterval is the interval between requests once Graph signals throttling for the first time.
+	minThrottleInterval = 100 * time.Millisecond
+
+	// maxThrottleInterval caps the interval between requests, so the provider never stalls completely.
+	maxThrottleInterval = 5 * time.Second
+
+	// throttleLimitWarning is the x-ms-throttle-limit-percentage from which Graph is about to throttle the tenant.
+	throttleLimitWarning = 0.8
+)
+
+// adaptiveThrottle paces all Graph requests of a provider instance. Graph throttles per tenant and application, so
+// when one request is throttled or Graph signals it is close to its limit, all concurrent requests slow down instead
+// of each of them being retried blindly. The interval halves again with every request which is not throttled.
+type adaptiveThrottle struct {
+	mu sync.Mutex
+
+	// interval is the least time between the start of two requests, zero when not throttled.
+	interval time.Duration
+
+	// next is the earliest time the next request may start.
+	next time.Time
+
+	now func() time.Time
+}
+
+func newAdaptiveThrottle() *adaptiveThrottle {
+	return &adaptiveThrottle{now: time.Now}
+}
+
+// wait blocks until the next request may be sent, or ctx is done.
+func (t *adaptiveThrottle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := t.now()
+	start := now
+	if t.next.After(start) {
+		start = t.next
+	}
+	t.next = start.Add(t.interval)
+	t.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe adapts the pace to the throttle hints of resp.
+func (t *adaptiveThrottle) observe(ctx context.Context, resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous := t.interval
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		t.interval = min(max(2*t.interval, minThrottleInterval), maxThrottleInterval)
+
+		// No request is sent before Graph asked to retry, whichever request was throttled.
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			if until := t.now().Add(retryAfterDelay(retryAfter, 0)); until.After(t.next) {
+				t.next = until
+			}
+		}
+	case rateLimitInterval(resp.Header) > 0:
+		t.interval = min(max(t.interval, rateLimitInterval(resp.Header)), maxThrottleInterval)
+	case throttleLimitPercentage(resp.Header) >= throttleLimitWarning:
+		t.interval = min(max(2*t.interval, minThrottleInterval), maxThrottleInterval)
+	default:
+		t.interval /= 2
+		if t.interval < minThrottleInterval {
+			t.interval = 0
+		}
+	}
+
+	if t.interval != previous && (t.interval == 0 || previous == 0) {
+		tflog.Info(ctx, "adapting the pace of microsoft graph requests to throttling", map[string]any{
+			"status":   resp.StatusCode,
+			"interval": t.interval.String(),
+		})
+	}
+}
+
+// rateLimitInterval spreads the requests left according to the RateLimit headers over the time until the limit resets.
+// Graph only sends them once 80% of the limit is used, zero is returned without them.
+func rateLimitInterval(header http.Header) time.Duration {
+	remaining, err := strconv.Atoi(header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return 0
+	}
+	reset, err := strconv.Atoi(header.Get("RateLimit-Reset"))
+	if err != nil || reset <= 0 {
+		return 0
+	}
+
+	return time.Duration(reset) * time.Second / time.Duration(max(remaining, 1))
+}
+
+// throttleLimitPercentage returns the x-ms-throttle-limit-percentage header, sent by Graph once the tenant used 80%
+// of its limit, where 1.0 is the limit. Zero is returned without it.
+func throttleLimitPercentage(header http.Header) float64 {
+	percentage, err := strconv.ParseFloat(header.Get("x-ms-throttle-limit-percentage"), 64)
+	if err != nil {
+		return 0
+	}
+
+	return percentage
+}
+
+// adaptiveThrottleMiddleware paces the Graph SDK. It is placed after the retry handler so every attempt is paced.
+type adaptiveThrottleMiddleware struct {
+	throttle *adaptiveThrottle
+}
+
+func (m *adaptiveThrottleMiddleware) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	if err := m.throttle.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := pipeline.Next(req, middlewareIndex)
+	if err != nil {
+		return resp, err
+	}
+
+	m.throttle.observe(req.Context(), resp)
+
+	return resp, nil
+}
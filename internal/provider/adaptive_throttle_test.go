@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveThrottleObserve(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	throttle := &adaptiveThrottle{now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	throttle.observe(ctx, &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"10"}}})
+	if throttle.interval != minThrottleInterval {
+		t.Errorf("got interval %s after a throttled request, want %s", throttle.interval, minThrottleInterval)
+	}
+	if want := now.Add(10 * time.Second); !throttle.next.Equal(want) {
+		t.Errorf("got next request at %s, want %s as asked by Retry-After", throttle.next, want)
+	}
+
+	throttle.observe(ctx, &http.Response{StatusCode: http.StatusOK, Header: http.Header{"X-Ms-Throttle-Limit-Percentage": {"0.9"}}})
+	if throttle.interval != 2*minThrottleInterval {
+		t.Errorf("got interval %s close to the limit, want %s", throttle.interval, 2*minThrottleInterval)
+	}
+
+	throttle.observe(ctx, &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Ratelimit-Remaining": {"10"}, "Ratelimit-Reset": {"30"}}})
+	if throttle.interval != 3*time.Second {
+		t.Errorf("got interval %s, want the 10 remaining requests spread over 30 seconds", throttle.interval)
+	}
+
+	for i := 0; i < 10; i++ {
+		throttle.observe(ctx, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}})
+	}
+	if throttle.interval != 0 {
+		t.Errorf("got interval %s, want no pacing once Graph stops throttling", throttle.interval)
+	}
+
+	for i := 0; i < 10; i++ {
+		throttle.observe(ctx, &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}})
+	}
+	if throttle.interval != maxThrottleInterval {
+		t.Errorf("got interval %s, want it capped at %s", throttle.interval, maxThrottleInterval)
+	}
+}
+
+func TestAdaptiveThrottleWait(t *testing.T) {
+	throttle := newAdaptiveThrottle()
+	if err := throttle.wait(context.Background()); err != nil {
+		t.Fatalf("got error %s, want no wait without throttling", err)
+	}
+
+	throttle.next = time.Now().Add(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := throttle.wait(ctx); err == nil {
+		t.Error("got no error, want the wait to end with the context")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/clients"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/armpim"
+)
+
+// armAuthorizationAPIVersion is the version of the Microsoft.Authorization API used for PIM for Azure resources.
+const armAuthorizationAPIVersion = "2020-10-01"
+
+// armClient implements the Azure Resource Manager operations with raw HTTP, as the provider only needs a few read
+// calls and does not depend on the Azure SDK for Go resource manager modules.
+type armClient struct {
+	options clients.Options
+}
+
+var _ armpim.Client = &armClient{}
+
+// newARMClient creates the Azure Resource Manager client shared by all data sources.
+func newARMClient(opts clients.Options) *armClient {
+	return &armClient{options: opts}
+}
+
+func (c *armClient) ListRoleAssignmentScheduleRequests(ctx context.Context, scope, filter string) ([]armpim.RoleAssignmentScheduleRequest, error) {
+	query := url.Values{"api-version": []string{armAuthorizationAPIVersion}}
+	if filter != "" {
+		query.Set("$filter", filter)
+	}
+
+	next := fmt.Sprintf("%s/%s/providers/Microsoft.Authorization/roleAssignmentScheduleRequests?%s", c.options.ARMEndpoint, strings.Trim(scope, "/"), query.Encode())
+
+	var requests []armpim.RoleAssignmentScheduleRequest
+	for next != "" {
+		var page struct {
+			Value    []armpim.RoleAssignmentScheduleRequest `json:"value"`
+			NextLink string                                 `json:"nextLink"`
+		}
+		if err := c.get(ctx, next, &page); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, page.Value...)
+		next = page.NextLink
+	}
+
+	return requests, nil
+}
+
+func (c *armClient) ListRoleEligibilityScheduleInstances(ctx context.Context, scope, filter string) ([]armpim.RoleEligibilityScheduleInstance, error) {
+	query := url.Values{"api-version": []string{armAuthorizationAPIVersion}}
+	if filter != "" {
+		query.Set("$filter", filter)
+	}
+
+	next := fmt.Sprintf("%s/%s/providers/Microsoft.Authorization/roleEligibilityScheduleInstances?%s", c.options.ARMEndpoint, strings.Trim(scope, "/"), query.Encode())
+
+	var instances []armpim.RoleEligibilityScheduleInstance
+	for next != "" {
+		var page struct {
+			Value    []armpim.RoleEligibilityScheduleInstance `json:"value"`
+			NextLink string                                   `json:"nextLink"`
+		}
+		if err := c.get(ctx, next, &page); err != nil {
+			return nil, err
+		}
+
+		instances = append(instances, page.Value...)
+		next = page.NextLink
+	}
+
+	return instances, nil
+}
+
+// get sends a GET request to requestURL and decodes the JSON response into v.
+func (c *armClient) get(ctx context.Context, requestURL string, v any) error {
+	t, err := c.options.Credential.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{c.options.ARMScope}})
+	if err != nil {
+		return fmt.Errorf("unable to get token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.Token))
+	if c.options.CorrelationID != "" {
+		req.Header.Set(clientRequestIDHeader, c.options.CorrelationID)
+	}
+
+	resp, err := c.options.ARMHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	recordThrottle(ctx, resp)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response from Azure Resource Manager, got %d want %d: %s", resp.StatusCode, http.StatusOK, sanitize(string(body)))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unable to parse response body: %w", err)
+	}
+
+	return nil
+}
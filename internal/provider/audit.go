@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	khttp "github.com/microsoft/kiota-http-go"
+)
+
+// auditEntry is a single line in the audit log.
+type auditEntry struct {
+	Time          string `json:"time"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Actor         string `json:"actor,omitempty"`
+	Method        string `json:"method"`
+	URL           string `json:"url"`
+	GroupID       string `json:"group_id,omitempty"`
+	PrincipalID   string `json:"principal_id,omitempty"`
+	StatusCode    int    `json:"status_code,omitempty"`
+	Result        string `json:"result"`
+	Error         string `json:"error,omitempty"`
+}
+
+// auditLog appends an entry for every mutating Graph call to a JSON lines file.
+// A nil *auditLog is valid and records nothing.
+type auditLog struct {
+	mu            sync.Mutex
+	path          string
+	correlationID string
+}
+
+func newAuditLog(path, correlationID string) *auditLog {
+	if path == "" {
+		return nil
+	}
+
+	return &auditLog{path: path, correlationID: correlationID}
+}
+
+type auditTargetKey struct{}
+
+type auditTarget struct {
+	groupID     string
+	principalID string
+}
+
+// withAuditTarget returns a context where audited calls are attributed to the given group and principal.
+func withAuditTarget(ctx context.Context, groupID, principalID string) context.Context {
+	return context.WithValue(ctx, auditTargetKey{}, auditTarget{groupID: groupID, principalID: principalID})
+}
+
+// record writes an entry for req if it is a mutating call. Failing to write the entry is returned so it can be surfaced.
+func (a *auditLog) record(ctx context.Context, req *http.Request, resp *http.Response, err error) error {
+	if a == nil || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return nil
+	}
+
+	entry := auditEntry{
+		Time:          time.Now().UTC().Format(time.RFC3339),
+		CorrelationID: a.correlationID,
+		Actor:         tokenActor(req.Header.Get("Authorization")),
+		Method:        req.Method,
+		URL:           req.URL.Path,
+		Result:        "success",
+	}
+
+	if t, ok := ctx.Value(auditTargetKey{}).(auditTarget); ok {
+		entry.GroupID = t.groupID
+		entry.PrincipalID = t.principalID
+	}
+
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+
+	switch {
+	case err != nil:
+		entry.Result = "failure"
+		entry.Error = sanitizeError(err)
+	case resp == nil || resp.StatusCode >= http.StatusBadRequest:
+		entry.Result = "failure"
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("unable to marshal audit entry: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("unable to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// tokenActor returns who a bearer token was issued to, without validating the token.
+func tokenActor(authorization string) string {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims struct {
+		UPN   string `json:"upn"`
+		AppID string `json:"appid"`
+		OID   string `json:"oid"`
+	}
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return ""
+	}
+
+	switch {
+	case claims.UPN != "":
+		return claims.UPN
+	case claims.AppID != "":
+		return claims.AppID
+	default:
+		return claims.OID
+	}
+}
+
+// auditMiddleware records mutating Graph calls made through the SDK.
+type auditMiddleware struct {
+	log *auditLog
+}
+
+func (m *auditMiddleware) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	resp, err := pipeline.Next(req, middlewareIndex)
+	if auditErr := m.log.record(req.Context(), req, resp, err); auditErr != nil && err == nil {
+		return resp, auditErr
+	}
+
+	return resp, err
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	a := newAuditLog(path, "run-1")
+
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"appid":"11111111-1111-1111-1111-111111111111"}`))
+	req := &http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: "/beta/identityGovernance/privilegedAccess/group/eligibilityScheduleRequests"},
+		Header: http.Header{"Authorization": []string{"Bearer eyJ0eXAiOiJKV1QifQ." + claims + ".sig"}},
+	}
+	ctx := withAuditTarget(context.Background(), "group-id", "principal-id")
+
+	if err := a.record(ctx, req, &http.Response{StatusCode: http.StatusCreated}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.record(ctx, req, nil, errors.New("connection reset")); err != nil {
+		t.Fatal(err)
+	}
+
+	getReq := &http.Request{Method: http.MethodGet, URL: req.URL, Header: http.Header{}}
+	if err := a.record(ctx, getReq, &http.Response{StatusCode: http.StatusOK}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d entries, want 2", len(lines))
+	}
+
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	want := auditEntry{
+		Time:          entry.Time,
+		CorrelationID: "run-1",
+		Actor:         "11111111-1111-1111-1111-111111111111",
+		Method:        http.MethodPost,
+		URL:           req.URL.Path,
+		GroupID:       "group-id",
+		PrincipalID:   "principal-id",
+		StatusCode:    http.StatusCreated,
+		Result:        "success",
+	}
+	if entry != want {
+		t.Errorf("got %+v, want %+v", entry, want)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Result != "failure" || entry.Error != "connection reset" {
+		t.Errorf("got %+v, want failed entry", entry)
+	}
+}
+
+func TestAuditLogNil(t *testing.T) {
+	var a *auditLog
+	req := &http.Request{Method: http.MethodPost, URL: &url.URL{}, Header: http.Header{}}
+	if err := a.record(context.Background(), req, nil, nil); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
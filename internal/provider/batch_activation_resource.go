@@ -0,0 +1,928 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// defaultActivationDuration is how long activations last when duration is not set.
+const defaultActivationDuration = "PT8H"
+
+// approvalPollInterval is how often create checks on the activations pending approval while it waits for them.
+var approvalPollInterval = 30 * time.Second
+
+// endedRequestStatuses are the statuses of requests which will not activate their role anymore.
+var endedRequestStatuses = []string{"Denied", "Canceled", "Revoked", "Failed", "TimedOut"}
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &BatchActivation{}
+var _ resource.ResourceWithModifyPlan = &BatchActivation{}
+
+func NewBatchActivation() resource.Resource {
+	return &BatchActivation{}
+}
+
+// BatchActivation defines the resource implementation.
+type BatchActivation struct {
+	groups    *grouppim.Service
+	roles     *rolepim.Service
+	directory *directory.Service
+	policies  *pimpolicy.Service
+	resourceSettings
+}
+
+// BatchActivationModel describes the resource data model.
+type BatchActivationModel struct {
+	Id            types.String     `tfsdk:"id"`
+	PrincipalID   customtypes.GUID `tfsdk:"principal_id"`
+	Justification types.String     `tfsdk:"justification"`
+	Duration      types.String     `tfsdk:"duration"`
+	ClampDuration types.Bool       `tfsdk:"clamp_duration"`
+	// WaitForApproval is whether create waits for the activations pending approval.
+	WaitForApproval types.Bool              `tfsdk:"wait_for_approval"`
+	Targets         []BatchActivationTarget `tfsdk:"targets"`
+	Activations     types.List              `tfsdk:"activations"`
+	Timeouts        timeouts.Value          `tfsdk:"timeouts"`
+}
+
+// BatchActivationTarget is a role to activate, either in a group or in the directory.
+type BatchActivationTarget struct {
+	GroupID          customtypes.GUID `tfsdk:"group_id"`
+	Role             types.String     `tfsdk:"role"`
+	RoleDefinitionID types.String     `tfsdk:"role_definition_id"`
+}
+
+// BatchActivationActivation is an activation requested by the resource.
+type BatchActivationActivation struct {
+	GroupID          customtypes.GUID    `tfsdk:"group_id"`
+	Role             types.String        `tfsdk:"role"`
+	RoleDefinitionID types.String        `tfsdk:"role_definition_id"`
+	RequestID        types.String        `tfsdk:"request_id"`
+	Status           types.String        `tfsdk:"status"`
+	StartDateTime    customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime      customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+// batchActivationAttributeTypes are the attributes of the elements of activations.
+var batchActivationAttributeTypes = map[string]attr.Type{
+	"group_id":           customtypes.GUIDType{},
+	"role":               types.StringType,
+	"role_definition_id": types.StringType,
+	"request_id":         types.StringType,
+	"status":             types.StringType,
+	"start_date_time":    customtypes.RFC3339Type{},
+	"end_date_time":      customtypes.RFC3339Type{},
+}
+
+// throttleTarget describes the batch in throttling warnings.
+func (m BatchActivationModel) throttleTarget() string {
+	return fmt.Sprintf("batch activation of %d roles", len(m.Targets))
+}
+
+// role returns the role of a group target, member when it is not set.
+func (t BatchActivationTarget) role() string {
+	if t.Role.ValueString() == "" {
+		return "member"
+	}
+
+	return t.Role.ValueString()
+}
+
+// groupActivation returns the activation of a, which was requested in a group.
+func groupActivation(a grouppim.Activation) BatchActivationActivation {
+	return BatchActivationActivation{
+		GroupID:          customtypes.NewGUIDValue(a.GroupID),
+		Role:             types.StringValue(a.Role),
+		RoleDefinitionID: types.StringNull(),
+		RequestID:        types.StringValue(a.RequestID),
+		Status:           types.StringValue(a.Status),
+		StartDateTime:    customtypes.NewRFC3339Value(a.StartDateTime),
+		EndDateTime:      customtypes.NewRFC3339Value(a.EndDateTime),
+	}
+}
+
+// roleActivation returns the activation of a, which was requested for a directory role.
+func roleActivation(a rolepim.Activation) BatchActivationActivation {
+	return BatchActivationActivation{
+		GroupID:          customtypes.NewGUIDNull(),
+		Role:             types.StringNull(),
+		RoleDefinitionID: types.StringValue(a.RoleDefinitionID),
+		RequestID:        types.StringValue(a.RequestID),
+		Status:           types.StringValue(a.Status),
+		StartDateTime:    customtypes.NewRFC3339Value(a.StartDateTime),
+		EndDateTime:      customtypes.NewRFC3339Value(a.EndDateTime),
+	}
+}
+
+// current returns whether a has not lapsed yet, i.e. is waiting for approval or has not reached its end. A request
+// which was just created may not show up as active assignment yet. The status of a must be refreshed, so requests
+// which were denied while pending approval are not kept forever.
+func (a BatchActivationActivation) current(now time.Time) bool {
+	if a.Status.ValueString() == grouppim.StatusPendingApproval {
+		return true
+	}
+
+	end, err := time.Parse(time.RFC3339, a.EndDateTime.ValueString())
+	return err == nil && end.After(now)
+}
+
+// description describes the role of a in diagnostics.
+func (a BatchActivationActivation) description() string {
+	if groupID := a.GroupID.ValueString(); groupID != "" {
+		return fmt.Sprintf("the %s role in group %s", a.Role.ValueString(), groupID)
+	}
+
+	return "the directory role " + a.RoleDefinitionID.ValueString()
+}
+
+func (r *BatchActivation) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_batch_activation"
+}
+
+func (r *BatchActivation) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Activates several eligible roles in PIM enabled groups and Microsoft Entra roles of the user or service principal the
+provider authenticates as in one apply, e.g. for break-glass runbooks which need several activations at once.
+
+The activations of groups and of directory roles are each requested in as few Microsoft Graph JSON batches as possible,
+so throttled tenants see few calls. A target which fails does not stop the others, and every failure is reported.
+
+Refresh removes the resource once none of its activations is active or waiting for approval anymore, so the next apply
+activates the targets again. Activations whose request is denied, canceled or revoked while it waits for approval are
+left out of the state. Create returns as soon as the activations are requested, unless wait_for_approval is set. Destroying the resource ends the activations which are still active.
+
+Directory roles are activated for the whole tenant.
+
+It requires the following graph permissions:
+- PrivilegedAssignmentSchedule.ReadWrite.AzureADGroup for groups
+- RoleAssignmentSchedule.ReadWrite.Directory for directory roles
+- RoleManagementPolicy.Read.AzureADGroup and RoleManagementPolicy.Read.Directory when clamp_duration is set
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ID of the resource is `{principal_id}|{time of activation}`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"principal_id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The object ID of the user or service principal the provider authenticates as, which the roles are activated for.",
+				CustomType:          customtypes.GUIDType{},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"justification": schema.StringAttribute{
+				MarkdownDescription: "The justification of the activations. Defaults to the `default_justification` of the provider.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"duration": schema.StringAttribute{
+				MarkdownDescription: "How long the activations last, as an ISO 8601 duration such as `PT4H`. It cannot exceed the maximum activation duration of the policies of the targets, unless `clamp_duration` is set. Increasing it extends the activations which are still active from their start, rather than activating them again, which could require approval again. Decreasing it activates the targets again. Defaults to `" + defaultActivationDuration + "`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(defaultActivationDuration),
+				Validators:          []validator.String{isoDurationValidator{}},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIf(
+						durationShortened,
+						"If the duration is shortened, Terraform will destroy and recreate the resource. A longer duration extends the active activations in place.",
+						"If the duration is shortened, Terraform will destroy and recreate the resource. A longer duration extends the active activations in place.",
+					),
+				},
+			},
+			"clamp_duration": schema.BoolAttribute{
+				MarkdownDescription: "Activate targets whose policy allows shorter activations than `duration` for the maximum their policy allows, with a warning, instead of failing to activate them. Defaults to `false`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"wait_for_approval": schema.BoolAttribute{
+				MarkdownDescription: "Wait in create until the activations which need approval are approved or denied, checking on them every 30 seconds. Denied activations fail the apply. Activations still pending approval when the create timeout passes are kept with a warning, and refresh keeps checking on them. Defaults to `false`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"targets": schema.ListNestedAttribute{
+				MarkdownDescription: "The roles to activate.",
+				Required:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.UniqueValues(),
+				},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"group_id": schema.StringAttribute{
+							MarkdownDescription: "The PIM enabled group to activate a role in.",
+							Optional:            true,
+							CustomType:          customtypes.GUIDType{},
+							Validators: []validator.String{
+								stringvalidator.ExactlyOneOf(path.MatchRelative().AtParent().AtName("role_definition_id")),
+							},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "The role to activate in the group, either `member` or `owner`. Defaults to `member`.",
+							Optional:            true,
+							Validators: []validator.String{
+								stringvalidator.OneOf("owner", "member"),
+								stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("group_id")),
+							},
+						},
+						"role_definition_id": schema.StringAttribute{
+							MarkdownDescription: "The Microsoft Entra role to activate.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"activations": schema.ListNestedAttribute{
+				MarkdownDescription: "The activations which were requested, in the order of `targets`. Targets which failed to activate are left out.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"group_id": schema.StringAttribute{
+							MarkdownDescription: "The group the role was activated in, null for directory roles.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "The role activated in the group, null for directory roles.",
+							Computed:            true,
+						},
+						"role_definition_id": schema.StringAttribute{
+							MarkdownDescription: "The Microsoft Entra role which was activated, null for groups.",
+							Computed:            true,
+						},
+						"request_id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the assignment schedule request of the activation.",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							MarkdownDescription: "The status of the request, e.g. `Provisioned` or `PendingApproval`. Refresh updates it while the request is pending approval.",
+							Computed:            true,
+						},
+						"start_date_time": schema.StringAttribute{
+							MarkdownDescription: "When the activation starts, formatted as RFC 3339.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "When the activation ends, formatted as RFC 3339. Refresh updates it while the activation is active.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(ctx),
+		},
+	}
+}
+
+// ModifyPlan checks the justification against the justification rules of the provider.
+func (r *BatchActivation) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The resource is destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	r.justificationRules.validate(ctx, req.Config, req.State, &resp.Diagnostics, path.Root("justification"))
+}
+
+func (r *BatchActivation) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	r.groups = grouppim.NewService(pd.clients.GroupPIM)
+	r.groups.SetTicketInfo(pd.ticketInfo)
+	r.roles = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	r.directory = directory.NewService(pd.clients.Directory)
+	r.policies = pimpolicy.NewService(pd.clients.Policies)
+	r.resourceSettings = newResourceSettings(pd)
+}
+
+func (r *BatchActivation) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data BatchActivationModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := data.Timeouts.Create(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	principalID, err := r.directory.CallerObjectID(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get the object ID of the caller: "+sanitizeError(err))
+		return
+	}
+	data.PrincipalID = customtypes.NewGUIDValue(principalID)
+	data.Id = types.StringValue(principalID + "|" + time.Now().UTC().Format(time.RFC3339))
+
+	ctx = withAuditTarget(ctx, "", principalID)
+	justification := justificationOrDefault(data.Justification, r.defaultJustification)
+
+	var groupActivations []grouppim.Activation
+	var roleActivations []rolepim.Activation
+	// The indexes in targets of the group and role activations, so the activations keep the order of targets.
+	var groupIndexes, roleIndexes []int
+	for i, t := range data.Targets {
+		if groupID := t.GroupID.ValueString(); groupID != "" {
+			groupActivations = append(groupActivations, grouppim.Activation{
+				GroupID:       groupID,
+				PrincipalID:   principalID,
+				Role:          t.role(),
+				Justification: justification,
+				Duration:      r.duration(ctx, data, policyTarget(groupID, t.role(), ""), &resp.Diagnostics),
+			})
+			groupIndexes = append(groupIndexes, i)
+			continue
+		}
+
+		roleActivations = append(roleActivations, rolepim.Activation{
+			RoleDefinitionID: t.RoleDefinitionID.ValueString(),
+			PrincipalID:      principalID,
+			DirectoryScopeID: "/",
+			Justification:    justification,
+			Duration:         r.duration(ctx, data, policyTarget("", "", t.RoleDefinitionID.ValueString()), &resp.Diagnostics),
+		})
+		roleIndexes = append(roleIndexes, i)
+	}
+
+	// The activations by the index of their target, nil for targets which failed to activate.
+	activated := make([]*BatchActivationActivation, len(data.Targets))
+	if len(groupActivations) > 0 {
+		results, errs := r.groups.Activate(ctx, groupActivations)
+		for j, a := range results {
+			if errs[j] != nil {
+				addActivationError(&resp.Diagnostics, fmt.Sprintf("the %s role in group %s", a.Role, a.GroupID), errs[j])
+				continue
+			}
+			activation := groupActivation(a)
+			activated[groupIndexes[j]] = &activation
+		}
+	}
+
+	if len(roleActivations) > 0 {
+		results, errs := r.roles.Activate(ctx, roleActivations)
+		for j, a := range results {
+			if errs[j] != nil {
+				addActivationError(&resp.Diagnostics, "the directory role "+a.RoleDefinitionID, errs[j])
+				continue
+			}
+			activation := roleActivation(a)
+			activated[roleIndexes[j]] = &activation
+		}
+	}
+
+	var activations []BatchActivationActivation
+	for _, a := range activated {
+		if a != nil {
+			activations = append(activations, *a)
+		}
+	}
+
+	if data.WaitForApproval.ValueBool() {
+		activations = r.waitForApproval(ctx, principalID, activations, &resp.Diagnostics)
+	}
+
+	// The activations which succeeded are saved even when others failed, so destroying the tainted resource ends them.
+	if len(activations) == 0 {
+		return
+	}
+
+	resp.Diagnostics.Append(data.setActivations(ctx, activations)...)
+
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// addActivationError adds the error of activating target to diags. Claims challenges get a diagnostic of their own, as
+// activating again only succeeds once the caller satisfies the authentication context the policy of target requires.
+func addActivationError(diags *diag.Diagnostics, target string, err error) {
+	var challenge *claimsChallengeError
+	if !errors.As(err, &challenge) {
+		diags.AddError("Activation failed", fmt.Sprintf("Unable to activate %s: %s", target, sanitizeError(err)))
+		return
+	}
+
+	detail := fmt.Sprintf("The policy of %s requires an authentication context, and Conditional Access rejected the activation because the token of the provider does not satisfy it. "+
+		"Authenticate with a method satisfying the authentication context, e.g. by requesting a token with the claims challenge below, or activate the role in the portal, which prompts for it.", target)
+	if challenge.Claims != "" {
+		detail += "\n\nClaims challenge: " + challenge.Claims
+	}
+
+	diags.AddError("Authentication context required", detail)
+}
+
+// waitForApproval refreshes the activations pending approval every approvalPollInterval, until none is pending anymore
+// or ctx is done. Activations whose request ended before it was approved are left out with an error, and those still
+// pending approval when ctx is done are kept with a warning.
+func (r *BatchActivation) waitForApproval(ctx context.Context, principalID string, activations []BatchActivationActivation, diags *diag.Diagnostics) []BatchActivationActivation {
+	for {
+		current, ended, err := r.refreshPending(ctx, principalID, activations)
+		if err != nil {
+			diags.AddWarning("Unable to wait for approval", "Unable to check on the activations pending approval, refresh checks on them instead: "+sanitizeError(err))
+			return activations
+		}
+
+		for _, a := range ended {
+			diags.AddError("Activation not approved", fmt.Sprintf("The activation of %s ended with status %s before it was approved.", a.description(), a.Status.ValueString()))
+		}
+		activations = current
+
+		pending := 0
+		for _, a := range activations {
+			if a.Status.ValueString() == grouppim.StatusPendingApproval {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return activations
+		}
+
+		tflog.Info(ctx, "waiting for activations pending approval", map[string]any{"pending": pending, "delay": approvalPollInterval.String()})
+
+		select {
+		case <-ctx.Done():
+			diags.AddWarning("Activations pending approval", fmt.Sprintf("%d activations were still pending approval when the create timeout passed. They are kept, and refresh removes those which are not approved.", pending))
+			return activations
+		case <-time.After(approvalPollInterval):
+		}
+	}
+}
+
+// refreshPending updates the status of the activations pending approval. It returns the activations whose request
+// ended without activating its role, e.g. because it was denied, apart from the others.
+func (r *BatchActivation) refreshPending(ctx context.Context, principalID string, activations []BatchActivationActivation) (current, ended []BatchActivationActivation, err error) {
+	for _, a := range activations {
+		if a.Status.ValueString() == grouppim.StatusPendingApproval {
+			status, err := r.activationStatus(ctx, principalID, a)
+			if err != nil {
+				return nil, nil, err
+			}
+			a.Status = types.StringValue(status)
+		}
+
+		if slices.Contains(endedRequestStatuses, a.Status.ValueString()) {
+			ended = append(ended, a)
+			continue
+		}
+		current = append(current, a)
+	}
+
+	return current, ended, nil
+}
+
+// activationStatus returns the current status of the request of a.
+func (r *BatchActivation) activationStatus(ctx context.Context, principalID string, a BatchActivationActivation) (string, error) {
+	if groupID := a.GroupID.ValueString(); groupID != "" {
+		return r.groups.ActivationStatus(ctx, grouppim.Activation{
+			GroupID:     groupID,
+			PrincipalID: principalID,
+			Role:        a.Role.ValueString(),
+			RequestID:   a.RequestID.ValueString(),
+			Status:      a.Status.ValueString(),
+		})
+	}
+
+	return r.roles.ActivationStatus(ctx, rolepim.Activation{
+		RoleDefinitionID: a.RoleDefinitionID.ValueString(),
+		PrincipalID:      principalID,
+		DirectoryScopeID: "/",
+		RequestID:        a.RequestID.ValueString(),
+		Status:           a.Status.ValueString(),
+	})
+}
+
+// activations returns the activations in the state.
+func (m BatchActivationModel) activations(ctx context.Context) ([]BatchActivationActivation, diag.Diagnostics) {
+	var activations []BatchActivationActivation
+	diags := m.Activations.ElementsAs(ctx, &activations, false)
+
+	return activations, diags
+}
+
+// setActivations sets the activations in the state.
+func (m *BatchActivationModel) setActivations(ctx context.Context, activations []BatchActivationActivation) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.Activations, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: batchActivationAttributeTypes}, activations)
+
+	return diags
+}
+
+// activeEndDateTimes returns the end of the active assignments of principalID matching activations, keyed by
+// activationKey. Assignments without end have an empty end.
+func (r *BatchActivation) activeEndDateTimes(ctx context.Context, principalID string, activations []BatchActivationActivation) (map[string]string, error) {
+	var groups, roles bool
+	for _, a := range activations {
+		if a.GroupID.ValueString() != "" {
+			groups = true
+		} else {
+			roles = true
+		}
+	}
+
+	result := map[string]string{}
+	if groups {
+		assignments, err := r.groups.ListActiveAssignments(ctx, "", principalID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assignments {
+			result[activationKey(a.GroupID, a.Role, "")] = a.EndDateTime
+		}
+	}
+
+	if roles {
+		assignments, err := r.roles.ListActiveAssignments(ctx, principalID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assignments {
+			if a.DirectoryScopeID == "/" {
+				result[activationKey("", "", a.RoleDefinitionID)] = a.EndDateTime
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// activationKey identifies the role of an activation, regardless of the casing of IDs.
+func activationKey(groupID, role, roleDefinitionID string) string {
+	return strings.ToLower(groupID + "|" + role + "|" + roleDefinitionID)
+}
+
+// key returns the activationKey of a.
+func (a BatchActivationActivation) key() string {
+	return activationKey(a.GroupID.ValueString(), a.Role.ValueString(), a.RoleDefinitionID.ValueString())
+}
+
+func (r *BatchActivation) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.deferredReason != "" {
+		deferResourceRead(req, resp, r.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data BatchActivationModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	activations, diags := data.activations(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	activations, ended, err := r.refreshPending(ctx, data.PrincipalID.ValueString(), activations)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to check on the activations pending approval: "+sanitizeError(err))
+		return
+	}
+	for _, a := range ended {
+		tflog.Info(ctx, "activation ended before it was approved, removing it from state", map[string]any{"request_id": a.RequestID.ValueString(), "status": a.Status.ValueString()})
+	}
+
+	active, err := r.activeEndDateTimes(ctx, data.PrincipalID.ValueString(), activations)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list active assignments: "+sanitizeError(err))
+		return
+	}
+
+	now := time.Now()
+	lapsed := true
+	for i, a := range activations {
+		if end, ok := active[a.key()]; ok {
+			activations[i].EndDateTime = customtypes.NewRFC3339Value(end)
+			lapsed = false
+			continue
+		}
+
+		if a.current(now) {
+			lapsed = false
+		}
+	}
+
+	if lapsed {
+		tflog.Info(ctx, "activations have lapsed, removing batch activation from state")
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(data.setActivations(ctx, activations)...)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *BatchActivation) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	// Only a longer duration and the timeouts can change, the other attributes require replacement.
+	var data, plan BatchActivationModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Timeouts = plan.Timeouts
+	data.ClampDuration = plan.ClampDuration
+	data.WaitForApproval = plan.WaitForApproval
+
+	if !data.Duration.Equal(plan.Duration) {
+		updateTimeout, diags := plan.Timeouts.Update(ctx, defaultOperationTimeout)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+		defer cancel()
+
+		r.extend(withAuditTarget(ctx, "", data.PrincipalID.ValueString()), &data, plan.Duration, &resp.Diagnostics)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// policyTarget returns the policy target of the role in groupID, or of the directory role roleDefinitionID.
+func policyTarget(groupID, role, roleDefinitionID string) pimpolicy.Target {
+	switch {
+	case groupID != "" && role == "owner":
+		return pimpolicy.Target{Kind: pimpolicy.TargetGroupOwner, ID: groupID}
+	case groupID != "":
+		return pimpolicy.Target{Kind: pimpolicy.TargetGroup, ID: groupID}
+	default:
+		return pimpolicy.Target{Kind: pimpolicy.TargetDirectoryRole, ID: roleDefinitionID}
+	}
+}
+
+// duration returns the duration to activate target for. It is the duration of m, unless clamp_duration is set and the
+// policy of target allows less, in which case it is the maximum of the policy and a warning is added to diags.
+func (r *BatchActivation) duration(ctx context.Context, m BatchActivationModel, target pimpolicy.Target, diags *diag.Diagnostics) string {
+	duration := m.Duration.ValueString()
+	if !m.ClampDuration.ValueBool() {
+		return duration
+	}
+
+	maximum, err := r.policies.ActivationMaximumDuration(ctx, target)
+	if err != nil {
+		diags.AddWarning("Unable to clamp activation duration", fmt.Sprintf("Unable to read the maximum activation duration of %s, activating it for %s: %s", target, duration, sanitizeError(err)))
+		return duration
+	}
+
+	requested, errRequested := conversions.ISODuration(duration)
+	allowed, errAllowed := conversions.ISODuration(maximum)
+	if errRequested != nil || errAllowed != nil || requested <= allowed {
+		return duration
+	}
+
+	diags.AddWarning("Activation duration clamped", fmt.Sprintf("The policy of %s allows activations of at most %s, so it is activated for %s instead of %s.", target, maximum, maximum, duration))
+	return maximum
+}
+
+// durationShortened requires replacement when the duration of the activations is shortened, which extending them
+// cannot do.
+func durationShortened(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	prior, errPrior := conversions.ISODuration(req.StateValue.ValueString())
+	planned, errPlanned := conversions.ISODuration(req.PlanValue.ValueString())
+
+	resp.RequiresReplace = errPrior != nil || errPlanned != nil || planned < prior
+}
+
+// extend extends the active activations of data to last duration. The duration in data is only updated when all of
+// them were extended, so the next apply retries the others.
+func (r *BatchActivation) extend(ctx context.Context, data *BatchActivationModel, duration types.String, diags *diag.Diagnostics) {
+	principalID := data.PrincipalID.ValueString()
+	planned := *data
+	planned.Duration = duration
+
+	activations, d := data.activations(ctx)
+	diags.Append(d...)
+	if diags.HasError() {
+		return
+	}
+
+	// Activations which are not active, e.g. pending approval or lapsed, cannot be extended.
+	active, err := r.activeEndDateTimes(ctx, principalID, activations)
+	if err != nil {
+		diags.AddError("Client call failed", "Unable to list active assignments: "+sanitizeError(err))
+		return
+	}
+
+	var groupActivations []grouppim.Activation
+	var roleActivations []rolepim.Activation
+	// The indexes in activations of the group and role activations to extend.
+	var groupIndexes, roleIndexes []int
+	for i, a := range activations {
+		if _, ok := active[a.key()]; !ok {
+			continue
+		}
+
+		if groupID := a.GroupID.ValueString(); groupID != "" {
+			groupActivations = append(groupActivations, grouppim.Activation{
+				GroupID:       groupID,
+				PrincipalID:   principalID,
+				Role:          a.Role.ValueString(),
+				Justification: justificationOrDefault(data.Justification, r.defaultJustification),
+				Duration:      r.duration(ctx, planned, policyTarget(groupID, a.Role.ValueString(), ""), diags),
+				StartDateTime: a.StartDateTime.ValueString(),
+			})
+			groupIndexes = append(groupIndexes, i)
+			continue
+		}
+
+		roleActivations = append(roleActivations, rolepim.Activation{
+			RoleDefinitionID: a.RoleDefinitionID.ValueString(),
+			PrincipalID:      principalID,
+			DirectoryScopeID: "/",
+			Justification:    justificationOrDefault(data.Justification, r.defaultJustification),
+			Duration:         r.duration(ctx, planned, policyTarget("", "", a.RoleDefinitionID.ValueString()), diags),
+			StartDateTime:    a.StartDateTime.ValueString(),
+		})
+		roleIndexes = append(roleIndexes, i)
+	}
+
+	extended := true
+	if len(groupActivations) > 0 {
+		results, errs := r.groups.Extend(ctx, groupActivations)
+		for j, a := range results {
+			if errs[j] != nil {
+				diags.AddError("Extension failed", fmt.Sprintf("Unable to extend the activation of the %s role in group %s: %s", a.Role, a.GroupID, sanitizeError(errs[j])))
+				extended = false
+				continue
+			}
+			activations[groupIndexes[j]] = groupActivation(a)
+		}
+	}
+
+	if len(roleActivations) > 0 {
+		results, errs := r.roles.Extend(ctx, roleActivations)
+		for j, a := range results {
+			if errs[j] != nil {
+				diags.AddError("Extension failed", fmt.Sprintf("Unable to extend the activation of the directory role %s: %s", a.RoleDefinitionID, sanitizeError(errs[j])))
+				extended = false
+				continue
+			}
+			activations[roleIndexes[j]] = roleActivation(a)
+		}
+	}
+
+	if extended {
+		data.Duration = duration
+	}
+
+	diags.Append(data.setActivations(ctx, activations)...)
+}
+
+func (r *BatchActivation) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data BatchActivationModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	principalID := data.PrincipalID.ValueString()
+	ctx = withAuditTarget(ctx, "", principalID)
+
+	activations, diags := data.activations(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Graph rejects ending activations which have already ended.
+	active, err := r.activeEndDateTimes(ctx, principalID, activations)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list active assignments: "+sanitizeError(err))
+		return
+	}
+
+	var groupActivations []grouppim.Activation
+	var roleActivations []rolepim.Activation
+	for _, a := range activations {
+		if _, ok := active[a.key()]; !ok {
+			continue
+		}
+
+		if groupID := a.GroupID.ValueString(); groupID != "" {
+			groupActivations = append(groupActivations, grouppim.Activation{GroupID: groupID, PrincipalID: principalID, Role: a.Role.ValueString()})
+			continue
+		}
+
+		roleActivations = append(roleActivations, rolepim.Activation{RoleDefinitionID: a.RoleDefinitionID.ValueString(), PrincipalID: principalID, DirectoryScopeID: "/"})
+	}
+
+	if len(groupActivations) > 0 {
+		for i, err := range r.groups.Deactivate(ctx, groupActivations) {
+			if err != nil {
+				a := groupActivations[i]
+				resp.Diagnostics.AddError("Error deleting resource", fmt.Sprintf("Unable to end the activation of the %s role in group %s: %s", a.Role, a.GroupID, sanitizeError(err)))
+			}
+		}
+	}
+
+	if len(roleActivations) > 0 {
+		for i, err := range r.roles.Deactivate(ctx, roleActivations) {
+			if err != nil {
+				resp.Diagnostics.AddError("Error deleting resource", fmt.Sprintf("Unable to end the activation of the directory role %s: %s", roleActivations[i].RoleDefinitionID, sanitizeError(err)))
+			}
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+func TestBatchActivationLifecycle(t *testing.T) {
+	ctx := context.Background()
+	groups := newFakeGroupEligibilityClient()
+	groups.activationErrs = map[string]error{
+		"group-c": errors.New("not eligible"),
+		"group-d": &claimsChallengeError{Claims: `{"access_token":{"acrs":{"essential":true,"value":"c1"}}}`, err: errors.New("got status 400")},
+	}
+	roles := &fakeRolePIMClient{}
+
+	r := &BatchActivation{
+		groups:    grouppim.NewService(groups),
+		roles:     rolepim.NewService(roles),
+		directory: directory.NewService(fakeDirectoryClient{caller: {"caller-id"}}),
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+	empty := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+
+	plan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	if diags := plan.Set(ctx, BatchActivationModel{
+		Id:            types.StringUnknown(),
+		PrincipalID:   customtypes.NewGUIDUnknown(),
+		Justification: types.StringValue("incident"),
+		Duration:      types.StringValue("PT2H"),
+		// The directory role comes first, as the activations keep the order of targets although groups are activated first.
+		Targets: []BatchActivationTarget{
+			{GroupID: customtypes.NewGUIDNull(), Role: types.StringNull(), RoleDefinitionID: types.StringValue("role-1")},
+			{GroupID: customtypes.NewGUIDValue("group-a"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+			{GroupID: customtypes.NewGUIDValue("group-b"), Role: types.StringValue("owner"), RoleDefinitionID: types.StringNull()},
+			{GroupID: customtypes.NewGUIDValue("group-c"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+			{GroupID: customtypes.NewGUIDValue("group-d"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+		},
+		Activations: types.ListUnknown(types.ObjectType{AttrTypes: batchActivationAttributeTypes}),
+		Timeouts:    nullTimeouts(),
+	}); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	createResp := &fwresource.CreateResponse{State: empty}
+	r.Create(ctx, fwresource.CreateRequest{Plan: plan}, createResp)
+	if createResp.Diagnostics.ErrorsCount() != 2 {
+		t.Fatalf("got diagnostics %v, want one error for group-c and one for group-d", createResp.Diagnostics)
+	}
+	if got := createResp.Diagnostics.Errors()[1].Summary(); got != "Authentication context required" {
+		t.Errorf("got error %q for group-d, want the claims challenge explained", got)
+	}
+
+	if groups.batches != 1 || roles.batches != 1 {
+		t.Errorf("got %d group and %d role batches, want one of each", groups.batches, roles.batches)
+	}
+	if len(groups.activations) != 2 || len(roles.assignmentInstances) != 1 {
+		t.Fatalf("got %d group and %d role activations, want 2 and 1", len(groups.activations), len(roles.assignmentInstances))
+	}
+
+	var created BatchActivationModel
+	createResp.State.Get(ctx, &created)
+	activations, _ := created.activations(ctx)
+	if len(activations) != 3 {
+		t.Fatalf("got %d activations in state, want the 3 which succeeded", len(activations))
+	}
+	if got := activations[0].RoleDefinitionID.ValueString(); got != "role-1" {
+		t.Errorf("got role definition %q first, want the directory role of the first target", got)
+	}
+	if activations[0].EndDateTime.ValueString() == "" {
+		t.Errorf("got no end_date_time for the directory role")
+	}
+	if got := activations[2].Role.ValueString(); got != "owner" {
+		t.Errorf("got role %s for group-b, want owner", got)
+	}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", readResp.Diagnostics)
+	}
+	if readResp.State.Raw.IsNull() {
+		t.Fatalf("got the resource removed, want it kept while the activations are active")
+	}
+
+	deleteResp := &fwresource.DeleteResponse{State: readResp.State}
+	r.Delete(ctx, fwresource.DeleteRequest{State: readResp.State}, deleteResp)
+	if deleteResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected delete diagnostics: %v", deleteResp.Diagnostics)
+	}
+	if len(groups.activations) != 0 || len(roles.assignmentInstances) != 0 {
+		t.Errorf("got %d group and %d role activations left, want them ended", len(groups.activations), len(roles.assignmentInstances))
+	}
+}
+
+func TestBatchActivationReadLapsed(t *testing.T) {
+	ctx := context.Background()
+
+	r := &BatchActivation{
+		groups: grouppim.NewService(newFakeGroupEligibilityClient()),
+		roles:  rolepim.NewService(&fakeRolePIMClient{}),
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+
+	activation := func(status, end string) attr.Value {
+		v, _ := types.ObjectValueFrom(ctx, batchActivationAttributeTypes, BatchActivationActivation{
+			GroupID:          customtypes.NewGUIDValue("group-a"),
+			Role:             types.StringValue("member"),
+			RoleDefinitionID: types.StringNull(),
+			RequestID:        types.StringValue("request-1"),
+			Status:           types.StringValue(status),
+			StartDateTime:    customtypes.NewRFC3339Value("2024-05-01T10:00:00Z"),
+			EndDateTime:      customtypes.NewRFC3339Value(end),
+		})
+		return v
+	}
+
+	tests := []struct {
+		name       string
+		activation attr.Value
+		removed    bool
+	}{
+		{name: "ended", activation: activation("Provisioned", "2024-05-01T12:00:00Z"), removed: true},
+		{name: "pending approval", activation: activation("PendingApproval", "2024-05-01T12:00:00Z")},
+		{name: "not listed yet", activation: activation("Provisioned", "2999-01-01T00:00:00Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			if diags := state.Set(ctx, BatchActivationModel{
+				Id:            types.StringValue("caller-id|2024-05-01T10:00:00Z"),
+				PrincipalID:   customtypes.NewGUIDValue("caller-id"),
+				Justification: types.StringNull(),
+				Duration:      types.StringValue("PT2H"),
+				Targets: []BatchActivationTarget{
+					{GroupID: customtypes.NewGUIDValue("group-a"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+				},
+				Activations: types.ListValueMust(types.ObjectType{AttrTypes: batchActivationAttributeTypes}, []attr.Value{tt.activation}),
+				Timeouts:    nullTimeouts(),
+			}); diags.HasError() {
+				t.Fatalf("unable to set state: %v", diags)
+			}
+
+			resp := &fwresource.ReadResponse{State: state}
+			r.Read(ctx, fwresource.ReadRequest{State: state}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+
+			if removed := resp.State.Raw.IsNull(); removed != tt.removed {
+				t.Errorf("got removed %t, want %t", removed, tt.removed)
+			}
+		})
+	}
+}
+
+func TestBatchActivationWaitsForApproval(t *testing.T) {
+	interval := approvalPollInterval
+	approvalPollInterval = time.Millisecond
+	t.Cleanup(func() { approvalPollInterval = interval })
+
+	groups := newFakeGroupEligibilityClient()
+	groups.approvalRequired = map[string]bool{"group-a": true, "group-b": true, "group-c": true}
+	groups.decisions = map[string]string{"group-a": grouppim.StatusProvisioned, "group-b": "Denied"}
+
+	r := &BatchActivation{
+		groups:    grouppim.NewService(groups),
+		roles:     rolepim.NewService(&fakeRolePIMClient{}),
+		directory: directory.NewService(fakeDirectoryClient{caller: {"caller-id"}}),
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(context.Background(), fwresource.SchemaRequest{}, &schemaResp)
+	empty := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), nil),
+	}
+
+	plan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	if diags := plan.Set(context.Background(), BatchActivationModel{
+		Id:              types.StringUnknown(),
+		PrincipalID:     customtypes.NewGUIDUnknown(),
+		Justification:   types.StringValue("incident"),
+		Duration:        types.StringValue("PT2H"),
+		ClampDuration:   types.BoolValue(false),
+		WaitForApproval: types.BoolValue(true),
+		Targets: []BatchActivationTarget{
+			{GroupID: customtypes.NewGUIDValue("group-a"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+			{GroupID: customtypes.NewGUIDValue("group-b"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+			{GroupID: customtypes.NewGUIDValue("group-c"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+		},
+		Activations: types.ListUnknown(types.ObjectType{AttrTypes: batchActivationAttributeTypes}),
+		Timeouts:    nullTimeouts(),
+	}); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	// Nobody decides on group-c, so create waits until its context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	createResp := &fwresource.CreateResponse{State: empty}
+	r.Create(ctx, fwresource.CreateRequest{Plan: plan}, createResp)
+	if createResp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("got diagnostics %v, want one error for the denied group-b", createResp.Diagnostics)
+	}
+	if createResp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("got diagnostics %v, want one warning for group-c pending approval", createResp.Diagnostics)
+	}
+
+	var created BatchActivationModel
+	createResp.State.Get(context.Background(), &created)
+	activations, _ := created.activations(context.Background())
+	got := map[string]string{}
+	for _, a := range activations {
+		got[a.GroupID.ValueString()] = a.Status.ValueString()
+	}
+	want := map[string]string{"group-a": grouppim.StatusProvisioned, "group-c": grouppim.StatusPendingApproval}
+	if len(got) != len(want) || got["group-a"] != want["group-a"] || got["group-c"] != want["group-c"] {
+		t.Fatalf("got activations %v, want %v", got, want)
+	}
+
+	// Refresh drops group-c once it is canceled, and keeps group-a.
+	groups.decisions["group-c"] = "Canceled"
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(context.Background(), fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", readResp.Diagnostics)
+	}
+
+	var read BatchActivationModel
+	readResp.State.Get(context.Background(), &read)
+	activations, _ = read.activations(context.Background())
+	if len(activations) != 1 || activations[0].GroupID.ValueString() != "group-a" {
+		t.Errorf("got %d activations after refresh, want only the one of group-a", len(activations))
+	}
+}
+
+func TestBatchActivationUpdateExtends(t *testing.T) {
+	ctx := context.Background()
+	groups := newFakeGroupEligibilityClient()
+	roles := &fakeRolePIMClient{}
+
+	r := &BatchActivation{
+		groups:    grouppim.NewService(groups),
+		roles:     rolepim.NewService(roles),
+		directory: directory.NewService(fakeDirectoryClient{caller: {"caller-id"}}),
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	empty := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+
+	model := BatchActivationModel{
+		Id:            types.StringUnknown(),
+		PrincipalID:   customtypes.NewGUIDUnknown(),
+		Justification: types.StringNull(),
+		Duration:      types.StringValue("PT2H"),
+		Targets: []BatchActivationTarget{
+			{GroupID: customtypes.NewGUIDValue("group-a"), Role: types.StringNull(), RoleDefinitionID: types.StringNull()},
+			{GroupID: customtypes.NewGUIDNull(), Role: types.StringNull(), RoleDefinitionID: types.StringValue("role-1")},
+		},
+		Activations: types.ListUnknown(types.ObjectType{AttrTypes: batchActivationAttributeTypes}),
+		Timeouts:    nullTimeouts(),
+	}
+
+	plan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	plan.Set(ctx, model)
+	createResp := &fwresource.CreateResponse{State: empty}
+	r.Create(ctx, fwresource.CreateRequest{Plan: plan}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected create diagnostics: %v", createResp.Diagnostics)
+	}
+
+	var created BatchActivationModel
+	createResp.State.Get(ctx, &created)
+	createdActivations, _ := created.activations(ctx)
+
+	model = created
+	model.Duration = types.StringValue("PT4H")
+	plan.Set(ctx, model)
+	updateResp := &fwresource.UpdateResponse{State: createResp.State}
+	r.Update(ctx, fwresource.UpdateRequest{State: createResp.State, Plan: plan}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected update diagnostics: %v", updateResp.Diagnostics)
+	}
+
+	if groups.batches != 2 || roles.batches != 2 {
+		t.Errorf("got %d group and %d role batches, want the activation and the extension of each", groups.batches, roles.batches)
+	}
+
+	var updated BatchActivationModel
+	updateResp.State.Get(ctx, &updated)
+	if updated.Duration.ValueString() != "PT4H" {
+		t.Errorf("got duration %s, want PT4H", updated.Duration.ValueString())
+	}
+
+	activations, _ := updated.activations(ctx)
+	for i, a := range activations {
+		start, _ := time.Parse(time.RFC3339, createdActivations[i].StartDateTime.ValueString())
+		if got, want := a.EndDateTime.ValueString(), start.Add(4*time.Hour).Format(time.RFC3339); got != want {
+			t.Errorf("got end %s for activation %d, want 4 hours after its start %s", got, i, want)
+		}
+		if a.StartDateTime.ValueString() != createdActivations[i].StartDateTime.ValueString() {
+			t.Errorf("got start %s for activation %d, want it kept", a.StartDateTime.ValueString(), i)
+		}
+	}
+}
+
+func TestDurationShortened(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		prior, planned string
+		want           bool
+	}{
+		{prior: "PT2H", planned: "PT4H", want: false},
+		{prior: "PT4H", planned: "PT2H", want: true},
+		{prior: "PT8H", planned: "P1D", want: false},
+	}
+
+	for _, tt := range tests {
+		req := planmodifier.StringRequest{StateValue: types.StringValue(tt.prior), PlanValue: types.StringValue(tt.planned)}
+		var resp stringplanmodifier.RequiresReplaceIfFuncResponse
+		durationShortened(ctx, req, &resp)
+
+		if resp.RequiresReplace != tt.want {
+			t.Errorf("durationShortened(%s, %s) = %t, want %t", tt.prior, tt.planned, resp.RequiresReplace, tt.want)
+		}
+	}
+}
+
+func TestBatchActivationClampsDuration(t *testing.T) {
+	ctx := context.Background()
+	policies := newFakePolicyClient()
+	policies.rules["owner@group-b/"+pimpolicy.RuleExpirationEndUserAssignment] = pimpolicy.Rule{ID: pimpolicy.RuleExpirationEndUserAssignment, IsExpirationRequired: true, MaximumDuration: "PT1H"}
+
+	r := &BatchActivation{policies: pimpolicy.NewService(policies)}
+
+	tests := []struct {
+		name         string
+		clamp        bool
+		target       pimpolicy.Target
+		want         string
+		wantWarnings int
+	}{
+		{name: "within maximum", clamp: true, target: policyTarget("group-a", "member", ""), want: "PT4H"},
+		{name: "above maximum", clamp: true, target: policyTarget("group-b", "owner", ""), want: "PT1H", wantWarnings: 1},
+		{name: "not clamped", target: policyTarget("group-b", "owner", ""), want: "PT4H"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			got := r.duration(ctx, BatchActivationModel{Duration: types.StringValue("PT4H"), ClampDuration: types.BoolValue(tt.clamp)}, tt.target, &diags)
+
+			if got != tt.want {
+				t.Errorf("got duration %s, want %s", got, tt.want)
+			}
+			if diags.WarningsCount() != tt.wantWarnings {
+				t.Errorf("got diagnostics %v, want %d warnings", diags, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/armpim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &CallerEligibilities{}
+
+func NewCallerEligibilities() datasource.DataSource {
+	return &CallerEligibilities{}
+}
+
+// CallerEligibilities defines the data source implementation.
+type CallerEligibilities struct {
+	groups    *grouppim.Service
+	roles     *rolepim.Service
+	resources *armpim.Service
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// CallerEligibilitiesModel describes the data source data model.
+type CallerEligibilitiesModel struct {
+	ResourceScopes []types.String                          `tfsdk:"resource_scopes"`
+	PrincipalID    customtypes.GUID                        `tfsdk:"principal_id"`
+	Groups         []CallerEligibilitiesGroupModel         `tfsdk:"groups"`
+	DirectoryRoles []CallerEligibilitiesDirectoryRoleModel `tfsdk:"directory_roles"`
+	ResourceRoles  []CallerEligibilitiesResourceRoleModel  `tfsdk:"resource_roles"`
+}
+
+// CallerEligibilitiesGroupModel describes an eligibility for a role in a group.
+type CallerEligibilitiesGroupModel struct {
+	Scope         customtypes.GUID    `tfsdk:"scope"`
+	Role          types.String        `tfsdk:"role"`
+	MemberType    types.String        `tfsdk:"member_type"`
+	StartDateTime customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime   customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+// CallerEligibilitiesDirectoryRoleModel describes an eligibility for a Microsoft Entra role.
+type CallerEligibilitiesDirectoryRoleModel struct {
+	RoleDefinitionID types.String        `tfsdk:"role_definition_id"`
+	DirectoryScopeID types.String        `tfsdk:"directory_scope_id"`
+	MemberType       types.String        `tfsdk:"member_type"`
+	StartDateTime    customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime      customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+// CallerEligibilitiesResourceRoleModel describes an eligibility for an Azure role.
+type CallerEligibilitiesResourceRoleModel struct {
+	Scope            types.String        `tfsdk:"scope"`
+	RoleDefinitionID types.String        `tfsdk:"role_definition_id"`
+	MemberType       types.String        `tfsdk:"member_type"`
+	StartDateTime    customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime      customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+func (d *CallerEligibilities) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_caller_eligibilities"
+}
+
+func (d *CallerEligibilities) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists what the user or service principal the provider authenticates as is eligible for: roles in PIM enabled groups,
+Microsoft Entra roles, and Azure roles at the given resource scopes. Use it to check what a pipeline can activate
+before it tries to.
+
+Eligibilities the caller holds through membership of a group are included, with ` + "`member_type`" + ` telling them apart.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.Read.AzureADGroup
+- RoleEligibilitySchedule.Read.Directory
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"resource_scopes": schema.ListAttribute{
+				MarkdownDescription: "The Azure resource scopes to list eligible Azure roles at and below, e.g. `/subscriptions/{id}`. Azure roles are not listed when not set, as Azure Resource Manager cannot list them across the tenant.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "The object ID of the user or service principal the provider authenticates as.",
+				Computed:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"groups": schema.ListNestedAttribute{
+				MarkdownDescription: "The roles in groups the caller is eligible for, sorted by group and role.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The group.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "Either `member` or `owner`.",
+							Computed:            true,
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "`direct` when the caller is eligible itself, `group` when it is eligible through a group.",
+							Computed:            true,
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "Empty for permanent eligibilities.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+			"directory_roles": schema.ListNestedAttribute{
+				MarkdownDescription: "The Microsoft Entra roles the caller is eligible for, sorted by role and scope.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"role_definition_id": schema.StringAttribute{
+							Computed: true,
+						},
+						"directory_scope_id": schema.StringAttribute{
+							MarkdownDescription: "The scope of the role, `/` for the whole tenant.",
+							Computed:            true,
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "`Direct` when the caller is eligible itself, `Group` when it is eligible through a group.",
+							Computed:            true,
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "Empty for permanent eligibilities.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+			"resource_roles": schema.ListNestedAttribute{
+				MarkdownDescription: "The Azure roles the caller is eligible for at and below `resource_scopes`, sorted by scope and role.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The resource the role applies to, e.g. `/subscriptions/{id}`.",
+							Computed:            true,
+						},
+						"role_definition_id": schema.StringAttribute{
+							MarkdownDescription: "The resource ID of the role definition.",
+							Computed:            true,
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "`Direct`, `Group`, or `Inherited` when the eligibility is at a parent scope.",
+							Computed:            true,
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "Empty for permanent eligibilities.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *CallerEligibilities) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.groups = grouppim.NewService(pd.clients.GroupPIM)
+	d.roles = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	d.resources = armpim.NewService(pd.clients.ARMPIM)
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *CallerEligibilities) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of the caller's eligibilities") }()
+
+	var data CallerEligibilitiesModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	callerID, err := d.directory.CallerObjectID(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get the object ID of the caller: "+sanitizeError(err))
+		return
+	}
+	data.PrincipalID = customtypes.NewGUIDValue(callerID)
+
+	groups, err := d.groups.ListEligibleAssignments(ctx, "", callerID)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list eligible assignments in groups: "+sanitizeError(err))
+		return
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].GroupID != groups[j].GroupID {
+			return groups[i].GroupID < groups[j].GroupID
+		}
+		return groups[i].Role < groups[j].Role
+	})
+
+	data.Groups = []CallerEligibilitiesGroupModel{}
+	for _, a := range groups {
+		data.Groups = append(data.Groups, CallerEligibilitiesGroupModel{
+			Scope:         customtypes.NewGUIDValue(a.GroupID),
+			Role:          types.StringValue(a.Role),
+			MemberType:    types.StringValue(a.MemberType),
+			StartDateTime: customtypes.NewRFC3339Value(a.StartDateTime),
+			EndDateTime:   customtypes.NewRFC3339Value(a.EndDateTime),
+		})
+	}
+
+	roles, err := d.roles.ListEligibilities(ctx, callerID)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list eligible directory roles: "+sanitizeError(err))
+		return
+	}
+
+	sort.SliceStable(roles, func(i, j int) bool {
+		if roles[i].RoleDefinitionID != roles[j].RoleDefinitionID {
+			return roles[i].RoleDefinitionID < roles[j].RoleDefinitionID
+		}
+		return roles[i].DirectoryScopeID < roles[j].DirectoryScopeID
+	})
+
+	data.DirectoryRoles = []CallerEligibilitiesDirectoryRoleModel{}
+	for _, e := range roles {
+		data.DirectoryRoles = append(data.DirectoryRoles, CallerEligibilitiesDirectoryRoleModel{
+			RoleDefinitionID: types.StringValue(e.RoleDefinitionID),
+			DirectoryScopeID: types.StringValue(e.DirectoryScopeID),
+			MemberType:       types.StringValue(e.MemberType),
+			StartDateTime:    customtypes.NewRFC3339Value(e.StartDateTime),
+			EndDateTime:      customtypes.NewRFC3339Value(e.EndDateTime),
+		})
+	}
+
+	// An eligibility below one scope is also below every parent scope given, so it is only listed once.
+	seen := map[string]bool{}
+	var resources []armpim.Eligibility
+	for _, scope := range data.ResourceScopes {
+		eligibilities, err := d.resources.ListCallerEligibilities(ctx, scope.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list eligible Azure roles: "+sanitizeError(err))
+			return
+		}
+
+		for _, e := range eligibilities {
+			if !seen[e.ID] {
+				seen[e.ID] = true
+				resources = append(resources, e)
+			}
+		}
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Scope != resources[j].Scope {
+			return resources[i].Scope < resources[j].Scope
+		}
+		return resources[i].RoleDefinitionID < resources[j].RoleDefinitionID
+	})
+
+	data.ResourceRoles = []CallerEligibilitiesResourceRoleModel{}
+	for _, e := range resources {
+		data.ResourceRoles = append(data.ResourceRoles, CallerEligibilitiesResourceRoleModel{
+			Scope:            types.StringValue(e.Scope),
+			RoleDefinitionID: types.StringValue(e.RoleDefinitionID),
+			MemberType:       types.StringValue(e.MemberType),
+			StartDateTime:    customtypes.NewRFC3339Value(e.StartDateTime),
+			EndDateTime:      customtypes.NewRFC3339Value(e.EndDateTime),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
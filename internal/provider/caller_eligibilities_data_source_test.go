@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/armpim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// fakeARMPIMClient returns the role eligibility schedule instances under the requested scope, ignoring the filter.
+type fakeARMPIMClient struct {
+	eligibilities map[string][]armpim.RoleEligibilityScheduleInstance
+}
+
+func (f *fakeARMPIMClient) ListRoleAssignmentScheduleRequests(ctx context.Context, scope, filter string) ([]armpim.RoleAssignmentScheduleRequest, error) {
+	return nil, nil
+}
+
+func (f *fakeARMPIMClient) ListRoleEligibilityScheduleInstances(ctx context.Context, scope, filter string) ([]armpim.RoleEligibilityScheduleInstance, error) {
+	return f.eligibilities[scope], nil
+}
+
+func TestCallerEligibilitiesRead(t *testing.T) {
+	ctx := context.Background()
+
+	groupClient := newFakeGroupEligibilityClient()
+	groups := grouppim.NewService(groupClient)
+	for _, a := range []grouppim.EligibleAssignment{
+		{GroupID: "group-b", PrincipalID: "caller-id", Role: "member"},
+		{GroupID: "group-a", PrincipalID: "caller-id", Role: "owner"},
+		{GroupID: "group-a", PrincipalID: "other-id", Role: "member"},
+	} {
+		if _, err := groups.CreateEligibleAssignment(ctx, a); err != nil {
+			t.Fatalf("unable to create eligible assignment: %v", err)
+		}
+	}
+
+	instance := graphmodels.NewUnifiedRoleEligibilityScheduleInstance()
+	instance.SetId(toPtr("instance-1"))
+	instance.SetRoleDefinitionId(toPtr("role-1"))
+	instance.SetPrincipalId(toPtr("caller-id"))
+	instance.SetDirectoryScopeId(toPtr("/"))
+	instance.SetMemberType(toPtr("Direct"))
+	roleClient := &fakeRolePIMClient{eligibilityInstances: []graphmodels.UnifiedRoleEligibilityScheduleInstanceable{instance}}
+
+	// The eligibility in rg-1 is listed at both scopes, but must only be returned once.
+	rg := armpim.RoleEligibilityScheduleInstance{ID: "eligibility-rg", Properties: armpim.RoleEligibilityScheduleInstanceProperties{
+		Scope: "/subscriptions/sub-1/resourceGroups/rg-1", RoleDefinitionID: "reader", MemberType: "Group",
+	}}
+	sub := armpim.RoleEligibilityScheduleInstance{ID: "eligibility-sub", Properties: armpim.RoleEligibilityScheduleInstanceProperties{
+		Scope: "/subscriptions/sub-1", RoleDefinitionID: "contributor", MemberType: "Direct",
+	}}
+	armClient := &fakeARMPIMClient{eligibilities: map[string][]armpim.RoleEligibilityScheduleInstance{
+		"/subscriptions/sub-1":                     {rg, sub},
+		"/subscriptions/sub-1/resourceGroups/rg-1": {rg},
+	}}
+
+	d := &CallerEligibilities{
+		groups:    groups,
+		roles:     rolepim.NewService(roleClient),
+		resources: armpim.NewService(armClient),
+		directory: directory.NewService(fakeDirectoryClient{caller: {"caller-id"}}),
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, CallerEligibilitiesModel{
+		ResourceScopes: []types.String{
+			types.StringValue("/subscriptions/sub-1"),
+			types.StringValue("/subscriptions/sub-1/resourceGroups/rg-1"),
+		},
+	}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var read CallerEligibilitiesModel
+	resp.State.Get(ctx, &read)
+
+	if got := read.PrincipalID.ValueString(); got != "caller-id" {
+		t.Errorf("got principal_id %s, want caller-id", got)
+	}
+
+	if len(read.Groups) != 2 {
+		t.Fatalf("got %d groups, want the eligibilities of the caller in group-a and group-b", len(read.Groups))
+	}
+	if got := read.Groups[0].Scope.ValueString() + "/" + read.Groups[0].Role.ValueString(); got != "group-a/owner" {
+		t.Errorf("got first group %s, want group-a/owner", got)
+	}
+
+	if want := "principalId eq 'caller-id'"; roleClient.filter != want {
+		t.Errorf("got filter %q, want %q", roleClient.filter, want)
+	}
+	if len(read.DirectoryRoles) != 1 || read.DirectoryRoles[0].RoleDefinitionID.ValueString() != "role-1" {
+		t.Errorf("got directory roles %v, want role-1", read.DirectoryRoles)
+	}
+
+	if len(read.ResourceRoles) != 2 {
+		t.Fatalf("got %d resource roles, want 2", len(read.ResourceRoles))
+	}
+	if got := read.ResourceRoles[0].RoleDefinitionID.ValueString(); got != "contributor" {
+		t.Errorf("got first resource role %s, want contributor at the subscription", got)
+	}
+}
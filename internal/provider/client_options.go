@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	khttp "github.com/microsoft/kiota-http-go"
+)
+
+const (
+	// defaultGraphMaxRetries and defaultGraphRetryDelay are the defaults of the retry handler of the Graph SDK.
+	defaultGraphMaxRetries = 3
+	defaultGraphRetryDelay = 3 * time.Second
+
+	// maxGraphRetries is the most retries the retry handler of the Graph SDK allows.
+	maxGraphRetries = 10
+)
+
+// clientOptions tunes the HTTP clients of the Graph SDK and of the credentials. Zero values keep the SDK defaults.
+type clientOptions struct {
+	// timeout bounds a Graph call, including its retries.
+	timeout time.Duration
+
+	// tryTimeout bounds every single attempt of a Graph call or token request.
+	tryTimeout time.Duration
+
+	// maxRetries is how often a Graph call or token request is retried, nil for the default.
+	maxRetries *int
+
+	// retryDelay is the delay between retries of Graph calls which do not get a Retry-After header.
+	retryDelay time.Duration
+
+	// disableCompression stops request bodies sent to Graph from being compressed.
+	disableCompression bool
+}
+
+// applyMiddleware replaces the retry handler in the default middleware of the Graph SDK according to o, drops the
+// compression handler when disabled, and appends the per attempt timeout, so every retry gets its own timeout.
+func (o clientOptions) applyMiddleware(middleware []khttp.Middleware) []khttp.Middleware {
+	result := make([]khttp.Middleware, 0, len(middleware)+1)
+	for _, m := range middleware {
+		switch m.(type) {
+		case *khttp.RetryHandler:
+			if o.maxRetries != nil || o.retryDelay != 0 {
+				m = khttp.NewRetryHandlerWithOptions(o.retryHandlerOptions())
+			}
+		case *khttp.CompressionHandler:
+			if o.disableCompression {
+				continue
+			}
+		}
+		result = append(result, m)
+	}
+
+	if o.tryTimeout != 0 {
+		result = append(result, &tryTimeoutMiddleware{timeout: o.tryTimeout})
+	}
+
+	return result
+}
+
+func (o clientOptions) retryHandlerOptions() khttp.RetryHandlerOptions {
+	// The retry handler treats zero retries as its default, so no retries are configured by refusing every retry.
+	retry := o.maxRetries == nil || *o.maxRetries > 0
+	options := khttp.RetryHandlerOptions{
+		MaxRetries:   defaultGraphMaxRetries,
+		DelaySeconds: int(defaultGraphRetryDelay / time.Second),
+		ShouldRetry: func(delay time.Duration, executionCount int, request *http.Request, response *http.Response) bool {
+			return retry
+		},
+	}
+	if o.maxRetries != nil {
+		options.MaxRetries = *o.maxRetries
+	}
+	if o.retryDelay != 0 {
+		options.DelaySeconds = int(o.retryDelay.Round(time.Second) / time.Second)
+	}
+
+	return options
+}
+
+// azcoreRetryOptions returns the retry options of the credentials.
+func (o clientOptions) azcoreRetryOptions() azcorepolicy.RetryOptions {
+	options := azcorepolicy.RetryOptions{TryTimeout: o.tryTimeout}
+	if o.maxRetries != nil {
+		// azcore treats zero as its default, and a negative value as no retries.
+		options.MaxRetries = int32(*o.maxRetries)
+		if *o.maxRetries == 0 {
+			options.MaxRetries = -1
+		}
+	}
+
+	return options
+}
+
+// tryTimeoutMiddleware bounds every attempt of a Graph call. It is placed after the retry handler.
+type tryTimeoutMiddleware struct {
+	timeout time.Duration
+}
+
+func (m *tryTimeoutMiddleware) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), m.timeout)
+
+	resp, err := pipeline.Next(req.WithContext(ctx), middlewareIndex)
+	if err != nil || resp == nil {
+		cancel()
+		return resp, err
+	}
+
+	// The body is read after the middleware returns, so the timeout is only released when the body is closed.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelOnClose cancels the context of a response when its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
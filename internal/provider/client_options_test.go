@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	khttp "github.com/microsoft/kiota-http-go"
+)
+
+func TestClientOptionsApplyMiddleware(t *testing.T) {
+	maxRetries := 5
+	o := clientOptions{
+		tryTimeout:         10 * time.Second,
+		maxRetries:         &maxRetries,
+		retryDelay:         2 * time.Second,
+		disableCompression: true,
+	}
+
+	middleware := o.applyMiddleware([]khttp.Middleware{khttp.NewRetryHandler(), khttp.NewCompressionHandler()})
+	if len(middleware) != 2 {
+		t.Fatalf("got %d middleware, want the retry handler and the per attempt timeout", len(middleware))
+	}
+
+	if _, ok := middleware[0].(*khttp.RetryHandler); !ok {
+		t.Errorf("got %T, want the retry handler first", middleware[0])
+	}
+
+	if m, ok := middleware[1].(*tryTimeoutMiddleware); !ok || m.timeout != o.tryTimeout {
+		t.Errorf("got %T, want the per attempt timeout last", middleware[1])
+	}
+
+	options := o.retryHandlerOptions()
+	if options.MaxRetries != 5 || options.DelaySeconds != 2 || options.ShouldRetry == nil {
+		t.Errorf("got retry handler options %+v, want 5 retries 2 seconds apart", options)
+	}
+}
+
+func TestClientOptionsDefaults(t *testing.T) {
+	defaults := []khttp.Middleware{khttp.NewRetryHandler(), khttp.NewCompressionHandler()}
+
+	middleware := clientOptions{}.applyMiddleware(defaults)
+	if len(middleware) != len(defaults) || middleware[0] != defaults[0] || middleware[1] != defaults[1] {
+		t.Errorf("got middleware %v, want the defaults unchanged", middleware)
+	}
+
+	if retry := (clientOptions{}).azcoreRetryOptions(); retry.MaxRetries != 0 || retry.TryTimeout != 0 {
+		t.Errorf("got azcore retry options %+v, want the defaults", retry)
+	}
+
+	noRetries := 0
+	if retry := (clientOptions{maxRetries: &noRetries}).azcoreRetryOptions(); retry.MaxRetries != -1 {
+		t.Errorf("got azcore MaxRetries %d, want -1 to disable retries", retry.MaxRetries)
+	}
+
+	// The retry handler of the Graph SDK retries three times when told to retry zero times.
+	if options := (clientOptions{maxRetries: &noRetries}).retryHandlerOptions(); options.ShouldRetry(0, 0, nil, nil) {
+		t.Errorf("got a retry with graph_max_retries 0, want none")
+	}
+}
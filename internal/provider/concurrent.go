@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"sync"
+)
+
+// maxConcurrentFetches bounds how many lists a data source fetches at once, e.g. when it lists the eligible
+// assignments of every security group of the tenant. Graph throttles per tenant and application, so more would mostly
+// be throttled, and the adaptive throttle paces all of them when it is.
+const maxConcurrentFetches = 8
+
+// fetchAll calls fetch for every key, with at most limit calls in flight, and returns the results in the order of
+// keys. The first error cancels the context of the calls in flight, stops starting new ones, and is returned.
+func fetchAll[K, V any](ctx context.Context, keys []K, limit int, fetch func(context.Context, K) (V, error)) ([]V, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	results := make([]V, len(keys))
+	next := make(chan int)
+	for w := 0; w < min(max(limit, 1), len(keys)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range next {
+				v, err := fetch(ctx, keys[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				results[i] = v
+			}
+		}()
+	}
+
+feed:
+	for i := range keys {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// The parent context was cancelled before every key was fetched.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchAll(t *testing.T) {
+	ctx := context.Background()
+
+	keys := make([]int, 50)
+	for i := range keys {
+		keys[i] = i
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+	got, err := fetchAll(ctx, keys, 4, func(ctx context.Context, key int) (string, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+		return fmt.Sprint(key), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range got {
+		if v != fmt.Sprint(i) {
+			t.Fatalf("got %q at %d, want the results in the order of the keys", v, i)
+		}
+	}
+	if m := maxInFlight.Load(); m > 4 {
+		t.Errorf("got %d calls in flight, want at most 4", m)
+	}
+}
+
+func TestFetchAllStopsOnError(t *testing.T) {
+	ctx := context.Background()
+	errFetch := errors.New("fetch failed")
+
+	keys := make([]int, 100)
+	for i := range keys {
+		keys[i] = i
+	}
+
+	var calls atomic.Int32
+	_, err := fetchAll(ctx, keys, 2, func(ctx context.Context, key int) (string, error) {
+		calls.Add(1)
+		if key == 3 {
+			return "", errFetch
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Millisecond):
+			return fmt.Sprint(key), nil
+		}
+	})
+
+	if !errors.Is(err, errFetch) {
+		t.Errorf("got error %v, want the error of the failed call", err)
+	}
+	if n := calls.Load(); n == int32(len(keys)) {
+		t.Errorf("got %d calls, want no calls started after the error", n)
+	}
+}
+
+func TestFetchAllNoKeys(t *testing.T) {
+	got, err := fetchAll(context.Background(), nil, maxConcurrentFetches, func(ctx context.Context, key string) (string, error) {
+		t.Fatal("got a call without keys")
+		return "", nil
+	})
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v, want no results", got, err)
+	}
+}
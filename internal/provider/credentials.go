@@ -0,0 +1,260 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// managedIdentityProbeTimeout bounds the first managed identity attempt, the same way DefaultAzureCredential does,
+// so the chain does not hang on machines without a managed identity endpoint.
+const managedIdentityProbeTimeout = time.Second
+
+// credentialAttempt is the outcome of creating or using a single credential in the chain.
+type credentialAttempt struct {
+	name string
+	err  error
+}
+
+// credentialChainError lists every credential that was tried and why it failed.
+type credentialChainError struct {
+	summary  string
+	attempts []credentialAttempt
+}
+
+func (e *credentialChainError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.summary)
+	b.WriteString(", attempted credentials:")
+	for _, a := range e.attempts {
+		fmt.Fprintf(&b, "\n- %s: %s", a.name, a.err)
+	}
+
+	return b.String()
+}
+
+type chainedCredential struct {
+	name         string
+	cred         azcore.TokenCredential
+	err          error
+	probeTimeout time.Duration
+}
+
+// credentialChain mirrors the order of azidentity.DefaultAzureCredential by default, but keeps track of why each
+// credential failed.
+// The first credential to return a token is used for all later requests.
+type credentialChain struct {
+	mu          sync.Mutex
+	credentials []chainedCredential
+	selected    azcore.TokenCredential
+}
+
+var _ azcore.TokenCredential = &credentialChain{}
+
+// credentialOptions selects the tenant and identity the provider authenticates as. Empty fields fall back to the
+// environment variables read by azidentity, so each provider alias can target its own tenant.
+type credentialOptions struct {
+	tenantID     string
+	clientID     string
+	clientSecret string
+	cloud        cloud.Configuration
+	retry        azcorepolicy.RetryOptions
+	// transport replaces the network transport of token requests when set, e.g. to trust a custom CA bundle.
+	transport azcorepolicy.Transporter
+
+	// managedIdentityFederation authenticates as clientID in tenantID with a token of the managed identity of the
+	// machine as client assertion, so the app registration may live in another tenant than the managed identity.
+	managedIdentityFederation bool
+	// managedIdentityClientID selects a user-assigned managed identity for the federation, the system-assigned one when empty.
+	managedIdentityClientID string
+	// tokenExchangeAudience is the audience of the managed identity token used as client assertion.
+	tokenExchangeAudience string
+
+	// credentialTypes are the types of credentials tried in order, defaultCredentialTypes when empty.
+	credentialTypes []string
+}
+
+// newCredential creates the credential chain used by the provider. It only fails when no credential could be created.
+// When a client secret is configured, only that credential is used. Otherwise the credentials of o.credentialTypes are
+// tried in order.
+func newCredential(o credentialOptions) (azcore.TokenCredential, error) {
+	c := &credentialChain{}
+	clientOptions := azcore.ClientOptions{Cloud: o.cloud, Retry: o.retry, Transport: o.transport}
+
+	if o.managedIdentityFederation {
+		return newManagedIdentityFederationCredential(o, clientOptions)
+	}
+
+	if o.clientSecret != "" {
+		secretCred, err := azidentity.NewClientSecretCredential(o.tenantID, o.clientID, o.clientSecret, &azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions})
+		if err != nil {
+			return nil, &credentialChainError{summary: "unable to create any credential", attempts: []credentialAttempt{{name: "ClientSecretCredential", err: err}}}
+		}
+		c.add(chainedCredential{name: "ClientSecretCredential", cred: secretCred})
+
+		return c, nil
+	}
+
+	credentialTypes := o.credentialTypes
+	if len(credentialTypes) == 0 {
+		credentialTypes = defaultCredentialTypes
+	}
+	for _, name := range credentialTypes {
+		newChained, ok := chainedCredentials[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown credential type %q, must be one of %q", name, defaultCredentialTypes)
+		}
+		c.add(newChained(o, clientOptions))
+	}
+
+	var attempts []credentialAttempt
+	for _, cc := range c.credentials {
+		if cc.err == nil {
+			return c, nil
+		}
+		attempts = append(attempts, credentialAttempt{name: cc.name, err: cc.err})
+	}
+
+	return nil, &credentialChainError{summary: "unable to create any credential", attempts: attempts}
+}
+
+// defaultCredentialTypes is the order of credential types tried when credential_types is not configured.
+var defaultCredentialTypes = []string{"environment", "oidc", "managed_identity", "cli", "developer_cli"}
+
+// chainedCredentials creates the credential of each type in the chain.
+var chainedCredentials = map[string]func(o credentialOptions, clientOptions azcore.ClientOptions) chainedCredential{
+	"environment": func(o credentialOptions, clientOptions azcore.ClientOptions) chainedCredential {
+		// The environment credential always authenticates in AZURE_TENANT_ID, so it is skipped when another tenant is configured.
+		if envTenantID := os.Getenv("AZURE_TENANT_ID"); o.tenantID != "" && envTenantID != "" && !strings.EqualFold(envTenantID, o.tenantID) {
+			return chainedCredential{name: "EnvironmentCredential", err: fmt.Errorf("AZURE_TENANT_ID %s differs from the configured tenant_id %s", envTenantID, o.tenantID)}
+		}
+		envCred, err := azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{ClientOptions: clientOptions})
+		return chainedCredential{name: "EnvironmentCredential", cred: envCred, err: err}
+	},
+	"oidc": func(o credentialOptions, clientOptions azcore.ClientOptions) chainedCredential {
+		wiCred, err := azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{ClientOptions: clientOptions, ClientID: o.clientID, TenantID: o.tenantID})
+		return chainedCredential{name: "WorkloadIdentityCredential", cred: wiCred, err: err}
+	},
+	"managed_identity": func(o credentialOptions, clientOptions azcore.ClientOptions) chainedCredential {
+		miOptions := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		if o.clientID != "" {
+			miOptions.ID = azidentity.ClientID(o.clientID)
+		} else if id, ok := os.LookupEnv("AZURE_CLIENT_ID"); ok {
+			miOptions.ID = azidentity.ClientID(id)
+		}
+		miCred, err := azidentity.NewManagedIdentityCredential(miOptions)
+		return chainedCredential{name: "ManagedIdentityCredential", cred: miCred, err: err, probeTimeout: managedIdentityProbeTimeout}
+	},
+	"cli": func(o credentialOptions, clientOptions azcore.ClientOptions) chainedCredential {
+		cliCred, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: o.tenantID})
+		return chainedCredential{name: "AzureCLICredential", cred: cliCred, err: err}
+	},
+	"developer_cli": func(o credentialOptions, clientOptions azcore.ClientOptions) chainedCredential {
+		azdCred, err := azidentity.NewAzureDeveloperCLICredential(&azidentity.AzureDeveloperCLICredentialOptions{TenantID: o.tenantID})
+		return chainedCredential{name: "AzureDeveloperCLICredential", cred: azdCred, err: err}
+	},
+}
+
+// newManagedIdentityFederationCredential creates a chain with only the client assertion credential of the managed
+// identity federation.
+func newManagedIdentityFederationCredential(o credentialOptions, clientOptions azcore.ClientOptions) (azcore.TokenCredential, error) {
+	c := &credentialChain{}
+
+	miOptions := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+	if o.managedIdentityClientID != "" {
+		miOptions.ID = azidentity.ClientID(o.managedIdentityClientID)
+	}
+	miCred, err := azidentity.NewManagedIdentityCredential(miOptions)
+	if err != nil {
+		return nil, &credentialChainError{summary: "unable to create any credential", attempts: []credentialAttempt{{name: "ManagedIdentityCredential", err: err}}}
+	}
+
+	assertionCred, err := azidentity.NewClientAssertionCredential(o.tenantID, o.clientID, managedIdentityAssertion(miCred, o.tokenExchangeAudience), &azidentity.ClientAssertionCredentialOptions{ClientOptions: clientOptions})
+	if err != nil {
+		return nil, &credentialChainError{summary: "unable to create any credential", attempts: []credentialAttempt{{name: "ClientAssertionCredential", err: err}}}
+	}
+	c.add(chainedCredential{name: "ClientAssertionCredential", cred: assertionCred})
+
+	return c, nil
+}
+
+// managedIdentityAssertion returns a function getting a token of the managed identity cred for audience, which is
+// accepted as client assertion by app registrations with a federated credential for the managed identity.
+func managedIdentityAssertion(cred azcore.TokenCredential, audience string) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		t, err := cred.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{audience + "/.default"}})
+		if err != nil {
+			return "", fmt.Errorf("unable to get a token of the managed identity: %w", err)
+		}
+
+		return t.Token, nil
+	}
+}
+
+// add appends cc to the chain. The credential is dropped when creating it failed so a typed nil is never called.
+func (c *credentialChain) add(cc chainedCredential) {
+	if cc.err != nil {
+		cc.cred = nil
+	}
+
+	c.credentials = append(c.credentials, cc)
+}
+
+func (c *credentialChain) GetToken(ctx context.Context, opts azcorepolicy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.mu.Lock()
+	selected := c.selected
+	c.mu.Unlock()
+
+	if selected != nil {
+		return selected.GetToken(ctx, opts)
+	}
+
+	var attempts []credentialAttempt
+	for _, cc := range c.credentials {
+		if cc.err != nil {
+			attempts = append(attempts, credentialAttempt{name: cc.name, err: cc.err})
+			continue
+		}
+
+		t, err := cc.getToken(ctx, opts)
+		if err != nil {
+			attempts = append(attempts, credentialAttempt{name: cc.name, err: err})
+			continue
+		}
+
+		c.mu.Lock()
+		c.selected = cc.cred
+		c.mu.Unlock()
+
+		return t, nil
+	}
+
+	return azcore.AccessToken{}, &credentialChainError{summary: "unable to acquire a token", attempts: attempts}
+}
+
+func (cc chainedCredential) getToken(ctx context.Context, opts azcorepolicy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if cc.probeTimeout == 0 {
+		return cc.cred.GetToken(ctx, opts)
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, cc.probeTimeout)
+	defer cancel()
+
+	t, err := cc.cred.GetToken(probeCtx, opts)
+	if err != nil && probeCtx.Err() != nil && ctx.Err() == nil {
+		return t, fmt.Errorf("no response within %s", cc.probeTimeout)
+	}
+
+	return t, err
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+type fakeCredential struct {
+	token string
+	err   error
+	calls int
+}
+
+func (f *fakeCredential) GetToken(ctx context.Context, opts azcorepolicy.TokenRequestOptions) (azcore.AccessToken, error) {
+	f.calls++
+	return azcore.AccessToken{Token: f.token}, f.err
+}
+
+func TestCredentialChainReportsAttempts(t *testing.T) {
+	c := &credentialChain{}
+	c.add(chainedCredential{name: "EnvironmentCredential", err: errors.New("missing environment variable AZURE_TENANT_ID")})
+	c.add(chainedCredential{name: "AzureCLICredential", cred: &fakeCredential{err: errors.New("az login required")}})
+
+	_, err := c.GetToken(context.Background(), azcorepolicy.TokenRequestOptions{Scopes: []string{"https://graph.microsoft.com/.default"}})
+	if err == nil {
+		t.Fatal("got nil error, want error")
+	}
+
+	var chainErr *credentialChainError
+	if !errors.As(err, &chainErr) {
+		t.Fatalf("got %T, want *credentialChainError", err)
+	}
+
+	for _, want := range []string{"EnvironmentCredential: missing environment variable AZURE_TENANT_ID", "AzureCLICredential: az login required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestCredentialChainSelectsFirstWorkingCredential(t *testing.T) {
+	failing := &fakeCredential{err: errors.New("unavailable")}
+	working := &fakeCredential{token: "token"}
+
+	c := &credentialChain{}
+	c.add(chainedCredential{name: "ManagedIdentityCredential", cred: failing})
+	c.add(chainedCredential{name: "AzureCLICredential", cred: working})
+
+	for i := 0; i < 2; i++ {
+		tk, err := c.GetToken(context.Background(), azcorepolicy.TokenRequestOptions{Scopes: []string{"https://graph.microsoft.com/.default"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tk.Token != "token" {
+			t.Errorf("got token %q, want %q", tk.Token, "token")
+		}
+	}
+
+	if failing.calls != 1 || working.calls != 2 {
+		t.Errorf("got %d and %d calls, want 1 and 2", failing.calls, working.calls)
+	}
+}
+
+func TestNewCredentialClientSecretOnly(t *testing.T) {
+	creds, err := newCredential(credentialOptions{
+		tenantID:     "00000000-0000-0000-0000-000000000001",
+		clientID:     "00000000-0000-0000-0000-000000000002",
+		clientSecret: "secret",
+		cloud:        cloudEnvironments["usgovernment"].cloud,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := creds.(*credentialChain)
+	if len(c.credentials) != 1 || c.credentials[0].name != "ClientSecretCredential" {
+		t.Errorf("got credentials %v, want only ClientSecretCredential", c.credentials)
+	}
+}
+
+func TestNewCredentialSkipsEnvironmentOfOtherTenant(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "00000000-0000-0000-0000-000000000001")
+
+	creds, err := newCredential(credentialOptions{tenantID: "00000000-0000-0000-0000-000000000002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := creds.(*credentialChain)
+	if c.credentials[0].name != "EnvironmentCredential" || c.credentials[0].err == nil || !strings.Contains(c.credentials[0].err.Error(), "differs from the configured tenant_id") {
+		t.Errorf("got %s: %v, want EnvironmentCredential to be skipped", c.credentials[0].name, c.credentials[0].err)
+	}
+}
+
+func TestNewCredentialManagedIdentityFederationOnly(t *testing.T) {
+	creds, err := newCredential(credentialOptions{
+		tenantID:                  "00000000-0000-0000-0000-000000000001",
+		clientID:                  "00000000-0000-0000-0000-000000000002",
+		managedIdentityFederation: true,
+		managedIdentityClientID:   "00000000-0000-0000-0000-000000000003",
+		tokenExchangeAudience:     cloudEnvironments["public"].tokenExchangeAudience,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := creds.(*credentialChain)
+	if len(c.credentials) != 1 || c.credentials[0].name != "ClientAssertionCredential" {
+		t.Errorf("got credentials %v, want only ClientAssertionCredential", c.credentials)
+	}
+}
+
+func TestManagedIdentityAssertion(t *testing.T) {
+	var scopes []string
+	mi := credentialFunc(func(ctx context.Context, opts azcorepolicy.TokenRequestOptions) (azcore.AccessToken, error) {
+		scopes = opts.Scopes
+		return azcore.AccessToken{Token: "mi-token"}, nil
+	})
+
+	assertion, err := managedIdentityAssertion(mi, "api://AzureADTokenExchangeUSGov")(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assertion != "mi-token" || len(scopes) != 1 || scopes[0] != "api://AzureADTokenExchangeUSGov/.default" {
+		t.Errorf("got assertion %q for scopes %v, want the managed identity token for the token exchange audience", assertion, scopes)
+	}
+
+	failing := &fakeCredential{err: errors.New("no managed identity endpoint")}
+	if _, err := managedIdentityAssertion(failing, "api://AzureADTokenExchange")(context.Background()); err == nil || !strings.Contains(err.Error(), "no managed identity endpoint") {
+		t.Errorf("got error %v, want the managed identity error", err)
+	}
+}
+
+type credentialFunc func(ctx context.Context, opts azcorepolicy.TokenRequestOptions) (azcore.AccessToken, error)
+
+func (f credentialFunc) GetToken(ctx context.Context, opts azcorepolicy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return f(ctx, opts)
+}
+
+func TestNewCredentialTypesOrder(t *testing.T) {
+	creds, err := newCredential(credentialOptions{credentialTypes: []string{"cli", "oidc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := creds.(*credentialChain)
+	var names []string
+	for _, cc := range c.credentials {
+		names = append(names, cc.name)
+	}
+	if strings.Join(names, ",") != "AzureCLICredential,WorkloadIdentityCredential" {
+		t.Errorf("got credentials %v, want only the configured types in order", names)
+	}
+
+	if _, err := newCredential(credentialOptions{credentialTypes: []string{"browser"}}); err == nil || !strings.Contains(err.Error(), `unknown credential type "browser"`) {
+		t.Errorf("got error %v, want an unknown credential type error", err)
+	}
+}
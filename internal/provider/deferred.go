@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+// deferredReason returns why Graph calls are deferred until apply, empty when the attributes selecting the tenant and
+// identity are known. They are unknown during plan when e.g. the service principal is created in the same run.
+func (m AzurepimProviderModel) deferredReason() string {
+	for name, v := range map[string]attr.Value{
+		"tenant_id":                       m.TenantID,
+		"client_id":                       m.ClientID,
+		"client_secret":                   m.ClientSecret,
+		"use_managed_identity_federation": m.UseMSIFederation,
+		"managed_identity_client_id":      m.MSIClientID,
+		"credential_types":                m.CredentialTypes,
+		"environment":                     m.Environment,
+		"graph_endpoint":                  m.GraphEndpoint,
+	} {
+		if v.IsUnknown() {
+			return fmt.Sprintf("The provider attribute %s is not known until apply", name)
+		}
+	}
+
+	return ""
+}
+
+// deferDataSourceRead defers the read of a data source until the provider configuration is known. Terraform versions
+// without deferred actions get an error instead.
+func deferDataSourceRead(req datasource.ReadRequest, resp *datasource.ReadResponse, reason string) {
+	if req.ClientCapabilities.DeferralAllowed {
+		resp.Deferred = &datasource.Deferred{Reason: datasource.DeferredReasonProviderConfigUnknown}
+		return
+	}
+
+	addDeferredError(&resp.Diagnostics, reason)
+}
+
+// deferResourceRead defers the refresh of a resource until the provider configuration is known. Terraform versions
+// without deferred actions keep the prior state with a warning instead.
+func deferResourceRead(req resource.ReadRequest, resp *resource.ReadResponse, reason string) {
+	if req.ClientCapabilities.DeferralAllowed {
+		resp.Deferred = &resource.Deferred{Reason: resource.DeferredReasonProviderConfigUnknown}
+		return
+	}
+
+	addDeferredReadWarning(&resp.Diagnostics, reason)
+}
+
+// deferImportState defers an import until the provider configuration is known. Terraform versions without deferred
+// actions get an error instead.
+func deferImportState(req resource.ImportStateRequest, resp *resource.ImportStateResponse, reason string) {
+	if req.ClientCapabilities.DeferralAllowed {
+		resp.Deferred = &resource.Deferred{Reason: resource.DeferredReasonProviderConfigUnknown}
+		return
+	}
+
+	addDeferredError(&resp.Diagnostics, reason)
+}
+
+// addDeferredReadWarning tells that the prior state is kept, as nothing can be refreshed before the provider is known.
+func addDeferredReadWarning(diags *diag.Diagnostics, reason string) {
+	diags.AddWarning("Refresh deferred", reason+", so the prior state is kept and changes made outside of Terraform are detected in a later run.")
+}
+
+// addDeferredError fails an operation which can not be performed before the provider is known.
+func addDeferredError(diags *diag.Diagnostics, reason string) {
+	diags.AddError("Provider configuration unknown", reason+". Apply the resources it depends on first, e.g. with -target.")
+}
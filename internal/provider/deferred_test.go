@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestProviderModelDeferredReason(t *testing.T) {
+	known := AzurepimProviderModel{TenantID: types.StringValue("tenant-id"), ClientID: types.StringNull()}
+	if reason := known.deferredReason(); reason != "" {
+		t.Errorf("got reason %q, want none for a known configuration", reason)
+	}
+
+	unknown := AzurepimProviderModel{TenantID: types.StringUnknown()}
+	if reason := unknown.deferredReason(); !strings.Contains(reason, "tenant_id") {
+		t.Errorf("got reason %q, want it to name tenant_id", reason)
+	}
+}
+
+func TestGroupEligibleAssignmentReadDeferred(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	r, empty := testGroupEligibleAssignmentResource(t, client)
+
+	state := empty
+	prior := testGroupEligibleAssignmentModel()
+	prior.Id = types.StringValue("group-id|principal-id")
+	if diags := state.Set(ctx, prior); diags.HasError() {
+		t.Fatalf("unable to set state: %v", diags)
+	}
+
+	r.deferredReason = "The provider attribute tenant_id is not known until apply"
+
+	readResp := &fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state}, readResp)
+	if readResp.Diagnostics.HasError() || readResp.Diagnostics.WarningsCount() != 1 {
+		t.Fatalf("got diagnostics %v, want a single warning", readResp.Diagnostics)
+	}
+
+	var read GroupEligibleAssignmentModel
+	readResp.State.Get(ctx, &read)
+	if read.Id.ValueString() != "group-id|principal-id" {
+		t.Errorf("got id %q, want the prior state to be kept", read.Id.ValueString())
+	}
+
+	importResp := &fwresource.ImportStateResponse{State: empty}
+	r.ImportState(ctx, fwresource.ImportStateRequest{ID: "group-id|principal-id"}, importResp)
+	if !importResp.Diagnostics.HasError() {
+		t.Errorf("got no error importing while the provider configuration is unknown")
+	}
+}
+
+func TestGroupEligibleAssignmentDeferralAllowed(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	r, empty := testGroupEligibleAssignmentResource(t, client)
+	r.deferredReason = "The provider attribute tenant_id is not known until apply"
+
+	state := empty
+	prior := testGroupEligibleAssignmentModel()
+	prior.Id = types.StringValue("group-id|principal-id")
+	if diags := state.Set(ctx, prior); diags.HasError() {
+		t.Fatalf("unable to set state: %v", diags)
+	}
+
+	readResp := &fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state, ClientCapabilities: fwresource.ReadClientCapabilities{DeferralAllowed: true}}, readResp)
+	if len(readResp.Diagnostics) != 0 || readResp.Deferred == nil || readResp.Deferred.Reason != fwresource.DeferredReasonProviderConfigUnknown {
+		t.Errorf("got diagnostics %v and deferred %v, want the read deferred without diagnostics", readResp.Diagnostics, readResp.Deferred)
+	}
+
+	importResp := &fwresource.ImportStateResponse{State: empty}
+	r.ImportState(ctx, fwresource.ImportStateRequest{ID: "group-id|principal-id", ClientCapabilities: fwresource.ImportStateClientCapabilities{DeferralAllowed: true}}, importResp)
+	if importResp.Diagnostics.HasError() || importResp.Deferred == nil {
+		t.Errorf("got diagnostics %v and deferred %v, want the import deferred", importResp.Diagnostics, importResp.Deferred)
+	}
+
+	d := &GroupEligibleAssignments{deferredReason: r.deferredReason}
+	dataResp := &datasource.ReadResponse{}
+	d.Read(ctx, datasource.ReadRequest{ClientCapabilities: datasource.ReadClientCapabilities{DeferralAllowed: true}}, dataResp)
+	if dataResp.Diagnostics.HasError() || dataResp.Deferred == nil {
+		t.Errorf("got diagnostics %v and deferred %v, want the data source read deferred", dataResp.Diagnostics, dataResp.Deferred)
+	}
+
+	if len(client.requests) != 0 {
+		t.Errorf("got %d requests, want no Graph calls while deferred", len(client.requests))
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// scheduleRequestStatuses are the statuses of schedule requests which can be filtered on.
+var scheduleRequestStatuses = []string{"PendingApproval", "Provisioned", "Denied", "Canceled", "Failed", "Revoked", "ScheduleCreated", "PendingScheduleCreation"}
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &DirectoryRoleActivationRequests{}
+
+func NewDirectoryRoleActivationRequests() datasource.DataSource {
+	return &DirectoryRoleActivationRequests{}
+}
+
+// DirectoryRoleActivationRequests defines the data source implementation.
+type DirectoryRoleActivationRequests struct {
+	service *rolepim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// DirectoryRoleActivationRequestsModel describes the data source data model.
+type DirectoryRoleActivationRequestsModel struct {
+	Status           types.String                               `tfsdk:"status"`
+	PrincipalID      customtypes.GUID                           `tfsdk:"principal_id"`
+	RoleDefinitionID types.String                               `tfsdk:"role_definition_id"`
+	Requests         []DirectoryRoleActivationRequestsItemModel `tfsdk:"requests"`
+}
+
+// DirectoryRoleActivationRequestsItemModel describes an activation request listed by the data source.
+type DirectoryRoleActivationRequestsItemModel struct {
+	ID                types.String        `tfsdk:"id"`
+	RoleDefinitionID  types.String        `tfsdk:"role_definition_id"`
+	PrincipalID       customtypes.GUID    `tfsdk:"principal_id"`
+	DirectoryScopeID  types.String        `tfsdk:"directory_scope_id"`
+	Status            types.String        `tfsdk:"status"`
+	Justification     types.String        `tfsdk:"justification"`
+	CreatedDateTime   customtypes.RFC3339 `tfsdk:"created_date_time"`
+	CompletedDateTime customtypes.RFC3339 `tfsdk:"completed_date_time"`
+	StartDateTime     customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime       customtypes.RFC3339 `tfsdk:"end_date_time"`
+	ApprovalID        types.String        `tfsdk:"approval_id"`
+}
+
+func (d *DirectoryRoleActivationRequests) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_directory_role_activation_requests"
+}
+
+func (d *DirectoryRoleActivationRequests) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists the requests of principals to activate their eligible Microsoft Entra roles, e.g. to surface pending elevations
+in dashboards.
+
+It requires the following graph permissions:
+- RoleAssignmentSchedule.Read.Directory
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"status": schema.StringAttribute{
+				MarkdownDescription: "Only list requests with this status, e.g. `PendingApproval` or `Provisioned`.",
+				Optional:            true,
+				Validators:          []validator.String{stringvalidator.OneOf(scheduleRequestStatuses...)},
+			},
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "Only list the requests of this principal, i.e. the requestor.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"role_definition_id": schema.StringAttribute{
+				MarkdownDescription: "Only list requests to activate this role.",
+				Optional:            true,
+			},
+			"requests": schema.ListNestedAttribute{
+				MarkdownDescription: "The activation requests, oldest first.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the role assignment schedule request.",
+							Computed:            true,
+						},
+						"role_definition_id": schema.StringAttribute{
+							MarkdownDescription: "The role to activate.",
+							Computed:            true,
+						},
+						"principal_id": schema.StringAttribute{
+							MarkdownDescription: "The principal which requested the activation.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"directory_scope_id": schema.StringAttribute{
+							MarkdownDescription: "The scope of the role, `/` for the whole tenant.",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							Computed: true,
+						},
+						"justification": schema.StringAttribute{
+							Computed: true,
+						},
+						"created_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"completed_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"approval_id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the approval of the request, empty when the role needs no approval.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *DirectoryRoleActivationRequests) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *DirectoryRoleActivationRequests) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of directory role activation requests") }()
+
+	var data DirectoryRoleActivationRequestsModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	requests, err := d.service.ListActivationRequests(ctx, rolepim.RequestFilter{
+		Status:           data.Status.ValueString(),
+		PrincipalID:      data.PrincipalID.ValueString(),
+		RoleDefinitionID: data.RoleDefinitionID.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list directory role activation requests: "+sanitizeError(err))
+		return
+	}
+
+	// RFC 3339 timestamps in UTC sort chronologically.
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CreatedDateTime < requests[j].CreatedDateTime
+	})
+
+	data.Requests = []DirectoryRoleActivationRequestsItemModel{}
+	for _, r := range requests {
+		data.Requests = append(data.Requests, DirectoryRoleActivationRequestsItemModel{
+			ID:                types.StringValue(r.ID),
+			RoleDefinitionID:  types.StringValue(r.RoleDefinitionID),
+			PrincipalID:       customtypes.NewGUIDValue(r.PrincipalID),
+			DirectoryScopeID:  types.StringValue(r.DirectoryScopeID),
+			Status:            types.StringValue(r.Status),
+			Justification:     types.StringValue(r.Justification),
+			CreatedDateTime:   customtypes.NewRFC3339Value(r.CreatedDateTime),
+			CompletedDateTime: customtypes.NewRFC3339Value(r.CompletedDateTime),
+			StartDateTime:     customtypes.NewRFC3339Value(r.StartDateTime),
+			EndDateTime:       customtypes.NewRFC3339Value(r.EndDateTime),
+			ApprovalID:        types.StringValue(r.ApprovalID),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
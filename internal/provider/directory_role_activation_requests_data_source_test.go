@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// fakeRolePIMClient returns the same role schedule requests, instances and policy assignments for every filter, and
+// records the last filter.
+type fakeRolePIMClient struct {
+	requests             []graphmodels.UnifiedRoleAssignmentScheduleRequestable
+	eligibilityRequests  []graphmodels.UnifiedRoleEligibilityScheduleRequestable
+	eligibilityInstances []graphmodels.UnifiedRoleEligibilityScheduleInstanceable
+	assignmentInstances  []graphmodels.UnifiedRoleAssignmentScheduleInstanceable
+	policyAssignments    []graphmodels.UnifiedRoleManagementPolicyAssignmentable
+	filter               string
+	// batches counts the calls creating several role assignment schedule requests at once.
+	batches int
+}
+
+func (f *fakeRolePIMClient) ListRoleAssignmentScheduleRequests(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleAssignmentScheduleRequestable, error) {
+	f.filter = filter
+	return f.requests, nil
+}
+
+func (f *fakeRolePIMClient) ListRoleEligibilityScheduleRequests(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleEligibilityScheduleRequestable, error) {
+	f.filter = filter
+	return f.eligibilityRequests, nil
+}
+
+func (f *fakeRolePIMClient) ListRoleEligibilityScheduleInstances(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleEligibilityScheduleInstanceable, error) {
+	f.filter = filter
+	return f.eligibilityInstances, nil
+}
+
+func (f *fakeRolePIMClient) ListRoleAssignmentScheduleInstances(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleAssignmentScheduleInstanceable, error) {
+	f.filter = filter
+	return f.assignmentInstances, nil
+}
+
+func (f *fakeRolePIMClient) ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	f.filter = filter
+	return f.policyAssignments, nil
+}
+
+// CreateRoleAssignmentScheduleRequests activates the roles of selfActivate and selfExtend requests until the end of
+// their schedule, and ends the activations of selfDeactivate requests.
+func (f *fakeRolePIMClient) CreateRoleAssignmentScheduleRequests(ctx context.Context, bodies []graphmodels.UnifiedRoleAssignmentScheduleRequestable) ([]graphmodels.UnifiedRoleAssignmentScheduleRequestable, []error) {
+	f.batches++
+
+	errs := make([]error, len(bodies))
+	for n, body := range bodies {
+		var remaining []graphmodels.UnifiedRoleAssignmentScheduleInstanceable
+		for _, i := range f.assignmentInstances {
+			if *i.GetRoleDefinitionId() != *body.GetRoleDefinitionId() || *i.GetPrincipalId() != *body.GetPrincipalId() {
+				remaining = append(remaining, i)
+			}
+		}
+		f.assignmentInstances = remaining
+
+		body.SetId(toPtr(fmt.Sprintf("role-activation-%d-%d", f.batches, n)))
+		body.SetStatus(toPtr("Provisioned"))
+		if *body.GetAction() == rolepim.ActionSelfDeactivate {
+			continue
+		}
+
+		end, err := time.Parse(time.RFC3339, conversions.ScheduleEnd(body.GetScheduleInfo()))
+		if err != nil {
+			errs[n] = err
+			continue
+		}
+
+		i := graphmodels.NewUnifiedRoleAssignmentScheduleInstance()
+		i.SetRoleDefinitionId(body.GetRoleDefinitionId())
+		i.SetPrincipalId(body.GetPrincipalId())
+		i.SetDirectoryScopeId(body.GetDirectoryScopeId())
+		i.SetAssignmentType(toPtr("Activated"))
+		i.SetEndDateTime(&end)
+		f.assignmentInstances = append(f.assignmentInstances, i)
+	}
+
+	return bodies, errs
+}
+
+func newFakeRoleAssignmentScheduleRequest(id, principalID string, created time.Time) graphmodels.UnifiedRoleAssignmentScheduleRequestable {
+	r := graphmodels.NewUnifiedRoleAssignmentScheduleRequest()
+	r.SetId(toPtr(id))
+	r.SetPrincipalId(toPtr(principalID))
+	r.SetRoleDefinitionId(toPtr("role-1"))
+	r.SetDirectoryScopeId(toPtr("/"))
+	r.SetStatus(toPtr("PendingApproval"))
+	r.SetCreatedDateTime(&created)
+	return r
+}
+
+func TestDirectoryRoleActivationRequestsRead(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := &fakeRolePIMClient{
+		requests: []graphmodels.UnifiedRoleAssignmentScheduleRequestable{
+			newFakeRoleAssignmentScheduleRequest("request-2", "principal-1", now),
+			newFakeRoleAssignmentScheduleRequest("request-1", "principal-1", now.Add(-time.Hour)),
+		},
+	}
+
+	d := &DirectoryRoleActivationRequests{service: rolepim.NewService(client)}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, DirectoryRoleActivationRequestsModel{
+		Status:           types.StringValue("PendingApproval"),
+		PrincipalID:      customtypes.NewGUIDValue("principal-1"),
+		RoleDefinitionID: types.StringNull(),
+	}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	if want := "action eq 'selfActivate' and status eq 'PendingApproval' and principalId eq 'principal-1'"; client.filter != want {
+		t.Errorf("got filter %q, want %q", client.filter, want)
+	}
+
+	var read DirectoryRoleActivationRequestsModel
+	resp.State.Get(ctx, &read)
+
+	if len(read.Requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(read.Requests))
+	}
+	if got := read.Requests[0].ID.ValueString(); got != "request-1" {
+		t.Errorf("got first request %s, want the oldest request-1", got)
+	}
+	if got := read.Requests[1].CreatedDateTime.ValueString(); got != "2024-05-01T12:00:00Z" {
+		t.Errorf("got created_date_time %s, want 2024-05-01T12:00:00Z", got)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &DirectoryRolePolicyAssignments{}
+
+func NewDirectoryRolePolicyAssignments() datasource.DataSource {
+	return &DirectoryRolePolicyAssignments{}
+}
+
+// DirectoryRolePolicyAssignments defines the data source implementation.
+type DirectoryRolePolicyAssignments struct {
+	service *rolepim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// DirectoryRolePolicyAssignmentsModel describes the data source data model.
+type DirectoryRolePolicyAssignmentsModel struct {
+	PolicyIDs map[string]types.String `tfsdk:"policy_ids"`
+}
+
+func (d *DirectoryRolePolicyAssignments) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_directory_role_policy_assignments"
+}
+
+func (d *DirectoryRolePolicyAssignments) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Maps every Microsoft Entra role to the ID of the role management policy which governs its activation and assignment in
+the whole tenant, so policies can be looked up by role definition ID instead of querying Microsoft Graph by hand.
+
+It requires the following graph permissions:
+- RoleManagementPolicy.Read.Directory
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"policy_ids": schema.MapAttribute{
+				MarkdownDescription: "The IDs of the role management policies by role definition ID.",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+		},
+	}
+}
+
+func (d *DirectoryRolePolicyAssignments) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *DirectoryRolePolicyAssignments) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of directory role policy assignments") }()
+
+	var data DirectoryRolePolicyAssignmentsModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	assignments, err := d.service.ListPolicyAssignments(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list directory role policy assignments: "+sanitizeError(err))
+		return
+	}
+
+	data.PolicyIDs = map[string]types.String{}
+	for _, a := range assignments {
+		data.PolicyIDs[a.RoleDefinitionID] = types.StringValue(a.PolicyID)
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+func TestDirectoryRolePolicyAssignmentsRead(t *testing.T) {
+	ctx := context.Background()
+
+	assignment := func(roleDefinitionID, policyID string) graphmodels.UnifiedRoleManagementPolicyAssignmentable {
+		a := graphmodels.NewUnifiedRoleManagementPolicyAssignment()
+		a.SetId(toPtr(policyID + "_" + roleDefinitionID))
+		a.SetPolicyId(toPtr(policyID))
+		a.SetRoleDefinitionId(toPtr(roleDefinitionID))
+		return a
+	}
+	client := &fakeRolePIMClient{
+		policyAssignments: []graphmodels.UnifiedRoleManagementPolicyAssignmentable{
+			assignment("role-1", "Directory_policy-1"),
+			assignment("role-2", "Directory_policy-2"),
+		},
+	}
+
+	d := &DirectoryRolePolicyAssignments{service: rolepim.NewService(client)}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, DirectoryRolePolicyAssignmentsModel{}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	if want := "scopeId eq '/' and scopeType eq 'Directory'"; client.filter != want {
+		t.Errorf("got filter %q, want %q", client.filter, want)
+	}
+
+	var read DirectoryRolePolicyAssignmentsModel
+	resp.State.Get(ctx, &read)
+
+	if len(read.PolicyIDs) != 2 {
+		t.Fatalf("got %d policy IDs, want 2", len(read.PolicyIDs))
+	}
+	if got := read.PolicyIDs["role-2"].ValueString(); got != "Directory_policy-2" {
+		t.Errorf("got policy %s for role-2, want Directory_policy-2", got)
+	}
+}
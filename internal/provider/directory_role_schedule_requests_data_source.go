@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &DirectoryRoleScheduleRequests{}
+
+func NewDirectoryRoleScheduleRequests() datasource.DataSource {
+	return &DirectoryRoleScheduleRequests{}
+}
+
+// DirectoryRoleScheduleRequests defines the data source implementation.
+type DirectoryRoleScheduleRequests struct {
+	service *rolepim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// DirectoryRoleScheduleRequestsModel describes the data source data model.
+type DirectoryRoleScheduleRequestsModel struct {
+	Kind     types.String                             `tfsdk:"kind"`
+	Filter   types.String                             `tfsdk:"filter"`
+	Requests []DirectoryRoleScheduleRequestsItemModel `tfsdk:"requests"`
+}
+
+// DirectoryRoleScheduleRequestsItemModel describes a schedule request listed by the data source.
+type DirectoryRoleScheduleRequestsItemModel struct {
+	ID               types.String        `tfsdk:"id"`
+	RoleDefinitionID types.String        `tfsdk:"role_definition_id"`
+	PrincipalID      customtypes.GUID    `tfsdk:"principal_id"`
+	DirectoryScopeID types.String        `tfsdk:"directory_scope_id"`
+	Action           types.String        `tfsdk:"action"`
+	Status           types.String        `tfsdk:"status"`
+	CreatedDateTime  customtypes.RFC3339 `tfsdk:"created_date_time"`
+	Raw              types.String        `tfsdk:"raw"`
+}
+
+func (d *DirectoryRoleScheduleRequests) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_directory_role_schedule_requests"
+}
+
+func (d *DirectoryRoleScheduleRequests) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists the eligibility or assignment schedule requests of Microsoft Entra roles with all their properties as returned by
+Microsoft Graph, for advanced reporting on properties the other data sources do not expose. Prefer the typed data
+sources where they suffice, as the raw properties follow the beta API and may change at any time.
+
+It requires the following graph permissions:
+- RoleEligibilitySchedule.Read.Directory for eligibility schedule requests
+- RoleAssignmentSchedule.Read.Directory for assignment schedule requests
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"kind": schema.StringAttribute{
+				MarkdownDescription: "Whether to list the `eligibility` or the `assignment` schedule requests.",
+				Required:            true,
+				Validators:          []validator.String{stringvalidator.OneOf(rolepim.KindEligibility, rolepim.KindAssignment)},
+			},
+			"filter": schema.StringAttribute{
+				MarkdownDescription: "An OData filter passed to Microsoft Graph as is, e.g. `principalId eq '...' and status eq 'Provisioned'`. Lists every request when not set.",
+				Optional:            true,
+			},
+			"requests": schema.ListNestedAttribute{
+				MarkdownDescription: "The schedule requests, in the order returned by Microsoft Graph.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the schedule request.",
+							Computed:            true,
+						},
+						"role_definition_id": schema.StringAttribute{
+							Computed: true,
+						},
+						"principal_id": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.GUIDType{},
+						},
+						"directory_scope_id": schema.StringAttribute{
+							MarkdownDescription: "The scope of the role, `/` for the whole tenant.",
+							Computed:            true,
+						},
+						"action": schema.StringAttribute{
+							MarkdownDescription: "The action of the request, e.g. `adminAssign` or `selfActivate`.",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							Computed: true,
+						},
+						"created_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"raw": schema.StringAttribute{
+							MarkdownDescription: "The schedule request as JSON, as returned by Microsoft Graph. Decode it with `jsondecode`.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *DirectoryRoleScheduleRequests) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *DirectoryRoleScheduleRequests) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of directory role schedule requests") }()
+
+	var data DirectoryRoleScheduleRequestsModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	requests, err := d.service.ListScheduleRequests(ctx, data.Kind.ValueString(), data.Filter.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list directory role schedule requests: "+sanitizeError(err))
+		return
+	}
+
+	data.Requests = []DirectoryRoleScheduleRequestsItemModel{}
+	for _, r := range requests {
+		raw := types.StringNull()
+		if payload, err := serializeGraphPayload(r.Raw); err != nil {
+			tflog.Warn(ctx, "unable to serialize graph payload", map[string]any{"error": sanitizeError(err), "id": r.ID})
+		} else {
+			raw = types.StringValue(sanitize(payload))
+		}
+
+		data.Requests = append(data.Requests, DirectoryRoleScheduleRequestsItemModel{
+			ID:               types.StringValue(r.ID),
+			RoleDefinitionID: types.StringValue(r.RoleDefinitionID),
+			PrincipalID:      customtypes.NewGUIDValue(r.PrincipalID),
+			DirectoryScopeID: types.StringValue(r.DirectoryScopeID),
+			Action:           types.StringValue(r.Action),
+			Status:           types.StringValue(r.Status),
+			CreatedDateTime:  customtypes.NewRFC3339Value(r.CreatedDateTime),
+			Raw:              raw,
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+func TestDirectoryRoleScheduleRequestsRead(t *testing.T) {
+	ctx := context.Background()
+
+	eligibility := graphmodels.NewUnifiedRoleEligibilityScheduleRequest()
+	eligibility.SetId(toPtr("request-1"))
+	eligibility.SetPrincipalId(toPtr("principal-1"))
+	eligibility.SetAction(toPtr("adminAssign"))
+	eligibility.SetIsValidationOnly(toPtr(false))
+
+	client := &fakeRolePIMClient{eligibilityRequests: []graphmodels.UnifiedRoleEligibilityScheduleRequestable{eligibility}}
+	d := &DirectoryRoleScheduleRequests{service: rolepim.NewService(client)}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, DirectoryRoleScheduleRequestsModel{
+		Kind:   types.StringValue(rolepim.KindEligibility),
+		Filter: types.StringValue("principalId eq 'principal-1'"),
+	}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	if client.filter != "principalId eq 'principal-1'" {
+		t.Errorf("got filter %q, want the configured filter", client.filter)
+	}
+
+	var read DirectoryRoleScheduleRequestsModel
+	resp.State.Get(ctx, &read)
+
+	if len(read.Requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(read.Requests))
+	}
+
+	// Properties without an attribute of their own are only available in the raw JSON.
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(read.Requests[0].Raw.ValueString()), &raw); err != nil {
+		t.Fatalf("raw is not JSON: %s", err)
+	}
+	if raw["isValidationOnly"] != false || raw["action"] != "adminAssign" {
+		t.Errorf("got raw %v, want isValidationOnly and action", raw)
+	}
+}
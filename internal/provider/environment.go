@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+const defaultEnvironment = "public"
+
+// cloudEnvironment is a national cloud the provider can manage PIM in.
+type cloudEnvironment struct {
+	// cloud configures the Entra ID authority the credentials authenticate with.
+	cloud cloud.Configuration
+
+	// graphEndpoint is the Microsoft Graph endpoint of the cloud, without version.
+	graphEndpoint string
+
+	// tokenExchangeAudience is the audience of managed identity tokens used as federated credentials in the cloud.
+	tokenExchangeAudience string
+}
+
+// cloudEnvironments are the clouds supported by the environment provider attribute.
+// See https://learn.microsoft.com/en-us/graph/deployments.
+var cloudEnvironments = map[string]cloudEnvironment{
+	"public": {
+		cloud:                 cloud.AzurePublic,
+		graphEndpoint:         "https://graph.microsoft.com",
+		tokenExchangeAudience: "api://AzureADTokenExchange",
+	},
+	"usgovernment": {
+		cloud:                 cloud.AzureGovernment,
+		graphEndpoint:         "https://graph.microsoft.us",
+		tokenExchangeAudience: "api://AzureADTokenExchangeUSGov",
+	},
+	"china": {
+		cloud:                 cloud.AzureChina,
+		graphEndpoint:         "https://microsoftgraph.chinacloudapi.cn",
+		tokenExchangeAudience: "api://AzureADTokenExchangeChina",
+	},
+}
+
+// cloudEnvironmentNames returns the names of the supported clouds, in documentation order.
+func cloudEnvironmentNames() []string {
+	return []string{"public", "usgovernment", "china"}
+}
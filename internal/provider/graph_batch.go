@@ -0,0 +1,322 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	khttp "github.com/microsoft/kiota-http-go"
+	jsonserialization "github.com/microsoft/kiota-serialization-json-go"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+)
+
+// maxBatchRequests is the most requests Graph accepts in one JSON batch.
+const maxBatchRequests = 20
+
+// acrsValidationFailedCode is the error code of activation requests which Conditional Access rejects until the token
+// carries the authentication context the policy of the role requires.
+const acrsValidationFailedCode = "RoleAssignmentRequestAcrsValidationFailed"
+
+// challengeClaimsPattern matches the claims of a claims challenge in a WWW-Authenticate header.
+var challengeClaimsPattern = regexp.MustCompile(`claims="([^"]*)"`)
+
+// claimsChallengeError is returned for requests which Graph rejects until the token carries the authentication
+// context Conditional Access requires, e.g. activations of roles whose policy requires one.
+type claimsChallengeError struct {
+	// Claims is the claims challenge to request a token with, empty when Graph did not send one.
+	Claims string
+	err    error
+}
+
+func (e *claimsChallengeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *claimsChallengeError) Unwrap() error {
+	return e.err
+}
+
+// batchRequest is a request in a JSON batch. The URL is relative to the Graph version, e.g. /groups.
+type batchRequest struct {
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// batchResponse is the response to a request in a JSON batch.
+type batchResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+func (c *graphClient) CreateAssignmentScheduleRequests(ctx context.Context, bodies []graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, []error) {
+	parsables := make([]serialization.Parsable, len(bodies))
+	for i, b := range bodies {
+		parsables[i] = b
+	}
+
+	created := make([]graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, len(bodies))
+	responses, errs := c.postBatch(ctx, "/identityGovernance/privilegedAccess/group/assignmentScheduleRequests", parsables)
+	for i, resp := range responses {
+		if errs[i] != nil {
+			continue
+		}
+
+		v, err := parseBatchBody(resp, graphmodels.CreatePrivilegedAccessGroupAssignmentScheduleRequestFromDiscriminatorValue)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		created[i] = v.(graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable)
+	}
+
+	return created, errs
+}
+
+func (c *graphClient) CreateRoleAssignmentScheduleRequests(ctx context.Context, bodies []graphmodels.UnifiedRoleAssignmentScheduleRequestable) ([]graphmodels.UnifiedRoleAssignmentScheduleRequestable, []error) {
+	parsables := make([]serialization.Parsable, len(bodies))
+	for i, b := range bodies {
+		parsables[i] = b
+	}
+
+	created := make([]graphmodels.UnifiedRoleAssignmentScheduleRequestable, len(bodies))
+	responses, errs := c.postBatch(ctx, "/roleManagement/directory/roleAssignmentScheduleRequests", parsables)
+	for i, resp := range responses {
+		if errs[i] != nil {
+			continue
+		}
+
+		v, err := parseBatchBody(resp, graphmodels.CreateUnifiedRoleAssignmentScheduleRequestFromDiscriminatorValue)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		created[i] = v.(graphmodels.UnifiedRoleAssignmentScheduleRequestable)
+	}
+
+	return created, errs
+}
+
+// parseBatchBody parses the body of a batch response into a Graph SDK model.
+func parseBatchBody(body []byte, factory serialization.ParsableFactory) (serialization.Parsable, error) {
+	node, err := jsonserialization.NewJsonParseNode(body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse response body: %w", err)
+	}
+
+	v, err := node.GetObjectValue(factory)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse response body: %w", err)
+	}
+
+	return v, nil
+}
+
+// postBatch POSTs each of bodies to path, relative to the Graph version, in JSON batches of at most maxBatchRequests.
+// It returns the response body and the error of each, in the order of bodies. Requests throttled within a batch are
+// sent again in the next batch, up to graph_max_retries times.
+func (c *graphClient) postBatch(ctx context.Context, path string, bodies []serialization.Parsable) ([][]byte, []error) {
+	payloads := make([][]byte, len(bodies))
+	responses := make([][]byte, len(bodies))
+	errs := make([]error, len(bodies))
+
+	// pending are the indexes of the bodies which were not answered yet.
+	var pending []int
+	for i, b := range bodies {
+		payload, err := serializeGraphPayload(b)
+		if err != nil {
+			errs[i] = fmt.Errorf("unable to serialize request body: %w", err)
+			continue
+		}
+		payloads[i] = []byte(payload)
+		pending = append(pending, i)
+	}
+
+	t, err := c.options.Credential.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{c.options.GraphScope()}})
+	if err != nil {
+		for _, i := range pending {
+			errs[i] = fmt.Errorf("unable to get token: %w", err)
+		}
+		return responses, errs
+	}
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		var throttled []int
+		retryAfter := ""
+		for start := 0; start < len(pending); start += maxBatchRequests {
+			chunk := pending[start:min(start+maxBatchRequests, len(pending))]
+
+			requests := make([]batchRequest, len(chunk))
+			for j, i := range chunk {
+				requests[j] = batchRequest{
+					ID:      strconv.Itoa(i),
+					Method:  http.MethodPost,
+					URL:     path,
+					Headers: map[string]string{"Content-Type": "application/json"},
+					Body:    payloads[i],
+				}
+			}
+
+			results, err := c.sendBatch(ctx, t.Token, requests)
+			if err != nil {
+				for _, i := range chunk {
+					errs[i] = err
+				}
+				continue
+			}
+
+			answered := map[int]bool{}
+			for _, r := range results {
+				i, err := strconv.Atoi(r.ID)
+				if err != nil || i < 0 || i >= len(bodies) {
+					continue
+				}
+				answered[i] = true
+
+				switch {
+				case r.Status == http.StatusTooManyRequests && attempt < c.options.MaxRetries:
+					throttled = append(throttled, i)
+					retryAfter = r.Headers["Retry-After"]
+				case r.Status >= 200 && r.Status < 300:
+					responses[i] = r.Body
+				default:
+					errs[i] = batchError(r)
+				}
+			}
+
+			for _, i := range chunk {
+				if !answered[i] {
+					errs[i] = fmt.Errorf("the batch response has no response to request %d", i)
+				}
+			}
+		}
+
+		pending = throttled
+		if len(pending) == 0 {
+			break
+		}
+
+		delay := retryAfterDelay(retryAfter, attempt)
+		tflog.Info(ctx, "retrying throttled requests of batch", map[string]any{"attempt": attempt + 1, "requests": len(pending), "delay": delay.String()})
+
+		select {
+		case <-ctx.Done():
+			for _, i := range pending {
+				errs[i] = ctx.Err()
+			}
+			return responses, errs
+		case <-time.After(delay):
+		}
+	}
+
+	return responses, errs
+}
+
+// batchError returns the error of a failed response in a JSON batch. Claims challenges of Conditional Access are
+// returned as claimsChallengeError.
+func batchError(r batchResponse) error {
+	err := fmt.Errorf("got status %d: %s", r.Status, sanitize(string(r.Body)))
+
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(r.Body, &body)
+
+	// The headers of responses in a batch keep the casing Graph sent.
+	var claims string
+	for name, value := range r.Headers {
+		if strings.EqualFold(name, "WWW-Authenticate") {
+			claims = challengeClaims(value)
+		}
+	}
+
+	if body.Error.Code != acrsValidationFailedCode && claims == "" {
+		return err
+	}
+
+	return &claimsChallengeError{Claims: claims, err: err}
+}
+
+// challengeClaims returns the claims of the claims challenge in a WWW-Authenticate header, decoded when they are
+// base64 encoded, or an empty string when the header has no claims challenge.
+func challengeClaims(header string) string {
+	match := challengeClaimsPattern.FindStringSubmatch(header)
+	if match == nil {
+		return ""
+	}
+
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(match[1]); err == nil && json.Valid(decoded) {
+			return string(decoded)
+		}
+	}
+
+	return match[1]
+}
+
+// sendBatch sends one JSON batch, and returns the responses to its requests.
+func (c *graphClient) sendBatch(ctx context.Context, token string, requests []batchRequest) ([]batchResponse, error) {
+	body, err := json.Marshal(map[string]any{"requests": requests})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal batch: %w", err)
+	}
+
+	// The retry handler can only rewind an uncompressed body.
+	noCompression := khttp.NewCompressionOptions(false)
+	ctx = context.WithValue(ctx, noCompression.GetKey(), noCompression)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.options.GraphBaseURL+"/$batch", nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Body = rewindableBody{bytes.NewReader(body)}
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) { return rewindableBody{bytes.NewReader(body)}, nil }
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.options.GraphHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send batch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to send batch, got %d want %d: %s", resp.StatusCode, http.StatusOK, sanitize(string(respBody)))
+	}
+
+	var result struct {
+		Responses []batchResponse `json:"responses"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unable to parse batch response: %w", err)
+	}
+
+	return result.Responses, nil
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+)
+
+func TestCreateAssignmentScheduleRequestsBatches(t *testing.T) {
+	ctx := context.Background()
+
+	// The first attempt of request 1 is throttled, request 2 is rejected, and the others are created.
+	var batches []int
+	throttled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/beta/$batch" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var batch struct {
+			Requests []batchRequest `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("unable to decode batch: %v", err)
+		}
+		batches = append(batches, len(batch.Requests))
+
+		var responses []batchResponse
+		for _, req := range batch.Requests {
+			if req.URL != "/identityGovernance/privilegedAccess/group/assignmentScheduleRequests" {
+				t.Errorf("got url %s, want the assignment schedule requests", req.URL)
+			}
+
+			switch {
+			case req.ID == "1" && !throttled:
+				throttled = true
+				responses = append(responses, batchResponse{ID: req.ID, Status: http.StatusTooManyRequests, Headers: map[string]string{"Retry-After": "0"}})
+			case req.ID == "2":
+				responses = append(responses, batchResponse{ID: req.ID, Status: http.StatusBadRequest, Body: json.RawMessage(`{"error":{"code":"RoleAssignmentRequestPolicyValidationFailed"}}`)})
+			default:
+				var body map[string]any
+				if err := json.Unmarshal(req.Body, &body); err != nil {
+					t.Errorf("unable to decode request %s: %v", req.ID, err)
+				}
+				body["id"] = "request-" + req.ID
+				body["status"] = "Provisioned"
+				created, _ := json.Marshal(body)
+				responses = append(responses, batchResponse{ID: req.ID, Status: http.StatusCreated, Body: created})
+			}
+		}
+
+		writeTestJSON(w, http.StatusOK, map[string]any{"responses": responses})
+	}))
+	defer server.Close()
+
+	client := testGraphClient(t, server, 1)
+
+	var bodies []graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable
+	for i := 0; i < maxBatchRequests+1; i++ {
+		body := graphmodels.NewPrivilegedAccessGroupAssignmentScheduleRequest()
+		body.SetGroupId(toPtr(fmt.Sprintf("group-%d", i)))
+		body.SetPrincipalId(toPtr("caller-id"))
+		bodies = append(bodies, body)
+	}
+
+	created, errs := client.CreateAssignmentScheduleRequests(ctx, bodies)
+
+	if want := []int{maxBatchRequests, 1, 1}; fmt.Sprint(batches) != fmt.Sprint(want) {
+		t.Errorf("got batches of %v requests, want %v", batches, want)
+	}
+
+	for i := range bodies {
+		if i == 2 {
+			if errs[i] == nil {
+				t.Errorf("got no error for the rejected request")
+			}
+			continue
+		}
+
+		if errs[i] != nil {
+			t.Errorf("unexpected error for request %d: %v", i, errs[i])
+			continue
+		}
+		if got := *created[i].GetGroupId(); got != fmt.Sprintf("group-%d", i) {
+			t.Errorf("got group %s for request %d, want the responses in the order of the requests", got, i)
+		}
+		if got := *created[i].GetId(); got != fmt.Sprintf("request-%d", i) {
+			t.Errorf("got id %s for request %d", got, i)
+		}
+	}
+}
+
+func TestBatchErrorClaimsChallenge(t *testing.T) {
+	claims := `{"access_token":{"acrs":{"essential":true,"value":"c1"}}}`
+
+	tests := []struct {
+		name       string
+		response   batchResponse
+		wantClaims string
+		wantErr    bool
+	}{
+		{
+			name:     "acrs validation failed",
+			response: batchResponse{Status: http.StatusBadRequest, Body: json.RawMessage(`{"error":{"code":"RoleAssignmentRequestAcrsValidationFailed"}}`)},
+			wantErr:  true,
+		},
+		{
+			name: "claims challenge",
+			response: batchResponse{
+				Status:  http.StatusUnauthorized,
+				Headers: map[string]string{"www-authenticate": `Bearer error="insufficient_claims", claims="` + base64.StdEncoding.EncodeToString([]byte(claims)) + `"`},
+			},
+			wantClaims: claims,
+			wantErr:    true,
+		},
+		{
+			name:     "other error",
+			response: batchResponse{Status: http.StatusBadRequest, Body: json.RawMessage(`{"error":{"code":"RoleAssignmentRequestPolicyValidationFailed"}}`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var challenge *claimsChallengeError
+			if got := errors.As(batchError(tt.response), &challenge); got != tt.wantErr {
+				t.Fatalf("got claims challenge %t, want %t", got, tt.wantErr)
+			}
+
+			if challenge != nil && challenge.Claims != tt.wantClaims {
+				t.Errorf("got claims %q, want %q", challenge.Claims, tt.wantClaims)
+			}
+		})
+	}
+}
@@ -0,0 +1,813 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	khttp "github.com/microsoft/kiota-http-go"
+	msgraphsdk "github.com/microsoftgraph/msgraph-beta-sdk-go"
+	graphgroups "github.com/microsoftgraph/msgraph-beta-sdk-go/groups"
+	"github.com/microsoftgraph/msgraph-beta-sdk-go/identitygovernance"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+	"github.com/microsoftgraph/msgraph-beta-sdk-go/models/odataerrors"
+	graphpolicies "github.com/microsoftgraph/msgraph-beta-sdk-go/policies"
+	graphrolemanagement "github.com/microsoftgraph/msgraph-beta-sdk-go/rolemanagement"
+	graphusers "github.com/microsoftgraph/msgraph-beta-sdk-go/users"
+	msgraphcore "github.com/microsoftgraph/msgraph-sdk-go-core"
+	azauth "github.com/microsoftgraph/msgraph-sdk-go-core/authentication"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/clients"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+)
+
+const clientRequestIDHeader = "client-request-id"
+
+// graphClient implements the Graph operations with the Microsoft Graph SDK, falling back to raw HTTP where the SDK falls short.
+// Raw calls are sent with the HTTP client of the SDK, so they are retried, paced, correlated, audited and bounded by
+// graph_timeout like SDK calls, and all of them together by the timeouts of the resource.
+type graphClient struct {
+	sdk     *msgraphsdk.GraphServiceClient
+	options clients.Options
+}
+
+var _ clients.Graph = &graphClient{}
+
+// newGraphClient creates the Graph client shared by all resources.
+func newGraphClient(opts clients.Options) (*graphClient, error) {
+	sdk, err := newGraphServiceClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &graphClient{sdk: sdk, options: opts}, nil
+}
+
+// newGraphHTTPClient creates an HTTP client with the default SDK middleware and the provider's own middleware appended.
+func newGraphHTTPClient(pd *providerData) *http.Client {
+	options := msgraphsdk.GetDefaultClientOptions()
+	middleware := msgraphcore.GetDefaultMiddlewaresWithOptions(&options)
+	// Waiting for the throttle does not count towards the timeout of an attempt.
+	if pd.throttle != nil {
+		middleware = append(middleware, &adaptiveThrottleMiddleware{throttle: pd.throttle})
+	}
+	middleware = pd.clientOptions.applyMiddleware(middleware)
+	middleware = append(middleware, &throttleMiddleware{})
+	if pd.correlationID != "" {
+		middleware = append(middleware, &correlationMiddleware{correlationID: pd.correlationID})
+	}
+	if pd.auditLog != nil {
+		middleware = append(middleware, &auditMiddleware{log: pd.auditLog})
+	}
+	if pd.blockMutations {
+		middleware = append([]khttp.Middleware{&mutationGuardMiddleware{}}, middleware...)
+	}
+
+	httpClient := msgraphcore.GetDefaultClient(&options, middleware...)
+	if pd.transport != nil {
+		httpClient.Transport = khttp.NewCustomTransportWithParentTransport(pd.transport, middleware...)
+	}
+	if pd.clientOptions.timeout != 0 {
+		httpClient.Timeout = pd.clientOptions.timeout
+	}
+
+	return httpClient
+}
+
+// newGraphServiceClient creates a graph client sending its requests with the Graph HTTP client of opts.
+func newGraphServiceClient(opts clients.Options) (*msgraphsdk.GraphServiceClient, error) {
+	// Tokens are only sent to the host of the Graph endpoint, which may be a custom one.
+	endpoint, err := url.Parse(opts.GraphEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse graph endpoint: %w", err)
+	}
+	auth, err := azauth.NewAzureIdentityAuthenticationProviderWithScopesAndValidHosts(opts.Credential, []string{opts.GraphScope()}, []string{endpoint.Host})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create authentication provider: %w", err)
+	}
+
+	adapter, err := msgraphsdk.NewGraphRequestAdapterWithParseNodeFactoryAndSerializationWriterFactoryAndHttpClient(auth, nil, nil, opts.GraphHTTPClient)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request adapter: %w", err)
+	}
+	adapter.SetBaseUrl(opts.GraphBaseURL)
+
+	return msgraphsdk.NewGraphServiceClient(adapter), nil
+}
+
+func (c *graphClient) CreateEligibilityScheduleRequest(ctx context.Context, body graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable) (graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error) {
+	return c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		EligibilityScheduleRequests().
+		Post(ctx, body, nil)
+}
+
+func (c *graphClient) ListEligibilityScheduleRequests(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error) {
+	builder := c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		EligibilityScheduleRequests()
+
+	resp, err := builder.Get(ctx, &identitygovernance.PrivilegedAccessGroupEligibilityScheduleRequestsRequestBuilderGetRequestConfiguration{
+		QueryParameters: &identitygovernance.PrivilegedAccessGroupEligibilityScheduleRequestsRequestBuilderGetQueryParameters{
+			Filter: &filter,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every change of an eligibility is a new request, so the history of a group spans many pages.
+	requests := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, resp.GetValue()...)
+	}
+
+	return requests, nil
+}
+
+func (c *graphClient) GetEligibilityScheduleRequest(ctx context.Context, requestID string) (graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error) {
+	request, err := c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		EligibilityScheduleRequests().
+		ByPrivilegedAccessGroupEligibilityScheduleRequestId(requestID).
+		Get(ctx, nil)
+	if isNotFound(err) {
+		return nil, grouppim.ErrNotFound
+	}
+
+	return request, err
+}
+
+func (c *graphClient) GetEligibilityScheduleInstance(ctx context.Context, instanceID string) (graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable, error) {
+	instance, err := c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		EligibilityScheduleInstances().
+		ByPrivilegedAccessGroupEligibilityScheduleInstanceId(instanceID).
+		Get(ctx, nil)
+	if isNotFound(err) {
+		return nil, grouppim.ErrNotFound
+	}
+
+	return instance, err
+}
+
+func (c *graphClient) CancelEligibilityScheduleRequest(ctx context.Context, requestID string) error {
+	return c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		EligibilityScheduleRequests().
+		ByPrivilegedAccessGroupEligibilityScheduleRequestId(requestID).
+		Cancel().
+		Post(ctx, nil)
+}
+
+func (c *graphClient) ListEligibilityScheduleInstances(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable, error) {
+	builder := c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		EligibilityScheduleInstances()
+
+	resp, err := builder.Get(ctx, &identitygovernance.PrivilegedAccessGroupEligibilityScheduleInstancesRequestBuilderGetRequestConfiguration{
+		QueryParameters: &identitygovernance.PrivilegedAccessGroupEligibilityScheduleInstancesRequestBuilderGetQueryParameters{
+			Filter: &filter,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A principal can be eligible in more groups than fit in one page.
+	instances := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, resp.GetValue()...)
+	}
+
+	return instances, nil
+}
+
+func (c *graphClient) GetUser(ctx context.Context, idOrUserPrincipalName string) (graphmodels.Userable, error) {
+	user, err := c.sdk.
+		Users().
+		ByUserId(idOrUserPrincipalName).
+		Get(ctx, &graphusers.UserItemRequestBuilderGetRequestConfiguration{
+			QueryParameters: &graphusers.UserItemRequestBuilderGetQueryParameters{
+				Select: []string{"id", "userPrincipalName"},
+			},
+		})
+	if isNotFound(err) {
+		return nil, directory.ErrNotFound
+	}
+
+	return user, err
+}
+
+func (c *graphClient) ListUsers(ctx context.Context, filter string, top int32) ([]graphmodels.Userable, error) {
+	resp, err := c.sdk.
+		Users().
+		Get(ctx, &graphusers.UsersRequestBuilderGetRequestConfiguration{
+			QueryParameters: &graphusers.UsersRequestBuilderGetQueryParameters{
+				Filter: &filter,
+				Select: []string{"id", "userPrincipalName", "mail"},
+				Top:    &top,
+			},
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetValue(), nil
+}
+
+func (c *graphClient) GetDirectoryObject(ctx context.Context, id string) (graphmodels.DirectoryObjectable, error) {
+	obj, err := c.sdk.
+		DirectoryObjects().
+		ByDirectoryObjectId(id).
+		Get(ctx, nil)
+
+	if isNotFound(err) {
+		return nil, directory.ErrNotFound
+	}
+
+	return obj, err
+}
+
+// isNotFound returns whether err is a Graph error response with status 404.
+func isNotFound(err error) bool {
+	var odataErr *odataerrors.ODataError
+	return errors.As(err, &odataErr) && odataErr.ResponseStatusCode == http.StatusNotFound
+}
+
+func (c *graphClient) ListGroups(ctx context.Context, filter string, top int32) ([]graphmodels.Groupable, error) {
+	resp, err := c.sdk.
+		Groups().
+		Get(ctx, &graphgroups.GroupsRequestBuilderGetRequestConfiguration{
+			QueryParameters: &graphgroups.GroupsRequestBuilderGetQueryParameters{
+				Filter: &filter,
+				Select: []string{"id", "displayName"},
+				Top:    &top,
+			},
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetValue(), nil
+}
+
+func (c *graphClient) CallerObjectID(ctx context.Context) (string, error) {
+	t, err := c.options.Credential.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{c.options.GraphScope()}})
+	if err != nil {
+		return "", fmt.Errorf("unable to get token: %w", err)
+	}
+
+	return tokenObjectID(t.Token)
+}
+
+// tokenObjectID returns the oid claim of an access token, which is the object ID of the user or service principal it
+// was issued to. The signature is not verified, the token is only read after the credential returned it.
+func tokenObjectID(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("access token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("unable to decode access token payload: %w", err)
+	}
+
+	var claims struct {
+		ObjectID string `json:"oid"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("unable to parse access token claims: %w", err)
+	}
+
+	if claims.ObjectID == "" {
+		return "", fmt.Errorf("access token has no oid claim")
+	}
+
+	return claims.ObjectID, nil
+}
+
+func (c *graphClient) ListAllGroups(ctx context.Context, filter string, visit func(page []graphmodels.Groupable) error) error {
+	builder := c.sdk.Groups()
+
+	resp, err := builder.Get(ctx, &graphgroups.GroupsRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphgroups.GroupsRequestBuilderGetQueryParameters{
+			Filter: &filter,
+			Select: []string{"id", "displayName"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only one page is held at a time, the groups of a large tenant span thousands of models.
+	for {
+		if err := visit(resp.GetValue()); err != nil {
+			return err
+		}
+
+		if resp.GetOdataNextLink() == nil {
+			return nil
+		}
+
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *graphClient) ListGroupMembers(ctx context.Context, groupID string) ([]graphmodels.DirectoryObjectable, error) {
+	builder := c.sdk.
+		Groups().
+		ByGroupId(groupID).
+		Members()
+
+	resp, err := builder.Get(ctx, &graphgroups.ItemMembersRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphgroups.ItemMembersRequestBuilderGetQueryParameters{
+			Select: []string{"id"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	members := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, resp.GetValue()...)
+	}
+
+	return members, nil
+}
+
+func (c *graphClient) RemoveGroupMember(ctx context.Context, groupID, memberID string) error {
+	return c.sdk.
+		Groups().
+		ByGroupId(groupID).
+		Members().
+		ByDirectoryObjectId(memberID).
+		Ref().
+		Delete(ctx, nil)
+}
+
+func (c *graphClient) ListAssignmentScheduleInstances(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable, error) {
+	builder := c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		AssignmentScheduleInstances()
+
+	resp, err := builder.Get(ctx, &identitygovernance.PrivilegedAccessGroupAssignmentScheduleInstancesRequestBuilderGetRequestConfiguration{
+		QueryParameters: &identitygovernance.PrivilegedAccessGroupAssignmentScheduleInstancesRequestBuilderGetQueryParameters{
+			Filter: &filter,
+			Expand: []string{"activatedUsing"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The next links keep the filter and expansion of the first page.
+	instances := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, resp.GetValue()...)
+	}
+
+	return instances, nil
+}
+
+func (c *graphClient) ListAssignmentScheduleRequests(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, error) {
+	builder := c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		AssignmentScheduleRequests()
+
+	resp, err := builder.Get(ctx, &identitygovernance.PrivilegedAccessGroupAssignmentScheduleRequestsRequestBuilderGetRequestConfiguration{
+		QueryParameters: &identitygovernance.PrivilegedAccessGroupAssignmentScheduleRequestsRequestBuilderGetQueryParameters{
+			Filter: &filter,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every activation, extension and deactivation is a new request, so the history of a principal spans many pages.
+	requests := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, resp.GetValue()...)
+	}
+
+	return requests, nil
+}
+
+func (c *graphClient) CreateAssignmentScheduleRequest(ctx context.Context, body graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable) (graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, error) {
+	return c.sdk.
+		IdentityGovernance().
+		PrivilegedAccess().
+		Group().
+		AssignmentScheduleRequests().
+		Post(ctx, body, nil)
+}
+
+func (c *graphClient) ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	builder := c.sdk.
+		Policies().
+		RoleManagementPolicyAssignments()
+
+	resp, err := builder.Get(ctx, &graphpolicies.RoleManagementPolicyAssignmentsRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphpolicies.RoleManagementPolicyAssignmentsRequestBuilderGetQueryParameters{
+			Filter: &filter,
+			Expand: []string{"policy($expand=rules)"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A group has one assignment per role, but the directory has one per built-in and custom role.
+	assignments := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, resp.GetValue()...)
+	}
+
+	return assignments, nil
+}
+
+func (c *graphClient) GetRoleManagementPolicy(ctx context.Context, policyID string) (graphmodels.UnifiedRoleManagementPolicyable, error) {
+	policy, err := c.sdk.
+		Policies().
+		RoleManagementPolicies().
+		ByUnifiedRoleManagementPolicyId(policyID).
+		Get(ctx, &graphpolicies.RoleManagementPoliciesUnifiedRoleManagementPolicyItemRequestBuilderGetRequestConfiguration{
+			QueryParameters: &graphpolicies.RoleManagementPoliciesUnifiedRoleManagementPolicyItemRequestBuilderGetQueryParameters{
+				Expand: []string{"rules", "effectiveRules"},
+			},
+		})
+	if isNotFound(err) {
+		return nil, fmt.Errorf("policy %s: %w", policyID, grouppim.ErrPolicyNotFound)
+	}
+
+	return policy, err
+}
+
+func (c *graphClient) ListRoleAssignmentScheduleRequests(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleAssignmentScheduleRequestable, error) {
+	builder := c.sdk.
+		RoleManagement().
+		Directory().
+		RoleAssignmentScheduleRequests()
+
+	// An empty $filter is rejected by Graph.
+	query := &graphrolemanagement.DirectoryRoleAssignmentScheduleRequestsRequestBuilderGetQueryParameters{}
+	if filter != "" {
+		query.Filter = &filter
+	}
+
+	resp, err := builder.Get(ctx, &graphrolemanagement.DirectoryRoleAssignmentScheduleRequestsRequestBuilderGetRequestConfiguration{
+		QueryParameters: query,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requests := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, resp.GetValue()...)
+	}
+
+	return requests, nil
+}
+
+func (c *graphClient) ListRoleEligibilityScheduleRequests(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleEligibilityScheduleRequestable, error) {
+	builder := c.sdk.
+		RoleManagement().
+		Directory().
+		RoleEligibilityScheduleRequests()
+
+	// An empty $filter is rejected by Graph.
+	query := &graphrolemanagement.DirectoryRoleEligibilityScheduleRequestsRequestBuilderGetQueryParameters{}
+	if filter != "" {
+		query.Filter = &filter
+	}
+
+	resp, err := builder.Get(ctx, &graphrolemanagement.DirectoryRoleEligibilityScheduleRequestsRequestBuilderGetRequestConfiguration{
+		QueryParameters: query,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requests := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, resp.GetValue()...)
+	}
+
+	return requests, nil
+}
+
+func (c *graphClient) ListRoleEligibilityScheduleInstances(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleEligibilityScheduleInstanceable, error) {
+	builder := c.sdk.
+		RoleManagement().
+		Directory().
+		RoleEligibilityScheduleInstances()
+
+	resp, err := builder.Get(ctx, &graphrolemanagement.DirectoryRoleEligibilityScheduleInstancesRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphrolemanagement.DirectoryRoleEligibilityScheduleInstancesRequestBuilderGetQueryParameters{
+			Filter: &filter,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instances := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, resp.GetValue()...)
+	}
+
+	return instances, nil
+}
+
+func (c *graphClient) ListRoleAssignmentScheduleInstances(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleAssignmentScheduleInstanceable, error) {
+	builder := c.sdk.
+		RoleManagement().
+		Directory().
+		RoleAssignmentScheduleInstances()
+
+	resp, err := builder.Get(ctx, &graphrolemanagement.DirectoryRoleAssignmentScheduleInstancesRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphrolemanagement.DirectoryRoleAssignmentScheduleInstancesRequestBuilderGetQueryParameters{
+			Filter: &filter,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instances := resp.GetValue()
+	for resp.GetOdataNextLink() != nil {
+		resp, err = builder.WithUrl(*resp.GetOdataNextLink()).Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, resp.GetValue()...)
+	}
+
+	return instances, nil
+}
+
+// UpdatePolicyExpirationRule had to be implemented without SDK because the SDK data model for this endpoint had several missing fields.
+// The rule is only written if it is not up to date yet, keeping the maximum duration as read.
+func (c *graphClient) UpdatePolicyExpirationRule(ctx context.Context, policyId string, isExpirationRequired bool) error {
+	pr := newExpirationAdminEligibilityRule(isExpirationRequired)
+	errs := policyRuleErrors{notFound: grouppim.ErrPolicyNotFound, conflict: grouppim.ErrPolicyConflict}
+
+	return c.updatePolicyRule(ctx, policyId, pr.ID, errs, func(rule graphmodels.UnifiedRoleManagementPolicyRuleable) (any, error) {
+		current, ok := rule.(graphmodels.UnifiedRoleManagementPolicyExpirationRuleable)
+		if !ok {
+			return nil, fmt.Errorf("policy rule %s is not an expiration rule", pr.ID)
+		}
+
+		if required := current.GetIsExpirationRequired(); required != nil && *required == isExpirationRequired {
+			tflog.Debug(ctx, "policy rule already up to date", map[string]any{"policy_id": policyId, "rule_id": pr.ID})
+			return nil, nil
+		}
+
+		// The maximum duration may have been changed in the portal, and is kept as read.
+		if duration := current.GetMaximumDuration(); duration != nil {
+			pr.MaximumDuration = duration.String()
+		}
+
+		return pr, nil
+	})
+}
+
+// UpdatePolicyRule replaces a rule of a policy governed by a policy template.
+func (c *graphClient) UpdatePolicyRule(ctx context.Context, policyID string, rule pimpolicy.Rule) error {
+	var body any
+	switch rule.ID {
+	case pimpolicy.RuleExpirationAdminEligibility, pimpolicy.RuleExpirationEndUserAssignment:
+		body = newExpirationRule(rule.ID, rule.IsExpirationRequired, rule.MaximumDuration)
+	case pimpolicy.RuleEnablementEndUserAssignment:
+		body = newEnablementRule(rule.ID, rule.EnabledRules)
+	default:
+		return fmt.Errorf("unsupported policy rule: %s", rule.ID)
+	}
+
+	errs := policyRuleErrors{notFound: pimpolicy.ErrPolicyNotFound, conflict: pimpolicy.ErrPolicyConflict}
+
+	// The template replaces the whole rule, unless it was read with the values of the template already, e.g. when it
+	// was read again after a conflict. Writing it anyway would bump the modification time of the policy.
+	return c.updatePolicyRule(ctx, policyID, rule.ID, errs, func(current graphmodels.UnifiedRoleManagementPolicyRuleable) (any, error) {
+		if read, ok := pimpolicy.ParseRule(current); ok && pimpolicy.Equal(read, rule) {
+			tflog.Debug(ctx, "policy rule already up to date", map[string]any{"policy_id": policyID, "rule_id": rule.ID})
+			return nil, nil
+		}
+
+		return body, nil
+	})
+}
+
+// policyRuleErrors are the errors of the service package calling updatePolicyRule.
+type policyRuleErrors struct {
+	notFound error
+	conflict error
+}
+
+// updatePolicyRule updates the rule ruleID of a policy. The rule is read before it is written, and the write is
+// conditional on the ETag of the read, so a concurrent change by another Terraform run or in the portal is not
+// silently overwritten. update gets the rule as read, and returns the body to write, or nil if the rule is up to date.
+// On a conflict the rule is read again and update called again, up to graph_max_retries. Throttled writes are only
+// retried by the retry handler of the SDK, like all other Graph calls.
+func (c *graphClient) updatePolicyRule(ctx context.Context, policyID, ruleID string, errs policyRuleErrors, update func(graphmodels.UnifiedRoleManagementPolicyRuleable) (any, error)) error {
+	ruleURL := fmt.Sprintf("%s/policies/roleManagementPolicies/%s/rules/%s", c.options.GraphBaseURL, policyID, ruleID)
+	for attempt := 0; ; attempt++ {
+		// The token is requested on every attempt, as the delays of throttled writes can outlast it.
+		t, err := c.options.Credential.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{c.options.GraphScope()}})
+		if err != nil {
+			return fmt.Errorf("unable to get token: %w", err)
+		}
+
+		current, etag, err := c.getPolicyRule(ctx, policyID, ruleID, errs.notFound)
+		if err != nil {
+			return err
+		}
+
+		body, err := update(current)
+		if err != nil || body == nil {
+			return err
+		}
+
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to marshal body: %w", err)
+		}
+
+		resp, err := c.patchPolicyRule(ctx, ruleURL, t.Token, etag, b)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+			resp.Body.Close()
+			return nil
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("unable to read response body: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("policy %s: %w", policyID, errs.notFound)
+		}
+
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			return fmt.Errorf("unable to update unified role management policy rule, got %d want %d: %s", resp.StatusCode, http.StatusOK, sanitize(string(respBody)))
+		}
+
+		if attempt >= c.options.MaxRetries {
+			return fmt.Errorf("policy %s: %w", policyID, errs.conflict)
+		}
+
+		tflog.Info(ctx, "policy rule was changed concurrently, reading it again", map[string]any{"attempt": attempt + 1, "policy_id": policyID})
+	}
+}
+
+// getPolicyRule reads the rule ruleID of a policy, along with its ETag. The ETag is empty when Graph does not return
+// one.
+func (c *graphClient) getPolicyRule(ctx context.Context, policyID, ruleID string, errNotFound error) (graphmodels.UnifiedRoleManagementPolicyRuleable, string, error) {
+	rule, err := c.sdk.
+		Policies().
+		RoleManagementPolicies().
+		ByUnifiedRoleManagementPolicyId(policyID).
+		Rules().
+		ByUnifiedRoleManagementPolicyRuleId(ruleID).
+		Get(ctx, nil)
+	if isNotFound(err) {
+		return nil, "", fmt.Errorf("policy %s: %w", policyID, errNotFound)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get unified role management policy rule: %w", err)
+	}
+
+	etag, _ := rule.GetAdditionalData()["@odata.etag"].(*string)
+	if etag == nil {
+		return rule, "", nil
+	}
+
+	return rule, *etag, nil
+}
+
+// patchPolicyRule sends a single PATCH of a policy rule. The PATCH only succeeds if the rule still has etag, unless
+// etag is empty.
+func (c *graphClient) patchPolicyRule(ctx context.Context, ruleURL, token, etag string, body []byte) (*http.Response, error) {
+	// The rule is small, and the retry handler can only rewind an uncompressed body.
+	noCompression := khttp.NewCompressionOptions(false)
+	ctx = context.WithValue(ctx, noCompression.GetKey(), noCompression)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, ruleURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	// The retry handler rewinds the body of a retried request when it can seek.
+	req.Body = rewindableBody{bytes.NewReader(body)}
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) { return rewindableBody{bytes.NewReader(body)}, nil }
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	resp, err := c.options.GraphHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request: %w", err)
+	}
+
+	return resp, nil
+}
+
+// rewindableBody is a request body which can seek, and needs no closing.
+type rewindableBody struct {
+	*bytes.Reader
+}
+
+func (rewindableBody) Close() error {
+	return nil
+}
+
+// correlationMiddleware sets a fixed client-request-id on every request, replacing the one generated by the SDK.
+type correlationMiddleware struct {
+	correlationID string
+}
+
+func (m *correlationMiddleware) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	req.Header.Set(clientRequestIDHeader, m.correlationID)
+	return pipeline.Next(req, middlewareIndex)
+}
@@ -0,0 +1,497 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+)
+
+// testPolicyRuleServer serves the expiration rule of a single policy like Graph, with an ETag which changes on every
+// update.
+type testPolicyRuleServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	rule    map[string]any
+	version int
+	// throttles is how many updates are still answered with 429 Too Many Requests.
+	throttles int
+	// conflicts is how many updates still see a concurrent change of the rule, and fail with 412 Precondition Failed.
+	conflicts int
+	// patches counts the updates received.
+	patches int
+}
+
+func newTestPolicyRuleServer(t *testing.T) *testPolicyRuleServer {
+	t.Helper()
+
+	s := &testPolicyRuleServer{rule: map[string]any{
+		"@odata.type":          "#microsoft.graph.unifiedRoleManagementPolicyExpirationRule",
+		"id":                   "Expiration_Admin_Eligibility",
+		"isExpirationRequired": true,
+		"maximumDuration":      "P365D",
+	}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *testPolicyRuleServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	etag := fmt.Sprintf(`W/"%d"`, s.version)
+
+	switch r.Method {
+	case http.MethodGet:
+		rule := map[string]any{"@odata.etag": etag}
+		for k, v := range s.rule {
+			rule[k] = v
+		}
+		writeTestJSON(w, http.StatusOK, rule)
+	case http.MethodPatch:
+		s.patches++
+		if s.throttles > 0 {
+			s.throttles--
+			w.Header().Set("Retry-After", "0")
+			writeTestJSON(w, http.StatusTooManyRequests, map[string]any{"error": map[string]any{"code": "TooManyRequests"}})
+			return
+		}
+
+		if s.conflicts > 0 {
+			s.conflicts--
+			s.version++
+		}
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != fmt.Sprintf(`W/"%d"`, s.version) {
+			writeTestJSON(w, http.StatusPreconditionFailed, map[string]any{"error": map[string]any{"code": "PreconditionFailed"}})
+			return
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&s.rule); err != nil {
+			writeTestJSON(w, http.StatusBadRequest, map[string]any{"error": map[string]any{"code": "BadRequest"}})
+			return
+		}
+		s.version++
+		writeTestJSON(w, http.StatusOK, s.rule)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// setRule replaces the rule, like an administrator editing it in the portal.
+func (s *testPolicyRuleServer) setRule(rule map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rule = rule
+	s.version++
+}
+
+func (s *testPolicyRuleServer) currentRule() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rule
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newTestPagedServer serves the items of each path like Graph, in pages of two linked by "@odata.nextLink".
+func newTestPagedServer(t *testing.T, items map[string][]map[string]any) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		all, ok := items[r.URL.Path]
+		if !ok {
+			writeTestJSON(w, http.StatusNotFound, map[string]any{"error": map[string]any{"code": "Request_ResourceNotFound"}})
+			return
+		}
+
+		start, _ := strconv.Atoi(r.URL.Query().Get("$skiptoken"))
+		end := min(start+2, len(all))
+		page := map[string]any{"value": all[start:end]}
+		if end < len(all) {
+			page["@odata.nextLink"] = fmt.Sprintf("%s%s?$skiptoken=%d", server.URL, r.URL.Path, end)
+		}
+		writeTestJSON(w, http.StatusOK, page)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func testGraphClient(t *testing.T, server *httptest.Server, maxRetries int) *graphClient {
+	t.Helper()
+
+	pd := &providerData{baseURL: server.URL + "/beta", maxRetries: maxRetries}
+	client, err := newGraphClient(pd.clientsOptions(&fakeCredential{token: "token"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestTokenObjectID(t *testing.T) {
+	// The payload is {"oid":"00000000-0000-0000-0000-00000000abcd"}.
+	payload := "eyJvaWQiOiIwMDAwMDAwMC0wMDAwLTAwMDAtMDAwMC0wMDAwMDAwMGFiY2QifQ"
+
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{name: "jwt", token: "header." + payload + ".signature", want: "00000000-0000-0000-0000-00000000abcd"},
+		{name: "not a jwt", token: "token", wantErr: true},
+		{name: "no oid", token: "header.e30.signature", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenObjectID(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tokenObjectID() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("tokenObjectID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphClientRetriesThrottledPolicyRuleUpdate(t *testing.T) {
+	ctx := context.Background()
+	server := newTestPolicyRuleServer(t)
+
+	// Only the retry handler of the SDK retries throttled updates, so they are not retried twice as often as
+	// configured.
+	handlerRetries := 2
+	pd := &providerData{
+		baseURL:       server.URL + "/beta",
+		maxRetries:    2,
+		clientOptions: clientOptions{maxRetries: &handlerRetries},
+	}
+	client, err := newGraphClient(pd.clientsOptions(&fakeCredential{token: "token"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.throttles = 2
+	if err := client.UpdatePolicyExpirationRule(ctx, "policy-id", false); err != nil {
+		t.Fatalf("got error %s, want the update to succeed on the last retry", err)
+	}
+
+	server.throttles = 3
+	if err := client.UpdatePolicyExpirationRule(ctx, "policy-id", true); err == nil {
+		t.Fatal("got no error, want an error once the retries are exhausted")
+	}
+
+}
+
+func TestGraphClientPolicyRuleUpdateConflict(t *testing.T) {
+	ctx := context.Background()
+	server := newTestPolicyRuleServer(t)
+	client := testGraphClient(t, server.Server, 2)
+
+	server.conflicts = 1
+	if err := client.UpdatePolicyExpirationRule(ctx, "policy-id", false); err != nil {
+		t.Fatalf("got error %s, want the update to succeed after reading the rule again", err)
+	}
+
+	// An administrator changes the maximum duration in the portal, which is kept by the next update.
+	server.setRule(map[string]any{
+		"@odata.type":          "#microsoft.graph.unifiedRoleManagementPolicyExpirationRule",
+		"id":                   "Expiration_Admin_Eligibility",
+		"isExpirationRequired": false,
+		"maximumDuration":      "P180D",
+	})
+	if err := client.UpdatePolicyExpirationRule(ctx, "policy-id", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if rule := server.currentRule(); rule["isExpirationRequired"] != true || rule["maximumDuration"] != "P180D" {
+		t.Errorf("got expiration rule %v, want isExpirationRequired true and the maximum duration kept", rule)
+	}
+
+	server.conflicts = 3
+	if err := client.UpdatePolicyExpirationRule(ctx, "policy-id", false); !errors.Is(err, grouppim.ErrPolicyConflict) {
+		t.Fatalf("got error %v, want %v once the retries are exhausted", err, grouppim.ErrPolicyConflict)
+	}
+}
+
+func TestGraphClientTemplatePolicyRuleUpdateConflict(t *testing.T) {
+	ctx := context.Background()
+	server := newTestPolicyRuleServer(t)
+	client := testGraphClient(t, server.Server, 2)
+	rule := pimpolicy.Rule{ID: pimpolicy.RuleExpirationAdminEligibility, IsExpirationRequired: true, MaximumDuration: "P90D"}
+
+	server.conflicts = 1
+	if err := client.UpdatePolicyRule(ctx, "policy-id", rule); err != nil {
+		t.Fatalf("got error %s, want the update to succeed after reading the rule again", err)
+	}
+
+	if got := server.currentRule(); got["maximumDuration"] != "P90D" {
+		t.Errorf("got expiration rule %v, want the maximum duration of the template", got)
+	}
+
+	// The rule is read with the values of the template, and not written again.
+	patches := server.patches
+	if err := client.UpdatePolicyRule(ctx, "policy-id", rule); err != nil {
+		t.Fatal(err)
+	}
+	if server.patches != patches {
+		t.Errorf("got %d updates of an unchanged rule, want none", server.patches-patches)
+	}
+
+	rule.MaximumDuration = "P60D"
+	server.conflicts = 3
+	if err := client.UpdatePolicyRule(ctx, "policy-id", rule); !errors.Is(err, pimpolicy.ErrPolicyConflict) {
+		t.Fatalf("got error %v, want %v once the retries are exhausted", err, pimpolicy.ErrPolicyConflict)
+	}
+}
+
+func TestGraphClientListGroupMembersAndActivationsPages(t *testing.T) {
+	ctx := context.Background()
+
+	var members, activations []map[string]any
+	for i := 0; i < 5; i++ {
+		members = append(members, map[string]any{"@odata.type": "#microsoft.graph.user", "id": fmt.Sprintf("member-%d", i)})
+	}
+	for i := 0; i < 3; i++ {
+		activations = append(activations, map[string]any{
+			"id":          fmt.Sprintf("activation-%d", i),
+			"groupId":     "group-id",
+			"principalId": fmt.Sprintf("member-%d", i),
+			"accessId":    "member",
+		})
+	}
+
+	server := newTestPagedServer(t, map[string][]map[string]any{
+		"/beta/groups/group-id/members":                                               members,
+		"/beta/identityGovernance/privilegedAccess/group/assignmentScheduleInstances": activations,
+	})
+	client := testGraphClient(t, server, 0)
+
+	gotMembers, err := client.ListGroupMembers(ctx, "group-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotMembers) != len(members) {
+		t.Errorf("got %d members, want %d from all pages", len(gotMembers), len(members))
+	}
+
+	gotActivations, err := client.ListAssignmentScheduleInstances(ctx, "groupId eq 'group-id'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotActivations) != len(activations) {
+		t.Errorf("got %d activations, want %d from all pages", len(gotActivations), len(activations))
+	}
+}
+
+func TestGraphClientListEligibilityScheduleRequestsPages(t *testing.T) {
+	var requests []map[string]any
+	for i := 0; i < 5; i++ {
+		requests = append(requests, map[string]any{
+			"id":          fmt.Sprintf("request-%d", i),
+			"groupId":     "group-id",
+			"principalId": "principal-id",
+			"accessId":    "member",
+			"status":      "Provisioned",
+		})
+	}
+
+	server := newTestPagedServer(t, map[string][]map[string]any{
+		"/beta/identityGovernance/privilegedAccess/group/eligibilityScheduleRequests": requests,
+	})
+
+	got, err := testGraphClient(t, server, 0).ListEligibilityScheduleRequests(context.Background(), "groupId eq 'group-id'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(requests) {
+		t.Errorf("got %d requests, want %d from all pages", len(got), len(requests))
+	}
+}
+
+func TestGraphClientListAllGroupsPages(t *testing.T) {
+	var groups []map[string]any
+	for i := 0; i < 5; i++ {
+		groups = append(groups, map[string]any{"id": fmt.Sprintf("group-%d", i), "displayName": fmt.Sprintf("Group %d", i)})
+	}
+
+	server := newTestPagedServer(t, map[string][]map[string]any{"/beta/groups": groups})
+	client := testGraphClient(t, server, 0)
+
+	var pages, got int
+	err := client.ListAllGroups(context.Background(), "securityEnabled eq true", func(page []graphmodels.Groupable) error {
+		pages++
+		got += len(page)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 3 || got != len(groups) {
+		t.Errorf("got %d groups in %d pages, want %d groups in 3 pages", got, pages, len(groups))
+	}
+
+	// An error of the visit stops the listing.
+	errStop := errors.New("stop")
+	pages = 0
+	err = client.ListAllGroups(context.Background(), "securityEnabled eq true", func(page []graphmodels.Groupable) error {
+		pages++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || pages != 1 {
+		t.Errorf("got error %v after %d pages, want the error of the first page", err, pages)
+	}
+}
+
+func TestGraphClientListGroupMemberChanges(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("$deltatoken") {
+		case "":
+			// The members of the initial synchronization are spread over two pages.
+			if r.URL.Query().Get("$skiptoken") == "" {
+				if got, want := r.URL.Query().Get("$filter"), "id eq 'group-id'"; got != want {
+					t.Errorf("got filter %q, want %q", got, want)
+				}
+				writeTestJSON(w, http.StatusOK, map[string]any{
+					"value":           []any{map[string]any{"id": "group-id", "members@delta": []any{map[string]any{"@odata.type": "#microsoft.graph.user", "id": "member-1"}}}},
+					"@odata.nextLink": "http://" + r.Host + r.URL.Path + "?$skiptoken=page-2",
+				})
+				return
+			}
+			writeTestJSON(w, http.StatusOK, map[string]any{
+				"value":            []any{map[string]any{"id": "group-id", "members@delta": []any{map[string]any{"@odata.type": "#microsoft.graph.device", "id": "member-2"}}}},
+				"@odata.deltaLink": "http://" + r.Host + r.URL.Path + "?$deltatoken=token-1",
+			})
+		case "token-1":
+			writeTestJSON(w, http.StatusOK, map[string]any{
+				"value":            []any{map[string]any{"id": "group-id", "members@delta": []any{map[string]any{"@odata.type": "#microsoft.graph.user", "id": "member-1", "@removed": map[string]any{"reason": "deleted"}}}}},
+				"@odata.deltaLink": "http://" + r.Host + r.URL.Path + "?$deltatoken=token-2",
+			})
+		default:
+			writeTestJSON(w, http.StatusGone, map[string]any{"error": map[string]any{"code": "syncStateNotFound"}})
+		}
+	}))
+	defer server.Close()
+
+	client := testGraphClient(t, server, 0)
+
+	changes, deltaLink, err := client.ListGroupMemberChanges(ctx, "group-id", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []directory.MemberChange{
+		{Member: directory.Member{ID: "member-1", OdataType: "#microsoft.graph.user"}},
+		{Member: directory.Member{ID: "member-2", OdataType: "#microsoft.graph.device"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+
+	changes, deltaLink, err = client.ListGroupMemberChanges(ctx, "group-id", deltaLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []directory.MemberChange{{Member: directory.Member{ID: "member-1", OdataType: "#microsoft.graph.user"}, Removed: true}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+	if !strings.HasSuffix(deltaLink, "token-2") {
+		t.Errorf("got delta link %q, want the delta link of the last page", deltaLink)
+	}
+
+	if _, _, err := client.ListGroupMemberChanges(ctx, "group-id", server.URL+"/beta/groups/delta?$deltatoken=expired"); !errors.Is(err, directory.ErrDeltaExpired) {
+		t.Errorf("got error %v, want ErrDeltaExpired", err)
+	}
+}
+
+func TestGraphClientListGroupChanges(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("$deltatoken") {
+		case "":
+			if got, want := r.URL.Query().Get("$select"), "id,securityEnabled"; got != want {
+				t.Errorf("got select %q, want %q", got, want)
+			}
+			if r.URL.Query().Get("$skiptoken") == "" {
+				writeTestJSON(w, http.StatusOK, map[string]any{
+					"value":           []any{map[string]any{"id": "group-1", "securityEnabled": true}},
+					"@odata.nextLink": "http://" + r.Host + r.URL.Path + "?$skiptoken=page-2",
+				})
+				return
+			}
+			writeTestJSON(w, http.StatusOK, map[string]any{
+				"value":            []any{map[string]any{"id": "group-2", "securityEnabled": false}},
+				"@odata.deltaLink": "http://" + r.Host + r.URL.Path + "?$deltatoken=token-1",
+			})
+		case "token-1":
+			writeTestJSON(w, http.StatusOK, map[string]any{
+				"value":            []any{map[string]any{"id": "group-1", "@removed": map[string]any{"reason": "changed"}}},
+				"@odata.deltaLink": "http://" + r.Host + r.URL.Path + "?$deltatoken=token-2",
+			})
+		default:
+			writeTestJSON(w, http.StatusGone, map[string]any{"error": map[string]any{"code": "syncStateNotFound"}})
+		}
+	}))
+	defer server.Close()
+
+	client := testGraphClient(t, server, 0)
+
+	changes, deltaLink, err := client.ListGroupChanges(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	securityEnabled, notSecurityEnabled := true, false
+	want := []directory.GroupChange{
+		{ID: "group-1", SecurityEnabled: &securityEnabled},
+		{ID: "group-2", SecurityEnabled: &notSecurityEnabled},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+
+	changes, deltaLink, err = client.ListGroupChanges(ctx, deltaLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []directory.GroupChange{{ID: "group-1", Removed: true}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+	if !strings.HasSuffix(deltaLink, "token-2") {
+		t.Errorf("got delta link %q, want the delta link of the last page", deltaLink)
+	}
+
+	if _, _, err := client.ListGroupChanges(ctx, server.URL+"/beta/groups/delta?$deltatoken=expired"); !errors.Is(err, directory.ErrDeltaExpired) {
+		t.Errorf("got error %v, want ErrDeltaExpired", err)
+	}
+}
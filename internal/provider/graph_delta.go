@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+)
+
+// groupDeltaPage is a page of the response to a delta query of groups.
+type groupDeltaPage struct {
+	Value []struct {
+		ID           string `json:"id"`
+		MembersDelta []struct {
+			OdataType string `json:"@odata.type"`
+			ID        string `json:"id"`
+			// Removed is only set on members which were removed.
+			Removed *struct {
+				Reason string `json:"reason"`
+			} `json:"@removed"`
+		} `json:"members@delta"`
+	} `json:"value"`
+	NextLink  string `json:"@odata.nextLink"`
+	DeltaLink string `json:"@odata.deltaLink"`
+}
+
+// ListGroupMemberChanges is implemented without SDK, as the SDK drops the members@delta annotation of the groups it
+// returns.
+func (c *graphClient) ListGroupMemberChanges(ctx context.Context, groupID, deltaLink string) ([]directory.MemberChange, string, error) {
+	t, err := c.options.Credential.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{c.options.GraphScope()}})
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get token: %w", err)
+	}
+
+	next := deltaLink
+	if next == "" {
+		query := url.Values{
+			"$filter": []string{fmt.Sprintf("id eq '%s'", groupID)},
+			"$select": []string{"members"},
+		}
+		next = c.options.GraphBaseURL + "/groups/delta?" + query.Encode()
+	}
+
+	// The members of a group are spread over the pages, and the last page has the delta link.
+	var changes []directory.MemberChange
+	for {
+		var page groupDeltaPage
+		if err := c.getDeltaPage(ctx, next, t.Token, &page); err != nil {
+			return nil, "", err
+		}
+
+		for _, g := range page.Value {
+			for _, m := range g.MembersDelta {
+				changes = append(changes, directory.MemberChange{
+					Member:  directory.Member{ID: m.ID, OdataType: m.OdataType},
+					Removed: m.Removed != nil,
+				})
+			}
+		}
+
+		if page.NextLink == "" {
+			if page.DeltaLink == "" {
+				return nil, "", fmt.Errorf("the last page of the delta query of group %q has no delta link", groupID)
+			}
+
+			return changes, page.DeltaLink, nil
+		}
+		next = page.NextLink
+	}
+}
+
+// groupChangesPage is a page of the response to a delta query of the groups of the tenant.
+type groupChangesPage struct {
+	Value []struct {
+		ID string `json:"id"`
+		// SecurityEnabled is only set when it changed.
+		SecurityEnabled *bool `json:"securityEnabled"`
+		// Removed is only set on groups which were deleted.
+		Removed *struct {
+			Reason string `json:"reason"`
+		} `json:"@removed"`
+	} `json:"value"`
+	NextLink  string `json:"@odata.nextLink"`
+	DeltaLink string `json:"@odata.deltaLink"`
+}
+
+// ListGroupChanges is implemented without SDK like ListGroupMemberChanges, to share the paging of delta queries.
+func (c *graphClient) ListGroupChanges(ctx context.Context, deltaLink string) ([]directory.GroupChange, string, error) {
+	t, err := c.options.Credential.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{c.options.GraphScope()}})
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get token: %w", err)
+	}
+
+	next := deltaLink
+	if next == "" {
+		query := url.Values{
+			"$select": []string{"id,securityEnabled"},
+		}
+		next = c.options.GraphBaseURL + "/groups/delta?" + query.Encode()
+	}
+
+	var changes []directory.GroupChange
+	for {
+		var page groupChangesPage
+		if err := c.getDeltaPage(ctx, next, t.Token, &page); err != nil {
+			return nil, "", err
+		}
+
+		for _, g := range page.Value {
+			changes = append(changes, directory.GroupChange{
+				ID:              g.ID,
+				SecurityEnabled: g.SecurityEnabled,
+				Removed:         g.Removed != nil,
+			})
+		}
+
+		if page.NextLink == "" {
+			if page.DeltaLink == "" {
+				return nil, "", fmt.Errorf("the last page of the delta query of groups has no delta link")
+			}
+
+			return changes, page.DeltaLink, nil
+		}
+		next = page.NextLink
+	}
+}
+
+// getDeltaPage GETs a page of a delta query and decodes it into v.
+func (c *graphClient) getDeltaPage(ctx context.Context, pageURL, token string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := c.options.GraphHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	// Graph answers with 410 Gone when it needs a full synchronization again.
+	if resp.StatusCode == http.StatusGone {
+		return directory.ErrDeltaExpired
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to get delta page, got %d want %d: %s", resp.StatusCode, http.StatusOK, sanitize(string(body)))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unable to parse response body: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &GroupEligibilityAssertion{}
+
+func NewGroupEligibilityAssertion() datasource.DataSource {
+	return &GroupEligibilityAssertion{}
+}
+
+// GroupEligibilityAssertion defines the data source implementation.
+type GroupEligibilityAssertion struct {
+	service *grouppim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// GroupEligibilityAssertionModel describes the data source data model.
+type GroupEligibilityAssertionModel struct {
+	Scope        customtypes.GUID    `tfsdk:"scope"`
+	PrincipalID  customtypes.GUID    `tfsdk:"principal_id"`
+	Role         types.String        `tfsdk:"role"`
+	MinRemaining types.String        `tfsdk:"min_remaining"`
+	MemberType   types.String        `tfsdk:"member_type"`
+	EndDateTime  customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+func (d *GroupEligibilityAssertion) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_eligibility_assertion"
+}
+
+func (d *GroupEligibilityAssertion) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Asserts that a principal is eligible for a role in a PIM enabled group, and fails to read when it is not, or when the
+eligibility expires within ` + "`min_remaining`" + `. It is meant to be used in a ` + "`check`" + ` block, where
+Terraform reports the failure as a warning without stopping the run, to continuously validate eligibilities which are
+managed elsewhere.
+
+Eligibilities the principal holds through membership of a group satisfy the assertion.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.Read.AzureADGroup
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "The group.",
+				Required:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "The principal which must be eligible.",
+				Required:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "Either `member` or `owner`.",
+				Required:            true,
+				Validators:          []validator.String{stringvalidator.OneOf("owner", "member")},
+			},
+			"min_remaining": schema.StringAttribute{
+				MarkdownDescription: "Fail when the eligibility expires within this duration, such as `720h`. Eligibilities which do not expire always satisfy it.",
+				Optional:            true,
+				Validators:          []validator.String{durationValidator{}},
+			},
+			"member_type": schema.StringAttribute{
+				MarkdownDescription: "`direct` when the principal is eligible itself, `group` when it is eligible through a group.",
+				Computed:            true,
+			},
+			"end_date_time": schema.StringAttribute{
+				MarkdownDescription: "When the eligibility expires. Empty for permanent eligibilities.",
+				Computed:            true,
+				CustomType:          customtypes.RFC3339Type{},
+			},
+		},
+	}
+}
+
+func (d *GroupEligibilityAssertion) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = grouppim.NewService(pd.clients.GroupPIM)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *GroupEligibilityAssertion) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "eligibility assertion") }()
+
+	var data GroupEligibilityAssertionModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	assignments, err := d.service.ListEligibleAssignments(ctx, data.Scope.ValueString(), data.PrincipalID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list eligible assignments: "+sanitizeError(err))
+		return
+	}
+
+	// The principal can be eligible both itself and through groups, so the eligibility lasting longest is asserted.
+	// Eligibilities without expiration outlast all others, and RFC 3339 timestamps in UTC sort chronologically.
+	var found *grouppim.EligibleAssignment
+	for i, a := range assignments {
+		if a.Role != data.Role.ValueString() {
+			continue
+		}
+		if found == nil || found.EndDateTime != "" && (a.EndDateTime == "" || a.EndDateTime > found.EndDateTime) {
+			found = &assignments[i]
+		}
+	}
+
+	if found == nil {
+		resp.Diagnostics.AddError(
+			"Eligibility not found",
+			fmt.Sprintf("%s is not eligible for the %s role in group %s.", data.PrincipalID.ValueString(), data.Role.ValueString(), data.Scope.ValueString()),
+		)
+		return
+	}
+
+	if found.EndDateTime != "" && !data.MinRemaining.IsNull() {
+		// The duration is validated by the schema.
+		minRemaining, _ := time.ParseDuration(data.MinRemaining.ValueString())
+
+		end, err := time.Parse(time.RFC3339, found.EndDateTime)
+		if err != nil {
+			resp.Diagnostics.AddError("Unexpected response", "Unable to parse endDateTime: "+err.Error())
+			return
+		}
+
+		if time.Until(end) < minRemaining {
+			resp.Diagnostics.AddError(
+				"Eligibility expires soon",
+				fmt.Sprintf("The eligibility of %s for the %s role in group %s expires at %s, within %s.",
+					data.PrincipalID.ValueString(), data.Role.ValueString(), data.Scope.ValueString(), found.EndDateTime, data.MinRemaining.ValueString()),
+			)
+			return
+		}
+	}
+
+	data.MemberType = types.StringValue(found.MemberType)
+	data.EndDateTime = customtypes.NewRFC3339Value(found.EndDateTime)
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
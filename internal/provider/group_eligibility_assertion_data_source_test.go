@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+func TestGroupEligibilityAssertionRead(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	service := grouppim.NewService(client)
+
+	for _, a := range []grouppim.EligibleAssignment{
+		{GroupID: "group-a", PrincipalID: "principal-1", Role: "member", EndDateTime: time.Now().Add(10 * 24 * time.Hour).UTC().Format(time.RFC3339)},
+		{GroupID: "group-a", PrincipalID: "principal-2", Role: "member"},
+	} {
+		if _, err := service.CreateEligibleAssignment(ctx, a); err != nil {
+			t.Fatalf("unable to create eligible assignment: %v", err)
+		}
+	}
+
+	d := &GroupEligibilityAssertion{service: service}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	tests := []struct {
+		name         string
+		principalID  string
+		role         string
+		minRemaining types.String
+		wantError    string
+	}{
+		{name: "eligible", principalID: "principal-1", role: "member", minRemaining: types.StringValue("168h")},
+		{name: "expires soon", principalID: "principal-1", role: "member", minRemaining: types.StringValue("720h"), wantError: "Eligibility expires soon"},
+		{name: "permanent", principalID: "principal-2", role: "member", minRemaining: types.StringValue("720h")},
+		{name: "other role", principalID: "principal-2", role: "owner", minRemaining: types.StringNull(), wantError: "Eligibility not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configState := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			if diags := configState.Set(ctx, GroupEligibilityAssertionModel{
+				Scope:        customtypes.NewGUIDValue("group-a"),
+				PrincipalID:  customtypes.NewGUIDValue(tt.principalID),
+				Role:         types.StringValue(tt.role),
+				MinRemaining: tt.minRemaining,
+			}); diags.HasError() {
+				t.Fatalf("unable to set config: %v", diags)
+			}
+
+			resp := &datasource.ReadResponse{State: tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}}
+			d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+
+			if tt.wantError != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tt.wantError {
+					t.Fatalf("got diagnostics %v, want error %q", resp.Diagnostics, tt.wantError)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+
+			var read GroupEligibilityAssertionModel
+			resp.State.Get(ctx, &read)
+			if read.MemberType.ValueString() != "direct" {
+				t.Errorf("got member type %q, want direct", read.MemberType.ValueString())
+			}
+		})
+	}
+}
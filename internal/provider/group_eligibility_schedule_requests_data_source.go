@@ -0,0 +1,240 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &GroupEligibilityScheduleRequests{}
+
+func NewGroupEligibilityScheduleRequests() datasource.DataSource {
+	return &GroupEligibilityScheduleRequests{}
+}
+
+// GroupEligibilityScheduleRequests defines the data source implementation.
+type GroupEligibilityScheduleRequests struct {
+	service   *grouppim.Service
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// GroupEligibilityScheduleRequestsModel describes the data source data model.
+type GroupEligibilityScheduleRequestsModel struct {
+	Scope       customtypes.GUID                            `tfsdk:"scope"`
+	PrincipalID customtypes.GUID                            `tfsdk:"principal_id"`
+	Status      types.String                                `tfsdk:"status"`
+	CreatedBy   customtypes.GUID                            `tfsdk:"created_by"`
+	Requests    []GroupEligibilityScheduleRequestsItemModel `tfsdk:"requests"`
+}
+
+// GroupEligibilityScheduleRequestsItemModel describes an eligibility schedule request listed by the data source.
+type GroupEligibilityScheduleRequestsItemModel struct {
+	ID              types.String        `tfsdk:"id"`
+	Scope           customtypes.GUID    `tfsdk:"scope"`
+	PrincipalID     customtypes.GUID    `tfsdk:"principal_id"`
+	Role            types.String        `tfsdk:"role"`
+	Status          types.String        `tfsdk:"status"`
+	Justification   types.String        `tfsdk:"justification"`
+	CreatedBy       customtypes.GUID    `tfsdk:"created_by"`
+	CreatedDateTime customtypes.RFC3339 `tfsdk:"created_date_time"`
+	StartDateTime   customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime     customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+func (d *GroupEligibilityScheduleRequests) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_eligibility_schedule_requests"
+}
+
+func (d *GroupEligibilityScheduleRequests) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists the eligibility schedule requests of PIM enabled groups, e.g. to detect requests created by Terraform which are
+stuck waiting for approval.
+
+Graph can only list eligibility schedule requests by group or principal. Without ` + "`scope`" + ` or ` + "`principal_id`" + `,
+every security group in the tenant is queried, which takes a while in large tenants.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.Read.AzureADGroup
+- Group.Read.All
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "Only list the requests of this group.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "Only list the requests for this principal.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "Only list requests with this status, e.g. `PendingApproval`.",
+				Optional:            true,
+				Validators:          []validator.String{stringvalidator.OneOf(scheduleRequestStatuses...)},
+			},
+			"created_by": schema.StringAttribute{
+				MarkdownDescription: "Only list the requests made by this user or service principal, i.e. the requestor.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"requests": schema.ListNestedAttribute{
+				MarkdownDescription: "The eligibility schedule requests, oldest first.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the eligibility schedule request.",
+							Computed:            true,
+						},
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The group of the request.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"principal_id": schema.StringAttribute{
+							MarkdownDescription: "The principal to make eligible.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "The role the principal is made eligible for.",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							Computed: true,
+						},
+						"justification": schema.StringAttribute{
+							Computed: true,
+						},
+						"created_by": schema.StringAttribute{
+							MarkdownDescription: "The object ID of the user or application which made the request.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"created_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *GroupEligibilityScheduleRequests) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = grouppim.NewService(pd.clients.GroupPIM)
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.directory.SetSecurityGroupCache(pd.securityGroups)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *GroupEligibilityScheduleRequests) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of eligibility schedule requests") }()
+
+	var data GroupEligibilityScheduleRequestsModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	groupIDs := []string{data.Scope.ValueString()}
+	if data.Scope.ValueString() == "" && data.PrincipalID.ValueString() == "" {
+		var err error
+		groupIDs, err = d.directory.SecurityGroupIDs(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list groups: "+sanitizeError(err))
+			return
+		}
+
+		tflog.Debug(ctx, "listing eligibility schedule requests of all security groups", map[string]any{"groups": len(groupIDs)})
+	}
+
+	var requests []grouppim.EligibleAssignment
+	for _, groupID := range groupIDs {
+		groupRequests, err := d.service.ListScheduleRequests(ctx, groupID, data.PrincipalID.ValueString(), data.Status.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list eligibility schedule requests: "+sanitizeError(err))
+			return
+		}
+		requests = append(requests, groupRequests...)
+	}
+
+	// RFC 3339 timestamps in UTC sort chronologically.
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CreatedDateTime < requests[j].CreatedDateTime
+	})
+
+	data.Requests = []GroupEligibilityScheduleRequestsItemModel{}
+	for _, r := range requests {
+		if createdBy := data.CreatedBy.ValueString(); createdBy != "" && !strings.EqualFold(r.CreatedBy, createdBy) {
+			continue
+		}
+
+		data.Requests = append(data.Requests, GroupEligibilityScheduleRequestsItemModel{
+			ID:              types.StringValue(r.RequestID),
+			Scope:           customtypes.NewGUIDValue(r.GroupID),
+			PrincipalID:     customtypes.NewGUIDValue(r.PrincipalID),
+			Role:            types.StringValue(r.Role),
+			Status:          types.StringValue(r.Status),
+			Justification:   types.StringValue(r.Justification),
+			CreatedBy:       customtypes.NewGUIDValue(r.CreatedBy),
+			CreatedDateTime: customtypes.NewRFC3339Value(r.CreatedDateTime),
+			StartDateTime:   customtypes.NewRFC3339Value(r.StartDateTime),
+			EndDateTime:     customtypes.NewRFC3339Value(r.EndDateTime),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
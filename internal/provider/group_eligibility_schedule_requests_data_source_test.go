@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+func TestGroupEligibilityScheduleRequestsRead(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	service := grouppim.NewService(client)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, a := range []grouppim.EligibleAssignment{
+		{GroupID: "group-a", PrincipalID: "principal-1", Role: "member"},
+		{GroupID: "group-b", PrincipalID: "principal-2", Role: "member"},
+		{GroupID: "group-b", PrincipalID: "principal-3", Role: "owner"},
+		{GroupID: "group-b", PrincipalID: "principal-4", Role: "owner"},
+	} {
+		if _, err := service.CreateEligibleAssignment(ctx, a); err != nil {
+			t.Fatalf("unable to create eligible assignment: %v", err)
+		}
+
+		// Every request but the one for principal-3 is pending, and the one for principal-4 was made by somebody else.
+		r := client.requests[len(client.requests)-1]
+		created := now.Add(-time.Duration(i) * time.Hour)
+		r.SetCreatedDateTime(&created)
+		r.SetCreatedBy(testIdentitySet("caller-id"))
+		if a.PrincipalID != "principal-3" {
+			r.SetStatus(toPtr("PendingApproval"))
+		}
+		if a.PrincipalID == "principal-4" {
+			r.SetCreatedBy(testIdentitySet("other-id"))
+		}
+	}
+
+	d := &GroupEligibilityScheduleRequests{
+		service:   service,
+		directory: directory.NewService(fakeDirectoryClient{tenantGroups: {"group-a", "group-b"}}),
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, GroupEligibilityScheduleRequestsModel{
+		Scope:       customtypes.NewGUIDNull(),
+		PrincipalID: customtypes.NewGUIDNull(),
+		Status:      types.StringValue("PendingApproval"),
+		CreatedBy:   customtypes.NewGUIDValue("caller-id"),
+	}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var read GroupEligibilityScheduleRequestsModel
+	resp.State.Get(ctx, &read)
+
+	if len(read.Requests) != 2 {
+		t.Fatalf("got %d requests, want the pending requests of principal-1 and principal-2", len(read.Requests))
+	}
+	if got := read.Requests[0].PrincipalID.ValueString(); got != "principal-2" {
+		t.Errorf("got first request for %s, want the oldest for principal-2", got)
+	}
+	if got := read.Requests[1].Scope.ValueString(); got != "group-a" {
+		t.Errorf("got scope %s, want group-a", got)
+	}
+	if got := read.Requests[1].CreatedDateTime.ValueString(); got != "2024-05-01T12:00:00Z" {
+		t.Errorf("got created_date_time %s, want 2024-05-01T12:00:00Z", got)
+	}
+}
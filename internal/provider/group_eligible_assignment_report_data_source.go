@@ -0,0 +1,263 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &GroupEligibleAssignmentReport{}
+
+func NewGroupEligibleAssignmentReport() datasource.DataSource {
+	return &GroupEligibleAssignmentReport{}
+}
+
+// GroupEligibleAssignmentReport defines the data source implementation.
+type GroupEligibleAssignmentReport struct {
+	service   *grouppim.Service
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// GroupEligibleAssignmentReportModel describes the data source data model.
+type GroupEligibleAssignmentReportModel struct {
+	CreatedBy        customtypes.GUID                     `tfsdk:"created_by"`
+	TotalAssignments types.Int64                          `tfsdk:"total_assignments"`
+	Groups           []GroupEligibleAssignmentReportGroup `tfsdk:"groups"`
+}
+
+// GroupEligibleAssignmentReportGroup describes the eligible assignments for a role of a group.
+type GroupEligibleAssignmentReportGroup struct {
+	Scope           customtypes.GUID                          `tfsdk:"scope"`
+	Role            types.String                              `tfsdk:"role"`
+	NextEndDateTime customtypes.RFC3339                       `tfsdk:"next_end_date_time"`
+	Assignments     []GroupEligibleAssignmentReportAssignment `tfsdk:"assignments"`
+}
+
+// GroupEligibleAssignmentReportAssignment describes an eligible assignment in the report.
+type GroupEligibleAssignmentReportAssignment struct {
+	PrincipalID   customtypes.GUID    `tfsdk:"principal_id"`
+	StartDateTime customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime   customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+func (d *GroupEligibleAssignmentReport) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_eligible_assignment_report"
+}
+
+func (d *GroupEligibleAssignmentReport) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Summarizes the eligible assignments created by the provider's credentials, grouped by group and role, e.g. to render
+an access report per workspace in an output.
+
+Every security group in the tenant is queried, which takes a while in large tenants.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.Read.AzureADGroup
+- Group.Read.All
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"created_by": schema.StringAttribute{
+				MarkdownDescription: "Only report the eligible assignments created by this user or service principal. Defaults to the one the provider authenticates as.",
+				Optional:            true,
+				Computed:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"total_assignments": schema.Int64Attribute{
+				MarkdownDescription: "The number of eligible assignments in the report.",
+				Computed:            true,
+			},
+			"groups": schema.ListNestedAttribute{
+				MarkdownDescription: "The eligible assignments per group and role, ordered by group and role.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The group of the eligible assignments.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "The role the principals can assume.",
+							Computed:            true,
+						},
+						"next_end_date_time": schema.StringAttribute{
+							MarkdownDescription: "When the first of the eligible assignments expires, in RFC 3339 format. Empty when none of them expire.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+						"assignments": schema.ListNestedAttribute{
+							MarkdownDescription: "The eligible assignments, ordered by principal.",
+							Computed:            true,
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"principal_id": schema.StringAttribute{
+										MarkdownDescription: "The principal which is eligible.",
+										Computed:            true,
+										CustomType:          customtypes.GUIDType{},
+									},
+									"start_date_time": schema.StringAttribute{
+										Computed:   true,
+										CustomType: customtypes.RFC3339Type{},
+									},
+									"end_date_time": schema.StringAttribute{
+										MarkdownDescription: "When the eligible assignment expires. Empty when it does not expire.",
+										Computed:            true,
+										CustomType:          customtypes.RFC3339Type{},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *GroupEligibleAssignmentReport) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = grouppim.NewService(pd.clients.GroupPIM)
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.directory.SetSecurityGroupCache(pd.securityGroups)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *GroupEligibleAssignmentReport) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "eligible assignment report") }()
+
+	var data GroupEligibleAssignmentReportModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createdBy := data.CreatedBy.ValueString()
+	if createdBy == "" {
+		var err error
+		createdBy, err = d.directory.CallerObjectID(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to get the object ID of the provider credentials: "+sanitizeError(err))
+			return
+		}
+		data.CreatedBy = customtypes.NewGUIDValue(createdBy)
+	}
+
+	groupIDs, err := d.directory.SecurityGroupIDs(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list groups: "+sanitizeError(err))
+		return
+	}
+	sort.Strings(groupIDs)
+
+	// The requests of each group are summarized as soon as they are fetched, so only the summaries of all groups are
+	// held rather than the Graph models of every request in the tenant.
+	byGroup, err := fetchAll(ctx, groupIDs, maxConcurrentFetches, func(ctx context.Context, groupID string) ([]GroupEligibleAssignmentReportGroup, error) {
+		assignments, err := d.service.ListProvisionedRequests(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+
+		return summarizeGroupEligibleAssignments(groupID, createdBy, assignments), nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list eligible assignments: "+sanitizeError(err))
+		return
+	}
+
+	data.Groups = []GroupEligibleAssignmentReportGroup{}
+	var total int64
+	for _, groups := range byGroup {
+		for _, g := range groups {
+			data.Groups = append(data.Groups, g)
+			total += int64(len(g.Assignments))
+		}
+	}
+	data.TotalAssignments = types.Int64Value(total)
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// summarizeGroupEligibleAssignments summarizes the eligible assignments in groupID created by createdBy, per role.
+func summarizeGroupEligibleAssignments(groupID, createdBy string, assignments []grouppim.EligibleAssignment) []GroupEligibleAssignmentReportGroup {
+	byRole := map[string][]grouppim.EligibleAssignment{}
+	for _, a := range assignments {
+		if strings.EqualFold(a.CreatedBy, createdBy) {
+			byRole[a.Role] = append(byRole[a.Role], a)
+		}
+	}
+
+	var groups []GroupEligibleAssignmentReportGroup
+	for _, role := range []string{"member", "owner"} {
+		if len(byRole[role]) > 0 {
+			groups = append(groups, newGroupEligibleAssignmentReportGroup(groupID, role, byRole[role]))
+		}
+	}
+
+	return groups
+}
+
+// newGroupEligibleAssignmentReportGroup summarizes the eligible assignments for role in groupID.
+func newGroupEligibleAssignmentReportGroup(groupID, role string, assignments []grouppim.EligibleAssignment) GroupEligibleAssignmentReportGroup {
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].PrincipalID < assignments[j].PrincipalID })
+
+	g := GroupEligibleAssignmentReportGroup{
+		Scope:       customtypes.NewGUIDValue(groupID),
+		Role:        types.StringValue(role),
+		Assignments: make([]GroupEligibleAssignmentReportAssignment, 0, len(assignments)),
+	}
+
+	// RFC 3339 times in UTC, as returned by Graph, sort chronologically.
+	var next string
+	for _, a := range assignments {
+		if a.EndDateTime != "" && (next == "" || a.EndDateTime < next) {
+			next = a.EndDateTime
+		}
+
+		g.Assignments = append(g.Assignments, GroupEligibleAssignmentReportAssignment{
+			PrincipalID:   customtypes.NewGUIDValue(a.PrincipalID),
+			StartDateTime: customtypes.NewRFC3339Value(a.StartDateTime),
+			EndDateTime:   customtypes.NewRFC3339Value(a.EndDateTime),
+		})
+	}
+	g.NextEndDateTime = customtypes.NewRFC3339Value(next)
+
+	return g
+}
@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+func TestGroupEligibleAssignmentReportRead(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	service := grouppim.NewService(client)
+
+	for _, a := range []grouppim.EligibleAssignment{
+		{GroupID: "group-a", PrincipalID: "principal-2", Role: "member", EndDateTime: "2030-01-01T00:00:00Z"},
+		{GroupID: "group-a", PrincipalID: "principal-1", Role: "member", EndDateTime: "2029-01-01T00:00:00Z"},
+		{GroupID: "group-a", PrincipalID: "principal-3", Role: "owner"},
+		{GroupID: "group-b", PrincipalID: "principal-1", Role: "member"},
+	} {
+		created, err := service.CreateEligibleAssignment(ctx, a)
+		if err != nil {
+			t.Fatalf("unable to create eligible assignment: %v", err)
+		}
+
+		// The eligibility in group-b was created by somebody else.
+		createdBy := "caller-id"
+		if created.GroupID == "group-b" {
+			createdBy = "other-id"
+		}
+		client.requests[len(client.requests)-1].SetCreatedBy(testIdentitySet(createdBy))
+	}
+
+	d := &GroupEligibleAssignmentReport{
+		service:   service,
+		directory: directory.NewService(fakeDirectoryClient{tenantGroups: {"group-b", "group-a"}, caller: {"caller-id"}}),
+	}
+
+	report := testGroupEligibleAssignmentReportRead(t, d)
+
+	if report.CreatedBy.ValueString() != "caller-id" {
+		t.Errorf("got created_by %q, want %q", report.CreatedBy.ValueString(), "caller-id")
+	}
+	if report.TotalAssignments.ValueInt64() != 3 {
+		t.Errorf("got total_assignments %d, want 3", report.TotalAssignments.ValueInt64())
+	}
+	if len(report.Groups) != 2 {
+		t.Fatalf("got %d groups, want member and owner of group-a", len(report.Groups))
+	}
+
+	member := report.Groups[0]
+	if member.Scope.ValueString() != "group-a" || member.Role.ValueString() != "member" {
+		t.Errorf("got group %s role %s, want group-a role member", member.Scope.ValueString(), member.Role.ValueString())
+	}
+	if member.NextEndDateTime.ValueString() != "2029-01-01T00:00:00Z" {
+		t.Errorf("got next_end_date_time %q, want %q", member.NextEndDateTime.ValueString(), "2029-01-01T00:00:00Z")
+	}
+	if len(member.Assignments) != 2 || member.Assignments[0].PrincipalID.ValueString() != "principal-1" {
+		t.Errorf("got assignments %v, want principal-1 and principal-2", member.Assignments)
+	}
+
+	if owner := report.Groups[1]; owner.Role.ValueString() != "owner" || owner.NextEndDateTime.ValueString() != "" {
+		t.Errorf("got role %s next_end_date_time %q, want owner without expiration", owner.Role.ValueString(), owner.NextEndDateTime.ValueString())
+	}
+}
+
+func TestGroupEligibleAssignmentReportReadPages(t *testing.T) {
+	var requests []map[string]any
+	for i := 0; i < 5; i++ {
+		requests = append(requests, map[string]any{
+			"id":          fmt.Sprintf("request-%d", i),
+			"groupId":     "group-a",
+			"principalId": fmt.Sprintf("principal-%d", i),
+			"accessId":    "member",
+			"status":      grouppim.StatusProvisioned,
+			"createdBy":   map[string]any{"user": map[string]any{"id": "caller-id"}},
+		})
+	}
+
+	server := newTestPagedServer(t, map[string][]map[string]any{
+		"/beta/identityGovernance/privilegedAccess/group/eligibilityScheduleRequests": requests,
+	})
+	d := &GroupEligibleAssignmentReport{
+		service:   grouppim.NewService(testGraphClient(t, server, 0)),
+		directory: directory.NewService(fakeDirectoryClient{tenantGroups: {"group-a"}, caller: {"caller-id"}}),
+	}
+
+	report := testGroupEligibleAssignmentReportRead(t, d)
+	if report.TotalAssignments.ValueInt64() != int64(len(requests)) {
+		t.Errorf("got total_assignments %d, want %d from all pages", report.TotalAssignments.ValueInt64(), len(requests))
+	}
+}
+
+// testGroupEligibleAssignmentReportRead reads the report of the caller with d.
+func testGroupEligibleAssignmentReportRead(t *testing.T, d *GroupEligibleAssignmentReport) GroupEligibleAssignmentReportModel {
+	t.Helper()
+
+	ctx := context.Background()
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	configState := tfsdk.State{Schema: schemaResp.Schema, Raw: state.Raw}
+	if diags := configState.Set(ctx, GroupEligibleAssignmentReportModel{CreatedBy: customtypes.NewGUIDNull()}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: state}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var report GroupEligibleAssignmentReportModel
+	resp.State.Get(ctx, &report)
+
+	return report
+}
+
+// testIdentitySet returns an identity set of the user with the given object ID.
+func testIdentitySet(userID string) graphmodels.IdentitySetable {
+	user := graphmodels.NewIdentity()
+	user.SetId(&userID)
+
+	identity := graphmodels.NewIdentitySet()
+	identity.SetUser(user)
+
+	return identity
+}
@@ -4,35 +4,66 @@
 package provider
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
-	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
-	msgraphsdk "github.com/microsoftgraph/msgraph-beta-sdk-go"
-	"github.com/microsoftgraph/msgraph-beta-sdk-go/identitygovernance"
-	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
-	graphpolicies "github.com/microsoftgraph/msgraph-beta-sdk-go/policies"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// The values of on_destroy.
+const (
+	onDestroyRemove  = "remove"
+	onDestroyCancel  = "cancel"
+	onDestroyAbandon = "abandon"
+)
+
+// The values of multiple_requests.
+const (
+	multipleRequestsNewest = "newest"
+	multipleRequestsError  = "error"
+)
+
+// defaultAutoRenewWindow is the default of auto_renew_window.
+const defaultAutoRenewWindow = "720h"
+
+// Filtered lists of schedule requests can miss a request for a while after it was created, while Graph indexes it.
+// Reads of eligibilities created within indexingLagWindow retry a missing eligibility up to indexingRetries times.
+const (
+	indexingLagWindow  = 5 * time.Minute
+	indexingRetries    = 5
+	indexingRetryDelay = 2 * time.Second
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &GroupEligibleAssignment{}
 var _ resource.ResourceWithImportState = &GroupEligibleAssignment{}
+var _ resource.ResourceWithValidateConfig = &GroupEligibleAssignment{}
+var _ resource.ResourceWithModifyPlan = &GroupEligibleAssignment{}
+var _ resource.ResourceWithUpgradeState = &GroupEligibleAssignment{}
 
 func NewGroupEligibleAssignment() resource.Resource {
 	return &GroupEligibleAssignment{}
@@ -40,19 +71,62 @@ func NewGroupEligibleAssignment() resource.Resource {
 
 // GroupEligibleAssignment defines the resource implementation.
 type GroupEligibleAssignment struct {
-	graphClient *msgraphsdk.GraphServiceClient
+	service   *grouppim.Service
+	directory *directory.Service
+	resourceSettings
+	// blockMutations is set when mutating Graph calls are blocked, so refresh does not renew eligibilities.
+	blockMutations bool
+	// strictPolicy is set when the expiration policy of groups must not be changed, so a policy requiring expiration
+	// again is only reported and not planned back.
+	strictPolicy bool
+	// indexingRetryDelay is the delay between reads of a missing eligibility which was just created, zero for
+	// indexingRetryDelay.
+	indexingRetryDelay time.Duration
 }
 
 // GroupEligibleAssignmentModel describes the resource data model.
 type GroupEligibleAssignmentModel struct {
-	Id                   types.String `tfsdk:"id"`
-	Role                 types.String `tfsdk:"role"`
-	Scope                types.String `tfsdk:"scope"`
-	Justification        types.String `tfsdk:"justification"`
-	PrincipalID          types.String `tfsdk:"principal_id"`
-	Status               types.String `tfsdk:"status"`
-	StartDateTime        types.String `tfsdk:"start_date_time"`
-	EligibleAssignmentID types.String `tfsdk:"eligible_assignment_id"`
+	Id                       types.String        `tfsdk:"id"`
+	Role                     types.String        `tfsdk:"role"`
+	AccessID                 types.String        `tfsdk:"access_id"`
+	Scope                    customtypes.GUID    `tfsdk:"scope"`
+	GroupID                  customtypes.GUID    `tfsdk:"group_id"`
+	GroupDisplayName         types.String        `tfsdk:"group_display_name"`
+	Justification            types.String        `tfsdk:"justification"`
+	JustificationWO          types.String        `tfsdk:"justification_wo"`
+	JustificationWOVersion   types.Int64         `tfsdk:"justification_wo_version"`
+	PrincipalID              customtypes.GUID    `tfsdk:"principal_id"`
+	PrincipalUPN             types.String        `tfsdk:"principal_upn"`
+	PrincipalUserType        types.String        `tfsdk:"principal_user_type"`
+	PrincipalHomeDomain      types.String        `tfsdk:"principal_home_domain"`
+	Status                   types.String        `tfsdk:"status"`
+	StartDateTime            customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime              customtypes.RFC3339 `tfsdk:"end_date_time"`
+	ExpirationType           types.String        `tfsdk:"expiration_type"`
+	ExpirationDuration       types.String        `tfsdk:"expiration_duration"`
+	MemberType               types.String        `tfsdk:"member_type"`
+	CreatedBy                types.String        `tfsdk:"created_by"`
+	CreatedDateTime          customtypes.RFC3339 `tfsdk:"created_date_time"`
+	CompletedDateTime        customtypes.RFC3339 `tfsdk:"completed_date_time"`
+	TargetScheduleID         types.String        `tfsdk:"target_schedule_id"`
+	InstanceID               types.String        `tfsdk:"instance_id"`
+	PolicyID                 types.String        `tfsdk:"policy_id"`
+	PolicyExpirationRequired types.Bool          `tfsdk:"policy_expiration_required"`
+	EligibleAssignmentID     types.String        `tfsdk:"eligible_assignment_id"`
+	Debug                    types.Bool          `tfsdk:"debug"`
+	RawPayload               types.String        `tfsdk:"raw_payload"`
+	OnDestroy                types.String        `tfsdk:"on_destroy"`
+	DestroyJustification     types.String        `tfsdk:"destroy_justification"`
+	ForceDestroy             types.Bool          `tfsdk:"force_destroy"`
+	AutoRenew                types.Bool          `tfsdk:"auto_renew"`
+	AutoRenewWindow          types.String        `tfsdk:"auto_renew_window"`
+	MultipleRequests         types.String        `tfsdk:"multiple_requests"`
+	Timeouts                 timeouts.Value      `tfsdk:"timeouts"`
+}
+
+// throttleTarget describes the assignment in throttling warnings.
+func (m GroupEligibleAssignmentModel) throttleTarget() string {
+	return fmt.Sprintf("%s assignment of principal %s in group %s", m.Role.ValueString(), m.PrincipalID.ValueString(), m.Scope.ValueString())
 }
 
 func (r *GroupEligibleAssignment) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -61,6 +135,8 @@ func (r *GroupEligibleAssignment) Metadata(ctx context.Context, req resource.Met
 
 func (r *GroupEligibleAssignment) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		// Version 1 added group_id and access_id.
+		Version: 1,
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: `
 Enables PIM for an Entra group, manages an PIM Eligible Role Assignment and sets the PIM policy for the member role to allow for no expiration on eligible assignments.
@@ -69,254 +145,527 @@ It requires the following graph permissions:
 - PrivilegedEligibilitySchedule.ReadWrite.AzureADGroup
 - RoleManagementPolicy.ReadWrite.AzureADGroup
 
-The resource does not support all the available configuration options for PIM Eligible Role Assignment for groups and its associated policy. 
+The resource does not support all the available configuration options for PIM Eligible Role Assignment for groups and its associated policy.
+
+Creating the eligibility resolves the policy of the group, patches its expiration rule, submits the eligibility schedule request and looks up the created schedule instance, in this order. When a step fails, the error names the step and what the steps before it already changed in the tenant. If submitting the request fails after the policy was changed to allow eligible assignments without expiration, the policy is changed back to require expiration.
+
+Changing ` + "`role`" + `, ` + "`access_id`" + ` or the justification updates the eligibility in place, creating the eligibility for a new role before removing the old one. Changing ` + "`scope`" + `, ` + "`group_id`" + `, ` + "`group_display_name`" + `, ` + "`principal_id`" + ` or ` + "`principal_upn`" + ` replaces the resource instead, and by default Terraform removes the old eligibility before it creates the new one, so the principal is not eligible in between. Set ` + "`create_before_destroy`" + ` in the ` + "`lifecycle`" + ` block of the resource to create the new eligibility first.
 `,
 
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				Computed:            true,
-				MarkdownDescription: "The ID of the resource is the '{scope}|{principal_id}' value.",
+				MarkdownDescription: "The ID of the resource is the '{scope}|{principal_id}' value for the member role, and the '{scope}|{principal_id}|{role}' value for the owner role, so a principal can be eligible for both roles of a group.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
+					unknownIDOnRoleChange{},
 				},
 			},
 			"role": schema.StringAttribute{
 				// The equivalent of accessId in the SDK
-				MarkdownDescription: "The role in which the principal can assume.",
-				Required:            true,
+				MarkdownDescription: "The role in which the principal can assume. When it changes, the eligibility for the new role is created before the one for the old role is removed, so the principal stays eligible throughout. Exactly one of `role` or its alias `access_id` must be set.",
+				Optional:            true,
+				Computed:            true,
 				Validators:          []validator.String{stringvalidator.OneOf("owner", "member")},
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+			},
+			"access_id": schema.StringAttribute{
+				MarkdownDescription: "An alias of `role`, named like the property in Microsoft Graph. Both are set in state, whichever is configured.",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("owner", "member"),
+					stringvalidator.ExactlyOneOf(path.MatchRoot("role")),
 				},
 			},
 			"scope": schema.StringAttribute{
 				// The equivalent of groupId in the SDK
-				MarkdownDescription: "The target group of which the principal ID can assume a role.",
-				Required:            true,
+				MarkdownDescription: "The target group of which the principal ID can assume a role. Changing it replaces the eligibility, see the description of the resource for how to avoid a gap. Groups with dynamic membership are rejected when planned, as PIM for Groups cannot manage them, or on create when the group is not known until apply. Exactly one of `scope`, its alias `group_id` or `group_display_name` must be set.",
+				Optional:            true,
+				Computed:            true,
+				CustomType:          customtypes.GUIDType{},
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+					customtypes.RequiresReplaceUnlessSemanticEqual(customtypes.GUIDType{}),
 				},
 			},
-			"justification": schema.StringAttribute{
-				MarkdownDescription: "A message provided by users and administrators when they create an assignment.",
+			"group_id": schema.StringAttribute{
+				MarkdownDescription: "An alias of `scope`, named like the property in Microsoft Graph. Both are set in state, whichever is configured.",
+				Optional:            true,
+				Computed:            true,
+				CustomType:          customtypes.GUIDType{},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					customtypes.RequiresReplaceUnlessSemanticEqual(customtypes.GUIDType{}),
+				},
+			},
+			"group_display_name": schema.StringAttribute{
+				MarkdownDescription: "The display name of the target group, resolved to `scope` on create. Fails unless exactly one group has the display name. Exactly one of `scope`, its alias `group_id` or `group_display_name` must be set.",
 				Optional:            true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("scope"), path.MatchRoot("group_id")),
+				},
+			},
+			"justification": schema.StringAttribute{
+				MarkdownDescription: "A message provided by users and administrators when they create an assignment. It is stored in plain text in the Terraform state, so it must not contain sensitive data such as incident details, use `justification_wo` for those. Changes are applied to the eligibility in place. Defaults to the `default_justification` of the provider. The placeholders `{run_id}`, `{workspace}` and `{commit_sha}` are expanded at apply, see `default_justification`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("justification_wo")),
+				},
+			},
+			"justification_wo": schema.StringAttribute{
+				MarkdownDescription: "A write-only variant of `justification`, which is sent to Graph but never stored in the plan or state. Requires Terraform 1.11 or later. As Terraform cannot detect changes of it, the eligibility is only updated with a new value when `justification_wo_version` changes. Also set `destroy_justification` when the eligibility is removed on destroy, as it defaults to `justification`.",
+				Optional:            true,
+				Sensitive:           true,
+				WriteOnly:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("justification_wo_version")),
+				},
+			},
+			"justification_wo_version": schema.Int64Attribute{
+				MarkdownDescription: "The version of `justification_wo`. Change it to apply a new value of `justification_wo` to the eligibility in place.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AlsoRequires(path.MatchRoot("justification_wo")),
+				},
 			},
 			"principal_id": schema.StringAttribute{
-				MarkdownDescription: "The identifier of the principal whose membership or ownership eligibility to the group is managed through PIM for groups.",
-				Required:            true,
+				MarkdownDescription: "The identifier of the principal whose membership or ownership eligibility to the group is managed through PIM for groups. Changing it replaces the eligibility, see the description of the resource for how to avoid a gap. Exactly one of `principal_id` or `principal_upn` must be set.",
+				Optional:            true,
+				Computed:            true,
+				CustomType:          customtypes.GUIDType{},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					customtypes.RequiresReplaceUnlessSemanticEqual(customtypes.GUIDType{}),
+				},
+			},
+			"principal_upn": schema.StringAttribute{
+				MarkdownDescription: "The user principal name of a user principal, resolved to `principal_id` on create. Guests (B2B users) can also be referenced by their external email address. Exactly one of `principal_id` or `principal_upn` must be set.",
+				Optional:            true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("principal_id")),
+				},
+			},
+			"principal_user_type": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The user type of the principal, `Member` or `Guest`. Empty when the principal is not a user.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"principal_home_domain": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The domain of the organization a guest principal was invited from, taken from its email address. Empty when the principal is not a guest.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"status": schema.StringAttribute{
 				Computed: true,
 			},
 			"start_date_time": schema.StringAttribute{
-				Computed: true,
+				Computed:   true,
+				CustomType: customtypes.RFC3339Type{},
+			},
+			"end_date_time": schema.StringAttribute{
+				Computed:            true,
+				CustomType:          customtypes.RFC3339Type{},
+				MarkdownDescription: "When the eligibility lapses, formatted as RFC 3339. Empty when the eligibility does not expire or expires after a duration.",
+			},
+			"expiration_type": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "How the eligibility expires, one of `noExpiration`, `afterDateTime` or `afterDuration`.",
+			},
+			"expiration_duration": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ISO 8601 duration after which the eligibility lapses when `expiration_type` is `afterDuration`.",
+			},
+			"member_type": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the principal is eligible directly (`direct`) or through a group (`group`). Empty until the eligibility schedule instance exists.",
+			},
+			"created_by": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The object ID of the user or application which created the eligibility schedule request.",
+			},
+			"created_date_time": schema.StringAttribute{
+				Computed:            true,
+				CustomType:          customtypes.RFC3339Type{},
+				MarkdownDescription: "When the eligibility schedule request was created, in RFC 3339 format.",
+			},
+			"completed_date_time": schema.StringAttribute{
+				Computed:            true,
+				CustomType:          customtypes.RFC3339Type{},
+				MarkdownDescription: "When the eligibility schedule request was completed, in RFC 3339 format.",
+			},
+			"target_schedule_id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ID of the eligibility schedule created by the request.",
+			},
+			"instance_id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ID of the eligibility schedule instance. Empty until the instance exists.",
+			},
+			"policy_id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ID of the role management policy governing the eligibility.",
+			},
+			"policy_expiration_required": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the role management policy requires eligible assignments made by admins to expire. The requirement is disabled when the eligibility is created. If it is enabled again outside of Terraform, e.g. in the portal, the next plan shows the change and applying it disables the requirement again. With `strict_policy` in the provider configuration the requirement is left as it is.",
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"eligible_assignment_id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "The ID of the eligibility schedule request.",
 			},
+			"debug": schema.BoolAttribute{
+				MarkdownDescription: "Store the raw JSON returned by Microsoft Graph in `raw_payload`. Useful when reporting mismatches between the SDK and Graph.",
+				Optional:            true,
+			},
+			"on_destroy": schema.StringAttribute{
+				MarkdownDescription: "What to do with the eligibility when the resource is destroyed. `remove` (default) removes it, `cancel` cancels the request if it is not provisioned yet, e.g. pending approval, and `abandon` leaves it in place and only removes it from the Terraform state.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(onDestroyRemove),
+				Validators: []validator.String{
+					stringvalidator.OneOf(onDestroyRemove, onDestroyCancel, onDestroyAbandon),
+				},
+			},
+			"destroy_justification": schema.StringAttribute{
+				MarkdownDescription: "The justification of the request removing the eligibility when the resource is destroyed. Defaults to `justification`. Like other destroy-time settings, a change must be applied before it is used by a destroy.",
+				Optional:            true,
+			},
+			"force_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Cascade the removal of the eligibility to the active assignments activated through it, so no access is left once it is destroyed. By default destroying an eligibility which is activated fails, rather than leaving the principal with an activation whose eligibility is gone.",
+				Optional:            true,
+			},
+			"auto_renew": schema.BoolAttribute{
+				MarkdownDescription: "Extend the eligibility by `auto_renew_window` during refresh, when it expires within `auto_renew_window`. Has no effect on eligibilities which do not expire.",
+				Optional:            true,
+			},
+			"auto_renew_window": schema.StringAttribute{
+				MarkdownDescription: "How long before it expires an eligibility is extended when `auto_renew` is set, as a duration such as `168h`. Defaults to `720h`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(defaultAutoRenewWindow),
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"multiple_requests": schema.StringAttribute{
+				MarkdownDescription: "What refresh does when several provisioned requests exist for the principal and group, e.g. after a renewal. `newest` (default) uses the most recently created request, `error` fails the refresh. `error` cannot be combined with `auto_renew`, since every renewal adds a request.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(multipleRequestsNewest),
+				Validators: []validator.String{
+					stringvalidator.OneOf(multipleRequestsNewest, multipleRequestsError),
+				},
+			},
+			"raw_payload": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The raw JSON of the eligibility schedule request as returned by Microsoft Graph. Only set when `debug` is enabled.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(ctx),
 		},
 	}
 }
 
-func (r *GroupEligibleAssignment) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
-	creds, err := azidentity.NewDefaultAzureCredential(nil)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", "Unable to create credentials")
+func (r *GroupEligibleAssignment) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data GroupEligibleAssignmentModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	graphClient, err := msgraphsdk.NewGraphServiceClientWithCredentials(creds, nil)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", "Unable to create graph client")
-		return
+	// A renewal provisions a new request next to the one it extends, so the refresh after it would fail.
+	if data.AutoRenew.ValueBool() && data.MultipleRequests.ValueString() == multipleRequestsError {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("multiple_requests"),
+			"Invalid attribute combination",
+			"multiple_requests cannot be \"error\" when auto_renew is set, since every renewal adds a provisioned request for the principal and group. "+
+				"Unset multiple_requests or set it to \"newest\".",
+		)
 	}
-
-	r.graphClient = graphClient
 }
 
-func (r *GroupEligibleAssignment) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var data GroupEligibleAssignmentModel
-
-	// Read Terraform plan data into the model
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+// ModifyPlan checks the justifications against the justification rules of the provider, and plans scope and role with
+// the values of their aliases group_id and access_id when those are configured instead, and the other way around, so
+// both are known in the plan whichever is used.
+func (r *GroupEligibleAssignment) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The resource is destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
 
+	var config, plan GroupEligibleAssignmentModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	data.StartDateTime = types.StringValue(time.Now().Format(time.RFC3339))
+	// Justifications which are not known yet, e.g. as they reference another resource, are validated when the plan is
+	// made again at apply.
+	r.justificationRules.validate(ctx, req.Config, req.State, &resp.Diagnostics,
+		path.Root("justification"), path.Root("justification_wo"), path.Root("destroy_justification"))
+
+	// An alias is planned unknown while the configured attribute is unknown. When group_display_name is configured
+	// instead, scope and group_id are both planned unknown or from state.
+	switch {
+	case !config.GroupID.IsNull():
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("scope"), plan.GroupID)...)
+	case !config.Scope.IsNull():
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("group_id"), plan.Scope)...)
+	}
 
-	policyId, err := r.getEligibleExpirationPolicyId(ctx, data.Scope.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError("Graph client error", "Unable to get eligible expiration policy ID: "+err.Error())
-		return
+	if !config.AccessID.IsNull() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("role"), plan.AccessID)...)
+	} else {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("access_id"), plan.Role)...)
 	}
 
-	if err := r.updateUnifiedRoleManagementPolicyRule(ctx, policyId, false); err != nil {
-		resp.Diagnostics.AddError("Graph client error", "Unable to update unified role management policy rule: "+err.Error())
-		return
+	// A policy requiring expiration again since the last refresh is planned back to not requiring it, unless the
+	// policy must not be changed.
+	var prior types.Bool
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("policy_expiration_required"), &prior)...)
+	}
+	if prior.ValueBool() && !r.strictPolicy {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("policy_expiration_required"), types.BoolValue(false))...)
 	}
 
-	requestBody, err := newPrivilegedAccessGroupEligibilityScheduleRequest(data)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", "Unable to create eligibility schedule requests: "+err.Error())
+	// Dynamic-membership groups are rejected when the eligibility is planned rather than only when it is created. The
+	// group is checked again on create, e.g. when it is only known by group_display_name now, or comes from a resource
+	// which is not created yet. Graph is not called while the provider configuration is unknown.
+	if r.directory == nil || r.deferredReason != "" || !req.State.Raw.IsNull() {
 		return
 	}
 
-	eligibilityScheduleRequests, err := r.graphClient.
-		IdentityGovernance().
-		PrivilegedAccess().
-		Group().
-		EligibilityScheduleRequests().
-		Post(ctx, requestBody, nil)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", "Unable to create eligibility schedule requests: "+err.Error())
+	groupID, groupPath := plan.Scope, path.Root("scope")
+	if !config.GroupID.IsNull() {
+		groupID, groupPath = plan.GroupID, path.Root("group_id")
+	}
+	if groupID.IsUnknown() || groupID.IsNull() {
 		return
 	}
 
-	data.Id = types.StringValue(fmt.Sprintf("%s|%s", *eligibilityScheduleRequests.GetGroupId(), *eligibilityScheduleRequests.GetPrincipalId()))
+	// A failed lookup, e.g. for lack of permissions, does not fail the plan, the group is checked again on create.
+	if err := r.checkGroupSupported(ctx, groupPath, groupID.ValueString(), &resp.Diagnostics); err != nil {
+		resp.Diagnostics.AddAttributeWarning(groupPath, "Group not checked",
+			"Unable to check whether PIM for Groups can manage the group, it is checked again on create: "+sanitizeError(err))
+	}
+}
 
-	status := eligibilityScheduleRequests.GetStatus()
-	if status == nil {
-		resp.Diagnostics.AddError("Client Error", "Unable to get eligibility schedule requests status")
-		return
+// checkGroupSupported adds an error for the attribute at p to diags if PIM for Groups cannot manage the group, e.g. as
+// its membership is dynamic. A missing group is left to the calls creating the eligibility to report. The error of
+// looking up the group is returned instead of added to diags, so the caller decides whether it fails.
+func (r *GroupEligibleAssignment) checkGroupSupported(ctx context.Context, p path.Path, groupID string, diags *diag.Diagnostics) error {
+	group, err := r.directory.GetGroup(ctx, groupID)
+	if errors.Is(err, directory.ErrNotFound) {
+		return nil
 	}
-	data.Status = types.StringValue(*status)
-	data.Justification = types.StringValue(*eligibilityScheduleRequests.GetJustification())
-	data.PrincipalID = types.StringValue(*eligibilityScheduleRequests.GetPrincipalId())
-	role, err := convertAccessIdToRole(*eligibilityScheduleRequests.GetAccessId())
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", "Unable to convert access ID to role: "+err.Error())
-		return
+		return err
 	}
-	data.Role = types.StringValue(role)
-	data.Scope = types.StringValue(*eligibilityScheduleRequests.GetGroupId())
-	data.StartDateTime = types.StringValue(eligibilityScheduleRequests.GetScheduleInfo().GetStartDateTime().Format(time.RFC3339))
-	data.EligibleAssignmentID = types.StringValue(*eligibilityScheduleRequests.GetId())
 
-	tflog.Trace(ctx, "created a resource")
+	if err := group.CheckPIMSupported(); err != nil {
+		diags.AddAttributeError(p, "Unsupported group", "PIM for Groups cannot manage this group: "+err.Error())
+	}
 
-	// Save data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	return nil
 }
 
-func (r *GroupEligibleAssignment) getEligibleExpirationPolicyId(ctx context.Context, scope string) (string, error) {
-	requestFilter := fmt.Sprintf("scopeId eq '%s' and scopeType eq 'Group' and roleDefinitionId eq 'member'", scope)
+// UpgradeState adds group_id and access_id to the state of version 0, with the values of scope and role.
+func (r *GroupEligibleAssignment) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {StateUpgrader: upgradeStateAliases(map[string]string{"group_id": "scope", "access_id": "role"})},
+	}
+}
 
-	roleManagementPolicyAssignments, err := r.graphClient.
-		Policies().
-		RoleManagementPolicyAssignments().
-		Get(ctx, &graphpolicies.RoleManagementPolicyAssignmentsRequestBuilderGetRequestConfiguration{
-			QueryParameters: &graphpolicies.RoleManagementPolicyAssignmentsRequestBuilderGetQueryParameters{
-				Filter: &requestFilter,
-				Expand: []string{"policy($expand=rules)"},
-			},
-		})
+// upgradeStateAliases returns a state upgrader adding aliases to the raw JSON state, with the values of the attributes
+// they are keyed by. The raw state is upgraded without the prior schema, which would repeat the whole current schema.
+func upgradeStateAliases(aliases map[string]string) func(context.Context, resource.UpgradeStateRequest, *resource.UpgradeStateResponse) {
+	return func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+		if req.RawState == nil || req.RawState.JSON == nil {
+			resp.Diagnostics.AddError("Unable to upgrade state", "The prior state is not in JSON format, it was written by a Terraform version older than 0.12.")
+			return
+		}
 
-	if err != nil {
-		return "", fmt.Errorf("unable to get role management policy assignments: %w", err)
+		var state map[string]json.RawMessage
+		if err := json.Unmarshal(req.RawState.JSON, &state); err != nil {
+			resp.Diagnostics.AddError("Unable to upgrade state", "Unable to parse the prior state: "+err.Error())
+			return
+		}
+
+		for alias, name := range aliases {
+			state[alias] = state[name]
+		}
+
+		b, err := json.Marshal(state)
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to upgrade state", "Unable to encode the upgraded state: "+err.Error())
+			return
+		}
+		resp.DynamicValue = &tfprotov6.DynamicValue{JSON: b}
 	}
+}
 
-	// Edit the policy group assignment and allow no expiration date for PIM eligible assignment
-	policyAssignments := roleManagementPolicyAssignments.GetValue()
-	if len(policyAssignments) == 0 {
-		return "", fmt.Errorf("unable to find role management policy assignments from result")
+func (r *GroupEligibleAssignment) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
 	}
 
-	if len(policyAssignments) > 1 {
-		tflog.Warn(ctx, "found more than one role management policy assignment")
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
 	}
 
-	return *policyAssignments[0].GetPolicyId(), nil
+	r.service = grouppim.NewService(pd.clients.GroupPIM)
+	r.service.SetTicketInfo(pd.ticketInfo)
+	r.service.SetStrictPolicy(pd.strictPolicy)
+	r.strictPolicy = pd.strictPolicy
+	r.service.SetPolicyCache(pd.policyCache)
+	r.directory = directory.NewService(pd.clients.Directory)
+	r.resourceSettings = newResourceSettings(pd)
+	r.blockMutations = pd.blockMutations
 }
 
-// updateUnifiedRoleManagementPolicyRule had to be implemented without SDK because the SDK data model for this endpoint had several missing fields.
-func (r *GroupEligibleAssignment) updateUnifiedRoleManagementPolicyRule(ctx context.Context, policyId string, isExpirationRequired bool) error {
+func (r *GroupEligibleAssignment) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
 
-	creds, err := azidentity.NewDefaultAzureCredential(nil)
-	if err != nil {
-		return fmt.Errorf("unable to create credentials: %w", err)
-	}
+	var data GroupEligibleAssignmentModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
 
-	t, err := creds.GetToken(ctx, azcorepolicy.TokenRequestOptions{Scopes: []string{"https://graph.microsoft.com/.default"}})
-	if err != nil {
-		return fmt.Errorf("unable to get token: %w", err)
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	c := &http.Client{
-		Timeout: 10 * time.Second,
+	createTimeout, diags := data.Timeouts.Create(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	type target struct {
-		Caller              string   `json:"caller"`
-		Operations          []string `json:"operations"`
-		Level               string   `json:"level"`
-		InheritableSettings []any    `json:"inheritableSettings"`
-		EnforcedSettings    []any    `json:"enforcedSettings"`
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	if upn := data.PrincipalUPN.ValueString(); upn != "" {
+		principalID, err := r.directory.UserIDByPrincipalName(ctx, upn)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("principal_upn"), "Graph client error", "Unable to resolve principal_upn: "+sanitizeError(err))
+			return
+		}
+		data.PrincipalID = customtypes.NewGUIDValue(principalID)
 	}
 
-	type policyRule struct {
-		OdataType            string `json:"@odata.type"`
-		ID                   string `json:"id"`
-		IsExpirationRequired bool   `json:"isExpirationRequired"`
-		MaximumDuration      string `json:"maximumDuration"`
-		Target               target `json:"target"`
+	if displayName := data.GroupDisplayName.ValueString(); displayName != "" {
+		groupID, err := r.directory.GroupIDByDisplayName(ctx, displayName)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("group_display_name"), "Graph client error", "Unable to resolve group_display_name: "+sanitizeError(err))
+			return
+		}
+		data.Scope = customtypes.NewGUIDValue(groupID)
 	}
 
-	pr := policyRule{
-		OdataType:            "#microsoft.graph.unifiedRoleManagementPolicyExpirationRule",
-		ID:                   "Expiration_Admin_Eligibility",
-		IsExpirationRequired: isExpirationRequired,
-		MaximumDuration:      "P365D",
-		Target: target{
-			Caller:              "Admin",
-			Operations:          []string{"All"},
-			Level:               "Eligibility",
-			EnforcedSettings:    []any{},
-			InheritableSettings: []any{},
-		},
+	groupPath := path.Root("scope")
+	if !data.GroupDisplayName.IsNull() {
+		groupPath = path.Root("group_display_name")
+	}
+	if err := r.checkGroupSupported(ctx, groupPath, data.Scope.ValueString(), &resp.Diagnostics); err != nil {
+		resp.Diagnostics.AddError("Graph client error", "Unable to get group: "+sanitizeError(err))
+	}
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	b, err := json.Marshal(pr)
+	principal, err := r.directory.GetPrincipal(ctx, data.PrincipalID.ValueString())
+	if errors.Is(err, directory.ErrNotFound) {
+		resp.Diagnostics.AddAttributeError(path.Root("principal_id"), "Principal not found", fmt.Sprintf("The principal %s does not exist in the directory.", data.PrincipalID.ValueString()))
+		return
+	}
 	if err != nil {
-		return fmt.Errorf("unable to marshal body: %w", err)
+		resp.Diagnostics.AddError("Graph client error", "Unable to get principal: "+sanitizeError(err))
+		return
+	}
+	if err := principal.CheckEligibleFor(data.Scope.ValueString(), data.Role.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("principal_id"), "Unsupported principal", "PIM for Groups does not support this eligibility: "+err.Error())
+		return
 	}
+	if principal.PendingAcceptance {
+		resp.Diagnostics.AddWarning(
+			"Guest invitation pending",
+			fmt.Sprintf("The guest %s has not accepted its invitation yet. It can only activate the eligibility after accepting it.", principal.ID),
+		)
+	}
+	data.setPrincipal(principal)
 
-	req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("https://graph.microsoft.com/beta/policies/roleManagementPolicies/%s/rules/Expiration_Admin_Eligibility", policyId), bytes.NewReader(b))
-	if err != nil {
-		return fmt.Errorf("unable to create request: %w", err)
+	ctx = withAuditTarget(ctx, data.Scope.ValueString(), data.PrincipalID.ValueString())
+
+	justification, diags := configuredJustification(ctx, req.Config, data.Justification)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.Token))
-	req.Header.Set("Content-Type", "application/json")
+	a := data.eligibleAssignment()
+	a.Justification = justificationOrDefault(justification, r.defaultJustification)
 
-	resp, err := c.Do(req)
+	assignment, err := r.service.CreateEligibleAssignment(ctx, a)
+	if errors.Is(err, grouppim.ErrExpirationRequired) {
+		addPolicyConflictError(&resp.Diagnostics, data.Scope.ValueString())
+		return
+	}
+	var stepErr *grouppim.CreateError
+	if errors.As(err, &stepErr) {
+		addCreateStepError(&resp.Diagnostics, stepErr)
+		return
+	}
 	if err != nil {
-		return fmt.Errorf("unable to send request: %w", err)
+		resp.Diagnostics.AddError("Graph client error", "Unable to create eligible assignment: "+sanitizeError(err))
+		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("unable to read response body: %w", err)
-		}
-		defer req.Body.Close()
+	// Creating the eligibility disabled the requirement, or failed in strict policy mode if it was enabled.
+	data.PolicyExpirationRequired = types.BoolValue(false)
 
-		return fmt.Errorf("unable to update unified role management policy rule, got %d want %d: %s", resp.StatusCode, http.StatusOK, string(b))
-	}
+	justification = data.Justification
+	data.setEligibleAssignment(ctx, assignment)
+	data.keepJustification(justification, r.defaultJustification)
 
-	return nil
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *GroupEligibleAssignment) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.deferredReason != "" {
+		deferResourceRead(req, resp, r.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+
 	var data GroupEligibleAssignmentModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
 
 	// Read Terraform prior state data into the model
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -325,82 +674,294 @@ func (r *GroupEligibleAssignment) Read(ctx context.Context, req resource.ReadReq
 		return
 	}
 
-	idSplit := strings.Split(data.Id.ValueString(), "|")
-	if len(idSplit) != 2 {
-		resp.Diagnostics.AddError("Invalid ID", "ID must be in the format '{scope}|{principal_id}'")
+	// IDs of eligibilities created before the role was part of the ID have no role, whichever role they are for. The
+	// refreshed ID has the role.
+	scope, principalID, _, err := conversions.ParseGroupAssignmentID(data.Id.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid ID", err.Error())
 		return
 	}
 
-	scope, principalID := idSplit[0], idSplit[1]
-	filter := toPtr(fmt.Sprintf("groupId eq '%s' and principalId eq '%s'", scope, principalID))
-	groupEligibleResp, err := r.graphClient.
-		IdentityGovernance().
-		PrivilegedAccess().
-		Group().
-		EligibilityScheduleRequests().
-		Get(ctx, &identitygovernance.PrivilegedAccessGroupEligibilityScheduleRequestsRequestBuilderGetRequestConfiguration{
-			QueryParameters: &identitygovernance.PrivilegedAccessGroupEligibilityScheduleRequestsRequestBuilderGetQueryParameters{
-				Filter: filter,
-			},
-		})
+	// Without the group the eligibility is gone as well, and listing its requests fails with a confusing error.
+	groupExists, err := r.directory.GroupExists(ctx, scope)
 	if err != nil {
-		resp.Diagnostics.AddError("Client call failed", fmt.Sprintf("Unable to get eligibility schedule requests with filter '%s': %s", *filter, err.Error()))
+		resp.Diagnostics.AddError("Client call failed", "Unable to get group: "+sanitizeError(err))
+		return
+	}
+	if !groupExists {
+		resp.Diagnostics.AddWarning(
+			"Group deleted",
+			fmt.Sprintf("The group %s no longer exists in the directory, so the %s eligibility of principal %s was removed from the state. "+
+				"If the group was recreated, the eligibility is created in the new group on the next apply.", scope, data.Role.ValueString(), principalID),
+		)
+		resp.State.RemoveResource(ctx)
 		return
 	}
 
-	groupEligibles := groupEligibleResp.GetValue()
-	var groupEligibleProvisioned []graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable
-	for _, groupEligible := range groupEligibles {
-		// The list can return multiple results, but we can remove old assignments which might have status like "Revoked".
-		if *groupEligible.GetStatus() == "Provisioned" {
-			groupEligibleProvisioned = append(groupEligibleProvisioned, groupEligible)
-		}
+	assignment, err := r.getEligibleAssignment(ctx, data, scope, principalID)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get eligible assignment: "+sanitizeError(err))
+		return
 	}
 
-	if len(groupEligibleProvisioned) != 1 {
-		resp.Diagnostics.AddError("Client call failed", fmt.Sprintf("Got %d results, want 1", len(groupEligibles)))
+	principal, err := r.directory.GetPrincipal(ctx, principalID)
+	switch {
+	case errors.Is(err, directory.ErrNotFound):
+		resp.Diagnostics.AddWarning(
+			"Principal deleted",
+			fmt.Sprintf("The principal %s of the %s eligibility in group %s no longer exists in the directory. "+
+				"Remove the resource from the configuration to remove the eligibility.", principalID, assignment.Role, scope),
+		)
+	case err != nil:
+		resp.Diagnostics.AddError("Client call failed", "Unable to get principal: "+sanitizeError(err))
 		return
+	default:
+		data.setPrincipal(principal)
 	}
 
-	groupEligible := groupEligibleProvisioned[0]
+	if data.AutoRenew.ValueBool() && assignment.EndDateTime != "" {
+		assignment, err = r.renewIfExpiring(ctx, data, assignment, &resp.Diagnostics)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to renew eligible assignment: "+sanitizeError(err))
+			return
+		}
+	}
 
-	data.EligibleAssignmentID = types.StringValue(*groupEligible.GetId())
-	data.Justification = types.StringValue(*groupEligible.GetJustification())
-	data.Status = types.StringValue(*groupEligible.GetStatus())
-	data.PrincipalID = types.StringValue(*groupEligible.GetPrincipalId())
+	// The policy is resolved during Create, so it is only looked up when missing from state, e.g. after import.
+	if data.PolicyID.ValueString() == "" {
+		assignment.PolicyID, err = r.service.EligibleExpirationPolicyID(ctx, scope)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to get eligible expiration policy ID: "+sanitizeError(err))
+			return
+		}
+	}
 
-	role, err := convertAccessIdToRole(*groupEligible.GetAccessId())
+	expirationRequired, err := r.service.ExpirationRequired(ctx, scope)
 	if err != nil {
-		resp.Diagnostics.AddError("Conversion failed", "Unable to convert access ID to role: "+err.Error())
+		resp.Diagnostics.AddError("Client call failed", "Unable to get eligible expiration policy rule: "+sanitizeError(err))
 		return
 	}
-	data.Role = types.StringValue(role)
+	data.PolicyExpirationRequired = types.BoolValue(expirationRequired)
+
+	justification := data.Justification
+	data.setEligibleAssignment(ctx, assignment)
+	data.keepJustification(justification, r.defaultJustification)
 
-	data.Scope = types.StringValue(*groupEligible.GetGroupId())
-	data.StartDateTime = types.StringValue(groupEligible.GetScheduleInfo().GetStartDateTime().Format(time.RFC3339))
+	data.setDefaults()
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// getEligibleAssignment gets the eligibility of m. An eligibility which was created within indexingLagWindow may not be
+// listed yet, so it is read again a few times before it is reported missing.
+func (r *GroupEligibleAssignment) getEligibleAssignment(ctx context.Context, m GroupEligibleAssignmentModel, scope, principalID string) (grouppim.EligibleAssignment, error) {
+	newest := m.MultipleRequests.ValueString() != multipleRequestsError
+
+	assignment, err := r.service.GetEligibleAssignment(ctx, scope, principalID, m.Role.ValueString(), newest)
+	if !errors.Is(err, grouppim.ErrNotFound) || !createdRecently(m.CreatedDateTime.ValueString(), time.Now()) {
+		return assignment, err
+	}
+
+	delay := r.indexingRetryDelay
+	if delay == 0 {
+		delay = indexingRetryDelay
+	}
+
+	for attempt := 1; attempt <= indexingRetries && errors.Is(err, grouppim.ErrNotFound); attempt++ {
+		tflog.Debug(ctx, "eligibility created recently is not listed yet, reading it again", map[string]any{"attempt": attempt, "delay": delay.String()})
+
+		select {
+		case <-ctx.Done():
+			return grouppim.EligibleAssignment{}, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		assignment, err = r.service.GetEligibleAssignment(ctx, scope, principalID, m.Role.ValueString(), newest)
+	}
+
+	return assignment, err
+}
+
+// createdRecently returns whether created, formatted as RFC 3339, is within indexingLagWindow before now. It is false
+// when created is empty, e.g. after import.
+func createdRecently(created string, now time.Time) bool {
+	t, err := time.Parse(time.RFC3339, created)
+	if err != nil {
+		return false
+	}
+
+	return now.Sub(t) < indexingLagWindow
+}
+
+// addPolicyConflictError adds the diagnostic for an eligibility without expiration in a group whose policy requires
+// expiration, which strict_policy does not allow changing.
+func addPolicyConflictError(diags *diag.Diagnostics, groupID string) {
+	diags.AddAttributeError(
+		path.Root("scope"),
+		"Policy conflict",
+		fmt.Sprintf("The PIM policy of group %s requires eligible assignments to expire, but the eligibility has no expiration. "+
+			"strict_policy is set on the provider, so the policy is not changed. "+
+			"Allow eligible assignments without expiration in the policy of the group, or unset strict_policy.", groupID),
+	)
+}
+
+// addCreateStepError adds the diagnostic for a failed step of creating an eligible assignment, telling what the steps
+// before it already changed in the tenant, and what was rolled back.
+func addCreateStepError(diags *diag.Diagnostics, err *grouppim.CreateError) {
+	detail := fmt.Sprintf("Creating the eligible assignment failed at step %q: %s\n\n", err.Step, sanitizeError(err.Err))
+	switch {
+	case len(err.Changed) > 0:
+		detail += "Already changed in the tenant:\n- " + strings.Join(err.Changed, "\n- ")
+	case len(err.RolledBack) > 0:
+		detail += "Rolled back, so nothing was left changed in the tenant:\n- " + strings.Join(err.RolledBack, "\n- ")
+	default:
+		detail += "Nothing was changed in the tenant."
+	}
+	if err.RollbackErr != nil {
+		detail += "\n\nRolling back failed, restore the policy of the group in the portal: " + sanitizeError(err.RollbackErr)
+	}
+
+	diags.AddError(fmt.Sprintf("Unable to %s", err.Step), detail)
+}
+
+// renewIfExpiring extends the assignment by the auto_renew_window of m when it expires within it. While mutating Graph
+// calls are blocked, a warning is added to diags instead, so refresh does not fail.
+func (r *GroupEligibleAssignment) renewIfExpiring(ctx context.Context, m GroupEligibleAssignmentModel, a grouppim.EligibleAssignment, diags *diag.Diagnostics) (grouppim.EligibleAssignment, error) {
+	window, err := time.ParseDuration(m.AutoRenewWindow.ValueString())
+	if err != nil {
+		window, _ = time.ParseDuration(defaultAutoRenewWindow)
+	}
+
+	end, err := time.Parse(time.RFC3339, a.EndDateTime)
+	if err != nil {
+		return a, fmt.Errorf("unable to parse endDateTime: %w", err)
+	}
+
+	if time.Until(end) > window {
+		return a, nil
+	}
+
+	if r.blockMutations {
+		diags.AddWarning("Eligibility not renewed", fmt.Sprintf("The %s eligibility of principal %s in group %s expires at %s, within auto_renew_window, "+
+			"but it is not renewed while mutating Microsoft Graph calls are blocked, as require_mutation_confirmation is set and mutation_confirmation is not the tenant ID.", a.Role, a.PrincipalID, a.GroupID, a.EndDateTime))
+		return a, nil
+	}
+
+	tflog.Info(ctx, "renewing eligible assignment", map[string]any{"end_date_time": a.EndDateTime})
+
+	return r.service.RenewEligibleAssignment(ctx, a, window)
+}
+
 func (r *GroupEligibleAssignment) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var data GroupEligibleAssignmentModel
 
-	// Read Terraform plan data into the model
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	var plan GroupEligibleAssignmentModel
+
+	// Read Terraform prior state and plan data into the models
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+
+	justification, diags := configuredJustification(ctx, req.Config, plan.Justification)
+	resp.Diagnostics.Append(diags...)
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	tflog.Info(ctx, "resource can only be replaced")
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	// The policy rule is planned back to not requiring expiration when it was changed outside of Terraform.
+	if data.PolicyExpirationRequired.ValueBool() && !plan.PolicyExpirationRequired.ValueBool() {
+		err := r.service.AllowNoExpiration(ctx, data.Scope.ValueString())
+		if errors.Is(err, grouppim.ErrExpirationRequired) {
+			addPolicyConflictError(&resp.Diagnostics, data.Scope.ValueString())
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to update eligible expiration policy rule: "+sanitizeError(err))
+			return
+		}
+	}
+	data.PolicyExpirationRequired = plan.PolicyExpirationRequired
+
+	ctx = withAuditTarget(ctx, data.Scope.ValueString(), data.PrincipalID.ValueString())
+
+	// A new role is assigned before the old one is removed, so the principal does not lose eligibility in between.
+	switch {
+	case !data.Role.Equal(plan.Role):
+		replacement := grouppim.EligibleAssignment{
+			GroupID:       data.Scope.ValueString(),
+			PrincipalID:   data.PrincipalID.ValueString(),
+			Role:          plan.Role.ValueString(),
+			Justification: justificationOrDefault(justification, r.defaultJustification),
+		}
+
+		assignment, err := r.service.ReplaceEligibleAssignment(ctx, data.eligibleAssignment(), replacement, plan.ForceDestroy.ValueBool())
+		if errors.Is(err, grouppim.ErrActivated) {
+			resp.Diagnostics.AddError(
+				"Eligibility is activated",
+				fmt.Sprintf("The principal %s has an active %s assignment in group %s, so the role cannot change. "+
+					"Wait for the activation to end, or set force_destroy to remove the activation as well.",
+					data.PrincipalID.ValueString(), data.Role.ValueString(), data.Scope.ValueString()),
+			)
+			return
+		}
+		if errors.Is(err, grouppim.ErrExpirationRequired) {
+			addPolicyConflictError(&resp.Diagnostics, data.Scope.ValueString())
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to change the role of the eligible assignment: "+sanitizeError(err))
+			// The replacement is kept in state when only removing the old eligibility failed.
+			if assignment.RequestID == "" {
+				return
+			}
+		}
+		data.setEligibleAssignment(ctx, assignment)
+	case !data.Justification.Equal(plan.Justification) || !data.JustificationWOVersion.Equal(plan.JustificationWOVersion):
+		a := data.eligibleAssignment()
+		a.Justification = justificationOrDefault(justification, r.defaultJustification)
+
+		assignment, err := r.service.UpdateEligibleAssignment(ctx, a)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to update the justification of the eligible assignment: "+sanitizeError(err))
+			return
+		}
+		data.setEligibleAssignment(ctx, assignment)
+	}
+	// An unset justification stays null rather than the empty string or default justification returned by Graph.
+	data.Justification = plan.Justification
+	data.JustificationWOVersion = plan.JustificationWOVersion
+
+	// The other attributes which do not require replacement are only used by the provider, the computed values are kept
+	// from the prior state.
+	// The payload is refreshed on the next read when debug is toggled.
+	if !data.Debug.Equal(plan.Debug) {
+		data.RawPayload = types.StringNull()
+	}
+	data.Debug = plan.Debug
+	data.OnDestroy = plan.OnDestroy
+	data.DestroyJustification = plan.DestroyJustification
+	data.ForceDestroy = plan.ForceDestroy
+	data.AutoRenew = plan.AutoRenew
+	data.AutoRenewWindow = plan.AutoRenewWindow
+	data.MultipleRequests = plan.MultipleRequests
+	data.Timeouts = plan.Timeouts
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *GroupEligibleAssignment) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
 	var data GroupEligibleAssignmentModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
 
 	// Read Terraform prior state data into the model
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -409,99 +970,237 @@ func (r *GroupEligibleAssignment) Delete(ctx context.Context, req resource.Delet
 		return
 	}
 
-	requestBody, err := newPrivilegedAccessGroupEligibilityScheduleRequest(data)
-	if err != nil {
-		resp.Diagnostics.AddError("Error deleting resource", "Unable to create eligibility schedule request: "+err.Error())
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, defaultOperationTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	requestBody.SetAction(toPtr(graphmodels.ADMINREMOVE_SCHEDULEREQUESTACTIONS))
-	requestBody.SetId(toPtr(data.EligibleAssignmentID.ValueString()))
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
 
-	_, err = r.graphClient.
-		IdentityGovernance().
-		PrivilegedAccess().
-		Group().
-		EligibilityScheduleRequests().
-		Post(ctx, requestBody, nil)
+	ctx = withAuditTarget(ctx, data.Scope.ValueString(), data.PrincipalID.ValueString())
 
+	switch data.OnDestroy.ValueString() {
+	case onDestroyAbandon:
+		tflog.Info(ctx, "leaving eligible assignment in place, on_destroy is abandon")
+	case onDestroyCancel:
+		if err := r.service.CancelEligibleAssignment(ctx, data.eligibleAssignment()); err != nil {
+			resp.Diagnostics.AddError("Error deleting resource", "Unable to cancel eligible assignment: "+sanitizeError(err))
+			return
+		}
+	default:
+		assignment := data.eligibleAssignment()
+		if justification := data.DestroyJustification.ValueString(); justification != "" {
+			assignment.Justification = expandJustification(justification)
+		}
+
+		err := r.service.DeleteEligibleAssignment(ctx, assignment, data.ForceDestroy.ValueBool())
+		if errors.Is(err, grouppim.ErrActivated) {
+			resp.Diagnostics.AddError(
+				"Eligibility is activated",
+				fmt.Sprintf("The principal %s has an active %s assignment in group %s through this eligibility. "+
+					"Wait for the activation to end, or set force_destroy to remove the activation as well.",
+					assignment.PrincipalID, assignment.Role, assignment.GroupID),
+			)
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Error deleting resource", "Unable to delete eligible assignment: "+sanitizeError(err))
+			return
+		}
+	}
+}
+
+// ImportState accepts the '{scope}|{principal_id}' ID, the '{scope}|{principal_id}|{role}' ID when the principal is
+// eligible for both roles, or the ID of an eligibility schedule request or instance as shown in the portal. Every
+// attribute is set, so the first plan after import only shows differences in configuration.
+func (r *GroupEligibleAssignment) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if r.deferredReason != "" {
+		deferImportState(req, resp, r.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "import of eligible assignment "+req.ID) }()
+
+	scope, principalID, role, err := conversions.ParseGroupAssignmentID(req.ID)
+	if err != nil {
+		scope, principalID, role, err = r.service.PrincipalOfSchedule(ctx, req.ID)
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Error deleting resource", "Unable to delete eligibility schedule request: "+err.Error())
+		resp.Diagnostics.AddError(
+			"Invalid import ID",
+			"The ID must be '{scope}|{principal_id}', '{scope}|{principal_id}|{role}', or the ID of an eligibility schedule request or instance: "+sanitizeError(err),
+		)
 		return
 	}
 
-	policyId, err := r.getEligibleExpirationPolicyId(ctx, data.Scope.ValueString())
+	assignment, err := r.service.GetEligibleAssignment(ctx, scope, principalID, role, true)
 	if err != nil {
-		resp.Diagnostics.AddError("Graph client error", "Unable to get eligible expiration policy ID: "+err.Error())
+		resp.Diagnostics.AddError("Client call failed", "Unable to get eligible assignment: "+sanitizeError(err))
 		return
 	}
 
-	if err := r.updateUnifiedRoleManagementPolicyRule(ctx, policyId, true); err != nil {
-		resp.Diagnostics.AddError("Graph client error", "Unable to update unified role management policy rule: "+err.Error())
+	assignment.PolicyID, err = r.service.EligibleExpirationPolicyID(ctx, scope)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get eligible expiration policy ID: "+sanitizeError(err))
+		return
+	}
+
+	expirationRequired, err := r.service.ExpirationRequired(ctx, scope)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get eligible expiration policy rule: "+sanitizeError(err))
 		return
 	}
+
+	var data GroupEligibleAssignmentModel
+	data.PolicyExpirationRequired = types.BoolValue(expirationRequired)
+	data.setEligibleAssignment(ctx, assignment)
+	data.keepJustification(types.StringNull(), r.defaultJustification)
+	data.setDefaults()
+	data.Timeouts = nullTimeouts()
+
+	// A deleted principal is reported by the Read following the import.
+	principal, err := r.directory.GetPrincipal(ctx, principalID)
+	if err != nil && !errors.Is(err, directory.ErrNotFound) {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get principal: "+sanitizeError(err))
+		return
+	}
+	data.setPrincipal(principal)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
-func (r *GroupEligibleAssignment) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+// eligibleAssignment returns the assignment described by the model.
+func (m GroupEligibleAssignmentModel) eligibleAssignment() grouppim.EligibleAssignment {
+	return grouppim.EligibleAssignment{
+		RequestID:     m.EligibleAssignmentID.ValueString(),
+		GroupID:       m.Scope.ValueString(),
+		PrincipalID:   m.PrincipalID.ValueString(),
+		Role:          m.Role.ValueString(),
+		Justification: expandJustification(m.Justification.ValueString()),
+		Status:        m.Status.ValueString(),
+		StartDateTime: m.StartDateTime.ValueString(),
+		EndDateTime:   m.EndDateTime.ValueString(),
+		InstanceID:    m.InstanceID.ValueString(),
+	}
+}
+
+// setDefaults sets the attributes with a default which are null, as defaults are not applied on import.
+func (m *GroupEligibleAssignmentModel) setDefaults() {
+	if m.OnDestroy.IsNull() {
+		m.OnDestroy = types.StringValue(onDestroyRemove)
+	}
+	if m.AutoRenewWindow.IsNull() {
+		m.AutoRenewWindow = types.StringValue(defaultAutoRenewWindow)
+	}
+	if m.MultipleRequests.IsNull() {
+		m.MultipleRequests = types.StringValue(multipleRequestsNewest)
+	}
 }
 
-func newPrivilegedAccessGroupEligibilityScheduleRequest(data GroupEligibleAssignmentModel) (*graphmodels.PrivilegedAccessGroupEligibilityScheduleRequest, error) {
-	requestBody := graphmodels.NewPrivilegedAccessGroupEligibilityScheduleRequest()
+// keepJustification keeps the justification null when it was not set, and Graph returned either no justification or
+// the default justification of the provider, so no difference is planned for configurations without one. Likewise, a
+// justification with placeholders is kept as configured while Graph returns an expansion of it.
+func (m *GroupEligibleAssignmentModel) keepJustification(prior types.String, defaultJustification string) {
+	// A write-only justification is never stored in state.
+	if !m.JustificationWOVersion.IsNull() {
+		m.Justification = types.StringNull()
+		return
+	}
 
-	accessId, err := convertRoleToAccessId(data.Role.ValueString())
-	if err != nil {
-		return nil, fmt.Errorf("unable to convert role to access ID: %w", err)
+	if prior.IsNull() && (m.Justification.ValueString() == "" || justificationMatches(defaultJustification, m.Justification.ValueString())) {
+		m.Justification = types.StringNull()
+		return
 	}
 
-	requestBody.SetAccessId(&accessId)
+	if !prior.IsNull() && justificationMatches(prior.ValueString(), m.Justification.ValueString()) {
+		m.Justification = prior
+	}
+}
 
-	principalId := data.PrincipalID.ValueString()
-	requestBody.SetPrincipalId(&principalId)
+// setPrincipal sets the computed attributes describing the principal.
+func (m *GroupEligibleAssignmentModel) setPrincipal(p directory.Principal) {
+	m.PrincipalUserType = types.StringValue(p.UserType)
+	m.PrincipalHomeDomain = types.StringValue(p.HomeDomain)
+}
 
-	groupId := data.Scope.ValueString()
-	requestBody.SetGroupId(&groupId)
+// configuredJustification returns the justification_wo of config when it is set, as write-only values are only
+// available in the configuration, and planned otherwise.
+func configuredJustification(ctx context.Context, config tfsdk.Config, planned types.String) (types.String, diag.Diagnostics) {
+	if config.Raw.IsNull() {
+		return planned, nil
+	}
 
-	action := graphmodels.ADMINASSIGN_SCHEDULEREQUESTACTIONS
-	requestBody.SetAction(&action)
+	var justificationWO types.String
+	diags := config.GetAttribute(ctx, path.Root("justification_wo"), &justificationWO)
+	if justificationWO.IsNull() {
+		return planned, diags
+	}
 
-	scheduleInfo := graphmodels.NewRequestSchedule()
-	startDateTime, err := time.Parse(time.RFC3339, data.StartDateTime.ValueString())
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse startDateTime: %w", err)
+	return justificationWO, diags
+}
+
+// setEligibleAssignment updates the model with the assignment returned by Graph.
+func (m *GroupEligibleAssignmentModel) setEligibleAssignment(ctx context.Context, a grouppim.EligibleAssignment) {
+	m.Id = types.StringValue(conversions.GroupAssignmentID(a.GroupID, a.PrincipalID, a.Role))
+	m.EligibleAssignmentID = types.StringValue(a.RequestID)
+	m.Scope = customtypes.NewGUIDValue(a.GroupID)
+	m.GroupID = m.Scope
+	m.PrincipalID = customtypes.NewGUIDValue(a.PrincipalID)
+	m.Role = types.StringValue(a.Role)
+	m.AccessID = m.Role
+	m.Justification = types.StringValue(a.Justification)
+	m.Status = types.StringValue(a.Status)
+	m.StartDateTime = customtypes.NewRFC3339Value(a.StartDateTime)
+	m.EndDateTime = customtypes.NewRFC3339Value(a.EndDateTime)
+	m.ExpirationType = types.StringValue(a.ExpirationType)
+	m.ExpirationDuration = types.StringValue(a.ExpirationDuration)
+	m.MemberType = types.StringValue(a.MemberType)
+	m.CreatedBy = types.StringValue(a.CreatedBy)
+	m.CreatedDateTime = customtypes.NewRFC3339Value(a.CreatedDateTime)
+	m.CompletedDateTime = customtypes.NewRFC3339Value(a.CompletedDateTime)
+	m.TargetScheduleID = types.StringValue(a.TargetScheduleID)
+	m.InstanceID = types.StringValue(a.InstanceID)
+	if a.PolicyID != "" {
+		m.PolicyID = types.StringValue(a.PolicyID)
 	}
+	m.RawPayload = rawPayloadValue(ctx, m.Debug, a.Raw)
+}
 
-	scheduleInfo.SetStartDateTime(&startDateTime)
-	expiration := graphmodels.NewExpirationPattern()
-	typ := graphmodels.NOEXPIRATION_EXPIRATIONPATTERNTYPE
-	expiration.SetTypeEscaped(&typ)
+// unknownIDOnRoleChange marks the planned ID as unknown when the role changes,
+// since the role is part of the ID of an owner eligibility.
+type unknownIDOnRoleChange struct{}
 
-	scheduleInfo.SetExpiration(expiration)
-	requestBody.SetScheduleInfo(scheduleInfo)
-	requestBody.SetJustification(toPtr(data.Justification.ValueString()))
+func (m unknownIDOnRoleChange) Description(_ context.Context) string {
+	return "If the role changes, the value of this attribute is known after apply."
+}
 
-	return requestBody, nil
+func (m unknownIDOnRoleChange) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
 }
 
-func convertRoleToAccessId(role string) (graphmodels.PrivilegedAccessGroupRelationships, error) {
-	switch role {
-	case "owner":
-		return graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, nil
-	case "member":
-		return graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, nil
-	default:
-		return 0, fmt.Errorf("invalid role: %s", role)
+func (m unknownIDOnRoleChange) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
 	}
-}
 
-func convertAccessIdToRole(accessId graphmodels.PrivilegedAccessGroupRelationships) (string, error) {
-	switch accessId {
-	case graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS:
-		return "owner", nil
-	case graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS:
-		return "member", nil
-	default:
-		return "", fmt.Errorf("invalid accessId: %d", accessId)
+	var planRole, planAccessID, stateRole types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("role"), &planRole)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("access_id"), &planAccessID)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("role"), &stateRole)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The role is only planned from its alias after the plan modifiers of the attributes ran.
+	if planRole.IsUnknown() {
+		planRole = planAccessID
+	}
+
+	if !planRole.Equal(stateRole) {
+		resp.PlanValue = types.StringUnknown()
 	}
 }
 
@@ -1,13 +1,44 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/fakegraph"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/vcr"
 )
 
 func TestAccGroupEligibleAssignmentResource(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	suffix := acctest.RandStringFromCharSet(8, acctest.CharSetAlphaNum)
+
+	resource.ParallelTest(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		ExternalProviders: map[string]resource.ExternalProvider{
@@ -19,7 +50,7 @@ func TestAccGroupEligibleAssignmentResource(t *testing.T) {
 		Steps: []resource.TestStep{
 			// Create and Read testing
 			{
-				Config: testAccGroupEligibleAssignmentConfig(),
+				Config: testAccGroupEligibleAssignmentConfig(suffix),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("azurepim_group_eligible_assignment.test", "role", "member"),
 				),
@@ -34,29 +65,2198 @@ func TestAccGroupEligibleAssignmentResource(t *testing.T) {
 	})
 }
 
+// TestAccGroupEligibleAssignmentResourceRecorded records or replays the Graph interactions, depending on AZUREPIM_VCR_MODE.
+// Recording requires an existing group and principal in AZUREPIM_TEST_GROUP_ID and AZUREPIM_TEST_PRINCIPAL_ID, their IDs are
+// stored in the cassette so the test can be replayed without a tenant.
+func TestAccGroupEligibleAssignmentResourceRecorded(t *testing.T) {
+	rec := testAccRecorder(t)
+
+	if rec.Mode() == vcr.ModeRecord {
+		for _, name := range []string{testAccFixtureGroupIDEnvironmentVariable, "AZUREPIM_TEST_PRINCIPAL_ID"} {
+			if os.Getenv(name) == "" {
+				t.Fatalf("%s must be set when recording", name)
+			}
+		}
+
+		rec.SetVariable("group_id", os.Getenv(testAccFixtureGroupIDEnvironmentVariable))
+		rec.SetVariable("principal_id", os.Getenv("AZUREPIM_TEST_PRINCIPAL_ID"))
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccRecordingProviderFactories(rec),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGroupEligibleAssignmentRecordedConfig(rec.Variable("group_id"), rec.Variable("principal_id")),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("azurepim_group_eligible_assignment.test", "role", "member"),
+					resource.TestCheckResourceAttr("azurepim_group_eligible_assignment.test", "status", "Provisioned"),
+				),
+			},
+		},
+	})
+}
+
+func testAccGroupEligibleAssignmentRecordedConfig(groupID, principalID string) string {
+	return fmt.Sprintf(`
+resource "azurepim_group_eligible_assignment" "test" {
+	role          = "member"
+	scope         = %q
+	justification = "this is a test"
+	principal_id  = %q
+}`, groupID, principalID)
+}
+
 // testAccGroupEligibleAssignmentConfig the config requires the following graph permissions in addition to the ones required by the azurepim_group_eligible_assignment resource:
 // - RoleManagement.ReadWrite.Directory.
 // - Group.Create.
-func testAccGroupEligibleAssignmentConfig() string {
-	return `
+// The groups are suffixed so tests can run in parallel, and so leftovers from an aborted run do not collide with the next one.
+// When AZUREPIM_TEST_GROUP_ID is set that group is used as the scope instead of creating one, see testAccFixtureGroupConfig.
+func testAccGroupEligibleAssignmentConfig(suffix string) string {
+	scopeConfig, scope := testAccFixtureGroupConfig("pag", suffix)
+
+	return fmt.Sprintf(`
 data "azuread_client_config" "current" {}
 
 resource "azuread_group" "main" {
-	display_name     = "azurepim-acc-test-group"
+	display_name     = "azurepim-acc-test-group-%[1]s"
 	owners           = [data.azuread_client_config.current.object_id]
 	security_enabled = true
 }
-
-resource "azuread_group" "pag" {
-	display_name       = "azurepim-acc-test-group-pag"
-	owners             = [data.azuread_client_config.current.object_id]
-	security_enabled   = true
-}
-
+%[2]s
 resource "azurepim_group_eligible_assignment" "test" {
 	role          = "member"
-	scope         = azuread_group.pag.object_id
+	scope         = %[3]s
 	justification = "this is a test"
 	principal_id  = azuread_group.main.object_id
-}`
+}`, suffix, scopeConfig, scope)
+}
+
+// fakeGroupEligibilityClient keeps eligibility schedule requests in memory.
+type fakeGroupEligibilityClient struct {
+	requests        []graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable
+	policyId        string
+	expirationRules map[string]bool
+	createErr       error
+	// policyErr fails listing policy assignments, and restoreErr updating the expiration rule to require expiration.
+	policyErr  error
+	restoreErr error
+	// tenantExpirationRequired is a tenant wide expiration rule taking precedence over the rules of all policies.
+	tenantExpirationRequired *bool
+	// removal is the last adminRemove request.
+	removal     graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable
+	activations []graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable
+	// deadline is the deadline of the context of the last request.
+	deadline time.Time
+	// activationErrs fails the activation requests of the groups they are keyed by.
+	activationErrs map[string]error
+	// approvalRequired leaves the activation requests of the groups it contains pending approval, and
+	// activationRequests are the assignment schedule requests which were created.
+	approvalRequired   map[string]bool
+	activationRequests []graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable
+	// decisions are the statuses the requests pending approval in the groups they are keyed by have once they are
+	// listed, like after an approver decided on them.
+	decisions map[string]string
+	// batches counts the calls creating several assignment schedule requests at once.
+	batches int
+	// policyQueries counts the calls listing policy assignments.
+	policyQueries atomic.Int32
+	// indexingLag is how many more calls listing schedule requests miss all requests, like Graph right after they
+	// were created.
+	indexingLag int
+}
+
+func newFakeGroupEligibilityClient() *fakeGroupEligibilityClient {
+	return &fakeGroupEligibilityClient{
+		policyId:        "Group_policy",
+		expirationRules: map[string]bool{},
+	}
+}
+
+func (f *fakeGroupEligibilityClient) CreateEligibilityScheduleRequest(ctx context.Context, body graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable) (graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error) {
+	f.deadline, _ = ctx.Deadline()
+
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+
+	if *body.GetAction() == graphmodels.ADMINREMOVE_SCHEDULEREQUESTACTIONS {
+		f.removal = body
+		f.revoke(body)
+		return body, nil
+	}
+
+	// An update replaces the eligibility of the principal for the same role.
+	if *body.GetAction() == graphmodels.ADMINUPDATE_SCHEDULEREQUESTACTIONS {
+		f.revoke(body)
+	}
+
+	// The first request of a principal keeps the ID "request-" + principal ID, later ones are numbered.
+	id := "request-" + *body.GetPrincipalId()
+	for n := 2; f.hasRequest(id); n++ {
+		id = fmt.Sprintf("request-%s-%d", *body.GetPrincipalId(), n)
+	}
+
+	body.SetId(&id)
+	body.SetStatus(toPtr("Provisioned"))
+	if body.GetCreatedDateTime() == nil {
+		created := time.Now()
+		body.SetCreatedDateTime(&created)
+	}
+	f.requests = append(f.requests, body)
+
+	return body, nil
+}
+
+// revoke revokes the provisioned requests for the group, principal and access ID of body.
+func (f *fakeGroupEligibilityClient) revoke(body graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable) {
+	for _, r := range f.requests {
+		if *r.GetGroupId() == *body.GetGroupId() && *r.GetPrincipalId() == *body.GetPrincipalId() && *r.GetAccessId() == *body.GetAccessId() && *r.GetStatus() == "Provisioned" {
+			r.SetStatus(toPtr("Revoked"))
+		}
+	}
+}
+
+func (f *fakeGroupEligibilityClient) hasRequest(id string) bool {
+	for _, r := range f.requests {
+		if *r.GetId() == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *fakeGroupEligibilityClient) CancelEligibilityScheduleRequest(ctx context.Context, requestID string) error {
+	for _, r := range f.requests {
+		if *r.GetId() == requestID {
+			r.SetStatus(toPtr("Canceled"))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("request %s not found", requestID)
+}
+
+func (f *fakeGroupEligibilityClient) ListEligibilityScheduleRequests(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error) {
+	if f.indexingLag > 0 {
+		f.indexingLag--
+		return nil, nil
+	}
+
+	var result []graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable
+	for _, r := range f.requests {
+		if filterMatches(filter, "groupId", *r.GetGroupId()) && filterMatches(filter, "principalId", *r.GetPrincipalId()) && filterMatches(filter, "accessId", r.GetAccessId().String()) {
+			result = append(result, r)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeGroupEligibilityClient) GetEligibilityScheduleRequest(ctx context.Context, requestID string) (graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error) {
+	for _, r := range f.requests {
+		if *r.GetId() == requestID {
+			return r, nil
+		}
+	}
+
+	return nil, grouppim.ErrNotFound
+}
+
+// GetEligibilityScheduleInstance finds instances by their ID prefixed with "instance-", to tell them apart from requests.
+func (f *fakeGroupEligibilityClient) GetEligibilityScheduleInstance(ctx context.Context, instanceID string) (graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable, error) {
+	for _, r := range f.requests {
+		if "instance-"+*r.GetId() == instanceID && *r.GetStatus() == "Provisioned" {
+			i := graphmodels.NewPrivilegedAccessGroupEligibilityScheduleInstance()
+			i.SetId(&instanceID)
+			i.SetGroupId(r.GetGroupId())
+			i.SetPrincipalId(r.GetPrincipalId())
+			i.SetAccessId(r.GetAccessId())
+			return i, nil
+		}
+	}
+
+	return nil, grouppim.ErrNotFound
+}
+
+func (f *fakeGroupEligibilityClient) ListEligibilityScheduleInstances(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable, error) {
+	var result []graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable
+	for _, r := range f.requests {
+		if *r.GetStatus() != "Provisioned" || !filterMatches(filter, "groupId", *r.GetGroupId()) || !filterMatches(filter, "principalId", *r.GetPrincipalId()) {
+			continue
+		}
+
+		i := graphmodels.NewPrivilegedAccessGroupEligibilityScheduleInstance()
+		i.SetId(r.GetId())
+		i.SetEligibilityScheduleId(toPtr("schedule-" + *r.GetId()))
+		i.SetGroupId(r.GetGroupId())
+		i.SetPrincipalId(r.GetPrincipalId())
+		i.SetAccessId(r.GetAccessId())
+		memberType := graphmodels.DIRECT_PRIVILEGEDACCESSGROUPMEMBERTYPE
+		i.SetMemberType(&memberType)
+		if info := r.GetScheduleInfo(); info != nil && info.GetExpiration() != nil {
+			i.SetEndDateTime(info.GetExpiration().GetEndDateTime())
+		}
+		result = append(result, i)
+	}
+
+	return result, nil
+}
+
+// filterMatches returns whether an OData filter either does not filter on property, or filters on it equalling value.
+func filterMatches(filter, property, value string) bool {
+	return !strings.Contains(filter, property) || strings.Contains(filter, fmt.Sprintf("%s eq '%s'", property, value))
+}
+
+// activate adds an activated member assignment for principalID in groupID, activated through the eligibility
+// schedule instance with ID activatedUsing.
+func (f *fakeGroupEligibilityClient) activate(groupID, principalID, activatedUsing string) {
+	i := graphmodels.NewPrivilegedAccessGroupAssignmentScheduleInstance()
+	eligibility := graphmodels.NewPrivilegedAccessGroupEligibilityScheduleInstance()
+	eligibility.SetId(&activatedUsing)
+	i.SetActivatedUsing(eligibility)
+	i.SetGroupId(&groupID)
+	i.SetPrincipalId(&principalID)
+	accessId := graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS
+	i.SetAccessId(&accessId)
+	assignmentType := graphmodels.ACTIVATED_PRIVILEGEDACCESSGROUPASSIGNMENTTYPE
+	i.SetAssignmentType(&assignmentType)
+	f.activations = append(f.activations, i)
+}
+
+func (f *fakeGroupEligibilityClient) ListAssignmentScheduleInstances(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable, error) {
+	var result []graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable
+	for _, i := range f.activations {
+		if filterMatches(filter, "groupId", *i.GetGroupId()) && filterMatches(filter, "principalId", *i.GetPrincipalId()) {
+			result = append(result, i)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeGroupEligibilityClient) ListAssignmentScheduleRequests(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, error) {
+	var result []graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable
+	for _, r := range f.activationRequests {
+		if status, ok := f.decisions[*r.GetGroupId()]; ok && *r.GetStatus() == grouppim.StatusPendingApproval {
+			r.SetStatus(&status)
+		}
+		if filterMatches(filter, "groupId", *r.GetGroupId()) && filterMatches(filter, "principalId", *r.GetPrincipalId()) {
+			result = append(result, r)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeGroupEligibilityClient) CreateAssignmentScheduleRequest(ctx context.Context, body graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable) (graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, error) {
+	var remaining []graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable
+	for _, i := range f.activations {
+		if *i.GetGroupId() != *body.GetGroupId() || *i.GetPrincipalId() != *body.GetPrincipalId() {
+			remaining = append(remaining, i)
+		}
+	}
+	f.activations = remaining
+
+	return body, nil
+}
+
+// CreateAssignmentScheduleRequests activates the roles of selfActivate and selfExtend requests until the end of their
+// schedule, and ends the activations of selfDeactivate requests.
+func (f *fakeGroupEligibilityClient) CreateAssignmentScheduleRequests(ctx context.Context, bodies []graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, []error) {
+	f.batches++
+
+	errs := make([]error, len(bodies))
+	for n, body := range bodies {
+		if err := f.activationErrs[*body.GetGroupId()]; err != nil {
+			errs[n] = err
+			continue
+		}
+
+		var remaining []graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable
+		for _, i := range f.activations {
+			if *i.GetGroupId() != *body.GetGroupId() || *i.GetPrincipalId() != *body.GetPrincipalId() || *i.GetAccessId() != *body.GetAccessId() {
+				remaining = append(remaining, i)
+			}
+		}
+		f.activations = remaining
+
+		body.SetId(toPtr(fmt.Sprintf("activation-%d-%d", f.batches, n)))
+		body.SetStatus(toPtr("Provisioned"))
+		f.activationRequests = append(f.activationRequests, body)
+		if *body.GetAction() == graphmodels.SELFDEACTIVATE_SCHEDULEREQUESTACTIONS {
+			continue
+		}
+
+		if f.approvalRequired[*body.GetGroupId()] {
+			body.SetStatus(toPtr(grouppim.StatusPendingApproval))
+			continue
+		}
+
+		end, err := time.Parse(time.RFC3339, conversions.ScheduleEnd(body.GetScheduleInfo()))
+		if err != nil {
+			errs[n] = err
+			continue
+		}
+
+		i := graphmodels.NewPrivilegedAccessGroupAssignmentScheduleInstance()
+		i.SetGroupId(body.GetGroupId())
+		i.SetPrincipalId(body.GetPrincipalId())
+		i.SetAccessId(body.GetAccessId())
+		i.SetEndDateTime(&end)
+		assignmentType := graphmodels.ACTIVATED_PRIVILEGEDACCESSGROUPASSIGNMENTTYPE
+		i.SetAssignmentType(&assignmentType)
+		f.activations = append(f.activations, i)
+	}
+
+	return bodies, errs
+}
+
+func (f *fakeGroupEligibilityClient) ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	f.policyQueries.Add(1)
+	if f.policyErr != nil {
+		return nil, f.policyErr
+	}
+
+	// The owner role is governed by a policy of its own.
+	policyId := f.policyId
+	if strings.Contains(filter, "roleDefinitionId eq 'owner'") {
+		policyId += "_owner"
+	}
+
+	// Like Graph, the assignments only expand the rules of their policy.
+	policy := f.policy(policyId)
+	policy.SetEffectiveRules(nil)
+
+	a := graphmodels.NewUnifiedRoleManagementPolicyAssignment()
+	a.SetId(toPtr(policyId + "_assignment"))
+	a.SetPolicyId(toPtr(policyId))
+	a.SetPolicy(policy)
+
+	return []graphmodels.UnifiedRoleManagementPolicyAssignmentable{a}, nil
+}
+
+func (f *fakeGroupEligibilityClient) GetRoleManagementPolicy(ctx context.Context, policyID string) (graphmodels.UnifiedRoleManagementPolicyable, error) {
+	if f.policyErr != nil {
+		return nil, f.policyErr
+	}
+
+	return f.policy(policyID), nil
+}
+
+// policy returns the policy policyId with its rules and effective rules. Its expiration rule has the default settings
+// until it is updated, and its enablement rule always has.
+func (f *fakeGroupEligibilityClient) policy(policyId string) graphmodels.UnifiedRoleManagementPolicyable {
+	// Expiration is required until the rule is updated, like in new groups.
+	required, ok := f.expirationRules[policyId]
+	if !ok {
+		required = true
+	}
+	maximumDuration, _ := serialization.ParseISODuration("P365D")
+
+	rule := graphmodels.NewUnifiedRoleManagementPolicyExpirationRule()
+	rule.SetId(toPtr("Expiration_Admin_Eligibility"))
+	rule.SetIsExpirationRequired(toPtr(required))
+	rule.SetMaximumDuration(maximumDuration)
+
+	effective := graphmodels.NewUnifiedRoleManagementPolicyExpirationRule()
+	effective.SetId(toPtr("Expiration_Admin_Eligibility"))
+	effective.SetIsExpirationRequired(toPtr(required))
+	effective.SetMaximumDuration(maximumDuration)
+	if f.tenantExpirationRequired != nil {
+		effective.SetIsExpirationRequired(f.tenantExpirationRequired)
+	}
+
+	enablement := graphmodels.NewUnifiedRoleManagementPolicyEnablementRule()
+	enablement.SetId(toPtr("Enablement_EndUser_Assignment"))
+	enablement.SetEnabledRules([]string{"Justification"})
+
+	policy := graphmodels.NewUnifiedRoleManagementPolicy()
+	policy.SetId(toPtr(policyId))
+	policy.SetRules([]graphmodels.UnifiedRoleManagementPolicyRuleable{rule, enablement})
+	policy.SetEffectiveRules([]graphmodels.UnifiedRoleManagementPolicyRuleable{effective, enablement})
+	if ok {
+		policy.SetLastModifiedDateTime(toPtr(time.Now()))
+	}
+
+	return policy
+}
+
+// fakeDirectoryClient resolves users by user principal name and groups by display name from a map of name to object IDs.
+// All directory objects exist, except the object IDs under the deletedObjects key.
+// The direct members of a group are listed under the groupMembersPrefix key of the group, and are users unless listed
+// under the devices key. The tenantGroups key lists all groups in the tenant, and the caller key the object ID the
+// client authenticates as. The directory objects under the servicePrincipals key are service principals. Guests are listed under the guestPrefix key of their object ID, with their email address and
+// external user state. The delta links of the delta queries of group members are recorded under the deltaQueries key.
+// The groups under the dynamicGroups key have dynamic membership.
+type fakeDirectoryClient map[string][]string
+
+const (
+	deletedObjects     = "deleted"
+	devices            = "devices"
+	tenantGroups       = "groups"
+	caller             = "caller"
+	groupMembersPrefix = "members/"
+	guestPrefix        = "guest/"
+	servicePrincipals  = "servicePrincipals"
+	deltaQueries       = "deltaQueries"
+	dynamicGroups      = "dynamic"
+)
+
+// CallerObjectID returns the ID under the caller key.
+func (f fakeDirectoryClient) CallerObjectID(ctx context.Context) (string, error) {
+	ids, ok := f[caller]
+	if !ok {
+		return "", fmt.Errorf("no caller")
+	}
+
+	return ids[0], nil
+}
+
+// ListAllGroups lists the groups under the tenantGroups key in pages of one group, ignoring the filter.
+func (f fakeDirectoryClient) ListAllGroups(ctx context.Context, filter string, visit func(page []graphmodels.Groupable) error) error {
+	for _, id := range f[tenantGroups] {
+		g := graphmodels.NewGroup()
+		g.SetId(toPtr(id))
+		if err := visit([]graphmodels.Groupable{g}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f fakeDirectoryClient) ListGroupMembers(ctx context.Context, groupID string) ([]graphmodels.DirectoryObjectable, error) {
+	var result []graphmodels.DirectoryObjectable
+	for _, id := range f[groupMembersPrefix+groupID] {
+		o := graphmodels.NewDirectoryObject()
+		o.SetId(toPtr(id))
+		o.SetOdataType(toPtr("#microsoft.graph.user"))
+		for _, device := range f[devices] {
+			if device == id {
+				o.SetOdataType(toPtr("#microsoft.graph.device"))
+			}
+		}
+		result = append(result, o)
+	}
+
+	return result, nil
+}
+
+// ListGroupMemberChanges returns delta links listing the members of the group as of the query, and lists the changes
+// since the members of deltaLink. Delta links not returned by it have expired.
+func (f fakeDirectoryClient) ListGroupMemberChanges(ctx context.Context, groupID, deltaLink string) ([]directory.MemberChange, string, error) {
+	f[deltaQueries] = append(f[deltaQueries], deltaLink)
+
+	previous := map[string]bool{}
+	if deltaLink != "" {
+		ids, ok := strings.CutPrefix(deltaLink, "delta:"+groupID+":")
+		if !ok {
+			return nil, "", directory.ErrDeltaExpired
+		}
+		for _, id := range strings.Split(ids, ",") {
+			previous[id] = id != ""
+		}
+	}
+
+	objects, _ := f.ListGroupMembers(ctx, groupID)
+	current := map[string]bool{}
+	var changes []directory.MemberChange
+	for _, o := range objects {
+		current[*o.GetId()] = true
+		if !previous[*o.GetId()] {
+			changes = append(changes, directory.MemberChange{Member: directory.Member{ID: *o.GetId(), OdataType: *o.GetOdataType()}})
+		}
+	}
+	for id, member := range previous {
+		if member && !current[id] {
+			changes = append(changes, directory.MemberChange{Member: directory.Member{ID: id}, Removed: true})
+		}
+	}
+
+	return changes, "delta:" + groupID + ":" + strings.Join(f[groupMembersPrefix+groupID], ","), nil
+}
+
+func (f fakeDirectoryClient) RemoveGroupMember(ctx context.Context, groupID, memberID string) error {
+	var remaining []string
+	for _, id := range f[groupMembersPrefix+groupID] {
+		if id != memberID {
+			remaining = append(remaining, id)
+		}
+	}
+	f[groupMembersPrefix+groupID] = remaining
+
+	return nil
+}
+
+func (f fakeDirectoryClient) GetDirectoryObject(ctx context.Context, id string) (graphmodels.DirectoryObjectable, error) {
+	for _, deleted := range f[deletedObjects] {
+		if deleted == id {
+			return nil, directory.ErrNotFound
+		}
+	}
+
+	for _, sp := range f[servicePrincipals] {
+		if sp == id {
+			o := graphmodels.NewServicePrincipal()
+			o.SetId(&id)
+			return o, nil
+		}
+	}
+
+	for _, group := range f[tenantGroups] {
+		if group == id {
+			o := graphmodels.NewGroup()
+			o.SetId(&id)
+			return o, nil
+		}
+	}
+
+	for _, group := range f[dynamicGroups] {
+		if group == id {
+			o := graphmodels.NewGroup()
+			o.SetId(&id)
+			o.SetGroupTypes([]string{"DynamicMembership"})
+			o.SetMembershipRule(toPtr(`user.department -eq "Sales"`))
+			return o, nil
+		}
+	}
+
+	if guest, ok := f[guestPrefix+id]; ok {
+		u := graphmodels.NewUser()
+		u.SetId(&id)
+		u.SetUserType(toPtr(directory.UserTypeGuest))
+		u.SetMail(&guest[0])
+		u.SetExternalUserState(&guest[1])
+		return u, nil
+	}
+
+	o := graphmodels.NewDirectoryObject()
+	o.SetId(&id)
+
+	return o, nil
+}
+
+func (f fakeDirectoryClient) GetUser(ctx context.Context, idOrUserPrincipalName string) (graphmodels.Userable, error) {
+	ids, ok := f[idOrUserPrincipalName]
+	if !ok {
+		return nil, fmt.Errorf("user %s: %w", idOrUserPrincipalName, directory.ErrNotFound)
+	}
+
+	u := graphmodels.NewUser()
+	u.SetId(&ids[0])
+
+	return u, nil
+}
+
+// ListUsers lists the guests whose email address matches the filter.
+func (f fakeDirectoryClient) ListUsers(ctx context.Context, filter string, top int32) ([]graphmodels.Userable, error) {
+	var result []graphmodels.Userable
+	for key, guest := range f {
+		id, ok := strings.CutPrefix(key, guestPrefix)
+		if !ok || !filterMatches(filter, "mail", guest[0]) {
+			continue
+		}
+
+		u := graphmodels.NewUser()
+		u.SetId(toPtr(id))
+		result = append(result, u)
+	}
+
+	return result, nil
+}
+
+func (f fakeDirectoryClient) ListGroups(ctx context.Context, filter string, top int32) ([]graphmodels.Groupable, error) {
+	var result []graphmodels.Groupable
+	for name, ids := range f {
+		if filter != fmt.Sprintf("displayName eq '%s'", name) {
+			continue
+		}
+
+		for _, id := range ids {
+			g := graphmodels.NewGroup()
+			g.SetId(toPtr(id))
+			result = append(result, g)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeGroupEligibilityClient) UpdatePolicyExpirationRule(ctx context.Context, policyId string, isExpirationRequired bool) error {
+	if isExpirationRequired && f.restoreErr != nil {
+		return f.restoreErr
+	}
+
+	f.expirationRules[policyId] = isExpirationRequired
+	return nil
+}
+
+func testGroupEligibleAssignmentResource(t *testing.T, client grouppim.Client) (*GroupEligibleAssignment, tfsdk.State) {
+	t.Helper()
+
+	r := &GroupEligibleAssignment{
+		service: grouppim.NewService(client),
+		directory: directory.NewService(fakeDirectoryClient{
+			"user@example.com": {"principal-id"},
+			"Group":            {"group-id"},
+			"Ambiguous":        {"group-id", "other-group-id"},
+		}),
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(context.Background(), fwresource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	return r, tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), nil),
+	}
+}
+
+func testGroupEligibleAssignmentModel() GroupEligibleAssignmentModel {
+	return GroupEligibleAssignmentModel{
+		Id:                   types.StringUnknown(),
+		Role:                 types.StringValue("member"),
+		AccessID:             types.StringUnknown(),
+		Scope:                customtypes.NewGUIDValue("group-id"),
+		GroupID:              customtypes.NewGUIDUnknown(),
+		Justification:        types.StringValue("this is a test"),
+		PrincipalID:          customtypes.NewGUIDValue("principal-id"),
+		Status:               types.StringUnknown(),
+		StartDateTime:        customtypes.NewRFC3339Unknown(),
+		EndDateTime:          customtypes.NewRFC3339Unknown(),
+		ExpirationType:       types.StringUnknown(),
+		ExpirationDuration:   types.StringUnknown(),
+		EligibleAssignmentID: types.StringUnknown(),
+		// The expiration requirement is planned unknown on create, as there is no prior state.
+		PolicyExpirationRequired: types.BoolUnknown(),
+		Debug:                    types.BoolNull(),
+		RawPayload:               types.StringUnknown(),
+		Timeouts:                 nullTimeouts(),
+	}
+}
+
+func testGroupEligibleAssignmentPlan(t *testing.T, empty tfsdk.State, model GroupEligibleAssignmentModel) tfsdk.Plan {
+	t.Helper()
+
+	plan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	if diags := plan.Set(context.Background(), model); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	return plan
+}
+
+// testGroupEligibleAssignmentCreate creates the eligibility of model, and returns the state after the create.
+func testGroupEligibleAssignmentCreate(t *testing.T, r *GroupEligibleAssignment, empty tfsdk.State, model GroupEligibleAssignmentModel) tfsdk.State {
+	t.Helper()
+
+	resp := &fwresource.CreateResponse{State: empty}
+	r.Create(context.Background(), fwresource.CreateRequest{Plan: testGroupEligibleAssignmentPlan(t, empty, model)}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected create diagnostics: %v", resp.Diagnostics)
+	}
+
+	return resp.State
+}
+
+func testGroupEligibleAssignmentState(t *testing.T, state tfsdk.State) GroupEligibleAssignmentModel {
+	t.Helper()
+
+	var m GroupEligibleAssignmentModel
+	if diags := state.Get(context.Background(), &m); diags.HasError() {
+		t.Fatalf("unable to get state: %v", diags)
+	}
+
+	return m
+}
+
+// addGroupEligibilityRequest adds a provisioned request to client for the same group and principal as the first
+// request, as if it was created outside of Terraform.
+func addGroupEligibilityRequest(client *fakeGroupEligibilityClient, id string, accessID graphmodels.PrivilegedAccessGroupRelationships, created time.Time) {
+	request := graphmodels.NewPrivilegedAccessGroupEligibilityScheduleRequest()
+	request.SetId(&id)
+	request.SetGroupId(client.requests[0].GetGroupId())
+	request.SetPrincipalId(client.requests[0].GetPrincipalId())
+	request.SetAccessId(&accessID)
+	request.SetStatus(toPtr(grouppim.StatusProvisioned))
+	request.SetCreatedDateTime(&created)
+	client.requests = append(client.requests, request)
+}
+
+func TestGroupEligibleAssignmentCreate(t *testing.T) {
+	tests := []struct {
+		name string
+		// directory replaces the directory of the test resource when set.
+		directory    fakeDirectoryClient
+		setup        func(r *GroupEligibleAssignment, client *fakeGroupEligibilityClient)
+		model        func(m *GroupEligibleAssignmentModel)
+		wantErr      bool
+		wantWarnings int
+		check        func(t *testing.T, client *fakeGroupEligibilityClient, created GroupEligibleAssignmentModel, diags diag.Diagnostics)
+	}{
+		{
+			name: "principal id",
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, created GroupEligibleAssignmentModel, _ diag.Diagnostics) {
+				if required, ok := client.expirationRules["Group_policy"]; !ok || required {
+					t.Errorf("expiration rule not updated to allow no expiration")
+				}
+
+				if created.Id.ValueString() != "group-id|principal-id" {
+					t.Errorf("got id %q, want %q", created.Id.ValueString(), "group-id|principal-id")
+				}
+
+				if created.Status.ValueString() != "Provisioned" {
+					t.Errorf("got status %q, want %q", created.Status.ValueString(), "Provisioned")
+				}
+
+				if _, err := time.Parse(time.RFC3339, created.StartDateTime.ValueString()); err != nil {
+					t.Errorf("start_date_time is not RFC3339: %s", err)
+				}
+
+				if created.ExpirationType.ValueString() != "noExpiration" || created.EndDateTime.ValueString() != "" {
+					t.Errorf("got expiration %q ending %q, want noExpiration", created.ExpirationType.ValueString(), created.EndDateTime.ValueString())
+				}
+
+				if created.PolicyID.ValueString() != "Group_policy" {
+					t.Errorf("got policy_id %q, want %q", created.PolicyID.ValueString(), "Group_policy")
+				}
+
+				if created.MemberType.ValueString() != "direct" {
+					t.Errorf("got member_type %q, want %q", created.MemberType.ValueString(), "direct")
+				}
+			},
+		},
+		{
+			name: "create timeout",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.Timeouts = timeouts.Value{Object: types.ObjectValueMust(timeoutsAttributeTypes, map[string]attr.Value{
+					"create": types.StringValue("45m"),
+					"update": types.StringNull(),
+					"delete": types.StringNull(),
+				})}
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, _ diag.Diagnostics) {
+				if remaining := time.Until(client.deadline); remaining > 45*time.Minute || remaining < 44*time.Minute {
+					t.Errorf("got request deadline in %s, want the 45m create timeout", remaining)
+				}
+			},
+		},
+		{
+			name: "principal upn",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.PrincipalID = customtypes.NewGUIDUnknown()
+				m.PrincipalUPN = types.StringValue("user@example.com")
+			},
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, created GroupEligibleAssignmentModel, _ diag.Diagnostics) {
+				if created.PrincipalID.ValueString() != "principal-id" || created.PrincipalUPN.ValueString() != "user@example.com" {
+					t.Errorf("got principal_id %q and principal_upn %q", created.PrincipalID.ValueString(), created.PrincipalUPN.ValueString())
+				}
+			},
+		},
+		{
+			name:      "guest email",
+			directory: fakeDirectoryClient{guestPrefix + "guest-id": {"Alice@Contoso.com", "PendingAcceptance"}},
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.PrincipalID = customtypes.NewGUIDUnknown()
+				m.PrincipalUPN = types.StringValue("Alice@Contoso.com")
+			},
+			// The invitation is still pending.
+			wantWarnings: 1,
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, created GroupEligibleAssignmentModel, _ diag.Diagnostics) {
+				if created.PrincipalID.ValueString() != "guest-id" {
+					t.Errorf("got principal_id %q, want guest-id", created.PrincipalID.ValueString())
+				}
+				if created.PrincipalUserType.ValueString() != "Guest" || created.PrincipalHomeDomain.ValueString() != "contoso.com" {
+					t.Errorf("got principal_user_type %q and principal_home_domain %q", created.PrincipalUserType.ValueString(), created.PrincipalHomeDomain.ValueString())
+				}
+			},
+		},
+		{
+			name: "group display name",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.Scope = customtypes.NewGUIDUnknown()
+				m.GroupDisplayName = types.StringValue("Group")
+			},
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, created GroupEligibleAssignmentModel, _ diag.Diagnostics) {
+				if created.Scope.ValueString() != "group-id" {
+					t.Errorf("got scope %q, want %q", created.Scope.ValueString(), "group-id")
+				}
+			},
+		},
+		{
+			name: "ambiguous group display name",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.Scope = customtypes.NewGUIDUnknown()
+				m.GroupDisplayName = types.StringValue("Ambiguous")
+			},
+			wantErr: true,
+		},
+		{
+			name:      "dynamic membership group",
+			directory: fakeDirectoryClient{dynamicGroups: {"group-id"}},
+			wantErr:   true,
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, diags diag.Diagnostics) {
+				if _, ok := client.expirationRules["Group_policy"]; ok {
+					t.Errorf("got expiration rule patched, want the group rejected before")
+				}
+				if !strings.Contains(diags.Errors()[0].Detail(), "dynamic membership") {
+					t.Errorf("got error %q, want it to explain the dynamic membership", diags.Errors()[0].Detail())
+				}
+			},
+		},
+		{
+			name: "missing group display name",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.Scope = customtypes.NewGUIDUnknown()
+				m.GroupDisplayName = types.StringValue("Missing")
+			},
+			wantErr: true,
+		},
+		{
+			name: "default justification",
+			setup: func(r *GroupEligibleAssignment, _ *fakeGroupEligibilityClient) {
+				r.defaultJustification = "required by change management"
+			},
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.Justification = types.StringNull()
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, _ diag.Diagnostics) {
+				if got := *client.requests[0].GetJustification(); got != "required by change management" {
+					t.Errorf("got request justification %q, want the default justification", got)
+				}
+			},
+		},
+		{
+			name:      "deleted principal",
+			directory: fakeDirectoryClient{deletedObjects: {"principal-id"}},
+			wantErr:   true,
+		},
+		{
+			name:      "service principal",
+			directory: fakeDirectoryClient{servicePrincipals: {"principal-id"}},
+			wantErr:   true,
+		},
+		{
+			name:      "group as owner",
+			directory: fakeDirectoryClient{tenantGroups: {"principal-id"}},
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.Role = types.StringValue("owner")
+			},
+			wantErr: true,
+		},
+		{
+			name: "strict policy requiring expiration",
+			setup: func(r *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.expirationRules["Group_policy"] = true
+				r.service.SetStrictPolicy(true)
+			},
+			wantErr: true,
+		},
+		{
+			name: "error",
+			setup: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.createErr = errors.New("request failed with Bearer abc")
+			},
+			wantErr: true,
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, diags diag.Diagnostics) {
+				if detail := diags.Errors()[0].Detail(); strings.Contains(detail, "abc") {
+					t.Errorf("token leaked in diagnostic: %s", detail)
+				}
+			},
+		},
+		{
+			name: "failed step after patching the policy",
+			setup: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.createErr = errors.New("request failed")
+			},
+			wantErr: true,
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, diags diag.Diagnostics) {
+				d := diags.Errors()[0]
+				if d.Summary() != "Unable to submit schedule request" {
+					t.Errorf("got summary %q, want the failed step", d.Summary())
+				}
+				if !strings.Contains(d.Detail(), "Rolled back") || !strings.Contains(d.Detail(), "policy Group_policy of group group-id was changed") {
+					t.Errorf("got detail %q, want the rolled back policy change", d.Detail())
+				}
+				if required := client.expirationRules["Group_policy"]; !required {
+					t.Error("got expiration not required, want the policy restored")
+				}
+			},
+		},
+		{
+			name: "failed rollback",
+			setup: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.createErr = errors.New("request failed")
+				client.restoreErr = errors.New("policy update failed")
+			},
+			wantErr: true,
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, diags diag.Diagnostics) {
+				d := diags.Errors()[0]
+				if !strings.Contains(d.Detail(), "Already changed") || !strings.Contains(d.Detail(), "Rolling back failed") {
+					t.Errorf("got detail %q, want the policy change left in place", d.Detail())
+				}
+			},
+		},
+		{
+			name: "failed step without weakening the policy",
+			setup: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.expirationRules["Group_policy"] = false
+				client.createErr = errors.New("request failed")
+			},
+			wantErr: true,
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, diags diag.Diagnostics) {
+				if required := client.expirationRules["Group_policy"]; required {
+					t.Error("got expiration required, want the policy left allowing no expiration")
+				}
+				if d := diags.Errors()[0]; !strings.Contains(d.Detail(), "Nothing was changed") {
+					t.Errorf("got detail %q, want nothing changed", d.Detail())
+				}
+			},
+		},
+		{
+			name: "failed step before changing anything",
+			setup: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.policyErr = errors.New("request failed")
+			},
+			wantErr: true,
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, _ GroupEligibleAssignmentModel, diags diag.Diagnostics) {
+				d := diags.Errors()[0]
+				if d.Summary() != "Unable to resolve policy" || !strings.Contains(d.Detail(), "Nothing was changed") {
+					t.Errorf("got %q: %q, want the failed step without changes", d.Summary(), d.Detail())
+				}
+				if len(client.expirationRules) != 0 {
+					t.Errorf("got expiration rules %v, want the policy unchanged", client.expirationRules)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newFakeGroupEligibilityClient()
+			r, empty := testGroupEligibleAssignmentResource(t, client)
+			if tt.directory != nil {
+				r.directory = directory.NewService(tt.directory)
+			}
+			if tt.setup != nil {
+				tt.setup(r, client)
+			}
+
+			model := testGroupEligibleAssignmentModel()
+			if tt.model != nil {
+				tt.model(&model)
+			}
+
+			resp := &fwresource.CreateResponse{State: empty}
+			r.Create(ctx, fwresource.CreateRequest{Plan: testGroupEligibleAssignmentPlan(t, empty, model)}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("got create diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+
+			if resp.Diagnostics.WarningsCount() != tt.wantWarnings {
+				t.Errorf("got %d warnings in %v, want %d", resp.Diagnostics.WarningsCount(), resp.Diagnostics, tt.wantWarnings)
+			}
+
+			var created GroupEligibleAssignmentModel
+			if tt.wantErr {
+				if len(client.requests) != 0 {
+					t.Errorf("got %d requests sent to Graph, want none", len(client.requests))
+				}
+			} else {
+				created = testGroupEligibleAssignmentState(t, resp.State)
+			}
+
+			if tt.check != nil {
+				tt.check(t, client, created, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *GroupEligibleAssignment)
+		model func(m *GroupEligibleAssignmentModel)
+		// change changes the eligibility after it is created, before it is read.
+		change       func(r *GroupEligibleAssignment, client *fakeGroupEligibilityClient)
+		wantErr      bool
+		wantRemoved  bool
+		wantWarnings int
+		check        func(t *testing.T, client *fakeGroupEligibilityClient, read GroupEligibleAssignmentModel)
+	}{
+		{
+			name: "unchanged",
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, read GroupEligibleAssignmentModel) {
+				if read.EligibleAssignmentID.ValueString() != "request-principal-id" {
+					t.Errorf("got eligible_assignment_id %q, want %q", read.EligibleAssignmentID.ValueString(), "request-principal-id")
+				}
+				if read.PolicyExpirationRequired.ValueBool() {
+					t.Errorf("policy_expiration_required = %s, want false", read.PolicyExpirationRequired)
+				}
+			},
+		},
+		{
+			name: "policy drift",
+			change: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				// Somebody requires expiration again in the portal.
+				client.expirationRules["Group_policy"] = true
+			},
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, read GroupEligibleAssignmentModel) {
+				if !read.PolicyExpirationRequired.ValueBool() {
+					t.Errorf("policy_expiration_required = %s, want true", read.PolicyExpirationRequired)
+				}
+			},
+		},
+		{
+			name: "default justification",
+			setup: func(r *GroupEligibleAssignment) {
+				r.defaultJustification = "required by change management"
+			},
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.Justification = types.StringNull()
+			},
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, read GroupEligibleAssignmentModel) {
+				if !read.Justification.IsNull() {
+					t.Errorf("got justification %s, want null so no difference is planned", read.Justification)
+				}
+			},
+		},
+		{
+			name: "deleted group",
+			change: func(r *GroupEligibleAssignment, _ *fakeGroupEligibilityClient) {
+				// The group is deleted and recreated with the same display name.
+				r.directory = directory.NewService(fakeDirectoryClient{"Group": {"new-group-id"}, deletedObjects: {"group-id"}})
+			},
+			wantRemoved:  true,
+			wantWarnings: 1,
+		},
+		{
+			name: "deleted principal",
+			change: func(r *GroupEligibleAssignment, _ *fakeGroupEligibilityClient) {
+				r.directory = directory.NewService(fakeDirectoryClient{deletedObjects: {"principal-id"}})
+			},
+			wantWarnings: 1,
+		},
+		{
+			name: "owner eligibility as well",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.MultipleRequests = types.StringValue(multipleRequestsError)
+			},
+			change: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				addGroupEligibilityRequest(client, "request-owner", graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, time.Now())
+			},
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, read GroupEligibleAssignmentModel) {
+				if read.Role.ValueString() != "member" || read.EligibleAssignmentID.ValueString() != "request-principal-id" {
+					t.Errorf("got role %q and eligible_assignment_id %q, want the member eligibility", read.Role.ValueString(), read.EligibleAssignmentID.ValueString())
+				}
+			},
+		},
+		{
+			name: "multiple requests newest",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.MultipleRequests = types.StringValue(multipleRequestsNewest)
+			},
+			change: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				older := time.Now().Add(-time.Hour)
+				client.requests[0].SetCreatedDateTime(&older)
+				addGroupEligibilityRequest(client, "request-newer", graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, time.Now())
+			},
+			check: func(t *testing.T, _ *fakeGroupEligibilityClient, read GroupEligibleAssignmentModel) {
+				if read.EligibleAssignmentID.ValueString() != "request-newer" {
+					t.Errorf("got eligible_assignment_id %q, want %q", read.EligibleAssignmentID.ValueString(), "request-newer")
+				}
+			},
+		},
+		{
+			name: "indexing lag after create",
+			setup: func(r *GroupEligibleAssignment) {
+				r.indexingRetryDelay = time.Millisecond
+			},
+			change: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.indexingLag = 2
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, read GroupEligibleAssignmentModel) {
+				if client.indexingLag != 0 || read.EligibleAssignmentID.ValueString() != "request-principal-id" {
+					t.Errorf("got eligible_assignment_id %q with %d lagging lists left, want the eligibility read again", read.EligibleAssignmentID.ValueString(), client.indexingLag)
+				}
+			},
+		},
+		{
+			name: "missing after retries",
+			setup: func(r *GroupEligibleAssignment) {
+				r.indexingRetryDelay = time.Millisecond
+			},
+			change: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				client.indexingLag = indexingRetries + 1
+			},
+			wantErr: true,
+		},
+		{
+			name: "multiple requests error",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.MultipleRequests = types.StringValue(multipleRequestsError)
+			},
+			change: func(_ *GroupEligibleAssignment, client *fakeGroupEligibilityClient) {
+				older := time.Now().Add(-time.Hour)
+				client.requests[0].SetCreatedDateTime(&older)
+				addGroupEligibilityRequest(client, "request-newer", graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, time.Now())
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newFakeGroupEligibilityClient()
+			r, empty := testGroupEligibleAssignmentResource(t, client)
+			if tt.setup != nil {
+				tt.setup(r)
+			}
+
+			model := testGroupEligibleAssignmentModel()
+			if tt.model != nil {
+				tt.model(&model)
+			}
+
+			state := testGroupEligibleAssignmentCreate(t, r, empty, model)
+			if tt.change != nil {
+				tt.change(r, client)
+			}
+
+			resp := &fwresource.ReadResponse{State: state}
+			r.Read(ctx, fwresource.ReadRequest{State: state}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("got read diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+
+			if resp.Diagnostics.WarningsCount() != tt.wantWarnings {
+				t.Errorf("got %d warnings in %v, want %d", resp.Diagnostics.WarningsCount(), resp.Diagnostics, tt.wantWarnings)
+			}
+
+			if resp.State.Raw.IsNull() != tt.wantRemoved {
+				t.Fatalf("got resource removed from state %t, want %t", resp.State.Raw.IsNull(), tt.wantRemoved)
+			}
+
+			if tt.check != nil && !tt.wantErr && !tt.wantRemoved {
+				tt.check(t, client, testGroupEligibleAssignmentState(t, resp.State))
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentAutoRenew(t *testing.T) {
+	tests := []struct {
+		name        string
+		expiresIn   time.Duration
+		blocked     bool
+		wantRenewed bool
+	}{
+		{name: "within window", expiresIn: time.Hour, wantRenewed: true},
+		{name: "outside window", expiresIn: 1000 * time.Hour, wantRenewed: false},
+		{name: "mutations blocked", expiresIn: time.Hour, blocked: true, wantRenewed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newFakeGroupEligibilityClient()
+			r, empty := testGroupEligibleAssignmentResource(t, client)
+
+			model := testGroupEligibleAssignmentModel()
+			model.AutoRenew = types.BoolValue(true)
+			model.AutoRenewWindow = types.StringValue("720h")
+			state := testGroupEligibleAssignmentCreate(t, r, empty, model)
+
+			// Make the eligibility expire, as if it was created with an expiration.
+			start := time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)
+			end := time.Now().UTC().Add(tt.expiresIn).Truncate(time.Second)
+			expiration := client.requests[0].GetScheduleInfo().GetExpiration()
+			expiration.SetTypeEscaped(toPtr(graphmodels.AFTERDATETIME_EXPIRATIONPATTERNTYPE))
+			expiration.SetEndDateTime(&end)
+			client.requests[0].GetScheduleInfo().SetStartDateTime(&start)
+
+			r.blockMutations = tt.blocked
+			resp := &fwresource.ReadResponse{State: state}
+			r.Read(ctx, fwresource.ReadRequest{State: state}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+			warned := false
+			for _, d := range resp.Diagnostics.Warnings() {
+				warned = warned || d.Summary() == "Eligibility not renewed"
+			}
+			if warned != tt.blocked {
+				t.Errorf("got diagnostics %v, want a warning about the renewal %t", resp.Diagnostics, tt.blocked)
+			}
+
+			wantEnd := end
+			if tt.wantRenewed {
+				wantEnd = end.Add(720 * time.Hour)
+			}
+
+			if got := testGroupEligibleAssignmentState(t, resp.State).EndDateTime.ValueString(); got != wantEnd.Format(time.RFC3339) {
+				t.Errorf("got end_date_time %q, want %q", got, wantEnd.Format(time.RFC3339))
+			}
+
+			if renewed := len(client.requests) == 2; renewed != tt.wantRenewed {
+				t.Errorf("got renewed %t, want %t", renewed, tt.wantRenewed)
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentAutoRenewTwice(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	r, empty := testGroupEligibleAssignmentResource(t, client)
+
+	model := testGroupEligibleAssignmentModel()
+	model.AutoRenew = types.BoolValue(true)
+	model.AutoRenewWindow = types.StringValue("720h")
+	state := testGroupEligibleAssignmentCreate(t, r, empty, model)
+
+	start := time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)
+	end := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	expiration := client.requests[0].GetScheduleInfo().GetExpiration()
+	expiration.SetTypeEscaped(toPtr(graphmodels.AFTERDATETIME_EXPIRATIONPATTERNTYPE))
+	expiration.SetEndDateTime(&end)
+	client.requests[0].GetScheduleInfo().SetStartDateTime(&start)
+
+	// Every renewal lengthens the schedule by the window, however long the schedule has grown.
+	for n := 1; n <= 2; n++ {
+		resp := &fwresource.ReadResponse{State: state}
+		r.Read(ctx, fwresource.ReadRequest{State: state}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected read diagnostics on renewal %d: %v", n, resp.Diagnostics)
+		}
+		state = resp.State
+
+		if got := len(client.requests); got != n+1 {
+			t.Fatalf("got %d requests after renewal %d, want %d", got, n, n+1)
+		}
+
+		got := testGroupEligibleAssignmentState(t, state)
+		gotStart, _ := time.Parse(time.RFC3339, got.StartDateTime.ValueString())
+		gotEnd, _ := time.Parse(time.RFC3339, got.EndDateTime.ValueString())
+		if want := end.Sub(start) + time.Duration(n)*720*time.Hour; gotEnd.Sub(gotStart) != want {
+			t.Errorf("got schedule of %s after renewal %d, want %s", gotEnd.Sub(gotStart), n, want)
+		}
+
+		// Let the window pass, so the renewed eligibility expires within it again.
+		renewed := client.requests[len(client.requests)-1].GetScheduleInfo()
+		passedStart := renewed.GetStartDateTime().Add(-720 * time.Hour)
+		passedEnd := renewed.GetExpiration().GetEndDateTime().Add(-720 * time.Hour)
+		renewed.SetStartDateTime(&passedStart)
+		renewed.GetExpiration().SetEndDateTime(&passedEnd)
+	}
+}
+
+func TestGroupEligibleAssignmentValidateConfig(t *testing.T) {
+	tests := []struct {
+		name             string
+		autoRenew        types.Bool
+		multipleRequests types.String
+		wantErr          bool
+	}{
+		{name: "auto_renew", autoRenew: types.BoolValue(true), multipleRequests: types.StringNull()},
+		{name: "auto_renew with newest", autoRenew: types.BoolValue(true), multipleRequests: types.StringValue(multipleRequestsNewest)},
+		{name: "auto_renew with error", autoRenew: types.BoolValue(true), multipleRequests: types.StringValue(multipleRequestsError), wantErr: true},
+		{name: "error without auto_renew", autoRenew: types.BoolNull(), multipleRequests: types.StringValue(multipleRequestsError)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, empty := testGroupEligibleAssignmentResource(t, newFakeGroupEligibilityClient())
+
+			model := testGroupEligibleAssignmentModel()
+			model.AutoRenew = tt.autoRenew
+			model.MultipleRequests = tt.multipleRequests
+			plan := testGroupEligibleAssignmentPlan(t, empty, model)
+
+			resp := &fwresource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), fwresource.ValidateConfigRequest{Config: tfsdk.Config{Schema: plan.Schema, Raw: plan.Raw}}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("got diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentUpdate(t *testing.T) {
+	tests := []struct {
+		name string
+		// change changes the eligibility and the prior state after it is created.
+		change  func(client *fakeGroupEligibilityClient, state *GroupEligibleAssignmentModel)
+		planned func(m *GroupEligibleAssignmentModel)
+		check   func(t *testing.T, client *fakeGroupEligibilityClient, updated GroupEligibleAssignmentModel)
+	}{
+		{
+			name: "role",
+			planned: func(m *GroupEligibleAssignmentModel) {
+				m.Role = types.StringValue("owner")
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, updated GroupEligibleAssignmentModel) {
+				// The owner eligibility is created before the member eligibility is removed.
+				if len(client.requests) != 2 || *client.requests[1].GetAccessId() != graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS {
+					t.Fatalf("got requests %v, want a second request for the owner role", client.requests)
+				}
+
+				if client.removal == nil || *client.removal.GetAccessId() != graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS {
+					t.Errorf("removal request does not target the member eligibility")
+				}
+
+				if *client.requests[1].GetStatus() != grouppim.StatusProvisioned {
+					t.Errorf("got owner request status %q, want %q", *client.requests[1].GetStatus(), grouppim.StatusProvisioned)
+				}
+
+				if updated.Role.ValueString() != "owner" || updated.EligibleAssignmentID.ValueString() != *client.requests[1].GetId() {
+					t.Errorf("got role %q and eligible_assignment_id %q, want the owner request", updated.Role.ValueString(), updated.EligibleAssignmentID.ValueString())
+				}
+
+				if updated.Id.ValueString() != "group-id|principal-id|owner" {
+					t.Errorf("got id %q, want %q", updated.Id.ValueString(), "group-id|principal-id|owner")
+				}
+			},
+		},
+		{
+			name: "justification",
+			planned: func(m *GroupEligibleAssignmentModel) {
+				m.Justification = types.StringValue("still needed")
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, updated GroupEligibleAssignmentModel) {
+				if client.removal != nil {
+					t.Errorf("got removal request, want the eligibility updated in place")
+				}
+
+				if len(client.requests) != 2 || *client.requests[1].GetAction() != graphmodels.ADMINUPDATE_SCHEDULEREQUESTACTIONS {
+					t.Fatalf("got requests %v, want an adminUpdate request", client.requests)
+				}
+
+				if updated.Justification.ValueString() != "still needed" || updated.EligibleAssignmentID.ValueString() != *client.requests[1].GetId() {
+					t.Errorf("got justification %q and eligible_assignment_id %q, want the update request", updated.Justification.ValueString(), updated.EligibleAssignmentID.ValueString())
+				}
+			},
+		},
+		{
+			name: "policy drift",
+			change: func(client *fakeGroupEligibilityClient, state *GroupEligibleAssignmentModel) {
+				// Somebody required expiration again in the portal, which was seen by the last refresh.
+				client.expirationRules["Group_policy"] = true
+				state.PolicyExpirationRequired = types.BoolValue(true)
+			},
+			planned: func(m *GroupEligibleAssignmentModel) {
+				m.PolicyExpirationRequired = types.BoolValue(false)
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient, updated GroupEligibleAssignmentModel) {
+				if client.expirationRules["Group_policy"] {
+					t.Errorf("expiration rule not updated to allow no expiration")
+				}
+
+				if updated.PolicyExpirationRequired.ValueBool() {
+					t.Errorf("policy_expiration_required = %s, want false", updated.PolicyExpirationRequired)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newFakeGroupEligibilityClient()
+			r, empty := testGroupEligibleAssignmentResource(t, client)
+
+			state := testGroupEligibleAssignmentCreate(t, r, empty, testGroupEligibleAssignmentModel())
+			prior := testGroupEligibleAssignmentState(t, state)
+			if tt.change != nil {
+				tt.change(client, &prior)
+				if diags := state.Set(ctx, prior); diags.HasError() {
+					t.Fatalf("unable to set state: %v", diags)
+				}
+			}
+
+			planned := prior
+			tt.planned(&planned)
+
+			resp := &fwresource.UpdateResponse{State: state}
+			r.Update(ctx, fwresource.UpdateRequest{State: state, Plan: testGroupEligibleAssignmentPlan(t, empty, planned)}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected update diagnostics: %v", resp.Diagnostics)
+			}
+
+			tt.check(t, client, testGroupEligibleAssignmentState(t, resp.State))
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentJustificationWriteOnly(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	r, empty := testGroupEligibleAssignmentResource(t, client)
+
+	// Write-only values are only in the configuration, never in the plan.
+	testConfig := func(justificationWO string, version int64) tfsdk.Config {
+		model := testGroupEligibleAssignmentModel()
+		model.Justification = types.StringNull()
+		model.JustificationWO = types.StringValue(justificationWO)
+		model.JustificationWOVersion = types.Int64Value(version)
+		plan := testGroupEligibleAssignmentPlan(t, empty, model)
+		return tfsdk.Config{Schema: plan.Schema, Raw: plan.Raw}
+	}
+
+	model := testGroupEligibleAssignmentModel()
+	model.Justification = types.StringNull()
+	model.JustificationWOVersion = types.Int64Value(1)
+
+	createResp := &fwresource.CreateResponse{State: empty}
+	r.Create(ctx, fwresource.CreateRequest{Config: testConfig("INC-1", 1), Plan: testGroupEligibleAssignmentPlan(t, empty, model)}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected create diagnostics: %v", createResp.Diagnostics)
+	}
+
+	if got := *client.requests[0].GetJustification(); got != "INC-1" {
+		t.Errorf("got justification %q, want %q", got, "INC-1")
+	}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", readResp.Diagnostics)
+	}
+
+	state := testGroupEligibleAssignmentState(t, readResp.State)
+	if !state.Justification.IsNull() || !state.JustificationWO.IsNull() {
+		t.Errorf("got justification %s and justification_wo %s in state, want null", state.Justification, state.JustificationWO)
+	}
+
+	planned := state
+	planned.JustificationWOVersion = types.Int64Value(2)
+
+	updateResp := &fwresource.UpdateResponse{State: readResp.State}
+	r.Update(ctx, fwresource.UpdateRequest{Config: testConfig("INC-2", 2), State: readResp.State, Plan: testGroupEligibleAssignmentPlan(t, empty, planned)}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected update diagnostics: %v", updateResp.Diagnostics)
+	}
+
+	if len(client.requests) != 2 || *client.requests[1].GetJustification() != "INC-2" {
+		t.Fatalf("got requests %v, want an update with the new write-only justification", client.requests)
+	}
+
+	if updated := testGroupEligibleAssignmentState(t, updateResp.State); !updated.Justification.IsNull() || updated.JustificationWOVersion.ValueInt64() != 2 {
+		t.Errorf("got justification %s and justification_wo_version %s, want null and 2", updated.Justification, updated.JustificationWOVersion)
+	}
+}
+
+func TestUnknownIDOnRoleChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		wantUnknown bool
+	}{
+		{name: "same role", role: "member"},
+		{name: "changed role", role: "owner", wantUnknown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newFakeGroupEligibilityClient()
+			r, empty := testGroupEligibleAssignmentResource(t, client)
+			state := testGroupEligibleAssignmentCreate(t, r, empty, testGroupEligibleAssignmentModel())
+
+			planned := testGroupEligibleAssignmentState(t, state)
+			planned.Role = types.StringValue(tt.role)
+			plan := testGroupEligibleAssignmentPlan(t, empty, planned)
+
+			req := planmodifier.StringRequest{
+				Path:       path.Root("id"),
+				Plan:       plan,
+				PlanValue:  planned.Id,
+				State:      state,
+				StateValue: planned.Id,
+			}
+			resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+			unknownIDOnRoleChange{}.PlanModifyString(ctx, req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			if resp.PlanValue.IsUnknown() != tt.wantUnknown {
+				t.Errorf("got planned id %s, want unknown %t", resp.PlanValue, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentModifyPlanAliases(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    func(m *GroupEligibleAssignmentModel)
+		wantScope string
+		wantRole  string
+	}{
+		{
+			name:      "scope and role",
+			config:    func(m *GroupEligibleAssignmentModel) {},
+			wantScope: "group-id",
+			wantRole:  "member",
+		},
+		{
+			name: "group_id and access_id",
+			config: func(m *GroupEligibleAssignmentModel) {
+				m.Scope, m.GroupID = customtypes.NewGUIDNull(), customtypes.NewGUIDValue("other-group-id")
+				m.Role, m.AccessID = types.StringNull(), types.StringValue("owner")
+			},
+			wantScope: "other-group-id",
+			wantRole:  "owner",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, empty := testGroupEligibleAssignmentResource(t, newFakeGroupEligibilityClient())
+
+			config := testGroupEligibleAssignmentModel()
+			config.AccessID, config.GroupID = types.StringNull(), customtypes.NewGUIDNull()
+			tt.config(&config)
+			planned := config
+			if planned.Scope.IsNull() {
+				planned.Scope = customtypes.NewGUIDUnknown()
+			} else {
+				planned.GroupID = customtypes.NewGUIDUnknown()
+			}
+			if planned.Role.IsNull() {
+				planned.Role = types.StringUnknown()
+			} else {
+				planned.AccessID = types.StringUnknown()
+			}
+
+			c := testGroupEligibleAssignmentPlan(t, empty, config)
+			plan := testGroupEligibleAssignmentPlan(t, empty, planned)
+			resp := &fwresource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(context.Background(), fwresource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: c.Schema, Raw: c.Raw},
+				Plan:   plan,
+				State:  empty,
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var got GroupEligibleAssignmentModel
+			if diags := resp.Plan.Get(context.Background(), &got); diags.HasError() {
+				t.Fatalf("unable to get plan: %v", diags)
+			}
+
+			if got.Scope.ValueString() != tt.wantScope || got.GroupID.ValueString() != tt.wantScope {
+				t.Errorf("got scope %s and group_id %s, want both %q", got.Scope, got.GroupID, tt.wantScope)
+			}
+			if got.Role.ValueString() != tt.wantRole || got.AccessID.ValueString() != tt.wantRole {
+				t.Errorf("got role %s and access_id %s, want both %q", got.Role, got.AccessID, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentModifyPlanPolicyDrift(t *testing.T) {
+	tests := []struct {
+		name         string
+		strictPolicy bool
+		want         bool
+	}{
+		{name: "planned back", want: false},
+		// The policy must not be changed, so planning it back would be a perpetual diff.
+		{name: "strict policy", strictPolicy: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, empty := testGroupEligibleAssignmentResource(t, newFakeGroupEligibilityClient())
+			r.strictPolicy = tt.strictPolicy
+
+			state := testGroupEligibleAssignmentCreate(t, r, empty, testGroupEligibleAssignmentModel())
+			// The last refresh saw the policy requiring expiration again, and the plan keeps the state.
+			if diags := state.SetAttribute(ctx, path.Root("policy_expiration_required"), types.BoolValue(true)); diags.HasError() {
+				t.Fatalf("unable to set state: %v", diags)
+			}
+			plan := tfsdk.Plan{Schema: state.Schema, Raw: state.Raw}
+
+			config := testGroupEligibleAssignmentModel()
+			config.AccessID, config.GroupID = types.StringNull(), customtypes.NewGUIDNull()
+			c := testGroupEligibleAssignmentPlan(t, empty, config)
+
+			resp := &fwresource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: c.Schema, Raw: c.Raw},
+				Plan:   plan,
+				State:  state,
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var got types.Bool
+			if diags := resp.Plan.GetAttribute(ctx, path.Root("policy_expiration_required"), &got); diags.HasError() {
+				t.Fatalf("unable to get plan: %v", diags)
+			}
+			if got.ValueBool() != tt.want {
+				t.Errorf("planned policy_expiration_required %s, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentModifyPlanDynamicGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		directory directory.Client
+		state     bool
+		// unknownScope plans a group which comes from a resource not created yet, and deferredReason an unknown provider
+		// configuration.
+		unknownScope   bool
+		deferredReason string
+		wantErr        bool
+		wantWarning    bool
+	}{
+		{name: "assigned membership", directory: fakeDirectoryClient{tenantGroups: {"group-id"}}},
+		{name: "dynamic membership", directory: fakeDirectoryClient{dynamicGroups: {"group-id"}}, wantErr: true},
+		// Existing eligibilities are not checked again, e.g. when the membership of their group became dynamic.
+		{name: "dynamic membership in state", directory: fakeDirectoryClient{dynamicGroups: {"group-id"}}, state: true},
+		// Groups which are only known at apply are checked on create.
+		{name: "unknown scope", directory: fakeDirectoryClient{dynamicGroups: {"group-id"}}, unknownScope: true},
+		{name: "provider configuration unknown", directory: fakeDirectoryClient{dynamicGroups: {"group-id"}}, deferredReason: "The provider attribute tenant_id is not known until apply"},
+		{name: "lookup failure", directory: failingDirectoryClient{fakeDirectoryClient{}, errors.New("forbidden")}, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, empty := testGroupEligibleAssignmentResource(t, newFakeGroupEligibilityClient())
+
+			state := empty
+			if tt.state {
+				state = testGroupEligibleAssignmentCreate(t, r, empty, testGroupEligibleAssignmentModel())
+			}
+			r.directory = directory.NewService(tt.directory)
+			r.deferredReason = tt.deferredReason
+
+			config := testGroupEligibleAssignmentModel()
+			config.AccessID, config.GroupID = types.StringNull(), customtypes.NewGUIDNull()
+			planned := testGroupEligibleAssignmentModel()
+			if tt.unknownScope {
+				config.Scope = customtypes.NewGUIDUnknown()
+				planned.Scope = customtypes.NewGUIDUnknown()
+			}
+			c := testGroupEligibleAssignmentPlan(t, empty, config)
+			plan := testGroupEligibleAssignmentPlan(t, empty, planned)
+
+			resp := &fwresource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(context.Background(), fwresource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: c.Schema, Raw: c.Raw},
+				Plan:   plan,
+				State:  state,
+			}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("got diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+			if got := resp.Diagnostics.WarningsCount() > 0; got != tt.wantWarning {
+				t.Errorf("got diagnostics %v, want warning %t", resp.Diagnostics, tt.wantWarning)
+			}
+
+			if tt.unknownScope {
+				var groupID customtypes.GUID
+				resp.Diagnostics.Append(resp.Plan.GetAttribute(context.Background(), path.Root("group_id"), &groupID)...)
+				if !groupID.IsUnknown() {
+					t.Errorf("got planned group_id %s, want it unknown like scope", groupID)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentModifyPlanUnknown(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(m *GroupEligibleAssignmentModel)
+		// wantGroupID and wantAccessID are the planned aliases, empty when unknown.
+		wantGroupID  string
+		wantAccessID string
+		wantErr      bool
+		// wantWarning is set when the group is known, and its lookup fails.
+		wantWarning bool
+	}{
+		{
+			name: "unknown scope, principal and justification",
+			config: func(m *GroupEligibleAssignmentModel) {
+				m.Scope = customtypes.NewGUIDUnknown()
+				m.PrincipalID = customtypes.NewGUIDUnknown()
+				m.Justification = types.StringUnknown()
+			},
+			wantAccessID: "member",
+		},
+		{
+			name: "unknown role",
+			config: func(m *GroupEligibleAssignmentModel) {
+				m.Role = types.StringUnknown()
+				m.Justification = types.StringUnknown()
+			},
+			wantGroupID: "group-id",
+			wantWarning: true,
+		},
+		// The justification rules apply once the justification is known.
+		{
+			name:         "known justification",
+			config:       func(m *GroupEligibleAssignmentModel) {},
+			wantGroupID:  "group-id",
+			wantAccessID: "member",
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, empty := testGroupEligibleAssignmentResource(t, newFakeGroupEligibilityClient())
+			r.justificationRules = justificationRules{minLength: 100}
+			// Any lookup of the group would add a warning.
+			r.directory = directory.NewService(failingDirectoryClient{fakeDirectoryClient{}, errors.New("forbidden")})
+
+			config := testGroupEligibleAssignmentModel()
+			config.AccessID, config.GroupID = types.StringNull(), customtypes.NewGUIDNull()
+			tt.config(&config)
+			planned := config
+			planned.AccessID, planned.GroupID = types.StringUnknown(), customtypes.NewGUIDUnknown()
+
+			c := testGroupEligibleAssignmentPlan(t, empty, config)
+			plan := testGroupEligibleAssignmentPlan(t, empty, planned)
+			resp := &fwresource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(context.Background(), fwresource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: c.Schema, Raw: c.Raw},
+				Plan:   plan,
+				State:  empty,
+			}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("got diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := resp.Diagnostics.WarningsCount() > 0; got != tt.wantWarning {
+				t.Errorf("got diagnostics %v, want warning %t", resp.Diagnostics, tt.wantWarning)
+			}
+
+			var got GroupEligibleAssignmentModel
+			if diags := resp.Plan.Get(context.Background(), &got); diags.HasError() {
+				t.Fatalf("unable to get plan: %v", diags)
+			}
+
+			if got.GroupID.IsUnknown() != (tt.wantGroupID == "") || got.GroupID.ValueString() != tt.wantGroupID {
+				t.Errorf("got planned group_id %s, want %q", got.GroupID, tt.wantGroupID)
+			}
+			if got.AccessID.IsUnknown() != (tt.wantAccessID == "") || got.AccessID.ValueString() != tt.wantAccessID {
+				t.Errorf("got planned access_id %s, want %q", got.AccessID, tt.wantAccessID)
+			}
+			if !got.PrincipalID.Equal(config.PrincipalID) || !got.Justification.Equal(config.Justification) {
+				t.Errorf("got planned principal_id %s and justification %s, want them as configured", got.PrincipalID, got.Justification)
+			}
+		})
+	}
+}
+
+// failingDirectoryClient fails looking up directory objects with err.
+type failingDirectoryClient struct {
+	fakeDirectoryClient
+	err error
+}
+
+func (f failingDirectoryClient) GetDirectoryObject(ctx context.Context, id string) (graphmodels.DirectoryObjectable, error) {
+	return nil, f.err
+}
+
+func TestGroupEligibleAssignmentUpgradeStateV0(t *testing.T) {
+	r, _ := testGroupEligibleAssignmentResource(t, newFakeGroupEligibilityClient())
+
+	upgrader, ok := r.UpgradeState(context.Background())[0]
+	if !ok {
+		t.Fatal("got no state upgrader of version 0")
+	}
+
+	raw := `{"id":"group-id|principal-id","role":"owner","scope":"group-id","principal_id":"principal-id"}`
+	resp := &fwresource.UpgradeStateResponse{}
+	upgrader.StateUpgrader(context.Background(), fwresource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: []byte(raw)}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(resp.DynamicValue.JSON, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["group_id"] != "group-id" || got["access_id"] != "owner" {
+		t.Errorf("got group_id %v and access_id %v, want the values of scope and role", got["group_id"], got["access_id"])
+	}
+	if got["id"] != "group-id|principal-id" {
+		t.Errorf("got id %v, want it unchanged", got["id"])
+	}
+}
+
+func TestGroupEligibleAssignmentDelete(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *GroupEligibleAssignment, client *fakeGroupEligibilityClient)
+		model func(m *GroupEligibleAssignmentModel)
+		// change changes the eligibility after it is created, before it is deleted.
+		change  func(t *testing.T, client *fakeGroupEligibilityClient, state *tfsdk.State)
+		wantErr bool
+		check   func(t *testing.T, client *fakeGroupEligibilityClient)
+	}{
+		{
+			name: onDestroyRemove,
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				testGroupEligibleAssignmentDeleted(t, client, "Revoked", true)
+			},
+		},
+		{
+			name: onDestroyCancel,
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.OnDestroy = types.StringValue(onDestroyCancel)
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				testGroupEligibleAssignmentDeleted(t, client, "Canceled", true)
+			},
+		},
+		{
+			name: onDestroyAbandon,
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.OnDestroy = types.StringValue(onDestroyAbandon)
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				testGroupEligibleAssignmentDeleted(t, client, "Provisioned", false)
+			},
+		},
+		{
+			name: "activated",
+			change: func(_ *testing.T, client *fakeGroupEligibilityClient, _ *tfsdk.State) {
+				client.activate("group-id", "principal-id", "request-principal-id")
+			},
+			wantErr: true,
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				testGroupEligibleAssignmentDeleted(t, client, "Provisioned", false)
+				if len(client.activations) != 1 {
+					t.Errorf("got %d activations after delete, want 1", len(client.activations))
+				}
+			},
+		},
+		{
+			name: "activated with force_destroy",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.ForceDestroy = types.BoolValue(true)
+			},
+			change: func(_ *testing.T, client *fakeGroupEligibilityClient, _ *tfsdk.State) {
+				client.activate("group-id", "principal-id", "request-principal-id")
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				testGroupEligibleAssignmentDeleted(t, client, "Revoked", true)
+				if len(client.activations) != 0 {
+					t.Errorf("got %d activations after delete, want none", len(client.activations))
+				}
+			},
+		},
+		{
+			name: "activated through other eligibility",
+			change: func(_ *testing.T, client *fakeGroupEligibilityClient, _ *tfsdk.State) {
+				client.activate("group-id", "principal-id", "other-instance-id")
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				if len(client.activations) != 1 {
+					t.Errorf("got %d activations after delete, want the activation through the other eligibility to remain", len(client.activations))
+				}
+			},
+		},
+		{
+			name: "pending approval",
+			change: func(_ *testing.T, client *fakeGroupEligibilityClient, _ *tfsdk.State) {
+				client.requests[0].SetStatus(toPtr(grouppim.StatusPendingApproval))
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				if status := *client.requests[0].GetStatus(); status != "Canceled" {
+					t.Errorf("got status %q after delete, want %q", status, "Canceled")
+				}
+				if client.removal != nil {
+					t.Errorf("got removal request for a request pending approval")
+				}
+			},
+		},
+		{
+			name: "expired request",
+			change: func(t *testing.T, _ *fakeGroupEligibilityClient, state *tfsdk.State) {
+				// The request in state no longer exists, e.g. after import or once Graph purged it.
+				if diags := state.SetAttribute(context.Background(), path.Root("eligible_assignment_id"), "expired-request"); diags.HasError() {
+					t.Fatalf("unable to set state: %v", diags)
+				}
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				if client.removal == nil || conversions.String(client.removal.GetTargetScheduleId()) != "schedule-request-principal-id" {
+					t.Errorf("removal request does not target the current eligibility schedule")
+				}
+			},
+		},
+		{
+			name: "destroy justification",
+			model: func(m *GroupEligibleAssignmentModel) {
+				m.DestroyJustification = types.StringValue("no longer needed")
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				if client.removal == nil || *client.removal.GetJustification() != "no longer needed" {
+					t.Errorf("removal request does not have the destroy justification")
+				}
+			},
+		},
+		{
+			name: "ticket info",
+			setup: func(r *GroupEligibleAssignment, _ *fakeGroupEligibilityClient) {
+				r.service.SetTicketInfo(grouppim.TicketInfo{System: "ServiceNow", Number: "CHG0001234"})
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				for name, request := range map[string]graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable{"create": client.requests[0], "remove": client.removal} {
+					ticket := request.GetTicketInfo()
+					if ticket == nil || conversions.String(ticket.GetTicketSystem()) != "ServiceNow" || conversions.String(ticket.GetTicketNumber()) != "CHG0001234" {
+						t.Errorf("%s request does not reference the ticket", name)
+					}
+				}
+			},
+		},
+		{
+			name: "strict policy",
+			setup: func(r *GroupEligibleAssignment, _ *fakeGroupEligibilityClient) {
+				r.service.SetStrictPolicy(true)
+			},
+			check: func(t *testing.T, client *fakeGroupEligibilityClient) {
+				if client.expirationRules["Group_policy"] {
+					t.Errorf("expiration rule was changed in strict policy mode")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newFakeGroupEligibilityClient()
+			r, empty := testGroupEligibleAssignmentResource(t, client)
+			if tt.setup != nil {
+				tt.setup(r, client)
+			}
+
+			model := testGroupEligibleAssignmentModel()
+			if tt.model != nil {
+				tt.model(&model)
+			}
+
+			state := testGroupEligibleAssignmentCreate(t, r, empty, model)
+			if tt.change != nil {
+				tt.change(t, client, &state)
+			}
+
+			resp := &fwresource.DeleteResponse{State: state}
+			r.Delete(ctx, fwresource.DeleteRequest{State: state}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("got delete diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+
+			tt.check(t, client)
+		})
+	}
+}
+
+// testGroupEligibleAssignmentDeleted checks the status of the created request and the expiration rule after a delete.
+func testGroupEligibleAssignmentDeleted(t *testing.T, client *fakeGroupEligibilityClient, wantStatus string, wantRequireExpiring bool) {
+	t.Helper()
+
+	if status := *client.requests[0].GetStatus(); status != wantStatus {
+		t.Errorf("got status %q after delete, want %q", status, wantStatus)
+	}
+
+	if required := client.expirationRules["Group_policy"]; required != wantRequireExpiring {
+		t.Errorf("got expiration required %t after delete, want %t", required, wantRequireExpiring)
+	}
+}
+
+// TestGroupEligibleAssignmentAgainstFakeGraph runs the lifecycle of an eligibility through the Graph client, against
+// the HTTP fake of the Graph endpoints instead of the in-memory client fakes.
+func TestGroupEligibleAssignmentAgainstFakeGraph(t *testing.T) {
+	ctx := context.Background()
+	server := fakegraph.NewServer(t)
+	client := testGraphClient(t, server.Server, 0)
+	policyID := fakegraph.PolicyID("group-id", "member")
+
+	r, empty := testGroupEligibleAssignmentResource(t, client)
+	r.directory = directory.NewService(client)
+
+	state := testGroupEligibleAssignmentCreate(t, r, empty, testGroupEligibleAssignmentModel())
+	requests := server.EligibilityScheduleRequests()
+	if len(requests) != 1 || requests[0]["action"] != "adminAssign" || requests[0]["status"] != grouppim.StatusProvisioned {
+		t.Fatalf("got requests %v, want a provisioned adminAssign request", requests)
+	}
+	if rule := server.PolicyRule(policyID, "Expiration_Admin_Eligibility"); rule == nil || rule["isExpirationRequired"] != false {
+		t.Errorf("got expiration rule %v, want eligible assignments allowed without expiration", rule)
+	}
+
+	created := testGroupEligibleAssignmentState(t, state)
+	if created.EligibleAssignmentID.ValueString() != requests[0]["id"] || created.PolicyID.ValueString() != policyID {
+		t.Errorf("got eligible_assignment_id %s and policy_id %s, want %v and %q", created.EligibleAssignmentID, created.PolicyID, requests[0]["id"], policyID)
+	}
+
+	readResp := &fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state}, readResp)
+	if readResp.Diagnostics.HasError() || readResp.State.Raw.IsNull() {
+		t.Fatalf("got read diagnostics %v, want the eligibility to be read", readResp.Diagnostics)
+	}
+	if read := testGroupEligibleAssignmentState(t, readResp.State); read.PolicyExpirationRequired.ValueBool() || read.MemberType.ValueString() != "direct" {
+		t.Errorf("got policy_expiration_required %s and member_type %s, want false and direct", read.PolicyExpirationRequired, read.MemberType)
+	}
+
+	planned := testGroupEligibleAssignmentState(t, readResp.State)
+	planned.Justification = types.StringValue("still needed")
+	updateResp := &fwresource.UpdateResponse{State: readResp.State}
+	r.Update(ctx, fwresource.UpdateRequest{State: readResp.State, Plan: testGroupEligibleAssignmentPlan(t, empty, planned)}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected update diagnostics: %v", updateResp.Diagnostics)
+	}
+	requests = server.EligibilityScheduleRequests()
+	if len(requests) != 2 || requests[1]["action"] != "adminUpdate" || requests[1]["justification"] != "still needed" {
+		t.Fatalf("got requests %v, want an adminUpdate request with the new justification", requests)
+	}
+
+	deleteResp := &fwresource.DeleteResponse{State: updateResp.State}
+	r.Delete(ctx, fwresource.DeleteRequest{State: updateResp.State}, deleteResp)
+	if deleteResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected delete diagnostics: %v", deleteResp.Diagnostics)
+	}
+	requests = server.EligibilityScheduleRequests()
+	if last := requests[len(requests)-1]; last["action"] != "adminRemove" {
+		t.Errorf("got last request %v, want an adminRemove request", last)
+	}
+	for _, request := range requests {
+		if request["status"] == grouppim.StatusProvisioned {
+			t.Errorf("got request %v still provisioned after delete", request)
+		}
+	}
+	if rule := server.PolicyRule(policyID, "Expiration_Admin_Eligibility"); rule["isExpirationRequired"] != true {
+		t.Errorf("got expiration rule %v, want expiration required again after delete", rule)
+	}
+}
+
+func TestGroupEligibleAssignmentImportState(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		// owner makes the principal owner-eligible in the group as well.
+		owner                    bool
+		wantErr                  bool
+		wantID                   string
+		wantRole                 string
+		wantEligibleAssignmentID string
+	}{
+		{name: "composite", id: "group-id|principal-id", wantID: "group-id|principal-id", wantRole: "member", wantEligibleAssignmentID: "request-principal-id"},
+		{name: "composite with role", id: "group-id|principal-id|member", wantID: "group-id|principal-id", wantRole: "member", wantEligibleAssignmentID: "request-principal-id"},
+		{name: "request", id: "request-principal-id", wantID: "group-id|principal-id", wantRole: "member", wantEligibleAssignmentID: "request-principal-id"},
+		{name: "instance", id: "instance-request-principal-id", wantID: "group-id|principal-id", wantRole: "member", wantEligibleAssignmentID: "request-principal-id"},
+		{name: "unknown", id: "unknown-id", wantErr: true},
+		{name: "composite with both roles", id: "group-id|principal-id", owner: true, wantErr: true},
+		{name: "composite with owner role", id: "group-id|principal-id|owner", owner: true, wantID: "group-id|principal-id|owner", wantRole: "owner", wantEligibleAssignmentID: "request-owner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newFakeGroupEligibilityClient()
+			r, empty := testGroupEligibleAssignmentResource(t, client)
+			testGroupEligibleAssignmentCreate(t, r, empty, testGroupEligibleAssignmentModel())
+			if tt.owner {
+				addGroupEligibilityRequest(client, "request-owner", graphmodels.OWNER_PRIVILEGEDACCESSGROUPRELATIONSHIPS, time.Now())
+			}
+
+			resp := &fwresource.ImportStateResponse{State: empty}
+			r.ImportState(ctx, fwresource.ImportStateRequest{ID: tt.id}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("got diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			imported := testGroupEligibleAssignmentState(t, resp.State)
+			if imported.Id.ValueString() != tt.wantID {
+				t.Errorf("got id %q, want %q", imported.Id.ValueString(), tt.wantID)
+			}
+
+			if imported.Role.ValueString() != tt.wantRole || imported.EligibleAssignmentID.ValueString() != tt.wantEligibleAssignmentID {
+				t.Errorf("got role %q and eligible_assignment_id %q, want %q and %q", imported.Role.ValueString(), imported.EligibleAssignmentID.ValueString(), tt.wantRole, tt.wantEligibleAssignmentID)
+			}
+
+			if imported.PolicyID.ValueString() == "" || imported.OnDestroy.ValueString() != onDestroyRemove {
+				t.Errorf("got policy_id %q and on_destroy %q, want them set on import", imported.PolicyID.ValueString(), imported.OnDestroy.ValueString())
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentPolicyCache(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+
+	cache := grouppim.NewPolicyCache()
+	services := []*grouppim.Service{grouppim.NewService(client), grouppim.NewService(client)}
+	for _, s := range services {
+		s.SetPolicyCache(cache)
+	}
+
+	// The resources of a plan look up the policy of the same group concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(s *grouppim.Service) {
+			defer wg.Done()
+
+			if _, err := s.ExpirationRequired(ctx, "group-id"); err != nil {
+				t.Error(err)
+			}
+		}(services[i%len(services)])
+	}
+	wg.Wait()
+
+	if got := client.policyQueries.Load(); got != 1 {
+		t.Errorf("got %d policy queries, want 1", got)
+	}
+
+	// The policy is looked up again after it was changed.
+	if err := services[0].AllowNoExpiration(ctx, "group-id"); err != nil {
+		t.Fatal(err)
+	}
+	required, err := services[1].ExpirationRequired(ctx, "GROUP-ID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if required {
+		t.Error("got expiration required, want the updated policy")
+	}
+	if got := client.policyQueries.Load(); got != 2 {
+		t.Errorf("got %d policy queries, want 2", got)
+	}
+
+	// Groups are cached separately.
+	if _, err := services[0].EligibleExpirationPolicyID(ctx, "other-group-id"); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.policyQueries.Load(); got != 3 {
+		t.Errorf("got %d policy queries, want 3", got)
+	}
 }
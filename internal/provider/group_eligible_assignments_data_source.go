@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &GroupEligibleAssignments{}
+
+func NewGroupEligibleAssignments() datasource.DataSource {
+	return &GroupEligibleAssignments{}
+}
+
+// GroupEligibleAssignments defines the data source implementation.
+type GroupEligibleAssignments struct {
+	service   *grouppim.Service
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// GroupEligibleAssignmentsModel describes the data source data model.
+type GroupEligibleAssignmentsModel struct {
+	PrincipalID customtypes.GUID                    `tfsdk:"principal_id"`
+	Role        types.String                        `tfsdk:"role"`
+	Assignments []GroupEligibleAssignmentsItemModel `tfsdk:"assignments"`
+}
+
+// GroupEligibleAssignmentsItemModel describes an eligible assignment listed by the data source.
+type GroupEligibleAssignmentsItemModel struct {
+	Scope            customtypes.GUID    `tfsdk:"scope"`
+	PrincipalID      customtypes.GUID    `tfsdk:"principal_id"`
+	Role             types.String        `tfsdk:"role"`
+	MemberType       types.String        `tfsdk:"member_type"`
+	StartDateTime    customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime      customtypes.RFC3339 `tfsdk:"end_date_time"`
+	TargetScheduleID types.String        `tfsdk:"target_schedule_id"`
+	InstanceID       types.String        `tfsdk:"instance_id"`
+}
+
+func (d *GroupEligibleAssignments) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_eligible_assignments"
+}
+
+func (d *GroupEligibleAssignments) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists the eligible assignments of PIM enabled groups in the tenant, e.g. for compliance exports.
+
+Graph can only list eligible assignments by group or principal. Without ` + "`principal_id`" + `, every security group in the
+tenant is queried, which takes a while in large tenants.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.Read.AzureADGroup
+- Group.Read.All
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "Only list the eligible assignments of this principal.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "Only list eligible assignments for this role.",
+				Optional:            true,
+				Validators:          []validator.String{stringvalidator.OneOf("owner", "member")},
+			},
+			"assignments": schema.ListNestedAttribute{
+				MarkdownDescription: "The eligible assignments, ordered by group and principal.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The group of the eligible assignment.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"principal_id": schema.StringAttribute{
+							MarkdownDescription: "The principal which is eligible.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "The role the principal can assume.",
+							Computed:            true,
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "Whether the principal is eligible directly (`direct`) or through a group (`group`).",
+							Computed:            true,
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"target_schedule_id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the eligibility schedule.",
+							Computed:            true,
+						},
+						"instance_id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the eligibility schedule instance.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *GroupEligibleAssignments) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = grouppim.NewService(pd.clients.GroupPIM)
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.directory.SetSecurityGroupCache(pd.securityGroups)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *GroupEligibleAssignments) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of eligible assignments") }()
+
+	var data GroupEligibleAssignmentsModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var assignments []grouppim.EligibleAssignment
+	if principalID := data.PrincipalID.ValueString(); principalID != "" {
+		var err error
+		assignments, err = d.service.ListEligibleAssignments(ctx, "", principalID)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list eligible assignments: "+sanitizeError(err))
+			return
+		}
+	} else {
+		groupIDs, err := d.directory.SecurityGroupIDs(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list groups: "+sanitizeError(err))
+			return
+		}
+
+		tflog.Debug(ctx, "listing eligible assignments of all security groups", map[string]any{"groups": len(groupIDs)})
+
+		byGroup, err := fetchAll(ctx, groupIDs, maxConcurrentFetches, func(ctx context.Context, groupID string) ([]grouppim.EligibleAssignment, error) {
+			return d.service.ListEligibleAssignments(ctx, groupID, "")
+		})
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list eligible assignments: "+sanitizeError(err))
+			return
+		}
+		for _, groupAssignments := range byGroup {
+			assignments = append(assignments, groupAssignments...)
+		}
+	}
+
+	sort.SliceStable(assignments, func(i, j int) bool {
+		if assignments[i].GroupID != assignments[j].GroupID {
+			return assignments[i].GroupID < assignments[j].GroupID
+		}
+		return assignments[i].PrincipalID < assignments[j].PrincipalID
+	})
+
+	data.Assignments = []GroupEligibleAssignmentsItemModel{}
+	for _, a := range assignments {
+		if role := data.Role.ValueString(); role != "" && a.Role != role {
+			continue
+		}
+
+		data.Assignments = append(data.Assignments, GroupEligibleAssignmentsItemModel{
+			Scope:            customtypes.NewGUIDValue(a.GroupID),
+			PrincipalID:      customtypes.NewGUIDValue(a.PrincipalID),
+			Role:             types.StringValue(a.Role),
+			MemberType:       types.StringValue(a.MemberType),
+			StartDateTime:    customtypes.NewRFC3339Value(a.StartDateTime),
+			EndDateTime:      customtypes.NewRFC3339Value(a.EndDateTime),
+			TargetScheduleID: types.StringValue(a.TargetScheduleID),
+			InstanceID:       types.StringValue(a.InstanceID),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
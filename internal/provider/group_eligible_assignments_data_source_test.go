@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+const (
+	// groupDeltaQueries records the delta links of the delta queries of the groups of the tenant.
+	groupDeltaQueries = "groupDeltaQueries"
+	// groupDeltaExpired makes all delta links of groups expire when set.
+	groupDeltaExpired = "groupDeltaExpired"
+)
+
+// ListGroupChanges returns delta links listing the groups under the tenantGroups key as of the query, all of them
+// security groups, and lists the changes since the groups of deltaLink.
+func (f fakeDirectoryClient) ListGroupChanges(ctx context.Context, deltaLink string) ([]directory.GroupChange, string, error) {
+	f[groupDeltaQueries] = append(f[groupDeltaQueries], deltaLink)
+
+	var previous []string
+	if deltaLink != "" {
+		ids, ok := strings.CutPrefix(deltaLink, "groups:")
+		if !ok || len(f[groupDeltaExpired]) > 0 {
+			return nil, "", directory.ErrDeltaExpired
+		}
+		previous = strings.Split(ids, ",")
+	}
+
+	securityEnabled := true
+	var changes []directory.GroupChange
+	for _, id := range f[tenantGroups] {
+		if !slices.Contains(previous, id) {
+			changes = append(changes, directory.GroupChange{ID: id, SecurityEnabled: &securityEnabled})
+		}
+	}
+	for _, id := range previous {
+		if !slices.Contains(f[tenantGroups], id) {
+			changes = append(changes, directory.GroupChange{ID: id, Removed: true})
+		}
+	}
+
+	return changes, "groups:" + strings.Join(f[tenantGroups], ","), nil
+}
+
+func TestGroupEligibleAssignmentsRead(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	service := grouppim.NewService(client)
+
+	for _, a := range []grouppim.EligibleAssignment{
+		{GroupID: "group-b", PrincipalID: "principal-1", Role: "member"},
+		{GroupID: "group-a", PrincipalID: "principal-2", Role: "owner"},
+		{GroupID: "group-a", PrincipalID: "principal-1", Role: "member"},
+	} {
+		if _, err := service.CreateEligibleAssignment(ctx, a); err != nil {
+			t.Fatalf("unable to create eligible assignment: %v", err)
+		}
+	}
+
+	d := &GroupEligibleAssignments{
+		service:   service,
+		directory: directory.NewService(fakeDirectoryClient{tenantGroups: {"group-a", "group-b", "group-c"}}),
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	tests := []struct {
+		name        string
+		principalID customtypes.GUID
+		role        types.String
+		want        []string
+	}{
+		{name: "tenant", principalID: customtypes.NewGUIDNull(), role: types.StringNull(), want: []string{"group-a|principal-1", "group-a|principal-2", "group-b|principal-1"}},
+		{name: "principal", principalID: customtypes.NewGUIDValue("principal-1"), role: types.StringNull(), want: []string{"group-a|principal-1", "group-b|principal-1"}},
+		{name: "role", principalID: customtypes.NewGUIDNull(), role: types.StringValue("owner"), want: []string{"group-a|principal-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tfsdk.Config{Schema: schemaResp.Schema}
+			state := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+
+			configState := tfsdk.State{Schema: schemaResp.Schema, Raw: state.Raw}
+			if diags := configState.Set(ctx, GroupEligibleAssignmentsModel{PrincipalID: tt.principalID, Role: tt.role}); diags.HasError() {
+				t.Fatalf("unable to set config: %v", diags)
+			}
+			config.Raw = configState.Raw
+
+			resp := &datasource.ReadResponse{State: state}
+			d.Read(ctx, datasource.ReadRequest{Config: config}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+
+			var read GroupEligibleAssignmentsModel
+			resp.State.Get(ctx, &read)
+
+			var got []string
+			for _, a := range read.Assignments {
+				got = append(got, a.Scope.ValueString()+"|"+a.PrincipalID.ValueString())
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got assignments %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got assignments %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGroupEligibleAssignmentsReadSecurityGroupCache(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	service := grouppim.NewService(client)
+
+	for _, a := range []grouppim.EligibleAssignment{
+		{GroupID: "group-a", PrincipalID: "principal-1", Role: "member"},
+		{GroupID: "group-b", PrincipalID: "principal-1", Role: "member"},
+		{GroupID: "group-c", PrincipalID: "principal-1", Role: "member"},
+	} {
+		if _, err := service.CreateEligibleAssignment(ctx, a); err != nil {
+			t.Fatalf("unable to create eligible assignment: %v", err)
+		}
+	}
+
+	directoryClient := fakeDirectoryClient{tenantGroups: {"group-a", "group-b"}}
+	d := &GroupEligibleAssignments{
+		service:   service,
+		directory: directory.NewService(directoryClient),
+	}
+	d.directory.SetSecurityGroupCache(directory.NewSecurityGroupCache())
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	read := func() []string {
+		t.Helper()
+
+		configState := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+		if diags := configState.Set(ctx, GroupEligibleAssignmentsModel{PrincipalID: customtypes.NewGUIDNull(), Role: types.StringNull()}); diags.HasError() {
+			t.Fatalf("unable to set config: %v", diags)
+		}
+
+		resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}}
+		d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+		}
+
+		var model GroupEligibleAssignmentsModel
+		resp.State.Get(ctx, &model)
+
+		var got []string
+		for _, a := range model.Assignments {
+			got = append(got, a.Scope.ValueString())
+		}
+
+		return got
+	}
+
+	if got, want := read(), []string{"group-a", "group-b"}; !slices.Equal(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+
+	// The second listing only fetches the changes since the first.
+	directoryClient[tenantGroups] = []string{"group-a", "group-c"}
+	if got, want := read(), []string{"group-a", "group-c"}; !slices.Equal(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+	if got, want := directoryClient[groupDeltaQueries], []string{"", "groups:group-a,group-b"}; !slices.Equal(got, want) {
+		t.Errorf("got delta queries %q, want %q", got, want)
+	}
+
+	// An expired delta link lists all groups again.
+	directoryClient[groupDeltaExpired] = []string{"true"}
+	directoryClient[tenantGroups] = []string{"group-b"}
+	if got, want := read(), []string{"group-b"}; !slices.Equal(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+	if got, want := directoryClient[groupDeltaQueries][2:], []string{"groups:group-a,group-c", ""}; !slices.Equal(got, want) {
+		t.Errorf("got delta queries %q, want %q", got, want)
+	}
+}
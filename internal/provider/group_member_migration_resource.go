@@ -0,0 +1,274 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &GroupMemberMigration{}
+var _ resource.ResourceWithModifyPlan = &GroupMemberMigration{}
+
+func NewGroupMemberMigration() resource.Resource {
+	return &GroupMemberMigration{}
+}
+
+// GroupMemberMigration defines the resource implementation.
+type GroupMemberMigration struct {
+	service   *grouppim.Service
+	directory *directory.Service
+	resourceSettings
+}
+
+// GroupMemberMigrationModel describes the resource data model.
+type GroupMemberMigrationModel struct {
+	Id                   types.String     `tfsdk:"id"`
+	Scope                customtypes.GUID `tfsdk:"scope"`
+	Justification        types.String     `tfsdk:"justification"`
+	MigratedPrincipalIDs types.Set        `tfsdk:"migrated_principal_ids"`
+}
+
+// throttleTarget describes the migration in throttling warnings.
+func (m GroupMemberMigrationModel) throttleTarget() string {
+	return fmt.Sprintf("migration of the members of group %s", m.Scope.ValueString())
+}
+
+func (r *GroupMemberMigration) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_member_migration"
+}
+
+func (r *GroupMemberMigration) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Migrates the direct members of a group to PIM eligible member assignments, e.g. to move a legacy always-on group to PIM.
+
+For every direct member which is a user or group, an eligible member assignment is created before the member is removed
+from the group, so no member loses access to the group in between. Other members, e.g. devices, are left in place.
+Members through an activated PIM assignment are left in place too, since removing them would end the activation.
+The migration runs once when the resource is created. Destroying the resource does not restore the members and leaves
+the eligible assignments in place.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.ReadWrite.AzureADGroup
+- RoleManagementPolicy.ReadWrite.AzureADGroup
+- PrivilegedAssignmentSchedule.Read.AzureADGroup
+- GroupMember.ReadWrite.All
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ID of the resource is the `scope` value.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "The group whose direct members are migrated.",
+				Required:            true,
+				CustomType:          customtypes.GUIDType{},
+				PlanModifiers: []planmodifier.String{
+					customtypes.RequiresReplaceUnlessSemanticEqual(customtypes.GUIDType{}),
+				},
+			},
+			"justification": schema.StringAttribute{
+				MarkdownDescription: "The justification of the eligible assignments. Note that it is stored in the Terraform state. Defaults to the `default_justification` of the provider.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"migrated_principal_ids": schema.SetAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "The object IDs of the members which were migrated to eligible assignments.",
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+// ModifyPlan checks the justification against the justification rules of the provider.
+func (r *GroupMemberMigration) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The resource is destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	r.justificationRules.validate(ctx, req.Config, req.State, &resp.Diagnostics, path.Root("justification"))
+}
+
+func (r *GroupMemberMigration) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	r.service = grouppim.NewService(pd.clients.GroupPIM)
+	r.service.SetTicketInfo(pd.ticketInfo)
+	r.service.SetStrictPolicy(pd.strictPolicy)
+	r.service.SetPolicyCache(pd.policyCache)
+	r.directory = directory.NewService(pd.clients.Directory)
+	r.resourceSettings = newResourceSettings(pd)
+}
+
+func (r *GroupMemberMigration) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data GroupMemberMigrationModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	groupID := data.Scope.ValueString()
+	data.Id = types.StringValue(groupID)
+
+	members, err := r.directory.GroupMembers(ctx, groupID)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list group members: "+sanitizeError(err))
+		return
+	}
+
+	// PIM adds activated members to the direct members while the activation lasts. Removing them would end the
+	// activation.
+	activated, err := r.service.ActivatedMemberIDs(ctx, groupID)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list activated members: "+sanitizeError(err))
+		return
+	}
+
+	migrated := []string{}
+	for _, m := range members {
+		if activated[strings.ToLower(m.ID)] {
+			tflog.Info(ctx, "skipping activated member", map[string]any{"member_id": m.ID})
+			continue
+		}
+
+		if !m.CanBeEligible() {
+			resp.Diagnostics.AddWarning(
+				"Member not migrated",
+				fmt.Sprintf("The member %s of group %s is a %s, which cannot be eligible for the group, so it is left as a direct member.", m.ID, groupID, m.OdataType),
+			)
+			continue
+		}
+
+		ctx := withAuditTarget(ctx, groupID, m.ID)
+		if err := migrateGroupMember(ctx, r.service, r.directory, groupID, m.ID, justificationOrDefault(data.Justification, r.defaultJustification)); err != nil {
+			resp.Diagnostics.AddError("Client call failed", fmt.Sprintf("Unable to migrate member %s: %s", m.ID, sanitizeError(err)))
+			break
+		}
+
+		migrated = append(migrated, m.ID)
+	}
+
+	// The members migrated so far are saved even when a member fails. The resource is then tainted, and the next apply
+	// continues with the remaining members.
+	var diags diag.Diagnostics
+	data.MigratedPrincipalIDs, diags = types.SetValueFrom(ctx, types.StringType, migrated)
+	resp.Diagnostics.Append(diags...)
+
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// migrateGroupMember makes memberID eligible for the member role of groupID, and then removes its direct membership.
+// An existing eligibility is kept, e.g. when a previous migration failed to remove the member.
+func migrateGroupMember(ctx context.Context, service *grouppim.Service, dir *directory.Service, groupID, memberID, justification string) error {
+	_, err := service.GetEligibleAssignment(ctx, groupID, memberID, "member", true)
+	if errors.Is(err, grouppim.ErrNotFound) {
+		_, err = service.CreateEligibleAssignment(ctx, grouppim.EligibleAssignment{
+			GroupID:       groupID,
+			PrincipalID:   memberID,
+			Role:          "member",
+			Justification: justification,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	return dir.RemoveGroupMember(ctx, groupID, memberID)
+}
+
+func (r *GroupMemberMigration) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.deferredReason != "" {
+		deferResourceRead(req, resp, r.deferredReason)
+		return
+	}
+
+	var data GroupMemberMigrationModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The migration is not repeated, so only the existence of the group is refreshed.
+	exists, err := r.directory.GroupExists(ctx, data.Scope.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get group: "+sanitizeError(err))
+		return
+	}
+	if !exists {
+		tflog.Info(ctx, "group no longer exists, removing migration from state")
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GroupMemberMigration) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// All configurable attributes require replacement.
+	var data GroupMemberMigrationModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GroupMemberMigration) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "leaving migrated eligible assignments in place, the members are not restored")
+}
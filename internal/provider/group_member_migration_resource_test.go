@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+func TestGroupMemberMigrationCreate(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	directoryClient := fakeDirectoryClient{
+		groupMembersPrefix + "group-id": {"user-1", "user-2", "user-3", "device-1"},
+		devices:                         {"device-1"},
+	}
+
+	r := &GroupMemberMigration{
+		service:   grouppim.NewService(client),
+		directory: directory.NewService(directoryClient),
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+	empty := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+
+	// user-2 is already eligible, e.g. from a migration which failed to remove the member.
+	if _, err := grouppim.NewService(client).CreateEligibleAssignment(ctx, grouppim.EligibleAssignment{
+		GroupID:     "group-id",
+		PrincipalID: "user-2",
+		Role:        "member",
+	}); err != nil {
+		t.Fatalf("unable to create eligible assignment: %v", err)
+	}
+
+	// user-3 is a member through an activation, which would end if the member was removed.
+	client.activate("group-id", "user-3", "instance-user-3")
+
+	plan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	if diags := plan.Set(ctx, GroupMemberMigrationModel{
+		Id:                   types.StringUnknown(),
+		Scope:                customtypes.NewGUIDValue("group-id"),
+		Justification:        types.StringValue("migrating to PIM"),
+		MigratedPrincipalIDs: types.SetUnknown(types.StringType),
+	}); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	resp := &fwresource.CreateResponse{State: empty}
+	r.Create(ctx, fwresource.CreateRequest{Plan: plan}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected create diagnostics: %v", resp.Diagnostics)
+	}
+
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("got diagnostics %v, want a warning about the device", resp.Diagnostics)
+	}
+
+	if len(client.requests) != 2 {
+		t.Errorf("got %d eligibility requests, want 2", len(client.requests))
+	}
+
+	if members := directoryClient[groupMembersPrefix+"group-id"]; !slices.Equal(members, []string{"user-3", "device-1"}) {
+		t.Errorf("got members %v, want the activated user-3 and device-1", members)
+	}
+
+	var created GroupMemberMigrationModel
+	resp.State.Get(ctx, &created)
+
+	var migrated []string
+	created.MigratedPrincipalIDs.ElementsAs(ctx, &migrated, false)
+	if len(migrated) != 2 {
+		t.Errorf("got migrated_principal_ids %v, want user-1 and user-2", migrated)
+	}
+}
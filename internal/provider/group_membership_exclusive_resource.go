@@ -0,0 +1,440 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// The values of on_violation.
+const (
+	onViolationConvert = "convert"
+	onViolationRemove  = "remove"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &GroupMembershipExclusive{}
+var _ resource.ResourceWithModifyPlan = &GroupMembershipExclusive{}
+var _ resource.ResourceWithImportState = &GroupMembershipExclusive{}
+
+func NewGroupMembershipExclusive() resource.Resource {
+	return &GroupMembershipExclusive{}
+}
+
+// GroupMembershipExclusive defines the resource implementation.
+type GroupMembershipExclusive struct {
+	service   *grouppim.Service
+	directory *directory.Service
+	resourceSettings
+}
+
+// GroupMembershipExclusiveModel describes the resource data model.
+type GroupMembershipExclusiveModel struct {
+	Id                 types.String     `tfsdk:"id"`
+	Scope              customtypes.GUID `tfsdk:"scope"`
+	AllowedMemberIDs   types.Set        `tfsdk:"allowed_member_ids"`
+	OnViolation        types.String     `tfsdk:"on_violation"`
+	Justification      types.String     `tfsdk:"justification"`
+	ViolatingMemberIDs types.Set        `tfsdk:"violating_member_ids"`
+}
+
+// throttleTarget describes the membership in throttling warnings.
+func (m GroupMembershipExclusiveModel) throttleTarget() string {
+	return fmt.Sprintf("exclusive membership of group %s", m.Scope.ValueString())
+}
+
+func (r *GroupMembershipExclusive) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_membership_exclusive"
+}
+
+func (r *GroupMembershipExclusive) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Enforces that a PIM managed group has no permanent members outside an allow-list, so PIM can't be bypassed by adding
+members to the group directly.
+
+Direct members which are not allowed are reported in ` + "`violating_member_ids`" + ` during refresh, and converted to eligible
+member assignments or removed on the next apply. Principals with an activated member assignment are members of the group
+while the activation lasts, and are not violations.
+
+The members are cached in the private state of the resource, so refreshes only list the changes of the members since the
+previous refresh with a delta query.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.ReadWrite.AzureADGroup
+- PrivilegedAssignmentSchedule.Read.AzureADGroup
+- RoleManagementPolicy.ReadWrite.AzureADGroup
+- GroupMember.ReadWrite.All
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ID of the resource is the `scope` value.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "The group whose direct members are enforced.",
+				Required:            true,
+				CustomType:          customtypes.GUIDType{},
+				PlanModifiers: []planmodifier.String{
+					customtypes.RequiresReplaceUnlessSemanticEqual(customtypes.GUIDType{}),
+				},
+			},
+			"allowed_member_ids": schema.SetAttribute{
+				MarkdownDescription: "The object IDs of the principals which may be direct members of the group.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"on_violation": schema.StringAttribute{
+				MarkdownDescription: "What to do with direct members which are not allowed. `convert` (default) makes users and groups eligible for the member role before removing them, and removes other members. `remove` removes them.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(onViolationConvert),
+				Validators:          []validator.String{stringvalidator.OneOf(onViolationConvert, onViolationRemove)},
+			},
+			"justification": schema.StringAttribute{
+				MarkdownDescription: "The justification of the eligible assignments created when converting members. Note that it is stored in the Terraform state. Defaults to the `default_justification` of the provider.",
+				Optional:            true,
+			},
+			"violating_member_ids": schema.SetAttribute{
+				MarkdownDescription: "The object IDs of the direct members which are not allowed. Always empty after apply, refresh fills it when members are added outside of Terraform.",
+				Computed:            true,
+				ElementType:         types.StringType,
+				Default:             setdefault.StaticValue(types.SetValueMust(types.StringType, nil)),
+			},
+		},
+	}
+}
+
+// ModifyPlan checks the justification against the justification rules of the provider.
+func (r *GroupMembershipExclusive) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The resource is destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	r.justificationRules.validate(ctx, req.Config, req.State, &resp.Diagnostics, path.Root("justification"))
+}
+
+func (r *GroupMembershipExclusive) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	r.service = grouppim.NewService(pd.clients.GroupPIM)
+	r.service.SetTicketInfo(pd.ticketInfo)
+	r.service.SetStrictPolicy(pd.strictPolicy)
+	r.service.SetPolicyCache(pd.policyCache)
+	r.directory = directory.NewService(pd.clients.Directory)
+	r.resourceSettings = newResourceSettings(pd)
+}
+
+func (r *GroupMembershipExclusive) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data GroupMembershipExclusiveModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(data.Scope.ValueString())
+
+	members := groupMembersCache{GroupID: data.Scope.ValueString()}
+	resp.Diagnostics.Append(r.enforce(ctx, &data, &members)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The framework always sets Private, it is only nil when the resource is called directly.
+	if resp.Private != nil {
+		resp.Diagnostics.Append(members.save(ctx, resp.Private)...)
+	}
+
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GroupMembershipExclusive) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.deferredReason != "" {
+		deferResourceRead(req, resp, r.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data GroupMembershipExclusiveModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	groupID := data.Scope.ValueString()
+	exists, err := r.directory.GroupExists(ctx, groupID)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to get group: "+sanitizeError(err))
+		return
+	}
+	if !exists {
+		tflog.Info(ctx, "group no longer exists, removing resource from state")
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	members, diags := loadGroupMembersCache(ctx, req.Private, groupID)
+	resp.Diagnostics.Append(diags...)
+
+	violations, diags := r.violations(ctx, data, &members)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if resp.Private != nil {
+		resp.Diagnostics.Append(members.save(ctx, resp.Private)...)
+	}
+
+	ids := make([]string, 0, len(violations))
+	for _, m := range violations {
+		ids = append(ids, m.ID)
+	}
+	sort.Strings(ids)
+
+	data.ViolatingMemberIDs, diags = types.SetValueFrom(ctx, types.StringType, ids)
+	resp.Diagnostics.Append(diags...)
+
+	// The default is not applied on import.
+	if data.OnViolation.IsNull() {
+		data.OnViolation = types.StringValue(onViolationConvert)
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GroupMembershipExclusive) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data GroupMembershipExclusiveModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, diags := loadGroupMembersCache(ctx, req.Private, data.Scope.ValueString())
+	resp.Diagnostics.Append(diags...)
+
+	resp.Diagnostics.Append(r.enforce(ctx, &data, &members)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if resp.Private != nil {
+		resp.Diagnostics.Append(members.save(ctx, resp.Private)...)
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GroupMembershipExclusive) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "no longer enforcing the members of the group, the members are left unchanged")
+}
+
+func (r *GroupMembershipExclusive) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("scope"), customtypes.NewGUIDValue(req.ID))...)
+}
+
+// violations returns the direct members of the group of m which are neither allowed nor activated through PIM.
+func (r *GroupMembershipExclusive) violations(ctx context.Context, m GroupMembershipExclusiveModel, cache *groupMembersCache) ([]directory.Member, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var allowedIDs []string
+	diags.Append(m.AllowedMemberIDs.ElementsAs(ctx, &allowedIDs, true)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	allowed := map[string]bool{}
+	for _, id := range allowedIDs {
+		allowed[strings.ToLower(id)] = true
+	}
+
+	groupID := m.Scope.ValueString()
+	members, deltaLink, err := r.directory.GroupMembersSince(ctx, groupID, cache.members(), cache.DeltaLink)
+	if err != nil {
+		diags.AddError("Client call failed", "Unable to list group members: "+sanitizeError(err))
+		return nil, diags
+	}
+	*cache = newGroupMembersCache(groupID, members, deltaLink)
+
+	activated, err := r.service.ActivatedMemberIDs(ctx, groupID)
+	if err != nil {
+		diags.AddError("Client call failed", "Unable to list activated members: "+sanitizeError(err))
+		return nil, diags
+	}
+
+	var result []directory.Member
+	for _, member := range members {
+		id := strings.ToLower(member.ID)
+		if !allowed[id] && !activated[id] {
+			result = append(result, member)
+		}
+	}
+
+	return result, diags
+}
+
+// enforce converts or removes the violating members of the group of m, and empties violating_member_ids.
+func (r *GroupMembershipExclusive) enforce(ctx context.Context, m *GroupMembershipExclusiveModel, cache *groupMembersCache) diag.Diagnostics {
+	violations, diags := r.violations(ctx, *m, cache)
+	if diags.HasError() {
+		return diags
+	}
+
+	groupID := m.Scope.ValueString()
+	for _, member := range violations {
+		ctx := withAuditTarget(ctx, groupID, member.ID)
+
+		var err error
+		if m.OnViolation.ValueString() == onViolationConvert && member.CanBeEligible() {
+			tflog.Info(ctx, "converting direct member to eligible member", map[string]any{"member_id": member.ID})
+			err = migrateGroupMember(ctx, r.service, r.directory, groupID, member.ID, justificationOrDefault(m.Justification, r.defaultJustification))
+		} else {
+			tflog.Info(ctx, "removing direct member", map[string]any{"member_id": member.ID})
+			err = r.directory.RemoveGroupMember(ctx, groupID, member.ID)
+		}
+		if err != nil {
+			diags.AddError("Client call failed", fmt.Sprintf("Unable to enforce member %s: %s", member.ID, sanitizeError(err)))
+			return diags
+		}
+	}
+
+	m.ViolatingMemberIDs = types.SetValueMust(types.StringType, nil)
+
+	return diags
+}
+
+// groupMembersPrivateKey is the key of the private state caching the direct members of the group, so refreshes only
+// list the changes since with a delta query instead of all members.
+const groupMembersPrivateKey = "group_members"
+
+// privateState is the private state of a resource, as read from requests and written to responses.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// groupMembersCache are the direct members of a group as of a delta link.
+type groupMembersCache struct {
+	GroupID   string               `json:"group_id"`
+	DeltaLink string               `json:"delta_link"`
+	Members   []groupMembersMember `json:"members"`
+}
+
+// groupMembersMember is a direct member in groupMembersCache.
+type groupMembersMember struct {
+	ID        string `json:"id"`
+	OdataType string `json:"odata_type"`
+}
+
+func newGroupMembersCache(groupID string, members []directory.Member, deltaLink string) groupMembersCache {
+	c := groupMembersCache{GroupID: groupID, DeltaLink: deltaLink, Members: make([]groupMembersMember, 0, len(members))}
+	for _, m := range members {
+		c.Members = append(c.Members, groupMembersMember{ID: m.ID, OdataType: m.OdataType})
+	}
+
+	return c
+}
+
+// loadGroupMembersCache reads the cached members of groupID from private. The cache is empty when there is none, it
+// can not be read or it is of another group, so all members are listed.
+func loadGroupMembersCache(ctx context.Context, private privateState, groupID string) (groupMembersCache, diag.Diagnostics) {
+	empty := groupMembersCache{GroupID: groupID}
+
+	b, diags := private.GetKey(ctx, groupMembersPrivateKey)
+	if diags.HasError() || len(b) == 0 {
+		return empty, diags
+	}
+
+	var c groupMembersCache
+	if err := json.Unmarshal(b, &c); err != nil {
+		tflog.Debug(ctx, "unable to read cached group members, listing all members", map[string]any{"error": err.Error()})
+		return empty, diags
+	}
+	if !strings.EqualFold(c.GroupID, groupID) {
+		return empty, diags
+	}
+
+	return c, diags
+}
+
+// members returns the cached members.
+func (c groupMembersCache) members() []directory.Member {
+	members := make([]directory.Member, 0, len(c.Members))
+	for _, m := range c.Members {
+		members = append(members, directory.Member{ID: m.ID, OdataType: m.OdataType})
+	}
+
+	return members
+}
+
+// save writes the cache to private.
+func (c groupMembersCache) save(ctx context.Context, private privateState) diag.Diagnostics {
+	b, err := json.Marshal(c)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Unable to cache group members", err.Error())
+		return diags
+	}
+
+	return private.SetKey(ctx, groupMembersPrivateKey, b)
+}
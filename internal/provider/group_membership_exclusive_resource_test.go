@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+func TestGroupMembershipExclusive(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	directoryClient := fakeDirectoryClient{
+		groupMembersPrefix + "group-id": {"allowed-user"},
+		devices:                         {"device"},
+	}
+
+	r := &GroupMembershipExclusive{
+		service:   grouppim.NewService(client),
+		directory: directory.NewService(directoryClient),
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+	empty := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+
+	model := GroupMembershipExclusiveModel{
+		Id:                 types.StringUnknown(),
+		Scope:              customtypes.NewGUIDValue("group-id"),
+		AllowedMemberIDs:   types.SetValueMust(types.StringType, []attr.Value{types.StringValue("ALLOWED-USER")}),
+		OnViolation:        types.StringValue(onViolationConvert),
+		Justification:      types.StringValue("direct member"),
+		ViolatingMemberIDs: types.SetValueMust(types.StringType, nil),
+	}
+
+	plan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	if diags := plan.Set(ctx, model); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	createResp := &fwresource.CreateResponse{State: empty}
+	r.Create(ctx, fwresource.CreateRequest{Plan: plan}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected create diagnostics: %v", createResp.Diagnostics)
+	}
+
+	// Members are added directly, and a principal activates its eligibility.
+	directoryClient[groupMembersPrefix+"group-id"] = []string{"allowed-user", "direct-user", "device", "activated-user"}
+	client.activate("group-id", "activated-user", "instance-id")
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", readResp.Diagnostics)
+	}
+
+	var read GroupMembershipExclusiveModel
+	readResp.State.Get(ctx, &read)
+
+	var violations []string
+	read.ViolatingMemberIDs.ElementsAs(ctx, &violations, false)
+	sort.Strings(violations)
+	if len(violations) != 2 || violations[0] != "device" || violations[1] != "direct-user" {
+		t.Fatalf("got violating_member_ids %v, want [device direct-user]", violations)
+	}
+
+	updatePlan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	model.Id = types.StringValue("group-id")
+	if diags := updatePlan.Set(ctx, model); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	updateResp := &fwresource.UpdateResponse{State: readResp.State}
+	r.Update(ctx, fwresource.UpdateRequest{State: readResp.State, Plan: updatePlan}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected update diagnostics: %v", updateResp.Diagnostics)
+	}
+
+	if members := directoryClient[groupMembersPrefix+"group-id"]; len(members) != 2 || members[0] != "allowed-user" || members[1] != "activated-user" {
+		t.Errorf("got members %v, want [allowed-user activated-user]", members)
+	}
+
+	if len(client.requests) != 1 || *client.requests[0].GetPrincipalId() != "direct-user" {
+		t.Errorf("got %d eligibility requests, want one for direct-user", len(client.requests))
+	}
+}
+
+// testPrivateState is the private state of a resource kept in memory.
+type testPrivateState map[string][]byte
+
+func (p testPrivateState) GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func (p testPrivateState) SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics {
+	p[key] = value
+	return nil
+}
+
+func TestGroupMembershipExclusiveCachesMembers(t *testing.T) {
+	ctx := context.Background()
+	directoryClient := fakeDirectoryClient{
+		groupMembersPrefix + "group-id": {"allowed-user", "direct-user"},
+	}
+
+	r := &GroupMembershipExclusive{
+		service:   grouppim.NewService(newFakeGroupEligibilityClient()),
+		directory: directory.NewService(directoryClient),
+	}
+	model := GroupMembershipExclusiveModel{
+		Scope:            customtypes.NewGUIDValue("group-id"),
+		AllowedMemberIDs: types.SetValueMust(types.StringType, []attr.Value{types.StringValue("allowed-user")}),
+	}
+	private := testPrivateState{}
+
+	// violations reads the violating members through the cache in private state, like Read does.
+	violations := func() []string {
+		t.Helper()
+
+		cache, diags := loadGroupMembersCache(ctx, private, "group-id")
+		violations, violationDiags := r.violations(ctx, model, &cache)
+		diags.Append(violationDiags...)
+		diags.Append(cache.save(ctx, private)...)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+
+		var ids []string
+		for _, m := range violations {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	if got := violations(); len(got) != 1 || got[0] != "direct-user" {
+		t.Fatalf("got violations %v, want [direct-user]", got)
+	}
+
+	directoryClient[groupMembersPrefix+"group-id"] = []string{"allowed-user", "new-user"}
+	if got := violations(); len(got) != 1 || got[0] != "new-user" {
+		t.Fatalf("got violations %v, want [new-user] from the changes", got)
+	}
+
+	want := []string{"", "delta:group-id:allowed-user,direct-user"}
+	if got := directoryClient[deltaQueries]; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got delta queries %q, want %q", got, want)
+	}
+
+	// All members are listed again once the delta link expired.
+	private[groupMembersPrivateKey] = []byte(`{"group_id":"group-id","delta_link":"expired","members":[]}`)
+	if got := violations(); len(got) != 1 || got[0] != "new-user" {
+		t.Fatalf("got violations %v, want [new-user] from all members", got)
+	}
+	if got := directoryClient[deltaQueries][2:]; len(got) != 2 || got[0] != "expired" || got[1] != "" {
+		t.Errorf("got delta queries %q, want the expired one followed by a full one", got)
+	}
+
+	// The members of another group are not used.
+	cache, _ := loadGroupMembersCache(ctx, private, "other-group-id")
+	if cache.DeltaLink != "" || len(cache.Members) != 0 {
+		t.Errorf("got cache %v of group-id for other-group-id, want an empty cache", cache)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &GroupPolicies{}
+
+func NewGroupPolicies() datasource.DataSource {
+	return &GroupPolicies{}
+}
+
+// GroupPolicies defines the data source implementation.
+type GroupPolicies struct {
+	service   *pimpolicy.Service
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// GroupPoliciesModel describes the data source data model.
+type GroupPoliciesModel struct {
+	Scope    customtypes.GUID         `tfsdk:"scope"`
+	Policies []GroupPoliciesItemModel `tfsdk:"policies"`
+}
+
+// GroupPoliciesItemModel describes the policy of a role in a group listed by the data source.
+type GroupPoliciesItemModel struct {
+	Scope                         customtypes.GUID `tfsdk:"scope"`
+	Role                          types.String     `tfsdk:"role"`
+	PolicyID                      types.String     `tfsdk:"policy_id"`
+	EligibilityExpirationRequired types.Bool       `tfsdk:"eligibility_expiration_required"`
+	EligibilityMaximumDuration    types.String     `tfsdk:"eligibility_maximum_duration"`
+	ActivationMaximumDuration     types.String     `tfsdk:"activation_maximum_duration"`
+	ActivationApprovalRequired    types.Bool       `tfsdk:"activation_approval_required"`
+}
+
+func (d *GroupPolicies) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_policies"
+}
+
+func (d *GroupPolicies) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists the role management policies of the owner and member roles of PIM enabled groups with their key rule values, for
+governance reporting.
+
+Graph can only list role management policies by group. Without ` + "`scope`" + `, every security group in the tenant is
+queried, following the pages of each response, which takes a while in large tenants. Groups which are not onboarded
+to PIM are left out.
+
+It requires the following graph permissions:
+- RoleManagementPolicy.Read.AzureADGroup
+- Group.Read.All
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "Only list the policies of this group.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"policies": schema.ListNestedAttribute{
+				MarkdownDescription: "The policies, sorted by group and role.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The group of the policy.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "Either `member` or `owner`.",
+							Computed:            true,
+						},
+						"policy_id": schema.StringAttribute{
+							Computed: true,
+						},
+						"eligibility_expiration_required": schema.BoolAttribute{
+							MarkdownDescription: "Whether eligibilities assigned by admins must expire.",
+							Computed:            true,
+						},
+						"eligibility_maximum_duration": schema.StringAttribute{
+							MarkdownDescription: "The ISO 8601 duration eligibilities assigned by admins can last at most.",
+							Computed:            true,
+						},
+						"activation_maximum_duration": schema.StringAttribute{
+							MarkdownDescription: "The ISO 8601 duration an activation can last at most.",
+							Computed:            true,
+						},
+						"activation_approval_required": schema.BoolAttribute{
+							MarkdownDescription: "Whether activations must be approved.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *GroupPolicies) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = pimpolicy.NewService(pd.clients.Policies)
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.directory.SetSecurityGroupCache(pd.securityGroups)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *GroupPolicies) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of group policies") }()
+
+	var data GroupPoliciesModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	groupIDs := []string{data.Scope.ValueString()}
+	if data.Scope.ValueString() == "" {
+		var err error
+		groupIDs, err = d.directory.SecurityGroupIDs(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list groups: "+sanitizeError(err))
+			return
+		}
+
+		tflog.Debug(ctx, "listing policies of all security groups", map[string]any{"groups": len(groupIDs)})
+	}
+
+	byGroup, err := fetchAll(ctx, groupIDs, maxConcurrentFetches, d.service.GroupPolicies)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list group policies: "+sanitizeError(err))
+		return
+	}
+
+	var policies []pimpolicy.Summary
+	for _, groupPolicies := range byGroup {
+		policies = append(policies, groupPolicies...)
+	}
+
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].GroupID != policies[j].GroupID {
+			return policies[i].GroupID < policies[j].GroupID
+		}
+		return policies[i].Role < policies[j].Role
+	})
+
+	data.Policies = []GroupPoliciesItemModel{}
+	for _, p := range policies {
+		data.Policies = append(data.Policies, GroupPoliciesItemModel{
+			Scope:                         customtypes.NewGUIDValue(p.GroupID),
+			Role:                          types.StringValue(p.Role),
+			PolicyID:                      types.StringValue(p.PolicyID),
+			EligibilityExpirationRequired: types.BoolValue(p.EligibilityExpirationRequired),
+			EligibilityMaximumDuration:    types.StringValue(p.EligibilityMaximumDuration),
+			ActivationMaximumDuration:     types.StringValue(p.ActivationMaximumDuration),
+			ActivationApprovalRequired:    types.BoolValue(p.ActivationApprovalRequired),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+)
+
+// groupPoliciesClient lists the policies of the member and owner roles of the groups in onboarded. Owner activations
+// require approval.
+type groupPoliciesClient struct {
+	*fakePolicyClient
+	onboarded []string
+}
+
+func (c groupPoliciesClient) ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	var result []graphmodels.UnifiedRoleManagementPolicyAssignmentable
+	for _, groupID := range c.onboarded {
+		if !filterMatches(filter, "scopeId", groupID) {
+			continue
+		}
+
+		for _, role := range []string{"member", "owner"} {
+			eligibility := graphmodels.NewUnifiedRoleManagementPolicyExpirationRule()
+			eligibility.SetId(toPtr(pimpolicy.RuleExpirationAdminEligibility))
+			eligibility.SetIsExpirationRequired(toPtr(true))
+			eligibility.SetMaximumDuration(serialization.NewDuration(0, 0, 365, 0, 0, 0, 0))
+
+			activation := graphmodels.NewUnifiedRoleManagementPolicyExpirationRule()
+			activation.SetId(toPtr(pimpolicy.RuleExpirationEndUserAssignment))
+			activation.SetMaximumDuration(serialization.NewDuration(0, 0, 0, 8, 0, 0, 0))
+
+			setting := graphmodels.NewApprovalSettings()
+			setting.SetIsApprovalRequired(toPtr(role == "owner"))
+			approval := graphmodels.NewUnifiedRoleManagementPolicyApprovalRule()
+			approval.SetId(toPtr(pimpolicy.RuleApprovalEndUserAssignment))
+			approval.SetSetting(setting)
+
+			policy := graphmodels.NewUnifiedRoleManagementPolicy()
+			policy.SetRules([]graphmodels.UnifiedRoleManagementPolicyRuleable{eligibility, activation, approval})
+
+			a := graphmodels.NewUnifiedRoleManagementPolicyAssignment()
+			a.SetPolicyId(toPtr("Group_" + groupID + "_" + role))
+			a.SetRoleDefinitionId(toPtr(role))
+			a.SetPolicy(policy)
+			result = append(result, a)
+		}
+	}
+
+	return result, nil
+}
+
+func TestGroupPoliciesRead(t *testing.T) {
+	ctx := context.Background()
+	client := groupPoliciesClient{fakePolicyClient: newFakePolicyClient(), onboarded: []string{"group-b", "group-a"}}
+
+	d := &GroupPolicies{
+		service:   pimpolicy.NewService(client),
+		directory: directory.NewService(fakeDirectoryClient{tenantGroups: {"group-a", "group-b", "group-c"}}),
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, GroupPoliciesModel{}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var read GroupPoliciesModel
+	resp.State.Get(ctx, &read)
+
+	var got []string
+	for _, p := range read.Policies {
+		got = append(got, strings.Join([]string{
+			p.PolicyID.ValueString(),
+			p.EligibilityMaximumDuration.ValueString(),
+			p.ActivationMaximumDuration.ValueString(),
+			p.ActivationApprovalRequired.String(),
+		}, "|"))
+	}
+	want := []string{
+		"Group_group-a_member|P365D|PT8H|false",
+		"Group_group-a_owner|P365D|PT8H|true",
+		"Group_group-b_member|P365D|PT8H|false",
+		"Group_group-b_owner|P365D|PT8H|true",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got policies %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &GroupPrivilegedAccess{}
+
+func NewGroupPrivilegedAccess() datasource.DataSource {
+	return &GroupPrivilegedAccess{}
+}
+
+// GroupPrivilegedAccess defines the data source implementation.
+type GroupPrivilegedAccess struct {
+	service *grouppim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// GroupPrivilegedAccessModel describes the data source data model.
+type GroupPrivilegedAccessModel struct {
+	Scope   customtypes.GUID               `tfsdk:"scope"`
+	Owners  GroupPrivilegedAccessRoleModel `tfsdk:"owners"`
+	Members GroupPrivilegedAccessRoleModel `tfsdk:"members"`
+}
+
+// GroupPrivilegedAccessRoleModel describes the eligible and active principals of a role in the group.
+type GroupPrivilegedAccessRoleModel struct {
+	Eligible []GroupPrivilegedAccessEligibleModel `tfsdk:"eligible"`
+	Active   []GroupPrivilegedAccessActiveModel   `tfsdk:"active"`
+}
+
+// GroupPrivilegedAccessEligibleModel describes an eligibility listed by the data source.
+type GroupPrivilegedAccessEligibleModel struct {
+	PrincipalID   customtypes.GUID    `tfsdk:"principal_id"`
+	MemberType    types.String        `tfsdk:"member_type"`
+	StartDateTime customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime   customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+// GroupPrivilegedAccessActiveModel describes an active assignment listed by the data source.
+type GroupPrivilegedAccessActiveModel struct {
+	PrincipalID    customtypes.GUID    `tfsdk:"principal_id"`
+	AssignmentType types.String        `tfsdk:"assignment_type"`
+	MemberType     types.String        `tfsdk:"member_type"`
+	StartDateTime  customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime    customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+func (d *GroupPrivilegedAccess) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_privileged_access"
+}
+
+// groupPrivilegedAccessRoleAttribute returns the schema of the eligible and active principals of role.
+func groupPrivilegedAccessRoleAttribute(role string) schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		MarkdownDescription: fmt.Sprintf("The principals which are eligible for or hold the %s role, sorted by principal.", role),
+		Computed:            true,
+		Attributes: map[string]schema.Attribute{
+			"eligible": schema.ListNestedAttribute{
+				MarkdownDescription: fmt.Sprintf("The principals eligible to activate the %s role.", role),
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"principal_id": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.GUIDType{},
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "`direct` when the principal is eligible itself, `group` when it is eligible through a group.",
+							Computed:            true,
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "Empty for permanent eligibilities.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+			"active": schema.ListNestedAttribute{
+				MarkdownDescription: fmt.Sprintf("The principals currently holding the %s role.", role),
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"principal_id": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.GUIDType{},
+						},
+						"assignment_type": schema.StringAttribute{
+							MarkdownDescription: "`activated` when the role was activated through an eligibility, `assigned` when it is assigned outside of PIM activation.",
+							Computed:            true,
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "`direct` when the principal is assigned itself, `group` when it is assigned through a group.",
+							Computed:            true,
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "Empty for permanent assignments.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *GroupPrivilegedAccess) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Reads the full privileged surface of a PIM enabled group in one place: who is eligible for and who currently holds the
+owner and member roles.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.Read.AzureADGroup
+- PrivilegedAssignmentSchedule.Read.AzureADGroup
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "The object ID of the group.",
+				Required:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"owners":  groupPrivilegedAccessRoleAttribute("owner"),
+			"members": groupPrivilegedAccessRoleAttribute("member"),
+		},
+	}
+}
+
+func (d *GroupPrivilegedAccess) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = grouppim.NewService(pd.clients.GroupPIM)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *GroupPrivilegedAccess) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "reading of group privileged access") }()
+
+	var data GroupPrivilegedAccessModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	eligibilities, err := d.service.ListEligibleAssignments(ctx, data.Scope.ValueString(), "")
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list eligible assignments: "+sanitizeError(err))
+		return
+	}
+
+	assignments, err := d.service.ListActiveAssignments(ctx, data.Scope.ValueString(), "")
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list active assignments: "+sanitizeError(err))
+		return
+	}
+
+	sort.SliceStable(eligibilities, func(i, j int) bool {
+		return eligibilities[i].PrincipalID < eligibilities[j].PrincipalID
+	})
+	sort.SliceStable(assignments, func(i, j int) bool {
+		return assignments[i].PrincipalID < assignments[j].PrincipalID
+	})
+
+	roles := map[string]*GroupPrivilegedAccessRoleModel{"owner": &data.Owners, "member": &data.Members}
+	for _, role := range roles {
+		role.Eligible = []GroupPrivilegedAccessEligibleModel{}
+		role.Active = []GroupPrivilegedAccessActiveModel{}
+	}
+
+	for _, e := range eligibilities {
+		role, ok := roles[e.Role]
+		if !ok {
+			continue
+		}
+
+		role.Eligible = append(role.Eligible, GroupPrivilegedAccessEligibleModel{
+			PrincipalID:   customtypes.NewGUIDValue(e.PrincipalID),
+			MemberType:    types.StringValue(e.MemberType),
+			StartDateTime: customtypes.NewRFC3339Value(e.StartDateTime),
+			EndDateTime:   customtypes.NewRFC3339Value(e.EndDateTime),
+		})
+	}
+
+	for _, a := range assignments {
+		role, ok := roles[a.Role]
+		if !ok {
+			continue
+		}
+
+		role.Active = append(role.Active, GroupPrivilegedAccessActiveModel{
+			PrincipalID:    customtypes.NewGUIDValue(a.PrincipalID),
+			AssignmentType: types.StringValue(a.AssignmentType),
+			MemberType:     types.StringValue(a.MemberType),
+			StartDateTime:  customtypes.NewRFC3339Value(a.StartDateTime),
+			EndDateTime:    customtypes.NewRFC3339Value(a.EndDateTime),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
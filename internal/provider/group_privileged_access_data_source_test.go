@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+func TestGroupPrivilegedAccessRead(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeGroupEligibilityClient()
+	service := grouppim.NewService(client)
+
+	var memberInstanceID string
+	for _, a := range []grouppim.EligibleAssignment{
+		{GroupID: "group-a", PrincipalID: "principal-2", Role: "member"},
+		{GroupID: "group-a", PrincipalID: "principal-1", Role: "member"},
+		{GroupID: "group-a", PrincipalID: "principal-1", Role: "owner"},
+		{GroupID: "group-b", PrincipalID: "principal-3", Role: "member"},
+	} {
+		created, err := service.CreateEligibleAssignment(ctx, a)
+		if err != nil {
+			t.Fatalf("unable to create eligible assignment: %v", err)
+		}
+		if a.PrincipalID == "principal-2" {
+			memberInstanceID = created.RequestID
+		}
+	}
+	client.activate("group-a", "principal-2", memberInstanceID)
+	client.activate("group-b", "principal-3", "other")
+
+	d := &GroupPrivilegedAccess{service: service}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.SetAttribute(ctx, path.Root("scope"), customtypes.NewGUIDValue("group-a")); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var read GroupPrivilegedAccessModel
+	resp.State.Get(ctx, &read)
+
+	principals := func(role GroupPrivilegedAccessRoleModel) (eligible, active []string) {
+		for _, e := range role.Eligible {
+			eligible = append(eligible, e.PrincipalID.ValueString())
+		}
+		for _, a := range role.Active {
+			active = append(active, a.PrincipalID.ValueString()+"|"+a.AssignmentType.ValueString())
+		}
+		return eligible, active
+	}
+
+	eligible, active := principals(read.Members)
+	if want := []string{"principal-1", "principal-2"}; !slices.Equal(eligible, want) {
+		t.Errorf("got eligible members %v, want %v", eligible, want)
+	}
+	if want := []string{"principal-2|activated"}; !slices.Equal(active, want) {
+		t.Errorf("got active members %v, want %v", active, want)
+	}
+
+	eligible, active = principals(read.Owners)
+	if want := []string{"principal-1"}; !slices.Equal(eligible, want) {
+		t.Errorf("got eligible owners %v, want %v", eligible, want)
+	}
+	if len(active) != 0 {
+		t.Errorf("got active owners %v, want none", active)
+	}
+}
+
+func TestGroupPrivilegedAccessReadPages(t *testing.T) {
+	ctx := context.Background()
+
+	var eligibilities, activations []map[string]any
+	for i := 0; i < 5; i++ {
+		eligibilities = append(eligibilities, map[string]any{
+			"id":          fmt.Sprintf("instance-%d", i),
+			"groupId":     "group-a",
+			"principalId": fmt.Sprintf("principal-%d", i),
+			"accessId":    "member",
+		})
+	}
+	for i := 0; i < 3; i++ {
+		activations = append(activations, map[string]any{
+			"id":             fmt.Sprintf("activation-%d", i),
+			"groupId":        "group-a",
+			"principalId":    fmt.Sprintf("principal-%d", i),
+			"accessId":       "member",
+			"assignmentType": "activated",
+		})
+	}
+
+	server := newTestPagedServer(t, map[string][]map[string]any{
+		"/beta/identityGovernance/privilegedAccess/group/eligibilityScheduleInstances": eligibilities,
+		"/beta/identityGovernance/privilegedAccess/group/assignmentScheduleInstances":  activations,
+	})
+	d := &GroupPrivilegedAccess{service: grouppim.NewService(testGraphClient(t, server, 0))}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.SetAttribute(ctx, path.Root("scope"), customtypes.NewGUIDValue("group-a")); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var read GroupPrivilegedAccessModel
+	resp.State.Get(ctx, &read)
+	if len(read.Members.Eligible) != len(eligibilities) || len(read.Members.Active) != len(activations) {
+		t.Errorf("got %d eligible and %d active members, want %d and %d from all pages", len(read.Members.Eligible), len(read.Members.Active), len(eligibilities), len(activations))
+	}
+}
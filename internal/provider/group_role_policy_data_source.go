@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &GroupRolePolicy{}
+
+func NewGroupRolePolicy() datasource.DataSource {
+	return &GroupRolePolicy{}
+}
+
+// GroupRolePolicy defines the data source implementation.
+type GroupRolePolicy struct {
+	service *grouppim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// GroupRolePolicyModel describes the data source data model.
+type GroupRolePolicyModel struct {
+	GroupID            customtypes.GUID           `tfsdk:"group_id"`
+	Role               types.String               `tfsdk:"role"`
+	PolicyID           types.String               `tfsdk:"policy_id"`
+	PolicyAssignmentID types.String               `tfsdk:"policy_assignment_id"`
+	Rules              []GroupRolePolicyRuleModel `tfsdk:"rules"`
+}
+
+// GroupRolePolicyRuleModel describes a rule of the policy.
+type GroupRolePolicyRuleModel struct {
+	ID        types.String `tfsdk:"id"`
+	Inherited types.Bool   `tfsdk:"inherited"`
+}
+
+func (d *GroupRolePolicy) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_role_policy"
+}
+
+func (d *GroupRolePolicy) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Looks up the role management policy governing the member or owner role of a PIM enabled group, and the assignment of
+the policy to the role, the same way the provider does before it changes a policy. Use it to reference the policy from
+other resources or scripts without repeating the filtered lookup.
+
+The rules of the policy tell whether they still follow the tenant defaults, so the impact of the provider changing a
+rule, e.g. the expiration rule when an eligibility without expiration is assigned, is known before it happens.
+
+It requires the following graph permissions:
+- RoleManagementPolicy.Read.AzureADGroup
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"group_id": schema.StringAttribute{
+				MarkdownDescription: "The PIM enabled group.",
+				Required:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "Either `member` or `owner`.",
+				Required:            true,
+				Validators:          []validator.String{stringvalidator.OneOf("owner", "member")},
+			},
+			"policy_id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the role management policy governing the role.",
+				Computed:            true,
+			},
+			"policy_assignment_id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the assignment of the policy to the role in the group.",
+				Computed:            true,
+			},
+			"rules": schema.ListNestedAttribute{
+				MarkdownDescription: "The rules of the policy, sorted by ID.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the rule, e.g. `Expiration_Admin_Eligibility`.",
+							Computed:            true,
+						},
+						"inherited": schema.BoolAttribute{
+							MarkdownDescription: "Whether the rule follows the tenant defaults instead of being set for the group, because it has the settings groups are onboarded to PIM with, or a tenant wide rule takes precedence over it. Each rule is compared on its own, so changing one rule does not affect whether the others are inherited.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *GroupRolePolicy) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = grouppim.NewService(pd.clients.GroupPIM)
+	d.service.SetPolicyCache(pd.policyCache)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *GroupRolePolicy) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data GroupRolePolicyModel
+	defer func() {
+		throttles.addWarnings(&resp.Diagnostics, "lookup of the policy of group "+data.GroupID.ValueString())
+	}()
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	assignment, err := d.service.RolePolicyAssignment(ctx, data.GroupID.ValueString(), data.Role.ValueString())
+	if errors.Is(err, grouppim.ErrPolicyNotFound) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("group_id"),
+			"Policy not found",
+			fmt.Sprintf("The %s role of group %s has no role management policy. The group is not onboarded to PIM, or is still being onboarded.", data.Role.ValueString(), data.GroupID.ValueString()),
+		)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to look up the role management policy: "+sanitizeError(err))
+		return
+	}
+
+	data.PolicyID = types.StringValue(assignment.PolicyID)
+	data.PolicyAssignmentID = types.StringValue(assignment.ID)
+	data.Rules = make([]GroupRolePolicyRuleModel, 0, len(assignment.Rules))
+	for _, rule := range assignment.Rules {
+		data.Rules = append(data.Rules, GroupRolePolicyRuleModel{
+			ID:        types.StringValue(rule.ID),
+			Inherited: types.BoolValue(rule.Inherited),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
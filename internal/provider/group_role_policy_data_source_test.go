@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+)
+
+func TestGroupRolePolicyRead(t *testing.T) {
+	tests := []struct {
+		name      string
+		role      string
+		policyErr error
+		// modified sets the expiration rule of the member policy, and tenantRequired adds a tenant wide rule. The
+		// enablement rule keeps its default settings either way.
+		modified         bool
+		tenantRequired   *bool
+		wantErr          bool
+		wantPolicyID     string
+		wantAssignmentID string
+		wantInherited    bool
+	}{
+		{name: "member", role: "member", wantPolicyID: "Group_policy", wantAssignmentID: "Group_policy_assignment", wantInherited: true},
+		{name: "owner", role: "owner", wantPolicyID: "Group_policy_owner", wantAssignmentID: "Group_policy_owner_assignment", wantInherited: true},
+		{name: "set for group", role: "member", modified: true, wantPolicyID: "Group_policy", wantAssignmentID: "Group_policy_assignment"},
+		{name: "tenant rule takes precedence", role: "member", modified: true, tenantRequired: toPtr(true), wantPolicyID: "Group_policy", wantAssignmentID: "Group_policy_assignment", wantInherited: true},
+		{name: "not onboarded", role: "member", policyErr: grouppim.ErrPolicyNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			client := newFakeGroupEligibilityClient()
+			client.policyErr = tt.policyErr
+			client.tenantExpirationRequired = tt.tenantRequired
+			if tt.modified {
+				client.expirationRules["Group_policy"] = false
+			}
+			d := &GroupRolePolicy{service: grouppim.NewService(client)}
+
+			var schemaResp datasource.SchemaResponse
+			d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+			if schemaResp.Diagnostics.HasError() {
+				t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+			}
+
+			configState := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			if diags := configState.Set(ctx, GroupRolePolicyModel{
+				GroupID:            customtypes.NewGUIDValue("group-id"),
+				Role:               types.StringValue(tt.role),
+				PolicyID:           types.StringNull(),
+				PolicyAssignmentID: types.StringNull(),
+			}); diags.HasError() {
+				t.Fatalf("unable to set config: %v", diags)
+			}
+
+			resp := &datasource.ReadResponse{State: tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}}
+			d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("got read diagnostics %v, want error %t", resp.Diagnostics, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var read GroupRolePolicyModel
+			resp.State.Get(ctx, &read)
+
+			if read.PolicyID.ValueString() != tt.wantPolicyID || read.PolicyAssignmentID.ValueString() != tt.wantAssignmentID {
+				t.Errorf("got policy_id %s and policy_assignment_id %s, want %q and %q", read.PolicyID, read.PolicyAssignmentID, tt.wantPolicyID, tt.wantAssignmentID)
+			}
+
+			if len(read.Rules) != 2 || read.Rules[0].ID.ValueString() != "Enablement_EndUser_Assignment" || read.Rules[1].ID.ValueString() != "Expiration_Admin_Eligibility" {
+				t.Fatalf("got rules %v, want Enablement_EndUser_Assignment and Expiration_Admin_Eligibility", read.Rules)
+			}
+			if !read.Rules[0].Inherited.ValueBool() {
+				t.Errorf("got Enablement_EndUser_Assignment inherited false, want true")
+			}
+			if read.Rules[1].Inherited.ValueBool() != tt.wantInherited {
+				t.Errorf("got Expiration_Admin_Eligibility inherited %t, want %t", read.Rules[1].Inherited.ValueBool(), tt.wantInherited)
+			}
+		})
+	}
+}
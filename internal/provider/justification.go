@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// justificationPlaceholders maps the placeholders a justification can contain to the environment variables of common
+// CI systems they are expanded from, in order of precedence: Terraform Cloud, GitHub Actions, Azure Pipelines and
+// GitLab CI.
+var justificationPlaceholders = map[string][]string{
+	"run_id":     {"TFC_RUN_ID", "GITHUB_RUN_ID", "BUILD_BUILDID", "CI_PIPELINE_ID"},
+	"workspace":  {"TFC_WORKSPACE_NAME", "TF_WORKSPACE"},
+	"commit_sha": {"TFC_CONFIGURATION_VERSION_GIT_COMMIT_SHA", "GITHUB_SHA", "BUILD_SOURCEVERSION", "CI_COMMIT_SHA"},
+}
+
+// justificationPlaceholderRegex matches the placeholders in a justification, e.g. {run_id}.
+var justificationPlaceholderRegex = regexp.MustCompile(`\{(run_id|workspace|commit_sha)\}`)
+
+// unknownPlaceholderValue replaces placeholders when none of their environment variables is set.
+const unknownPlaceholderValue = "unknown"
+
+// expandJustification replaces the placeholders in a justification with the metadata of the current run, so approvers
+// and auditors can tell which run made a request.
+func expandJustification(justification string) string {
+	return justificationPlaceholderRegex.ReplaceAllStringFunc(justification, func(placeholder string) string {
+		for _, env := range justificationPlaceholders[strings.Trim(placeholder, "{}")] {
+			if v := os.Getenv(env); v != "" {
+				return v
+			}
+		}
+
+		return unknownPlaceholderValue
+	})
+}
+
+// justificationMatches returns whether actual is the expansion of the justification template in any run. Graph returns
+// expanded justifications, which must not be planned as a difference to the configured template.
+func justificationMatches(template, actual string) bool {
+	if !justificationPlaceholderRegex.MatchString(template) {
+		return template == actual
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range justificationPlaceholderRegex.FindAllStringIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		pattern.WriteString(".*")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
+
+	return regexp.MustCompile(pattern.String()).MatchString(actual)
+}
+
+// justificationRules are the rules of the organization justifications must comply with. They are checked when
+// resources are planned, so a non-compliant justification fails the plan instead of a request to Graph.
+type justificationRules struct {
+	// minLength is the minimum number of characters, not counting surrounding whitespace, 0 for no minimum.
+	minLength int
+	// pattern must match the justification, e.g. to require a ticket reference, nil for no pattern.
+	pattern *regexp.Regexp
+}
+
+// check returns an error describing how justification violates the rules, or nil if it complies.
+func (r justificationRules) check(justification string) error {
+	if n := utf8.RuneCountInString(strings.TrimSpace(justification)); n < r.minLength {
+		return fmt.Errorf("the justification has %d characters, but at least %d are required", n, r.minLength)
+	}
+
+	if r.pattern != nil && !r.pattern.MatchString(justification) {
+		return fmt.Errorf("the justification does not match the required pattern %q", r.pattern)
+	}
+
+	return nil
+}
+
+// validate adds an error to diags for every justification at paths which is configured and violates the rules, with
+// the placeholders expanded as at apply. A justification unchanged from state is not checked, so tightening the rules
+// does not fail the plans of resources which are not sending their justification again.
+func (r justificationRules) validate(ctx context.Context, config tfsdk.Config, state tfsdk.State, diags *diag.Diagnostics, paths ...path.Path) {
+	if r.minLength == 0 && r.pattern == nil {
+		return
+	}
+
+	for _, p := range paths {
+		var v types.String
+		diags.Append(config.GetAttribute(ctx, p, &v)...)
+		if v.IsNull() || v.IsUnknown() {
+			continue
+		}
+
+		if !state.Raw.IsNull() {
+			var prior types.String
+			diags.Append(state.GetAttribute(ctx, p, &prior)...)
+			if prior.Equal(v) {
+				continue
+			}
+		}
+
+		if err := r.check(expandJustification(v.ValueString())); err != nil {
+			diags.AddAttributeError(p, "Non-compliant justification", fmt.Sprintf("The justification does not comply with the justification rules of the provider: %s.", err))
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestExpandJustification(t *testing.T) {
+	// The tests may run in CI, where some of the variables are set.
+	for _, envs := range justificationPlaceholders {
+		for _, env := range envs {
+			t.Setenv(env, "")
+		}
+	}
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("TFC_RUN_ID", "run-abc")
+	t.Setenv("GITHUB_SHA", "0123abc")
+
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"no placeholders":   {in: "Access for on-call", want: "Access for on-call"},
+		"precedence":        {in: "Terraform run {run_id}", want: "Terraform run run-abc"},
+		"several":           {in: "{run_id}@{commit_sha}", want: "run-abc@0123abc"},
+		"unset":             {in: "workspace {workspace}", want: "workspace unknown"},
+		"not a placeholder": {in: "{ticket} and {run_id", want: "{ticket} and {run_id"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := expandJustification(tt.in); got != tt.want {
+				t.Errorf("expandJustification(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJustificationMatches(t *testing.T) {
+	tests := map[string]struct {
+		template string
+		actual   string
+		want     bool
+	}{
+		"equal":            {template: "Access for on-call", actual: "Access for on-call", want: true},
+		"different":        {template: "Access for on-call", actual: "Access", want: false},
+		"expanded":         {template: "Run {run_id} (commit {commit_sha})", actual: "Run 42 (commit 0123abc)", want: true},
+		"expanded unknown": {template: "Run {run_id}", actual: "Run unknown", want: true},
+		"changed text":     {template: "Run {run_id}", actual: "Job 42", want: false},
+		"regex characters": {template: "[{workspace}] a.b", actual: "[prod] axb", want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := justificationMatches(tt.template, tt.actual); got != tt.want {
+				t.Errorf("justificationMatches(%q, %q) = %t, want %t", tt.template, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJustificationRulesCheck(t *testing.T) {
+	rules := justificationRules{minLength: 10, pattern: regexp.MustCompile(`\bCHG[0-9]{4}\b`)}
+
+	tests := map[string]struct {
+		in      string
+		wantErr bool
+	}{
+		"compliant":     {in: "Deploy CHG1234"},
+		"too short":     {in: "CHG1234", wantErr: true},
+		"whitespace":    {in: "   CHG1234    ", wantErr: true},
+		"no ticket":     {in: "Deploy the change", wantErr: true},
+		"partial match": {in: "Deploy CHG12345", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := rules.check(tt.in); (err != nil) != tt.wantErr {
+				t.Errorf("check(%q) = %v, want error %t", tt.in, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := (justificationRules{}).check(""); err != nil {
+		t.Errorf("got %v without rules, want every justification to comply", err)
+	}
+}
+
+func TestJustificationRulesValidate(t *testing.T) {
+	rules := justificationRules{minLength: 10}
+
+	tests := []struct {
+		name          string
+		justification types.String
+		// prior is the justification in state, the eligibility is created when not set.
+		prior   *types.String
+		wantErr bool
+	}{
+		{name: "compliant", justification: types.StringValue("Access for on-call")},
+		{name: "non-compliant", justification: types.StringValue("on-call"), wantErr: true},
+		{name: "default", justification: types.StringNull()},
+		{name: "unknown", justification: types.StringUnknown()},
+		{name: "unchanged", justification: types.StringValue("on-call"), prior: toPtr(types.StringValue("on-call"))},
+		{name: "changed", justification: types.StringValue("on-call"), prior: toPtr(types.StringValue("Access for on-call")), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			_, empty := testGroupEligibleAssignmentResource(t, newFakeGroupEligibilityClient())
+
+			model := testGroupEligibleAssignmentModel()
+			model.Justification = tt.justification
+			config := testGroupEligibleAssignmentPlan(t, empty, model)
+
+			state := empty
+			if tt.prior != nil {
+				model.Justification = *tt.prior
+				state = tfsdk.State{Schema: empty.Schema, Raw: testGroupEligibleAssignmentPlan(t, empty, model).Raw}
+			}
+
+			var diags diag.Diagnostics
+			rules.validate(ctx, tfsdk.Config{Schema: config.Schema, Raw: config.Raw}, state, &diags, path.Root("justification"))
+			if diags.HasError() != tt.wantErr {
+				t.Errorf("got diagnostics %v, want error %t", diags, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderJustificationRules(t *testing.T) {
+	t.Setenv("AZUREPIM_JUSTIFICATION_MIN_LENGTH", "12")
+	t.Setenv("AZUREPIM_JUSTIFICATION_PATTERN", "CHG[0-9]+")
+
+	rules, err := AzurepimProviderModel{JustificationMin: types.Int64Null(), JustificationPattern: types.StringNull()}.justificationRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.minLength != 12 || rules.pattern.String() != "CHG[0-9]+" {
+		t.Errorf("got minimum length %d and pattern %s from the environment, want 12 and CHG[0-9]+", rules.minLength, rules.pattern)
+	}
+
+	// The provider block takes precedence over the environment.
+	rules, err = AzurepimProviderModel{JustificationMin: types.Int64Value(3), JustificationPattern: types.StringValue("INC[0-9]+")}.justificationRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.minLength != 3 || rules.pattern.String() != "INC[0-9]+" {
+		t.Errorf("got minimum length %d and pattern %s, want those of the provider block", rules.minLength, rules.pattern)
+	}
+
+	if _, err := (AzurepimProviderModel{JustificationPattern: types.StringValue("CHG[")}).justificationRules(); err == nil {
+		t.Error("got no error for an invalid pattern")
+	}
+}
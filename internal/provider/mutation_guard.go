@@ -0,0 +1,36 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	khttp "github.com/microsoft/kiota-http-go"
+)
+
+// errMutationNotConfirmed is returned for mutating Graph calls while require_mutation_confirmation is set and
+// mutation_confirmation does not confirm the tenant.
+var errMutationNotConfirmed = errors.New("mutating Microsoft Graph calls are blocked, as require_mutation_confirmation is set and mutation_confirmation is not the tenant ID")
+
+// mutationsConfirmed returns whether mutating Graph calls may be made in tenantID. They may unless confirmation is
+// required, and then only when confirmation is the tenant ID, so a confirmation meant for one tenant does not unlock
+// another.
+func mutationsConfirmed(required bool, tenantID, confirmation string) bool {
+	return !required || (tenantID != "" && strings.EqualFold(strings.TrimSpace(confirmation), tenantID))
+}
+
+// mutationGuardMiddleware fails every Graph call which is not a read before it is sent, whether made through the SDK
+// or raw. It is the first middleware, so blocked calls are neither retried nor audited.
+type mutationGuardMiddleware struct{}
+
+func (m *mutationGuardMiddleware) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, errMutationNotConfirmed)
+	}
+
+	return pipeline.Next(req, middlewareIndex)
+}
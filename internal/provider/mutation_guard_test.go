@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+)
+
+func TestMutationsConfirmed(t *testing.T) {
+	tests := []struct {
+		name         string
+		required     bool
+		tenantID     string
+		confirmation string
+		want         bool
+	}{
+		{name: "not required", want: true},
+		{name: "confirmed", required: true, tenantID: "tenant-id", confirmation: " TENANT-ID ", want: true},
+		{name: "not confirmed", required: true, tenantID: "tenant-id"},
+		{name: "confirmed for another tenant", required: true, tenantID: "tenant-id", confirmation: "other-tenant-id"},
+		{name: "unknown tenant", required: true, confirmation: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mutationsConfirmed(tt.required, tt.tenantID, tt.confirmation); got != tt.want {
+				t.Errorf("got confirmed %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMutationGuardBlocksGraphWrites(t *testing.T) {
+	ctx := context.Background()
+
+	var writes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writes.Add(1)
+		}
+		writeTestJSON(w, http.StatusOK, map[string]any{"value": []any{}})
+	}))
+	t.Cleanup(server.Close)
+
+	pd := &providerData{baseURL: server.URL + "/beta", blockMutations: true}
+	client, err := newGraphClient(pd.clientsOptions(&fakeCredential{token: "token"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reads are still made, so plans and refreshes work.
+	if err := client.ListAllGroups(ctx, "securityEnabled eq true", func([]graphmodels.Groupable) error { return nil }); err != nil {
+		t.Fatalf("got error %v listing groups, want reads to pass", err)
+	}
+
+	if err := client.CancelEligibilityScheduleRequest(ctx, "request-id"); !errors.Is(err, errMutationNotConfirmed) {
+		t.Errorf("got error %v, want the write blocked", err)
+	}
+
+	if n := writes.Load(); n != 0 {
+		t.Errorf("got %d writes sent to Graph, want none", n)
+	}
+}
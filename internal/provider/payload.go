@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	jsonserialization "github.com/microsoft/kiota-serialization-json-go"
+)
+
+// rawPayloadValue returns the JSON of v when debug is enabled, and null otherwise.
+// Serialization failures are logged rather than failing the operation, as the payload is only informational.
+func rawPayloadValue(ctx context.Context, debug types.Bool, v serialization.Parsable) types.String {
+	if !debug.ValueBool() {
+		return types.StringNull()
+	}
+
+	payload, err := serializeGraphPayload(v)
+	if err != nil {
+		tflog.Warn(ctx, "unable to serialize graph payload", map[string]any{"error": sanitizeError(err)})
+		return types.StringNull()
+	}
+
+	return types.StringValue(sanitize(payload))
+}
+
+// serializeGraphPayload serializes a Graph SDK model the same way the SDK does when sending it.
+func serializeGraphPayload(v serialization.Parsable) (string, error) {
+	w := jsonserialization.NewJsonSerializationWriter()
+	defer w.Close()
+
+	if err := w.WriteObjectValue("", v); err != nil {
+		return "", fmt.Errorf("unable to write object: %w", err)
+	}
+
+	b, err := w.GetSerializedContent()
+	if err != nil {
+		return "", fmt.Errorf("unable to get serialized content: %w", err)
+	}
+
+	return string(b), nil
+}
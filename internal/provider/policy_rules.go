@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "strings"
+
+// The policy rule types are written by hand because the SDK data model for these endpoints had several missing fields.
+// The JSON they produce is covered by golden files in testdata/policy_rules.
+
+// policyRuleTarget is the unifiedRoleManagementPolicyRuleTarget a rule applies to.
+type policyRuleTarget struct {
+	Caller              string   `json:"caller"`
+	Operations          []string `json:"operations"`
+	Level               string   `json:"level"`
+	InheritableSettings []any    `json:"inheritableSettings"`
+	EnforcedSettings    []any    `json:"enforcedSettings"`
+}
+
+// expirationPolicyRule is a unifiedRoleManagementPolicyExpirationRule.
+type expirationPolicyRule struct {
+	OdataType            string           `json:"@odata.type"`
+	ID                   string           `json:"id"`
+	IsExpirationRequired bool             `json:"isExpirationRequired"`
+	MaximumDuration      string           `json:"maximumDuration"`
+	Target               policyRuleTarget `json:"target"`
+}
+
+// newExpirationAdminEligibilityRule returns the rule controlling whether eligible assignments made by admins must expire.
+func newExpirationAdminEligibilityRule(isExpirationRequired bool) expirationPolicyRule {
+	return newExpirationRule("Expiration_Admin_Eligibility", isExpirationRequired, "P365D")
+}
+
+// enablementPolicyRule is a unifiedRoleManagementPolicyEnablementRule.
+type enablementPolicyRule struct {
+	OdataType    string           `json:"@odata.type"`
+	ID           string           `json:"id"`
+	EnabledRules []string         `json:"enabledRules"`
+	Target       policyRuleTarget `json:"target"`
+}
+
+// approvalPolicyRule is a unifiedRoleManagementPolicyApprovalRule.
+type approvalPolicyRule struct {
+	OdataType string           `json:"@odata.type"`
+	ID        string           `json:"id"`
+	Setting   approvalSettings `json:"setting"`
+	Target    policyRuleTarget `json:"target"`
+}
+
+// approvalSettings are the approvalSettings of an approval rule.
+type approvalSettings struct {
+	IsApprovalRequired               bool            `json:"isApprovalRequired"`
+	IsApprovalRequiredForExtension   bool            `json:"isApprovalRequiredForExtension"`
+	IsRequestorJustificationRequired bool            `json:"isRequestorJustificationRequired"`
+	ApprovalMode                     string          `json:"approvalMode"`
+	ApprovalStages                   []approvalStage `json:"approvalStages"`
+}
+
+// approvalStage is a stage of the approval of a request.
+type approvalStage struct {
+	ApprovalStageTimeOutInDays      int32             `json:"approvalStageTimeOutInDays"`
+	IsApproverJustificationRequired bool              `json:"isApproverJustificationRequired"`
+	EscalationTimeInMinutes         int32             `json:"escalationTimeInMinutes"`
+	IsEscalationEnabled             bool              `json:"isEscalationEnabled"`
+	PrimaryApprovers                []approverSubject `json:"primaryApprovers"`
+	EscalationApprovers             []approverSubject `json:"escalationApprovers"`
+}
+
+// approverSubject is a subjectSet approving requests, either a single user or the members of a group.
+type approverSubject struct {
+	OdataType string `json:"@odata.type"`
+	UserID    string `json:"userId,omitempty"`
+	GroupID   string `json:"groupId,omitempty"`
+}
+
+// newSingleUserApprover returns the approver subject of a user.
+func newSingleUserApprover(userID string) approverSubject {
+	return approverSubject{OdataType: "#microsoft.graph.singleUser", UserID: userID}
+}
+
+// newGroupMembersApprover returns the approver subject of the members of a group.
+func newGroupMembersApprover(groupID string) approverSubject {
+	return approverSubject{OdataType: "#microsoft.graph.groupMembers", GroupID: groupID}
+}
+
+// newApprovalRule returns the approval rule with the given ID. Requests are approved in a single stage by any of
+// approvers, within a day, when isApprovalRequired is set.
+func newApprovalRule(ruleID string, isApprovalRequired bool, approvers []approverSubject) approvalPolicyRule {
+	if approvers == nil {
+		approvers = []approverSubject{}
+	}
+
+	return approvalPolicyRule{
+		OdataType: "#microsoft.graph.unifiedRoleManagementPolicyApprovalRule",
+		ID:        ruleID,
+		Setting: approvalSettings{
+			IsApprovalRequired:               isApprovalRequired,
+			IsRequestorJustificationRequired: true,
+			ApprovalMode:                     "SingleStage",
+			ApprovalStages: []approvalStage{{
+				ApprovalStageTimeOutInDays:      1,
+				IsApproverJustificationRequired: true,
+				PrimaryApprovers:                approvers,
+				EscalationApprovers:             []approverSubject{},
+			}},
+		},
+		Target: newPolicyRuleTarget(ruleID),
+	}
+}
+
+// notificationPolicyRule is a unifiedRoleManagementPolicyNotificationRule.
+type notificationPolicyRule struct {
+	OdataType                  string           `json:"@odata.type"`
+	ID                         string           `json:"id"`
+	NotificationType           string           `json:"notificationType"`
+	RecipientType              string           `json:"recipientType"`
+	NotificationLevel          string           `json:"notificationLevel"`
+	IsDefaultRecipientsEnabled bool             `json:"isDefaultRecipientsEnabled"`
+	NotificationRecipients     []string         `json:"notificationRecipients"`
+	Target                     policyRuleTarget `json:"target"`
+}
+
+// newNotificationRule returns the notification rule with the given ID, e.g. Notification_Admin_EndUser_Assignment.
+// The recipient type is taken from the ID. Notifications are sent by email, to the default recipients when
+// isDefaultRecipientsEnabled is set and to the addresses in recipients.
+func newNotificationRule(ruleID, notificationLevel string, isDefaultRecipientsEnabled bool, recipients []string) notificationPolicyRule {
+	if recipients == nil {
+		recipients = []string{}
+	}
+
+	var recipientType string
+	if parts := strings.Split(ruleID, "_"); len(parts) == 4 {
+		recipientType = parts[1]
+	}
+
+	return notificationPolicyRule{
+		OdataType:                  "#microsoft.graph.unifiedRoleManagementPolicyNotificationRule",
+		ID:                         ruleID,
+		NotificationType:           "Email",
+		RecipientType:              recipientType,
+		NotificationLevel:          notificationLevel,
+		IsDefaultRecipientsEnabled: isDefaultRecipientsEnabled,
+		NotificationRecipients:     recipients,
+		Target:                     newPolicyRuleTarget(ruleID),
+	}
+}
+
+// authenticationContextPolicyRule is a unifiedRoleManagementPolicyAuthenticationContextRule.
+type authenticationContextPolicyRule struct {
+	OdataType  string           `json:"@odata.type"`
+	ID         string           `json:"id"`
+	IsEnabled  bool             `json:"isEnabled"`
+	ClaimValue string           `json:"claimValue"`
+	Target     policyRuleTarget `json:"target"`
+}
+
+// newAuthenticationContextRule returns the authentication context rule with the given ID. An empty claimValue
+// disables the rule.
+func newAuthenticationContextRule(ruleID, claimValue string) authenticationContextPolicyRule {
+	return authenticationContextPolicyRule{
+		OdataType:  "#microsoft.graph.unifiedRoleManagementPolicyAuthenticationContextRule",
+		ID:         ruleID,
+		IsEnabled:  claimValue != "",
+		ClaimValue: claimValue,
+		Target:     newPolicyRuleTarget(ruleID),
+	}
+}
+
+// newPolicyRuleTarget returns the target of the rule with the given ID. Graph names rules after their type, caller and
+// level, e.g. Expiration_EndUser_Assignment, and notification rules after their recipient as well, e.g.
+// Notification_Admin_EndUser_Assignment.
+func newPolicyRuleTarget(ruleID string) policyRuleTarget {
+	target := policyRuleTarget{
+		Operations:          []string{"All"},
+		EnforcedSettings:    []any{},
+		InheritableSettings: []any{},
+	}
+	if parts := strings.Split(ruleID, "_"); len(parts) == 3 || len(parts) == 4 {
+		target.Caller = parts[len(parts)-2]
+		target.Level = parts[len(parts)-1]
+	}
+
+	return target
+}
+
+// newExpirationRule returns the expiration rule with the given ID.
+func newExpirationRule(ruleID string, isExpirationRequired bool, maximumDuration string) expirationPolicyRule {
+	return expirationPolicyRule{
+		OdataType:            "#microsoft.graph.unifiedRoleManagementPolicyExpirationRule",
+		ID:                   ruleID,
+		IsExpirationRequired: isExpirationRequired,
+		MaximumDuration:      maximumDuration,
+		Target:               newPolicyRuleTarget(ruleID),
+	}
+}
+
+// newEnablementRule returns the enablement rule with the given ID.
+func newEnablementRule(ruleID string, enabledRules []string) enablementPolicyRule {
+	if enabledRules == nil {
+		enabledRules = []string{}
+	}
+
+	return enablementPolicyRule{
+		OdataType:    "#microsoft.graph.unifiedRoleManagementPolicyEnablementRule",
+		ID:           ruleID,
+		EnabledRules: enabledRules,
+		Target:       newPolicyRuleTarget(ruleID),
+	}
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyRuleJSON(t *testing.T) {
+	tests := map[string]any{
+		"expiration_admin_eligibility_required.json":     newExpirationAdminEligibilityRule(true),
+		"expiration_admin_eligibility_not_required.json": newExpirationAdminEligibilityRule(false),
+		"expiration_enduser_assignment.json":             newExpirationRule("Expiration_EndUser_Assignment", true, "PT8H"),
+		"enablement_enduser_assignment.json":             newEnablementRule("Enablement_EndUser_Assignment", []string{"Justification", "MultiFactorAuthentication"}),
+		"enablement_enduser_assignment_none.json":        newEnablementRule("Enablement_EndUser_Assignment", nil),
+		"approval_enduser_assignment.json": newApprovalRule("Approval_EndUser_Assignment", true, []approverSubject{
+			newSingleUserApprover("00000000-0000-0000-0000-000000000001"),
+			newGroupMembersApprover("00000000-0000-0000-0000-000000000002"),
+		}),
+		"approval_enduser_assignment_none.json":                  newApprovalRule("Approval_EndUser_Assignment", false, nil),
+		"notification_admin_enduser_assignment.json":             newNotificationRule("Notification_Admin_EndUser_Assignment", "Critical", true, []string{"pim@example.com"}),
+		"notification_requestor_admin_eligibility.json":          newNotificationRule("Notification_Requestor_Admin_Eligibility", "All", false, nil),
+		"authenticationcontext_enduser_assignment.json":          newAuthenticationContextRule("AuthenticationContext_EndUser_Assignment", "c1"),
+		"authenticationcontext_enduser_assignment_disabled.json": newAuthenticationContextRule("AuthenticationContext_EndUser_Assignment", ""),
+	}
+
+	for name, rule := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := json.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The golden files are indented for readability, the comparison is done on the exact bytes sent to Graph.
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, got, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			indented.WriteByte('\n')
+			assertGolden(t, filepath.Join("policy_rules", name), indented.String())
+
+			want, err := os.ReadFile(filepath.Join("testdata", "policy_rules", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, want); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, compact.Bytes()) {
+				t.Errorf("got %s, want %s", got, compact.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+)
+
+// activationEnabledRules are the values of activation_enabled_rules.
+var activationEnabledRules = []string{"Justification", "MultiFactorAuthentication", "Ticketing"}
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &PolicyTemplate{}
+
+func NewPolicyTemplate() resource.Resource {
+	return &PolicyTemplate{}
+}
+
+// PolicyTemplate defines the resource implementation.
+type PolicyTemplate struct {
+	service *pimpolicy.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// PolicyTemplateModel describes the resource data model.
+type PolicyTemplateModel struct {
+	Id               types.String        `tfsdk:"id"`
+	Name             types.String        `tfsdk:"name"`
+	DirectoryRoleIDs types.Set           `tfsdk:"directory_role_ids"`
+	GroupIDs         types.Set           `tfsdk:"group_ids"`
+	Rules            *PolicyTemplateRule `tfsdk:"rules"`
+	DriftedTargets   types.Set           `tfsdk:"drifted_targets"`
+}
+
+// PolicyTemplateRule describes the shared rules of a policy template.
+type PolicyTemplateRule struct {
+	EligibilityExpirationRequired types.Bool   `tfsdk:"eligibility_expiration_required"`
+	EligibilityMaximumDuration    types.String `tfsdk:"eligibility_maximum_duration"`
+	ActivationMaximumDuration     types.String `tfsdk:"activation_maximum_duration"`
+	ActivationEnabledRules        types.Set    `tfsdk:"activation_enabled_rules"`
+}
+
+// throttleTarget describes the template in throttling warnings.
+func (m PolicyTemplateModel) throttleTarget() string {
+	return fmt.Sprintf("policy template %s", m.Name.ValueString())
+}
+
+func (r *PolicyTemplate) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_policy_template"
+}
+
+func (r *PolicyTemplate) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Keeps the role management policies of many Microsoft Entra roles and PIM enabled groups in sync with a shared set of
+rules, so a single baseline can govern them without repeating it per role or group.
+
+Only the rules set in ` + "`rules`" + ` are managed, other rules of the policies are left as they are. Targets whose
+policy differs from the template are reported in ` + "`drifted_targets`" + ` during refresh, and updated on the next
+apply. Destroying the resource leaves the policies as they are.
+
+For groups the policy of the member role is managed.
+
+It requires the following graph permissions:
+- RoleManagementPolicy.ReadWrite.Directory for directory roles
+- RoleManagementPolicy.ReadWrite.AzureADGroup for groups
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The ID of the resource is the `name` value.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the template, e.g. `baseline`. Only used to identify the resource.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"directory_role_ids": schema.SetAttribute{
+				MarkdownDescription: "The role definition IDs of the Microsoft Entra roles whose policy in the whole tenant follows the template.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"group_ids": schema.SetAttribute{
+				MarkdownDescription: "The object IDs of the PIM enabled groups whose member policy follows the template.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"rules": schema.SingleNestedAttribute{
+				MarkdownDescription: "The rules every target's policy is kept in sync with. Rules which are not set are left as they are.",
+				Required:            true,
+				Attributes: map[string]schema.Attribute{
+					"eligibility_expiration_required": schema.BoolAttribute{
+						MarkdownDescription: "Whether eligible assignments made by admins must expire.",
+						Optional:            true,
+					},
+					"eligibility_maximum_duration": schema.StringAttribute{
+						MarkdownDescription: "How long eligible assignments made by admins can last at most, as an ISO 8601 duration such as `P365D`.",
+						Optional:            true,
+						Validators:          []validator.String{isoDurationValidator{}},
+					},
+					"activation_maximum_duration": schema.StringAttribute{
+						MarkdownDescription: "How long an activation can last at most, as an ISO 8601 duration such as `PT8H`.",
+						Optional:            true,
+						Validators:          []validator.String{isoDurationValidator{}},
+					},
+					"activation_enabled_rules": schema.SetAttribute{
+						MarkdownDescription: "What principals must provide to activate, any of `Justification`, `MultiFactorAuthentication` and `Ticketing`. An empty set requires nothing.",
+						Optional:            true,
+						ElementType:         types.StringType,
+						Validators:          []validator.Set{setvalidator.ValueStringsAre(stringvalidator.OneOf(activationEnabledRules...))},
+					},
+				},
+			},
+			"drifted_targets": schema.SetAttribute{
+				MarkdownDescription: "The targets whose policy differs from the template, as `directoryRole/{id}` or `group/{id}`. Always empty after apply, refresh fills it when policies are changed outside of Terraform.",
+				Computed:            true,
+				ElementType:         types.StringType,
+				Default:             setdefault.StaticValue(types.SetValueMust(types.StringType, nil)),
+			},
+		},
+	}
+}
+
+func (r *PolicyTemplate) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	r.service = pimpolicy.NewService(pd.clients.Policies)
+	r.deferredReason = pd.deferredReason
+}
+
+func (r *PolicyTemplate) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data PolicyTemplateModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(data.Name.ValueString())
+
+	resp.Diagnostics.Append(r.apply(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *PolicyTemplate) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.deferredReason != "" {
+		deferResourceRead(req, resp, r.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data PolicyTemplateModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	targets, template, diags := r.expand(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var drifted []string
+	for _, target := range targets {
+		rules, err := r.service.Drift(ctx, target, template)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", fmt.Sprintf("Unable to read the policy of %s: %s", target, sanitizeError(err)))
+			return
+		}
+
+		if len(rules) > 0 {
+			tflog.Info(ctx, "policy differs from the template", map[string]any{"target": target.String(), "rules": rules})
+			drifted = append(drifted, target.String())
+		}
+	}
+	sort.Strings(drifted)
+
+	data.DriftedTargets, diags = types.SetValueFrom(ctx, types.StringType, drifted)
+	resp.Diagnostics.Append(diags...)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *PolicyTemplate) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, throttles := withThrottleRecorder(ctx)
+
+	var data PolicyTemplateModel
+	defer func() { throttles.addWarnings(&resp.Diagnostics, data.throttleTarget()) }()
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *PolicyTemplate) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "no longer applying the policy template, the policies are left unchanged")
+}
+
+// expand returns the targets and template of m.
+func (r *PolicyTemplate) expand(ctx context.Context, m PolicyTemplateModel) ([]pimpolicy.Target, pimpolicy.Template, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var roleIDs, groupIDs []string
+	diags.Append(m.DirectoryRoleIDs.ElementsAs(ctx, &roleIDs, true)...)
+	diags.Append(m.GroupIDs.ElementsAs(ctx, &groupIDs, true)...)
+
+	var template pimpolicy.Template
+	if m.Rules != nil {
+		template.EligibilityExpirationRequired = m.Rules.EligibilityExpirationRequired.ValueBoolPointer()
+		template.EligibilityMaximumDuration = m.Rules.EligibilityMaximumDuration.ValueString()
+		template.ActivationMaximumDuration = m.Rules.ActivationMaximumDuration.ValueString()
+		if !m.Rules.ActivationEnabledRules.IsNull() {
+			template.ActivationEnabledRules = []string{}
+			diags.Append(m.Rules.ActivationEnabledRules.ElementsAs(ctx, &template.ActivationEnabledRules, false)...)
+		}
+	}
+
+	if diags.HasError() {
+		return nil, template, diags
+	}
+
+	sort.Strings(roleIDs)
+	sort.Strings(groupIDs)
+	sort.Strings(template.ActivationEnabledRules)
+
+	targets := make([]pimpolicy.Target, 0, len(roleIDs)+len(groupIDs))
+	for _, id := range roleIDs {
+		targets = append(targets, pimpolicy.Target{Kind: pimpolicy.TargetDirectoryRole, ID: id})
+	}
+	for _, id := range groupIDs {
+		targets = append(targets, pimpolicy.Target{Kind: pimpolicy.TargetGroup, ID: id})
+	}
+
+	return targets, template, diags
+}
+
+// apply updates the policy of every target of m which differs from the template, and empties drifted_targets.
+func (r *PolicyTemplate) apply(ctx context.Context, m *PolicyTemplateModel) diag.Diagnostics {
+	targets, template, diags := r.expand(ctx, *m)
+	if diags.HasError() {
+		return diags
+	}
+
+	for _, target := range targets {
+		rules, err := r.service.Apply(ctx, target, template)
+		if err != nil {
+			diags.AddError("Client call failed", fmt.Sprintf("Unable to apply the template to %s: %s", target, sanitizeError(err)))
+			return diags
+		}
+
+		if len(rules) > 0 {
+			tflog.Info(ctx, "updated policy to the template", map[string]any{"target": target.String(), "rules": rules})
+		}
+	}
+
+	m.DriftedTargets = types.SetValueMust(types.StringType, nil)
+
+	return diags
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/pimpolicy"
+)
+
+// policyFilterClauseRegex matches a single "property eq 'value'" clause of an OData filter.
+var policyFilterClauseRegex = regexp.MustCompile(`(\w+) eq '([^']*)'`)
+
+// fakePolicyClient keeps the rules of role management policies in memory, keyed by policy ID and rule ID. The policy
+// of a target is named after its roleDefinitionId and scopeId. Rules which were never updated have the defaults of a
+// new policy.
+type fakePolicyClient struct {
+	rules   map[string]pimpolicy.Rule
+	updates int
+}
+
+func newFakePolicyClient() *fakePolicyClient {
+	return &fakePolicyClient{rules: map[string]pimpolicy.Rule{}}
+}
+
+func (f *fakePolicyClient) rule(policyID, ruleID string) pimpolicy.Rule {
+	if r, ok := f.rules[policyID+"/"+ruleID]; ok {
+		return r
+	}
+
+	switch ruleID {
+	case pimpolicy.RuleExpirationAdminEligibility:
+		return pimpolicy.Rule{ID: ruleID, IsExpirationRequired: true, MaximumDuration: "P365D"}
+	case pimpolicy.RuleExpirationEndUserAssignment:
+		return pimpolicy.Rule{ID: ruleID, IsExpirationRequired: true, MaximumDuration: "PT8H"}
+	default:
+		return pimpolicy.Rule{ID: ruleID, EnabledRules: []string{"Justification"}}
+	}
+}
+
+func (f *fakePolicyClient) ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	clauses := map[string]string{}
+	for _, m := range policyFilterClauseRegex.FindAllStringSubmatch(filter, -1) {
+		clauses[m[1]] = m[2]
+	}
+	policyID := clauses["roleDefinitionId"] + "@" + clauses["scopeId"]
+
+	var rules []graphmodels.UnifiedRoleManagementPolicyRuleable
+	for _, id := range []string{pimpolicy.RuleExpirationAdminEligibility, pimpolicy.RuleExpirationEndUserAssignment} {
+		r := f.rule(policyID, id)
+		duration, err := serialization.ParseISODuration(r.MaximumDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := graphmodels.NewUnifiedRoleManagementPolicyExpirationRule()
+		rule.SetId(toPtr(id))
+		rule.SetIsExpirationRequired(toPtr(r.IsExpirationRequired))
+		rule.SetMaximumDuration(duration)
+		rules = append(rules, rule)
+	}
+
+	enablement := graphmodels.NewUnifiedRoleManagementPolicyEnablementRule()
+	enablement.SetId(toPtr(pimpolicy.RuleEnablementEndUserAssignment))
+	enablement.SetEnabledRules(f.rule(policyID, pimpolicy.RuleEnablementEndUserAssignment).EnabledRules)
+	rules = append(rules, enablement)
+
+	policy := graphmodels.NewUnifiedRoleManagementPolicy()
+	policy.SetId(toPtr(policyID))
+	policy.SetRules(rules)
+
+	a := graphmodels.NewUnifiedRoleManagementPolicyAssignment()
+	a.SetPolicyId(toPtr(policyID))
+	a.SetPolicy(policy)
+
+	return []graphmodels.UnifiedRoleManagementPolicyAssignmentable{a}, nil
+}
+
+func (f *fakePolicyClient) UpdatePolicyRule(ctx context.Context, policyID string, rule pimpolicy.Rule) error {
+	f.rules[policyID+"/"+rule.ID] = rule
+	f.updates++
+	return nil
+}
+
+func TestPolicyTemplate(t *testing.T) {
+	ctx := context.Background()
+	client := newFakePolicyClient()
+	r := &PolicyTemplate{service: pimpolicy.NewService(client)}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+	empty := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+
+	model := PolicyTemplateModel{
+		Id:               types.StringUnknown(),
+		Name:             types.StringValue("baseline"),
+		DirectoryRoleIDs: types.SetValueMust(types.StringType, []attr.Value{types.StringValue("role-1")}),
+		GroupIDs:         types.SetValueMust(types.StringType, []attr.Value{types.StringValue("group-1")}),
+		Rules: &PolicyTemplateRule{
+			EligibilityExpirationRequired: types.BoolNull(),
+			EligibilityMaximumDuration:    types.StringNull(),
+			// Graph formats the duration as PT8H, which must not be reported as drift.
+			ActivationMaximumDuration: types.StringValue("PT480M"),
+			ActivationEnabledRules: types.SetValueMust(types.StringType, []attr.Value{
+				types.StringValue("MultiFactorAuthentication"),
+				types.StringValue("Justification"),
+			}),
+		},
+		DriftedTargets: types.SetValueMust(types.StringType, nil),
+	}
+
+	plan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	if diags := plan.Set(ctx, model); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	createResp := &fwresource.CreateResponse{State: empty}
+	r.Create(ctx, fwresource.CreateRequest{Plan: plan}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected create diagnostics: %v", createResp.Diagnostics)
+	}
+
+	// Only the enablement rule of both targets differs from the template.
+	if client.updates != 2 {
+		t.Errorf("got %d rule updates, want 2", client.updates)
+	}
+	if got := client.rule("member@group-1", pimpolicy.RuleEnablementEndUserAssignment).EnabledRules; strings.Join(got, ",") != "Justification,MultiFactorAuthentication" {
+		t.Errorf("got enabled rules %v for the group, want Justification and MultiFactorAuthentication", got)
+	}
+
+	// An administrator no longer requires MFA for the directory role in the portal.
+	client.rules["role-1@//"+pimpolicy.RuleEnablementEndUserAssignment] = pimpolicy.Rule{ID: pimpolicy.RuleEnablementEndUserAssignment, EnabledRules: []string{"Justification"}}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", readResp.Diagnostics)
+	}
+
+	var read PolicyTemplateModel
+	readResp.State.Get(ctx, &read)
+
+	var drifted []string
+	read.DriftedTargets.ElementsAs(ctx, &drifted, false)
+	if len(drifted) != 1 || drifted[0] != "directoryRole/role-1" {
+		t.Fatalf("got drifted_targets %v, want [directoryRole/role-1]", drifted)
+	}
+
+	updatePlan := tfsdk.Plan{Schema: empty.Schema, Raw: empty.Raw}
+	model.Id = types.StringValue("baseline")
+	if diags := updatePlan.Set(ctx, model); diags.HasError() {
+		t.Fatalf("unable to set plan: %v", diags)
+	}
+
+	updateResp := &fwresource.UpdateResponse{State: readResp.State}
+	r.Update(ctx, fwresource.UpdateRequest{State: readResp.State, Plan: updatePlan}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected update diagnostics: %v", updateResp.Diagnostics)
+	}
+
+	if client.updates != 3 {
+		t.Errorf("got %d rule updates, want the drifted rule updated once more", client.updates)
+	}
+}
@@ -5,12 +5,34 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	azcorepolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/clients"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/armpim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
 )
 
 // Ensure AzurepimProvider satisfies various provider interfaces.
@@ -22,10 +44,114 @@ type AzurepimProvider struct {
 	// provider is built and ran locally, and "test" when running acceptance
 	// testing.
 	version string
+
+	// transport and credential replace the network transport and credential chain, used to record and replay acceptance tests.
+	transport  http.RoundTripper
+	credential azcore.TokenCredential
 }
 
 // AzurepimProviderModel describes the provider data model.
 type AzurepimProviderModel struct {
+	CorrelationID        types.String `tfsdk:"correlation_id"`
+	AuditLogPath         types.String `tfsdk:"audit_log_path"`
+	TenantID             types.String `tfsdk:"tenant_id"`
+	ClientID             types.String `tfsdk:"client_id"`
+	ClientSecret         types.String `tfsdk:"client_secret"`
+	Environment          types.String `tfsdk:"environment"`
+	MaxRetries           types.Int64  `tfsdk:"max_retries"`
+	GraphEndpoint        types.String `tfsdk:"graph_endpoint"`
+	DefaultJustification types.String `tfsdk:"default_justification"`
+	TicketSystem         types.String `tfsdk:"ticket_system"`
+	TicketNumber         types.String `tfsdk:"ticket_number"`
+	StrictPolicy         types.Bool   `tfsdk:"strict_policy"`
+	GraphTimeout         types.String `tfsdk:"graph_timeout"`
+	GraphTryTimeout      types.String `tfsdk:"graph_try_timeout"`
+	GraphMaxRetries      types.Int64  `tfsdk:"graph_max_retries"`
+	GraphRetryDelay      types.String `tfsdk:"graph_retry_delay"`
+	GraphCompression     types.Bool   `tfsdk:"graph_compression"`
+	CABundlePath         types.String `tfsdk:"ca_bundle_path"`
+	TLSMinVersion        types.String `tfsdk:"tls_min_version"`
+	UseMSIFederation     types.Bool   `tfsdk:"use_managed_identity_federation"`
+	MSIClientID          types.String `tfsdk:"managed_identity_client_id"`
+	CredentialTypes      types.List   `tfsdk:"credential_types"`
+	RequireConfirmation  types.Bool   `tfsdk:"require_mutation_confirmation"`
+	MutationConfirmation types.String `tfsdk:"mutation_confirmation"`
+	JustificationMin     types.Int64  `tfsdk:"justification_min_length"`
+	JustificationPattern types.String `tfsdk:"justification_pattern"`
+}
+
+// providerData is handed to resources and data sources through their Configure method.
+type providerData struct {
+	// correlationID is sent as client-request-id on every Graph call, empty when not configured.
+	correlationID string
+
+	// auditLog records every mutating Graph call, nil when not configured.
+	auditLog *auditLog
+
+	// maxRetries is how often the clients retry requests throttled within a JSON batch and conflicting policy rule
+	// updates themselves, the graph_max_retries of the provider.
+	maxRetries int
+
+	// defaultJustification is used by resources which do not set a justification, empty when not configured.
+	defaultJustification string
+
+	// justificationRules are checked against the justifications of resources when they are planned.
+	justificationRules justificationRules
+
+	// ticketInfo is referenced by every schedule request created by resources, empty when not configured.
+	ticketInfo grouppim.TicketInfo
+
+	// strictPolicy makes resources fail instead of changing the expiration policy of groups.
+	strictPolicy bool
+
+	// blockMutations fails every mutating Graph call, as they were not confirmed for the tenant.
+	blockMutations bool
+
+	// policyCache caches the policy assignments of groups for the lifetime of the provider instance, i.e. one plan or
+	// apply.
+	policyCache *grouppim.PolicyCache
+
+	// securityGroups caches the security groups of the tenant listed by data sources for the lifetime of the provider
+	// instance, so later listings only fetch the changes.
+	securityGroups *directory.SecurityGroupCache
+
+	// transport replaces the network transport of all Graph calls when set.
+	transport http.RoundTripper
+
+	// clientOptions tunes the HTTP clients of the Graph SDK and of the credentials.
+	clientOptions clientOptions
+
+	// throttle paces all Graph calls when Graph signals throttling, nil to send them as fast as possible.
+	throttle *adaptiveThrottle
+
+	// environment is the cloud the provider manages PIM in, the public cloud when zero.
+	environment cloudEnvironment
+
+	// baseURL overrides the Microsoft Graph beta endpoint, e.g. to point at a fake server in tests.
+	baseURL string
+
+	// clients performs the Graph and Azure Resource Manager calls of all resources and data sources. It has no clients
+	// while deferredReason is set.
+	clients *clients.Clients
+
+	// deferredReason tells why no Graph calls can be made during this plan, empty when they can.
+	deferredReason string
+}
+
+// resourceSettings are the settings of the provider shared by the resources sending justifications to Graph, as
+// documented on providerData. The resources embed it and set it in Configure.
+type resourceSettings struct {
+	defaultJustification string
+	justificationRules   justificationRules
+	deferredReason       string
+}
+
+func newResourceSettings(pd *providerData) resourceSettings {
+	return resourceSettings{
+		defaultJustification: pd.defaultJustification,
+		justificationRules:   pd.justificationRules,
+		deferredReason:       pd.deferredReason,
+	}
 }
 
 func (p *AzurepimProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -39,7 +165,158 @@ func (p *AzurepimProvider) Schema(ctx context.Context, req provider.SchemaReques
 The Azure PIM provider was built as PIM group eligible assignment is as of writing not supported in the official azuread provider.
 
 Please note that this provider uses a beta API provided by Microsoft Graph and is subject to change at any time.
+
+Every provider block authenticates on its own, so one configuration can manage PIM in several tenants with
+[provider aliases](https://developer.hashicorp.com/terraform/language/providers/configuration#alias-multiple-provider-configurations).
+
+When the tenant or identity of a provider block is only known during apply, e.g. when the service principal is created
+in the same run, the plan proceeds without calling Microsoft Graph and resources keep their prior state until then.
 `,
+		Attributes: map[string]schema.Attribute{
+			"correlation_id": schema.StringAttribute{
+				MarkdownDescription: "Sent as the `client-request-id` header on every Microsoft Graph call, so tenant audit logs can be tied back to a Terraform run. Can also be set with the `AZUREPIM_CORRELATION_ID` environment variable.",
+				Optional:            true,
+			},
+			"audit_log_path": schema.StringAttribute{
+				MarkdownDescription: "Path to a JSON lines file where every mutating Microsoft Graph call is appended, with the caller, group, principal and result. Can also be set with the `AZUREPIM_AUDIT_LOG_PATH` environment variable.",
+				Optional:            true,
+			},
+			"tenant_id": schema.StringAttribute{
+				MarkdownDescription: "The tenant to manage PIM in. Defaults to the tenant of the credentials, e.g. the `AZURE_TENANT_ID` environment variable or the Azure CLI login.",
+				Optional:            true,
+			},
+			"client_id": schema.StringAttribute{
+				MarkdownDescription: "The client ID of the app registration or managed identity to authenticate as. Defaults to the `AZURE_CLIENT_ID` environment variable.",
+				Optional:            true,
+			},
+			"client_secret": schema.StringAttribute{
+				MarkdownDescription: "A client secret of the app registration `client_id`. When set, no other credentials are tried.",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("client_id"), path.MatchRoot("tenant_id")),
+				},
+			},
+			"use_managed_identity_federation": schema.BoolAttribute{
+				MarkdownDescription: "Authenticate as the app registration `client_id` in `tenant_id` with a token of the managed identity of the machine as client assertion. The app registration needs a federated credential for the managed identity, which may live in another tenant, e.g. a central platform tenant managing PIM in workload tenants. No other credentials are tried.",
+				Optional:            true,
+				Validators: []validator.Bool{
+					boolvalidator.AlsoRequires(path.MatchRoot("client_id"), path.MatchRoot("tenant_id")),
+					boolvalidator.ConflictsWith(path.MatchRoot("client_secret")),
+				},
+			},
+			"managed_identity_client_id": schema.StringAttribute{
+				MarkdownDescription: "The client ID of the user-assigned managed identity used with `use_managed_identity_federation`. Defaults to the system-assigned managed identity. Can also be set with the `AZUREPIM_MANAGED_IDENTITY_CLIENT_ID` environment variable.",
+				Optional:            true,
+			},
+			"credential_types": schema.ListAttribute{
+				MarkdownDescription: "The types of credentials to try, in order, from `environment`, `oidc` (workload identity federation), `managed_identity`, `cli` (Azure CLI) and `developer_cli` (Azure Developer CLI). Defaults to all of them in this order, like the DefaultAzureCredential of the Azure SDK. Restricting them makes runs fail fast and predictably, e.g. in locked-down CI. Not used with `client_secret` or `use_managed_identity_federation`. Can also be set with the `AZUREPIM_CREDENTIAL_TYPES` environment variable, separated by commas.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.UniqueValues(),
+					listvalidator.ValueStringsAre(stringvalidator.OneOf(defaultCredentialTypes...)),
+				},
+			},
+			"ca_bundle_path": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM file with root certificates to trust in addition to the system ones, e.g. the certificate of a TLS intercepting corporate proxy. Used for Microsoft Graph calls and token requests. Can also be set with the `AZUREPIM_CA_BUNDLE_PATH` environment variable.",
+				Optional:            true,
+			},
+			"tls_min_version": schema.StringAttribute{
+				MarkdownDescription: "The lowest TLS version accepted from Microsoft Graph and Entra ID, one of `1.2` or `1.3`. Defaults to `1.2`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("1.2", "1.3"),
+				},
+			},
+			"environment": schema.StringAttribute{
+				MarkdownDescription: "The cloud of the tenant, one of `public`, `usgovernment` or `china`. Defaults to `public`. Can also be set with the `AZUREPIM_ENVIRONMENT` environment variable.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(cloudEnvironmentNames()...),
+				},
+			},
+			"graph_endpoint": schema.StringAttribute{
+				MarkdownDescription: "The Microsoft Graph endpoint, without version, e.g. `https://graph.microsoft.us`. Tokens are requested for this endpoint. Defaults to the endpoint of `environment`. Can also be set with the `AZUREPIM_GRAPH_ENDPOINT` environment variable.",
+				Optional:            true,
+			},
+			"graph_timeout": schema.StringAttribute{
+				MarkdownDescription: "How long a Microsoft Graph call may take including its retries, as a duration such as `2m`. Defaults to the timeout of the Graph SDK.",
+				Optional:            true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"graph_try_timeout": schema.StringAttribute{
+				MarkdownDescription: "How long a single attempt of a Microsoft Graph call or token request may take, as a duration such as `30s`, so a hanging connection is retried instead of using up `graph_timeout`. Not limited by default.",
+				Optional:            true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"graph_max_retries": schema.Int64Attribute{
+				MarkdownDescription: "How often a Microsoft Graph call or token request failing with a transient error, such as `429` or `503`, is retried, waiting as long as its `Retry-After` header asks. It applies to every retry of the provider: requests throttled within a JSON batch, and policy rule updates retried after the rule was changed concurrently, e.g. by another Terraform run or in the portal. `0` disables retries. Defaults to `3`.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(0, maxGraphRetries),
+				},
+			},
+			"graph_retry_delay": schema.StringAttribute{
+				MarkdownDescription: "The delay before retrying a Microsoft Graph call which did not get a `Retry-After` header, as a duration in whole seconds such as `5s`. The delay grows with every retry. Defaults to `3s`.",
+				Optional:            true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"graph_compression": schema.BoolAttribute{
+				MarkdownDescription: "Whether request bodies sent to Microsoft Graph are compressed. Disable it when a proxy between the provider and Graph does not support compressed requests. Defaults to `true`.",
+				Optional:            true,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: "Deprecated alias of `graph_max_retries`, used when `graph_max_retries` is not set.",
+				DeprecationMessage:  "Use graph_max_retries instead, which max_retries is an alias of.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"default_justification": schema.StringAttribute{
+				MarkdownDescription: "The justification of eligible assignments created by resources which do not set `justification`. Can also be set with the `AZUREPIM_DEFAULT_JUSTIFICATION` environment variable. Like `justification`, it can contain the placeholders `{run_id}`, `{workspace}` and `{commit_sha}`, which are expanded at apply from the environment variables of Terraform Cloud, GitHub Actions, Azure Pipelines or GitLab CI, and to `unknown` elsewhere.",
+				Optional:            true,
+			},
+			"justification_min_length": schema.Int64Attribute{
+				MarkdownDescription: "The minimum number of characters of the justifications of resources, not counting surrounding whitespace. Checked when resources are planned, and against `default_justification` when the provider is configured. Can also be set with the `AZUREPIM_JUSTIFICATION_MIN_LENGTH` environment variable.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"justification_pattern": schema.StringAttribute{
+				MarkdownDescription: "A regular expression in [RE2 syntax](https://github.com/google/re2/wiki/Syntax) the justifications of resources must match, e.g. `(?i)\\bCHG[0-9]{7}\\b` to require a change request number. Checked when resources are planned, with the placeholders expanded, and against `default_justification` when the provider is configured. Can also be set with the `AZUREPIM_JUSTIFICATION_PATTERN` environment variable.",
+				Optional:            true,
+			},
+			"strict_policy": schema.BoolAttribute{
+				MarkdownDescription: "Never change the expiration policy of groups. Creating an eligible assignment then fails with a policy conflict when the policy of its group requires eligible assignments to expire, instead of the policy being changed to allow assignments without expiration. Defaults to `false`.",
+				Optional:            true,
+			},
+			"ticket_system": schema.StringAttribute{
+				MarkdownDescription: "The ticket system, e.g. `ServiceNow`, of the ticket referenced by every schedule request created in the run. Can also be set with the `AZUREPIM_TICKET_SYSTEM` environment variable.",
+				Optional:            true,
+			},
+			"ticket_number": schema.StringAttribute{
+				MarkdownDescription: "The number of the ticket, e.g. the change request of a CI pipeline, referenced by every schedule request created in the run. Can also be set with the `AZUREPIM_TICKET_NUMBER` environment variable.",
+				Optional:            true,
+			},
+			"require_mutation_confirmation": schema.BoolAttribute{
+				MarkdownDescription: "Block every mutating Microsoft Graph call unless `mutation_confirmation` is the ID of the tenant, so plans and refreshes of protected tenants can never change PIM configuration, e.g. by renewing an expiring eligibility. Requires `tenant_id` or the `AZURE_TENANT_ID` environment variable. Defaults to `false`. Can also be set with the `AZUREPIM_REQUIRE_MUTATION_CONFIRMATION` environment variable.",
+				Optional:            true,
+			},
+			"mutation_confirmation": schema.StringAttribute{
+				MarkdownDescription: "The ID of the tenant, confirming that the run may change PIM configuration when `require_mutation_confirmation` is set. Typically only set in the apply stage of a pipeline. Can also be set with the `AZUREPIM_MUTATION_CONFIRMATION` environment variable.",
+				Optional:            true,
+			},
+		},
 	}
 }
 
@@ -52,23 +329,344 @@ func (p *AzurepimProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
-	// Configuration values are now available.
-	// if data.Endpoint.IsNull() { /* ... */ }
+	// The secret is masked like those of the environment in the diagnostics and logs of this and all later RPCs.
+	registerSecret(data.ClientSecret.ValueString())
+	ctx = withSanitizedLogging(ctx)
+
+	pd := &providerData{
+		correlationID:  os.Getenv("AZUREPIM_CORRELATION_ID"),
+		maxRetries:     defaultMaxRetries,
+		transport:      p.transport,
+		throttle:       newAdaptiveThrottle(),
+		clients:        &clients.Clients{},
+		policyCache:    grouppim.NewPolicyCache(),
+		securityGroups: directory.NewSecurityGroupCache(),
+	}
+
+	// The credentials can not be created yet. Reads and imports are deferred when Terraform supports deferred actions,
+	// otherwise resources keep their prior state until apply.
+	if reason := data.deferredReason(); reason != "" {
+		tflog.Info(ctx, "deferring Graph calls until apply", map[string]interface{}{"reason": reason})
+		pd.deferredReason = reason
+		resp.DataSourceData = pd
+		resp.ResourceData = pd
+		return
+	}
+
+	// One number of retries applies to the retry handler of the Graph SDK, the credentials and the retries of the
+	// clients themselves. max_retries is a deprecated alias of graph_max_retries, and capped like it.
+	retries := data.GraphMaxRetries
+	if retries.IsNull() {
+		retries = data.MaxRetries
+	}
+	if !retries.IsNull() {
+		maxRetries := min(int(retries.ValueInt64()), maxGraphRetries)
+		pd.maxRetries = maxRetries
+		pd.clientOptions.maxRetries = &maxRetries
+	}
+
+	// The durations are checked by durationValidator.
+	pd.clientOptions.timeout, _ = time.ParseDuration(data.GraphTimeout.ValueString())
+	pd.clientOptions.tryTimeout, _ = time.ParseDuration(data.GraphTryTimeout.ValueString())
+	pd.clientOptions.retryDelay, _ = time.ParseDuration(data.GraphRetryDelay.ValueString())
+	pd.clientOptions.disableCompression = !data.GraphCompression.IsNull() && !data.GraphCompression.ValueBool()
+
+	if !data.CorrelationID.IsNull() {
+		pd.correlationID = data.CorrelationID.ValueString()
+	}
+
+	pd.defaultJustification = os.Getenv("AZUREPIM_DEFAULT_JUSTIFICATION")
+	if !data.DefaultJustification.IsNull() {
+		pd.defaultJustification = data.DefaultJustification.ValueString()
+	}
+
+	var err error
+	pd.justificationRules, err = data.justificationRules()
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid justification rules", err.Error())
+		return
+	}
+	if pd.defaultJustification != "" {
+		if err := pd.justificationRules.check(expandJustification(pd.defaultJustification)); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("default_justification"), "Non-compliant justification", fmt.Sprintf("The default justification does not comply with the justification rules of the provider: %s.", err))
+			return
+		}
+	}
+
+	pd.strictPolicy = data.StrictPolicy.ValueBool()
+
+	requireConfirmation, _ := strconv.ParseBool(os.Getenv("AZUREPIM_REQUIRE_MUTATION_CONFIRMATION"))
+	if !data.RequireConfirmation.IsNull() {
+		requireConfirmation = data.RequireConfirmation.ValueBool()
+	}
+	confirmation := os.Getenv("AZUREPIM_MUTATION_CONFIRMATION")
+	if !data.MutationConfirmation.IsNull() {
+		confirmation = data.MutationConfirmation.ValueString()
+	}
+	tenantID := data.TenantID.ValueString()
+	if tenantID == "" {
+		tenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	if requireConfirmation && tenantID == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("require_mutation_confirmation"), "Unknown tenant", "Mutations can only be confirmed for a known tenant. Set tenant_id or the AZURE_TENANT_ID environment variable.")
+		return
+	}
+	pd.blockMutations = !mutationsConfirmed(requireConfirmation, tenantID, confirmation)
+	if pd.blockMutations {
+		tflog.Info(ctx, "blocking mutating Graph calls, as they are not confirmed", map[string]interface{}{"tenant_id": tenantID})
+	}
+
+	pd.ticketInfo = grouppim.TicketInfo{
+		System: os.Getenv("AZUREPIM_TICKET_SYSTEM"),
+		Number: os.Getenv("AZUREPIM_TICKET_NUMBER"),
+	}
+	if !data.TicketSystem.IsNull() {
+		pd.ticketInfo.System = data.TicketSystem.ValueString()
+	}
+	if !data.TicketNumber.IsNull() {
+		pd.ticketInfo.Number = data.TicketNumber.ValueString()
+	}
+
+	auditLogPath := os.Getenv("AZUREPIM_AUDIT_LOG_PATH")
+	if !data.AuditLogPath.IsNull() {
+		auditLogPath = data.AuditLogPath.ValueString()
+	}
+	pd.auditLog = newAuditLog(auditLogPath, pd.correlationID)
+
+	environment := os.Getenv("AZUREPIM_ENVIRONMENT")
+	if !data.Environment.IsNull() {
+		environment = data.Environment.ValueString()
+	}
+	if environment == "" {
+		environment = defaultEnvironment
+	}
+	var ok bool
+	pd.environment, ok = cloudEnvironments[environment]
+	if !ok {
+		resp.Diagnostics.AddAttributeError(path.Root("environment"), "Invalid environment", fmt.Sprintf("Unknown environment %q, must be one of %q.", environment, cloudEnvironmentNames()))
+		return
+	}
 
-	// Example client configuration for data sources and resources
-	client := http.DefaultClient
-	resp.DataSourceData = client
-	resp.ResourceData = client
+	graphEndpoint := os.Getenv("AZUREPIM_GRAPH_ENDPOINT")
+	if !data.GraphEndpoint.IsNull() {
+		graphEndpoint = data.GraphEndpoint.ValueString()
+	}
+	if graphEndpoint != "" {
+		u, err := url.Parse(graphEndpoint)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("graph_endpoint"), "Invalid Graph endpoint", fmt.Sprintf("The Graph endpoint must be an https URL, got %q.", graphEndpoint))
+			return
+		}
+		pd.environment.graphEndpoint = strings.TrimSuffix(graphEndpoint, "/")
+	}
+
+	caBundlePath := os.Getenv("AZUREPIM_CA_BUNDLE_PATH")
+	if !data.CABundlePath.IsNull() {
+		caBundlePath = data.CABundlePath.ValueString()
+	}
+	tlsTransport, err := newTLSTransport(tlsOptions{caBundlePath: caBundlePath, minVersion: data.TLSMinVersion.ValueString()})
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid TLS configuration", sanitizeError(err))
+		return
+	}
+
+	// The transport of recorded acceptance tests takes precedence.
+	var tokenTransport azcorepolicy.Transporter
+	if tlsTransport != nil && pd.transport == nil {
+		pd.transport = tlsTransport
+		tokenTransport = &http.Client{Transport: tlsTransport}
+	}
+
+	var credentialTypes []string
+	if env := os.Getenv("AZUREPIM_CREDENTIAL_TYPES"); env != "" {
+		for _, t := range strings.Split(env, ",") {
+			credentialTypes = append(credentialTypes, strings.TrimSpace(t))
+		}
+	}
+	if !data.CredentialTypes.IsNull() {
+		credentialTypes = nil
+		resp.Diagnostics.Append(data.CredentialTypes.ElementsAs(ctx, &credentialTypes, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	msiClientID := os.Getenv("AZUREPIM_MANAGED_IDENTITY_CLIENT_ID")
+	if !data.MSIClientID.IsNull() {
+		msiClientID = data.MSIClientID.ValueString()
+	}
+
+	creds := p.credential
+	if creds == nil {
+		var err error
+		creds, err = newCredential(credentialOptions{
+			tenantID:     data.TenantID.ValueString(),
+			clientID:     data.ClientID.ValueString(),
+			clientSecret: data.ClientSecret.ValueString(),
+			cloud:        pd.environment.cloud,
+			retry:        pd.clientOptions.azcoreRetryOptions(),
+			transport:    tokenTransport,
+
+			managedIdentityFederation: data.UseMSIFederation.ValueBool(),
+			managedIdentityClientID:   msiClientID,
+			tokenExchangeAudience:     pd.environment.tokenExchangeAudience,
+			credentialTypes:           credentialTypes,
+		})
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", "Unable to create credentials: "+sanitizeError(err))
+			return
+		}
+	}
+
+	pd.clients, err = newClients(creds, pd)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", "Unable to create clients: "+sanitizeError(err))
+		return
+	}
+
+	resp.DataSourceData = pd
+	resp.ResourceData = pd
+}
+
+// justificationRules returns the justification rules configured in the provider block or the environment.
+func (m AzurepimProviderModel) justificationRules() (justificationRules, error) {
+	var rules justificationRules
+
+	minLength := os.Getenv("AZUREPIM_JUSTIFICATION_MIN_LENGTH")
+	if minLength != "" {
+		n, err := strconv.Atoi(minLength)
+		if err != nil || n < 0 {
+			return rules, fmt.Errorf("AZUREPIM_JUSTIFICATION_MIN_LENGTH must be a non-negative number, got %q", minLength)
+		}
+		rules.minLength = n
+	}
+	if !m.JustificationMin.IsNull() {
+		rules.minLength = int(m.JustificationMin.ValueInt64())
+	}
+
+	pattern := os.Getenv("AZUREPIM_JUSTIFICATION_PATTERN")
+	if !m.JustificationPattern.IsNull() {
+		pattern = m.JustificationPattern.ValueString()
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return rules, fmt.Errorf("unable to compile the justification pattern: %w", err)
+		}
+		rules.pattern = re
+	}
+
+	return rules, nil
+}
+
+// justificationOrDefault returns the justification v, or defaultJustification when v is not set, with the placeholders
+// of either expanded.
+func justificationOrDefault(v types.String, defaultJustification string) string {
+	if v.ValueString() == "" {
+		return expandJustification(defaultJustification)
+	}
+
+	return expandJustification(v.ValueString())
+}
+
+// newClients creates the clients of all areas, sharing creds, one HTTP pipeline per API and the options of pd.
+func newClients(creds azcore.TokenCredential, pd *providerData) (*clients.Clients, error) {
+	return clients.New(pd.clientsOptions(creds),
+		func(opts clients.Options) (clients.Graph, error) { return newGraphClient(opts) },
+		func(opts clients.Options) (armpim.Client, error) { return newARMClient(opts), nil },
+	)
+}
+
+// clientsOptions returns the options of the clients created with creds.
+func (pd *providerData) clientsOptions(creds azcore.TokenCredential) clients.Options {
+	return clients.Options{
+		Credential:      creds,
+		GraphHTTPClient: newGraphHTTPClient(pd),
+		ARMHTTPClient: &http.Client{
+			Timeout:   pd.clientOptions.timeout,
+			Transport: pd.transport,
+		},
+		GraphEndpoint: pd.graphEndpoint(),
+		GraphBaseURL:  pd.graphBaseURL(),
+		ARMEndpoint:   pd.armEndpoint(),
+		ARMScope:      pd.armScope(),
+		MaxRetries:    pd.maxRetries,
+		CorrelationID: pd.correlationID,
+	}
+}
+
+// graphEndpoint returns the Microsoft Graph endpoint of the environment, without version.
+func (pd *providerData) graphEndpoint() string {
+	if pd.environment.graphEndpoint != "" {
+		return pd.environment.graphEndpoint
+	}
+
+	return cloudEnvironments[defaultEnvironment].graphEndpoint
+}
+
+// graphBaseURL returns the Microsoft Graph beta endpoint the provider talks to.
+func (pd *providerData) graphBaseURL() string {
+	if pd.baseURL != "" {
+		return pd.baseURL
+	}
+
+	return pd.graphEndpoint() + "/beta"
+}
+
+// graphScope returns the scope of the tokens for the Microsoft Graph endpoint.
+func (pd *providerData) graphScope() string {
+	return pd.graphEndpoint() + "/.default"
+}
+
+// armEndpoint returns the Azure Resource Manager endpoint of the environment.
+func (pd *providerData) armEndpoint() string {
+	configuration := pd.environment.cloud
+	if configuration.Services == nil {
+		configuration = cloudEnvironments[defaultEnvironment].cloud
+	}
+
+	return strings.TrimSuffix(configuration.Services[cloud.ResourceManager].Endpoint, "/")
+}
+
+// armScope returns the scope of the tokens for Azure Resource Manager.
+func (pd *providerData) armScope() string {
+	configuration := pd.environment.cloud
+	if configuration.Services == nil {
+		configuration = cloudEnvironments[defaultEnvironment].cloud
+	}
+
+	return strings.TrimSuffix(configuration.Services[cloud.ResourceManager].Audience, "/") + "/.default"
 }
 
 func (p *AzurepimProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewGroupEligibleAssignment,
+		NewGroupMemberMigration,
+		NewGroupMembershipExclusive,
+		NewPolicyTemplate,
+		NewBatchActivation,
 	}
 }
 
 func (p *AzurepimProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewGroupEligibleAssignmentReport,
+		NewGroupEligibleAssignments,
+		NewGroupEligibilityScheduleRequests,
+		NewGroupPrivilegedAccess,
+		NewGroupEligibilityAssertion,
+		NewDirectoryRoleActivationRequests,
+		NewDirectoryRoleScheduleRequests,
+		NewDirectoryRolePolicyAssignments,
+		NewGroupPolicies,
+		NewResourceRoleActivationHistory,
+		NewCallerEligibilities,
+		NewActiveAccess,
+		NewRoleAssignableGroups,
+		NewActivationRemainingTime,
+		NewTransitiveEligibleAccess,
+		NewGroupRolePolicy,
+	}
 }
 
 func New(version string) func() provider.Provider {
@@ -4,10 +4,14 @@
 package provider
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/vcr"
 )
 
 // testAccProtoV6ProviderFactories are used to instantiate a provider during
@@ -15,7 +19,7 @@ import (
 // CLI command executed to create a provider server to which the CLI can
 // reattach.
 var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
-	"azurepim": providerserver.NewProtocol6WithError(New("test")()),
+	"azurepim": NewProtocol6WithError(New("test")()),
 }
 
 func testAccPreCheck(t *testing.T) {
@@ -23,3 +27,70 @@ func testAccPreCheck(t *testing.T) {
 	// about the appropriate environment variables being set are common to see in a pre-check
 	// function.
 }
+
+// testAccFixtureGroupIDEnvironmentVariable holds the object ID of a pre-provisioned group which is already onboarded to PIM.
+// Reusing it avoids creating a group per test, which is slow and flaky as new groups take a while before PIM accepts them.
+const testAccFixtureGroupIDEnvironmentVariable = "AZUREPIM_TEST_GROUP_ID"
+
+// testAccFixtureGroupConfig returns the config of a PIM group named name, and the expression referencing its object ID.
+// The pre-provisioned fixture group is used when configured, otherwise a new group is created.
+func testAccFixtureGroupConfig(name, suffix string) (config string, objectID string) {
+	if id := os.Getenv(testAccFixtureGroupIDEnvironmentVariable); id != "" {
+		return "", fmt.Sprintf("%q", id)
+	}
+
+	return fmt.Sprintf(`
+resource "azuread_group" %[1]q {
+	display_name     = "azurepim-acc-test-group-%[1]s-%[2]s"
+	owners           = [data.azuread_client_config.current.object_id]
+	security_enabled = true
+}
+`, name, suffix), fmt.Sprintf("azuread_group.%s.object_id", name)
+}
+
+// testAccRecorder returns a recorder for the cassette of the running test, and skips the test unless AZUREPIM_VCR_MODE is set.
+// The recording is saved when the test finishes.
+func testAccRecorder(t *testing.T) *vcr.Recorder {
+	t.Helper()
+
+	mode, ok, err := vcr.ModeFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok {
+		t.Skipf("%s must be set to record or replay this test", vcr.ModeEnvironmentVariable)
+	}
+
+	rec, err := vcr.New(filepath.Join("testdata", "cassettes", t.Name()+".json"), mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			return
+		}
+
+		if err := rec.Save(); err != nil {
+			t.Errorf("unable to save cassette: %s", err)
+		}
+	})
+
+	return rec
+}
+
+// testAccRecordingProviderFactories instantiates providers sending all Graph calls through rec.
+// When replaying no credentials are needed.
+func testAccRecordingProviderFactories(rec *vcr.Recorder) map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"azurepim": func() (tfprotov6.ProviderServer, error) {
+			p := &AzurepimProvider{version: "test", transport: rec}
+			if rec.Mode() == vcr.ModeReplay {
+				p.credential = &fakeCredential{token: "replay"}
+			}
+
+			return NewProtocol6WithError(p)()
+		},
+	}
+}
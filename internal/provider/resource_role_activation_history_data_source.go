@@ -0,0 +1,223 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/armpim"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ResourceRoleActivationHistory{}
+
+func NewResourceRoleActivationHistory() datasource.DataSource {
+	return &ResourceRoleActivationHistory{}
+}
+
+// ResourceRoleActivationHistory defines the data source implementation.
+type ResourceRoleActivationHistory struct {
+	service *armpim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// ResourceRoleActivationHistoryModel describes the data source data model.
+type ResourceRoleActivationHistoryModel struct {
+	Scope         types.String                             `tfsdk:"scope"`
+	PrincipalID   customtypes.GUID                         `tfsdk:"principal_id"`
+	CreatedAfter  customtypes.RFC3339                      `tfsdk:"created_after"`
+	CreatedBefore customtypes.RFC3339                      `tfsdk:"created_before"`
+	Activations   []ResourceRoleActivationHistoryItemModel `tfsdk:"activations"`
+}
+
+// ResourceRoleActivationHistoryItemModel describes an activation request listed by the data source.
+type ResourceRoleActivationHistoryItemModel struct {
+	ID               types.String        `tfsdk:"id"`
+	Scope            types.String        `tfsdk:"scope"`
+	RoleDefinitionID types.String        `tfsdk:"role_definition_id"`
+	PrincipalID      customtypes.GUID    `tfsdk:"principal_id"`
+	PrincipalType    types.String        `tfsdk:"principal_type"`
+	Status           types.String        `tfsdk:"status"`
+	Justification    types.String        `tfsdk:"justification"`
+	CreatedDateTime  customtypes.RFC3339 `tfsdk:"created_date_time"`
+	StartDateTime    customtypes.RFC3339 `tfsdk:"start_date_time"`
+	EndDateTime      customtypes.RFC3339 `tfsdk:"end_date_time"`
+	ApprovalID       types.String        `tfsdk:"approval_id"`
+}
+
+func (d *ResourceRoleActivationHistory) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_resource_role_activation_history"
+}
+
+func (d *ResourceRoleActivationHistory) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists the activations of eligible Azure roles at a scope, e.g. for access reviews of a subscription.
+
+Unlike the other data sources it calls Azure Resource Manager instead of Microsoft Graph, and requires the
+` + "`Microsoft.Authorization/roleAssignmentScheduleRequests/read`" + ` permission at the scope, e.g. through the Reader role.
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "The scope to list the activations at, e.g. `/subscriptions/{subscription_id}`. Activations at child scopes, such as resource groups, are included.",
+				Required:            true,
+			},
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "Only list the activations of this principal.",
+				Optional:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"created_after": schema.StringAttribute{
+				MarkdownDescription: "Only list activations requested at or after this time, formatted as RFC 3339.",
+				Optional:            true,
+				CustomType:          customtypes.RFC3339Type{},
+				Validators:          []validator.String{timestampValidator{}},
+			},
+			"created_before": schema.StringAttribute{
+				MarkdownDescription: "Only list activations requested before this time, formatted as RFC 3339.",
+				Optional:            true,
+				CustomType:          customtypes.RFC3339Type{},
+				Validators:          []validator.String{timestampValidator{}},
+			},
+			"activations": schema.ListNestedAttribute{
+				MarkdownDescription: "The activation requests, oldest first.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "The resource ID of the role assignment schedule request.",
+							Computed:            true,
+						},
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The scope the role was activated at.",
+							Computed:            true,
+						},
+						"role_definition_id": schema.StringAttribute{
+							MarkdownDescription: "The resource ID of the activated role definition.",
+							Computed:            true,
+						},
+						"principal_id": schema.StringAttribute{
+							MarkdownDescription: "The principal which activated the role.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"principal_type": schema.StringAttribute{
+							MarkdownDescription: "The type of the principal, e.g. `User`.",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							Computed: true,
+						},
+						"justification": schema.StringAttribute{
+							Computed: true,
+						},
+						"created_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"start_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"end_date_time": schema.StringAttribute{
+							Computed:   true,
+							CustomType: customtypes.RFC3339Type{},
+						},
+						"approval_id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the approval of the request, empty when the role needs no approval.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *ResourceRoleActivationHistory) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.service = armpim.NewService(pd.clients.ARMPIM)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *ResourceRoleActivationHistory) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of Azure role activations") }()
+
+	var data ResourceRoleActivationHistoryModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The timestamps are checked by timestampValidator.
+	filter := armpim.RequestFilter{PrincipalID: data.PrincipalID.ValueString()}
+	if !data.CreatedAfter.IsNull() {
+		filter.CreatedAfter, _ = time.Parse(time.RFC3339, data.CreatedAfter.ValueString())
+	}
+	if !data.CreatedBefore.IsNull() {
+		filter.CreatedBefore, _ = time.Parse(time.RFC3339, data.CreatedBefore.ValueString())
+	}
+
+	requests, err := d.service.ListActivationRequests(ctx, data.Scope.ValueString(), filter)
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list Azure role activations: "+sanitizeError(err))
+		return
+	}
+
+	// RFC 3339 timestamps in UTC sort chronologically.
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CreatedDateTime < requests[j].CreatedDateTime
+	})
+
+	data.Activations = []ResourceRoleActivationHistoryItemModel{}
+	for _, r := range requests {
+		data.Activations = append(data.Activations, ResourceRoleActivationHistoryItemModel{
+			ID:               types.StringValue(r.ID),
+			Scope:            types.StringValue(r.Scope),
+			RoleDefinitionID: types.StringValue(r.RoleDefinitionID),
+			PrincipalID:      customtypes.NewGUIDValue(r.PrincipalID),
+			PrincipalType:    types.StringValue(r.PrincipalType),
+			Status:           types.StringValue(r.Status),
+			Justification:    types.StringValue(r.Justification),
+			CreatedDateTime:  customtypes.NewRFC3339Value(r.CreatedDateTime),
+			StartDateTime:    customtypes.NewRFC3339Value(r.StartDateTime),
+			EndDateTime:      customtypes.NewRFC3339Value(r.EndDateTime),
+			ApprovalID:       types.StringValue(r.ApprovalID),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/armpim"
+)
+
+func TestResourceRoleActivationHistoryRead(t *testing.T) {
+	ctx := context.Background()
+
+	request := func(id, requestType, createdOn string) map[string]any {
+		return map[string]any{
+			"id": id,
+			"properties": map[string]any{
+				"scope":       "/subscriptions/sub-1/resourceGroups/rg-1",
+				"principalId": "principal-1",
+				"requestType": requestType,
+				"status":      "Provisioned",
+				"createdOn":   createdOn,
+			},
+		}
+	}
+
+	// The requests are split over two pages to check that the next link is followed.
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subscriptions/sub-1/providers/Microsoft.Authorization/roleAssignmentScheduleRequests" {
+			http.NotFound(w, r)
+			return
+		}
+
+		page := map[string]any{
+			"value": []any{
+				request("request-3", "SelfActivate", "2024-05-03T12:00:00Z"),
+				request("request-2", "SelfActivate", "2024-05-02T12:00:00Z"),
+			},
+			"nextLink": server.URL + r.URL.Path + "?page=2",
+		}
+		if r.URL.Query().Get("page") == "2" {
+			page = map[string]any{
+				"value": []any{
+					request("request-1", "SelfActivate", "2024-04-01T12:00:00Z"),
+					request("request-4", "AdminAssign", "2024-05-02T12:00:00Z"),
+				},
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	pd := &providerData{}
+	pd.environment.cloud = cloud.Configuration{Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+		cloud.ResourceManager: {Endpoint: server.URL, Audience: "https://management.core.windows.net/"},
+	}}
+	d := &ResourceRoleActivationHistory{service: armpim.NewService(newARMClient(pd.clientsOptions(&fakeCredential{token: "token"})))}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := configState.Set(ctx, ResourceRoleActivationHistoryModel{
+		Scope:         types.StringValue("/subscriptions/sub-1"),
+		PrincipalID:   customtypes.NewGUIDNull(),
+		CreatedAfter:  customtypes.NewRFC3339Value("2024-05-01T00:00:00Z"),
+		CreatedBefore: customtypes.NewRFC3339Null(),
+	}); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var read ResourceRoleActivationHistoryModel
+	resp.State.Get(ctx, &read)
+
+	var got []string
+	for _, a := range read.Activations {
+		got = append(got, a.ID.ValueString())
+	}
+
+	// request-1 is older than the window, and request-4 is no activation.
+	want := []string{"request-2", "request-3"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got activations %v, want %v", got, want)
+	}
+}
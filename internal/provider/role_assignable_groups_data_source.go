@@ -0,0 +1,143 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &RoleAssignableGroups{}
+
+func NewRoleAssignableGroups() datasource.DataSource {
+	return &RoleAssignableGroups{}
+}
+
+// RoleAssignableGroups defines the data source implementation.
+type RoleAssignableGroups struct {
+	directory *directory.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// RoleAssignableGroupsModel describes the data source data model.
+type RoleAssignableGroupsModel struct {
+	DisplayNamePrefix types.String                    `tfsdk:"display_name_prefix"`
+	Groups            []RoleAssignableGroupsItemModel `tfsdk:"groups"`
+}
+
+// RoleAssignableGroupsItemModel describes a group listed by the data source.
+type RoleAssignableGroupsItemModel struct {
+	ID          customtypes.GUID `tfsdk:"id"`
+	DisplayName types.String     `tfsdk:"display_name"`
+}
+
+func (d *RoleAssignableGroups) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_role_assignable_groups"
+}
+
+func (d *RoleAssignableGroups) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists the role-assignable groups in the tenant, i.e. the groups which can be assigned Microsoft Entra roles, e.g. to
+onboard each of them to PIM with ` + "`for_each`" + `.
+
+It requires the following graph permissions:
+- Group.Read.All
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"display_name_prefix": schema.StringAttribute{
+				MarkdownDescription: "Only list the groups whose display name starts with this prefix. The match is case insensitive.",
+				Optional:            true,
+			},
+			"groups": schema.ListNestedAttribute{
+				MarkdownDescription: "The role-assignable groups, ordered by display name.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "The object ID of the group.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"display_name": schema.StringAttribute{
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *RoleAssignableGroups) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.directory = directory.NewService(pd.clients.Directory)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *RoleAssignableGroups) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of role-assignable groups") }()
+
+	var data RoleAssignableGroupsModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	groups, err := d.directory.RoleAssignableGroups(ctx, data.DisplayNamePrefix.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client call failed", "Unable to list role-assignable groups: "+sanitizeError(err))
+		return
+	}
+
+	// Display names are not unique, so the object ID keeps the order stable.
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].DisplayName != groups[j].DisplayName {
+			return groups[i].DisplayName < groups[j].DisplayName
+		}
+		return groups[i].ID < groups[j].ID
+	})
+
+	data.Groups = []RoleAssignableGroupsItemModel{}
+	for _, g := range groups {
+		data.Groups = append(data.Groups, RoleAssignableGroupsItemModel{
+			ID:          customtypes.NewGUIDValue(g.ID),
+			DisplayName: types.StringValue(g.DisplayName),
+		})
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/directory"
+)
+
+// roleAssignableGroupsClient lists groups by display name, and records the filter of the last listing.
+type roleAssignableGroupsClient struct {
+	fakeDirectoryClient
+	displayNames map[string]string
+	filter       string
+}
+
+func (c *roleAssignableGroupsClient) ListAllGroups(ctx context.Context, filter string, visit func(page []graphmodels.Groupable) error) error {
+	c.filter = filter
+
+	for id, name := range c.displayNames {
+		g := graphmodels.NewGroup()
+		g.SetId(toPtr(id))
+		g.SetDisplayName(toPtr(name))
+		if err := visit([]graphmodels.Groupable{g}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestRoleAssignableGroupsRead(t *testing.T) {
+	ctx := context.Background()
+	client := &roleAssignableGroupsClient{
+		displayNames: map[string]string{"group-c": "pim-b", "group-b": "pim-a", "group-a": "pim-b"},
+	}
+
+	d := &RoleAssignableGroups{directory: directory.NewService(client)}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	tests := []struct {
+		name       string
+		prefix     types.String
+		wantFilter string
+	}{
+		{name: "all", prefix: types.StringNull(), wantFilter: "isAssignableToRole eq true"},
+		{name: "prefix", prefix: types.StringValue("o'pim"), wantFilter: "isAssignableToRole eq true and startswith(displayName, 'o''pim')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configState := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			if diags := configState.Set(ctx, RoleAssignableGroupsModel{DisplayNamePrefix: tt.prefix}); diags.HasError() {
+				t.Fatalf("unable to set config: %v", diags)
+			}
+
+			resp := &datasource.ReadResponse{State: tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}}
+			d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+
+			if client.filter != tt.wantFilter {
+				t.Errorf("got filter %q, want %q", client.filter, tt.wantFilter)
+			}
+
+			var read RoleAssignableGroupsModel
+			resp.State.Get(ctx, &read)
+
+			var got []string
+			for _, g := range read.Groups {
+				got = append(got, g.DisplayName.ValueString()+"|"+g.ID.ValueString())
+			}
+			if want := []string{"pim-a|group-b", "pim-b|group-a", "pim-b|group-c"}; !slices.Equal(got, want) {
+				t.Errorf("got groups %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const redactedValue = "<redacted>"
+
+// sensitiveValueRegexes match values which must never end up in logs or diagnostics.
+// The first capture group is kept when sanitizing so the output still shows what was removed.
+var sensitiveValueRegexes = []*regexp.Regexp{
+	// Authorization header values.
+	regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`),
+	// Secrets in JSON or form encoded bodies, e.g. requests to the token endpoint.
+	regexp.MustCompile(`(?i)((?:client_secret|client_assertion|access_token|refresh_token|id_token)["']?\s*[:=]\s*["']?)[^\s"'&,}]+`),
+	// JSON web tokens on their own.
+	regexp.MustCompile(`()eyJ[\w-]+\.[\w-]+\.[\w-]*`),
+}
+
+// sensitiveFieldKeys are log field keys whose values are always masked.
+var sensitiveFieldKeys = []string{"Authorization", "authorization", "token", "client_secret"}
+
+// sensitiveEnvironmentVariables are read by azidentity and hold secrets verbatim.
+var sensitiveEnvironmentVariables = []string{"AZURE_CLIENT_SECRET", "AZURE_CLIENT_CERTIFICATE_PASSWORD", "AZURE_PASSWORD"}
+
+// registeredSecrets are secrets set in the provider block, see registerSecret.
+var registeredSecrets struct {
+	sync.Mutex
+	values []string
+}
+
+// registerSecret makes sanitize and withSanitizedLogging mask v from now on, like the secrets of the environment.
+func registerSecret(v string) {
+	if v == "" {
+		return
+	}
+
+	registeredSecrets.Lock()
+	defer registeredSecrets.Unlock()
+	if !slices.Contains(registeredSecrets.values, v) {
+		registeredSecrets.values = append(registeredSecrets.values, v)
+	}
+}
+
+// sanitize removes bearer tokens, client secrets and other sensitive values from s.
+func sanitize(s string) string {
+	for _, re := range sensitiveValueRegexes {
+		s = re.ReplaceAllString(s, "${1}"+redactedValue)
+	}
+
+	for _, v := range sensitiveValues() {
+		s = strings.ReplaceAll(s, v, redactedValue)
+	}
+
+	return s
+}
+
+// sanitizeError returns the sanitized message of err, to be used in diagnostics.
+func sanitizeError(err error) string {
+	return sanitize(err.Error())
+}
+
+// withSanitizedLogging returns a context where all tflog output is masked with the same rules as sanitize.
+func withSanitizedLogging(ctx context.Context) context.Context {
+	ctx = tflog.MaskLogRegexes(ctx, sensitiveValueRegexes...)
+	ctx = tflog.MaskLogStrings(ctx, sensitiveValues()...)
+	return tflog.MaskFieldValuesWithFieldKeys(ctx, sensitiveFieldKeys...)
+}
+
+// sensitiveValues returns the secrets of the environment and those registered with registerSecret.
+func sensitiveValues() []string {
+	registeredSecrets.Lock()
+	values := slices.Clone(registeredSecrets.values)
+	registeredSecrets.Unlock()
+
+	for _, name := range sensitiveEnvironmentVariables {
+		if v := os.Getenv(name); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
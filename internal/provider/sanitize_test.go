@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestSanitize(t *testing.T) {
+	t.Setenv("AZURE_CLIENT_SECRET", "s3cr3t-value")
+
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"authorization header": {
+			in:   "Authorization: Bearer abc.def-ghi",
+			want: "Authorization: Bearer <redacted>",
+		},
+		"json body": {
+			in:   `{"access_token":"abc","token_type":"Bearer"}`,
+			want: `{"access_token":"<redacted>","token_type":"Bearer"}`,
+		},
+		"form body": {
+			in:   "grant_type=client_credentials&client_secret=abc&scope=x",
+			want: "grant_type=client_credentials&client_secret=<redacted>&scope=x",
+		},
+		"jwt": {
+			in:   "token eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl was rejected",
+			want: "token <redacted> was rejected",
+		},
+		"environment secret": {
+			in:   "invalid secret s3cr3t-value",
+			want: "invalid secret <redacted>",
+		},
+		"nothing sensitive": {
+			in:   "groupId eq '00000000-0000-0000-0000-000000000000'",
+			want: "groupId eq '00000000-0000-0000-0000-000000000000'",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := sanitize(tt.in); got != tt.want {
+				t.Errorf("sanitize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeError(t *testing.T) {
+	got := sanitizeError(errors.New("unable to send request: Bearer abc"))
+	if strings.Contains(got, "abc") {
+		t.Errorf("sanitizeError() = %q, token was not removed", got)
+	}
+}
+
+// loggingProviderServer logs the bearer token it is given while reading a resource.
+type loggingProviderServer struct {
+	tfprotov6.ProviderServer
+}
+
+func (s loggingProviderServer) ReadResource(ctx context.Context, req *tfprotov6.ReadResourceRequest) (*tfprotov6.ReadResourceResponse, error) {
+	tflog.Debug(ctx, "calling Graph", map[string]any{"header": "Bearer abc.def-ghi"})
+	return &tfprotov6.ReadResourceResponse{}, nil
+}
+
+func (s loggingProviderServer) ValidateProviderConfig(ctx context.Context, req *tfprotov6.ValidateProviderConfigRequest) (*tfprotov6.ValidateProviderConfigResponse, error) {
+	return &tfprotov6.ValidateProviderConfigResponse{Diagnostics: leakingDiagnostics()}, nil
+}
+
+func (s loggingProviderServer) UpgradeResourceState(ctx context.Context, req *tfprotov6.UpgradeResourceStateRequest) (*tfprotov6.UpgradeResourceStateResponse, error) {
+	return &tfprotov6.UpgradeResourceStateResponse{Diagnostics: leakingDiagnostics()}, nil
+}
+
+func (s loggingProviderServer) MoveResourceState(ctx context.Context, req *tfprotov6.MoveResourceStateRequest) (*tfprotov6.MoveResourceStateResponse, error) {
+	return &tfprotov6.MoveResourceStateResponse{Diagnostics: leakingDiagnostics()}, nil
+}
+
+func (s loggingProviderServer) CallFunction(ctx context.Context, req *tfprotov6.CallFunctionRequest) (*tfprotov6.CallFunctionResponse, error) {
+	return &tfprotov6.CallFunctionResponse{Error: &tfprotov6.FunctionError{Text: "Bearer abc.def-ghi"}}, nil
+}
+
+func (s loggingProviderServer) StopProvider(ctx context.Context, req *tfprotov6.StopProviderRequest) (*tfprotov6.StopProviderResponse, error) {
+	return &tfprotov6.StopProviderResponse{Error: "Bearer abc.def-ghi"}, nil
+}
+
+func leakingDiagnostics() []*tfprotov6.Diagnostic {
+	return []*tfprotov6.Diagnostic{{Severity: tfprotov6.DiagnosticSeverityError, Summary: "Client Error", Detail: "Authorization: Bearer abc.def-ghi"}}
+}
+
+func TestSanitizingServerResponses(t *testing.T) {
+	ctx := context.Background()
+	s := sanitizingServer{loggingProviderServer{}}
+
+	details := func(diags []*tfprotov6.Diagnostic) string {
+		var b strings.Builder
+		for _, d := range diags {
+			b.WriteString(d.Summary + " " + d.Detail)
+		}
+		return b.String()
+	}
+
+	tests := map[string]func() (string, error){
+		"validate provider config": func() (string, error) {
+			resp, err := s.ValidateProviderConfig(ctx, &tfprotov6.ValidateProviderConfigRequest{})
+			return details(resp.Diagnostics), err
+		},
+		"upgrade resource state": func() (string, error) {
+			resp, err := s.UpgradeResourceState(ctx, &tfprotov6.UpgradeResourceStateRequest{})
+			return details(resp.Diagnostics), err
+		},
+		"move resource state": func() (string, error) {
+			resp, err := s.MoveResourceState(ctx, &tfprotov6.MoveResourceStateRequest{})
+			return details(resp.Diagnostics), err
+		},
+		"call function": func() (string, error) {
+			resp, err := s.CallFunction(ctx, &tfprotov6.CallFunctionRequest{})
+			return resp.Error.Text, err
+		},
+		"stop provider": func() (string, error) {
+			resp, err := s.StopProvider(ctx, &tfprotov6.StopProviderRequest{})
+			return resp.Error, err
+		},
+	}
+
+	for name, rpc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := rpc()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(got, "abc.def-ghi") || !strings.Contains(got, redactedValue) {
+				t.Errorf("got %q, want the token redacted", got)
+			}
+		})
+	}
+}
+
+func TestSanitizingServer(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	if _, err := (sanitizingServer{loggingProviderServer{}}).ReadResource(ctx, &tfprotov6.ReadResourceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(output.String(), "abc.def-ghi") || !strings.Contains(output.String(), redactedValue) {
+		t.Errorf("got log output %q, want the token redacted", output.String())
+	}
+}
+
+func TestSanitizeProviderSecret(t *testing.T) {
+	ctx := context.Background()
+	p := &AzurepimProvider{}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	// The unknown environment defers the creation of the credentials, the secret is registered before.
+	configState := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	config := AzurepimProviderModel{
+		ClientSecret:    types.StringValue("provider-s3cr3t"),
+		Environment:     types.StringUnknown(),
+		CredentialTypes: types.ListNull(types.StringType),
+	}
+	if diags := configState.Set(ctx, config); diags.HasError() {
+		t.Fatalf("unable to set config: %v", diags)
+	}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(ctx, provider.ConfigureRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected configure diagnostics: %v", resp.Diagnostics)
+	}
+
+	if got := sanitizeError(errors.New("invalid secret provider-s3cr3t")); got != "invalid secret <redacted>" {
+		t.Errorf("sanitizeError() = %q, want the provider secret redacted", got)
+	}
+
+	var output bytes.Buffer
+	logCtx := withSanitizedLogging(tflogtest.RootLogger(ctx, &output))
+	tflog.Debug(logCtx, "requesting token with provider-s3cr3t")
+	if strings.Contains(output.String(), "provider-s3cr3t") {
+		t.Errorf("got log output %q, want the provider secret redacted", output.String())
+	}
+}
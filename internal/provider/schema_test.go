@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// snapshotAttribute is implemented by the attributes of provider, resource and data source schemas.
+type snapshotAttribute interface {
+	GetType() attr.Type
+	IsRequired() bool
+	IsOptional() bool
+	IsComputed() bool
+	IsSensitive() bool
+}
+
+type describer interface {
+	Description(ctx context.Context) string
+}
+
+// renderSchemaSnapshot renders attributes in a stable, line based format so schema changes show up as readable diffs.
+func renderSchemaSnapshot[T snapshotAttribute](ctx context.Context, attributes map[string]T) string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		a := attributes[name]
+
+		var mode []string
+		if a.IsRequired() {
+			mode = append(mode, "required")
+		}
+		if a.IsOptional() {
+			mode = append(mode, "optional")
+		}
+		if a.IsComputed() {
+			mode = append(mode, "computed")
+		}
+		if a.IsSensitive() {
+			mode = append(mode, "sensitive")
+		}
+		if w, ok := any(a).(interface{ IsWriteOnly() bool }); ok && w.IsWriteOnly() {
+			mode = append(mode, "write-only")
+		}
+
+		fmt.Fprintf(&b, "%s: %s (%s)\n", name, a.GetType(), strings.Join(mode, ", "))
+
+		v := reflect.ValueOf(a)
+		for _, field := range []string{"PlanModifiers", "Validators"} {
+			f := v.FieldByName(field)
+			if !f.IsValid() {
+				continue
+			}
+
+			for i := 0; i < f.Len(); i++ {
+				if d, ok := f.Index(i).Interface().(describer); ok {
+					fmt.Fprintf(&b, "  %s: %s\n", field, d.Description(ctx))
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// assertGolden compares got with the golden file at path, or rewrites it when the -update flag is set.
+func assertGolden(t *testing.T, path string, got string) {
+	t.Helper()
+
+	path = filepath.Join("testdata", path)
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read golden file, run with -update to create it: %s", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("%s does not match, run with -update if the change is intended.\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestProviderSchema(t *testing.T) {
+	ctx := context.Background()
+	p := New("test")()
+
+	var resp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	if diags := resp.Schema.ValidateImplementation(ctx); diags.HasError() {
+		t.Fatalf("invalid schema: %v", diags)
+	}
+
+	assertGolden(t, filepath.Join("schemas", "provider.golden"), renderSchemaSnapshot(ctx, resp.Schema.Attributes))
+}
+
+func TestResourceSchemas(t *testing.T) {
+	ctx := context.Background()
+	p := New("test")()
+
+	for _, newResource := range p.Resources(ctx) {
+		r := newResource()
+
+		var metadata resource.MetadataResponse
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "azurepim"}, &metadata)
+
+		t.Run(metadata.TypeName, func(t *testing.T) {
+			var resp resource.SchemaResponse
+			r.Schema(ctx, resource.SchemaRequest{}, &resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			if diags := resp.Schema.ValidateImplementation(ctx); diags.HasError() {
+				t.Fatalf("invalid schema: %v", diags)
+			}
+
+			snapshot := fmt.Sprintf("version: %d\n%s", resp.Schema.Version, renderSchemaSnapshot(ctx, resp.Schema.Attributes))
+			assertGolden(t, filepath.Join("schemas", "resource_"+metadata.TypeName+".golden"), snapshot)
+		})
+	}
+}
+
+func TestDataSourceSchemas(t *testing.T) {
+	ctx := context.Background()
+	p := New("test")()
+
+	for _, newDataSource := range p.DataSources(ctx) {
+		d := newDataSource()
+
+		var metadata datasource.MetadataResponse
+		d.Metadata(ctx, datasource.MetadataRequest{ProviderTypeName: "azurepim"}, &metadata)
+
+		t.Run(metadata.TypeName, func(t *testing.T) {
+			var resp datasource.SchemaResponse
+			d.Schema(ctx, datasource.SchemaRequest{}, &resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			if diags := resp.Schema.ValidateImplementation(ctx); diags.HasError() {
+				t.Fatalf("invalid schema: %v", diags)
+			}
+
+			assertGolden(t, filepath.Join("schemas", "data_source_"+metadata.TypeName+".golden"), renderSchemaSnapshot(ctx, resp.Schema.Attributes))
+		})
+	}
+}
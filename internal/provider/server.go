@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// NewProtocol6 returns a function creating the protocol version 6 server of p, which masks sensitive values in
+// everything logged while serving an RPC.
+func NewProtocol6(p provider.Provider) func() tfprotov6.ProviderServer {
+	return func() tfprotov6.ProviderServer {
+		return sanitizingServer{providerserver.NewProtocol6(p)()}
+	}
+}
+
+// NewProtocol6WithError is the same as NewProtocol6, for callers such as acceptance tests which expect an error.
+func NewProtocol6WithError(p provider.Provider) func() (tfprotov6.ProviderServer, error) {
+	return func() (tfprotov6.ProviderServer, error) {
+		server, err := providerserver.NewProtocol6WithError(p)()
+		if err != nil {
+			return nil, err
+		}
+
+		return sanitizingServer{server}, nil
+	}
+}
+
+// sanitizingServer applies withSanitizedLogging to the context of every RPC and sanitizes the diagnostics of its
+// response, so neither the logs nor the diagnostics of the provider, resources and data sources need to do it. The
+// server is not embedded, so an RPC added to tfprotov6.ProviderServer does not compile until it goes through serve.
+type sanitizingServer struct {
+	server tfprotov6.ProviderServer
+}
+
+// serve calls rpc with a masked context and sanitizes the diagnostics and errors of its response.
+func serve[Req, Resp any](ctx context.Context, req Req, rpc func(context.Context, Req) (Resp, error)) (Resp, error) {
+	resp, err := rpc(withSanitizedLogging(ctx), req)
+	sanitizeResponse(resp)
+	return resp, err
+}
+
+// sanitizeResponse sanitizes the Diagnostics, the function Error or the Error message of the response resp.
+func sanitizeResponse(resp any) {
+	v := reflect.ValueOf(resp)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return
+	}
+	v = v.Elem()
+
+	if f := v.FieldByName("Diagnostics"); f.IsValid() {
+		for _, d := range f.Interface().([]*tfprotov6.Diagnostic) {
+			if d != nil {
+				d.Summary = sanitize(d.Summary)
+				d.Detail = sanitize(d.Detail)
+			}
+		}
+	}
+	if f := v.FieldByName("Error"); f.IsValid() {
+		switch e := f.Interface().(type) {
+		case *tfprotov6.FunctionError:
+			if e != nil {
+				e.Text = sanitize(e.Text)
+			}
+		case string:
+			f.SetString(sanitize(e))
+		}
+	}
+}
+
+func (s sanitizingServer) GetMetadata(ctx context.Context, req *tfprotov6.GetMetadataRequest) (*tfprotov6.GetMetadataResponse, error) {
+	return serve(ctx, req, s.server.GetMetadata)
+}
+
+func (s sanitizingServer) GetProviderSchema(ctx context.Context, req *tfprotov6.GetProviderSchemaRequest) (*tfprotov6.GetProviderSchemaResponse, error) {
+	return serve(ctx, req, s.server.GetProviderSchema)
+}
+
+func (s sanitizingServer) ValidateProviderConfig(ctx context.Context, req *tfprotov6.ValidateProviderConfigRequest) (*tfprotov6.ValidateProviderConfigResponse, error) {
+	return serve(ctx, req, s.server.ValidateProviderConfig)
+}
+
+func (s sanitizingServer) ConfigureProvider(ctx context.Context, req *tfprotov6.ConfigureProviderRequest) (*tfprotov6.ConfigureProviderResponse, error) {
+	return serve(ctx, req, s.server.ConfigureProvider)
+}
+
+func (s sanitizingServer) StopProvider(ctx context.Context, req *tfprotov6.StopProviderRequest) (*tfprotov6.StopProviderResponse, error) {
+	return serve(ctx, req, s.server.StopProvider)
+}
+
+func (s sanitizingServer) ValidateResourceConfig(ctx context.Context, req *tfprotov6.ValidateResourceConfigRequest) (*tfprotov6.ValidateResourceConfigResponse, error) {
+	return serve(ctx, req, s.server.ValidateResourceConfig)
+}
+
+func (s sanitizingServer) UpgradeResourceState(ctx context.Context, req *tfprotov6.UpgradeResourceStateRequest) (*tfprotov6.UpgradeResourceStateResponse, error) {
+	return serve(ctx, req, s.server.UpgradeResourceState)
+}
+
+func (s sanitizingServer) ReadResource(ctx context.Context, req *tfprotov6.ReadResourceRequest) (*tfprotov6.ReadResourceResponse, error) {
+	return serve(ctx, req, s.server.ReadResource)
+}
+
+func (s sanitizingServer) PlanResourceChange(ctx context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error) {
+	return serve(ctx, req, s.server.PlanResourceChange)
+}
+
+func (s sanitizingServer) ApplyResourceChange(ctx context.Context, req *tfprotov6.ApplyResourceChangeRequest) (*tfprotov6.ApplyResourceChangeResponse, error) {
+	return serve(ctx, req, s.server.ApplyResourceChange)
+}
+
+func (s sanitizingServer) ImportResourceState(ctx context.Context, req *tfprotov6.ImportResourceStateRequest) (*tfprotov6.ImportResourceStateResponse, error) {
+	return serve(ctx, req, s.server.ImportResourceState)
+}
+
+func (s sanitizingServer) MoveResourceState(ctx context.Context, req *tfprotov6.MoveResourceStateRequest) (*tfprotov6.MoveResourceStateResponse, error) {
+	return serve(ctx, req, s.server.MoveResourceState)
+}
+
+func (s sanitizingServer) ValidateDataResourceConfig(ctx context.Context, req *tfprotov6.ValidateDataResourceConfigRequest) (*tfprotov6.ValidateDataResourceConfigResponse, error) {
+	return serve(ctx, req, s.server.ValidateDataResourceConfig)
+}
+
+func (s sanitizingServer) ReadDataSource(ctx context.Context, req *tfprotov6.ReadDataSourceRequest) (*tfprotov6.ReadDataSourceResponse, error) {
+	return serve(ctx, req, s.server.ReadDataSource)
+}
+
+func (s sanitizingServer) CallFunction(ctx context.Context, req *tfprotov6.CallFunctionRequest) (*tfprotov6.CallFunctionResponse, error) {
+	return serve(ctx, req, s.server.CallFunction)
+}
+
+func (s sanitizingServer) GetFunctions(ctx context.Context, req *tfprotov6.GetFunctionsRequest) (*tfprotov6.GetFunctionsResponse, error) {
+	return serve(ctx, req, s.server.GetFunctions)
+}
+
+func (s sanitizingServer) ValidateEphemeralResourceConfig(ctx context.Context, req *tfprotov6.ValidateEphemeralResourceConfigRequest) (*tfprotov6.ValidateEphemeralResourceConfigResponse, error) {
+	return serve(ctx, req, s.server.ValidateEphemeralResourceConfig)
+}
+
+func (s sanitizingServer) OpenEphemeralResource(ctx context.Context, req *tfprotov6.OpenEphemeralResourceRequest) (*tfprotov6.OpenEphemeralResourceResponse, error) {
+	return serve(ctx, req, s.server.OpenEphemeralResource)
+}
+
+func (s sanitizingServer) RenewEphemeralResource(ctx context.Context, req *tfprotov6.RenewEphemeralResourceRequest) (*tfprotov6.RenewEphemeralResourceResponse, error) {
+	return serve(ctx, req, s.server.RenewEphemeralResource)
+}
+
+func (s sanitizingServer) CloseEphemeralResource(ctx context.Context, req *tfprotov6.CloseEphemeralResourceRequest) (*tfprotov6.CloseEphemeralResourceResponse, error) {
+	return serve(ctx, req, s.server.CloseEphemeralResource)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	khttp "github.com/microsoft/kiota-http-go"
+)
+
+const (
+	// defaultMaxRetries is how often the clients retry throttled or conflicting calls themselves when
+	// graph_max_retries is not configured, the default of the retry handler of the Graph SDK.
+	defaultMaxRetries = 3
+
+	// maxRetryDelay caps the delay between retries, the same way the retry handler of the Graph SDK does.
+	maxRetryDelay = 180 * time.Second
+)
+
+// retryAfterDelay returns how long to wait before retrying a throttled request. The Retry-After header is either a
+// number of seconds or an HTTP date. Without it, the delay doubles with every attempt, starting at one second.
+func retryAfterDelay(retryAfter string, attempt int) time.Duration {
+	delay := maxRetryDelay
+	if attempt < 8 {
+		delay = time.Second << attempt
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(retryAfter); err == nil {
+		delay = time.Until(at)
+	}
+
+	if delay < 0 {
+		return 0
+	}
+
+	return min(delay, maxRetryDelay)
+}
+
+// throttleEvent is a single 429 response observed from Microsoft Graph.
+type throttleEvent struct {
+	Method     string
+	URL        string
+	RetryAfter string
+}
+
+// throttleRecorder collects the throttle events for a single resource operation.
+type throttleRecorder struct {
+	mu     sync.Mutex
+	events []throttleEvent
+}
+
+type throttleRecorderKey struct{}
+
+// withThrottleRecorder returns a context where throttled Graph calls are recorded on the returned recorder.
+func withThrottleRecorder(ctx context.Context) (context.Context, *throttleRecorder) {
+	r := &throttleRecorder{}
+	return context.WithValue(ctx, throttleRecorderKey{}, r), r
+}
+
+// recordThrottle records resp on the recorder in ctx if Graph throttled the request.
+func recordThrottle(ctx context.Context, resp *http.Response) {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+
+	event := throttleEvent{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.Path,
+		RetryAfter: resp.Header.Get("Retry-After"),
+	}
+
+	tflog.Warn(ctx, "request was throttled by microsoft graph", map[string]any{
+		"method":      event.Method,
+		"url":         event.URL,
+		"retry_after": event.RetryAfter,
+	})
+
+	if r, ok := ctx.Value(throttleRecorderKey{}).(*throttleRecorder); ok {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = append(r.events, event)
+	}
+}
+
+// addWarnings adds a single warning diagnostic describing all recorded throttle events for target.
+func (r *throttleRecorder) addWarnings(diags *diag.Diagnostics, target string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Microsoft Graph throttled %d request(s) for %s, which slows down the operation:\n", len(r.events), target)
+	for _, e := range r.events {
+		retryAfter := e.RetryAfter
+		if retryAfter == "" {
+			retryAfter = "not set"
+		}
+		fmt.Fprintf(&b, "- %s %s (Retry-After: %s)\n", e.Method, e.URL, retryAfter)
+	}
+
+	diags.AddWarning("Requests throttled by Microsoft Graph", b.String())
+}
+
+// throttleMiddleware records throttled responses. It is placed after the retry handler so every attempt is seen.
+type throttleMiddleware struct{}
+
+func (m *throttleMiddleware) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	resp, err := pipeline.Next(req, middlewareIndex)
+	if err != nil {
+		return resp, err
+	}
+
+	recordThrottle(req.Context(), resp)
+
+	return resp, nil
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestThrottleRecorder(t *testing.T) {
+	ctx, throttles := withThrottleRecorder(context.Background())
+
+	req := &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/beta/policies/roleManagementPolicyAssignments"}}
+	recordThrottle(ctx, &http.Response{StatusCode: http.StatusOK, Request: req, Header: http.Header{}})
+	recordThrottle(ctx, &http.Response{StatusCode: http.StatusTooManyRequests, Request: req, Header: http.Header{"Retry-After": []string{"7"}}})
+
+	var diags diag.Diagnostics
+	throttles.addWarnings(&diags, "member assignment")
+
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags))
+	}
+
+	if diags.HasError() {
+		t.Errorf("got error diagnostic, want warning")
+	}
+
+	if detail := diags[0].Detail(); !strings.Contains(detail, "Retry-After: 7") || !strings.Contains(detail, "member assignment") {
+		t.Errorf("unexpected detail: %s", detail)
+	}
+}
+
+func TestThrottleRecorderNoEvents(t *testing.T) {
+	_, throttles := withThrottleRecorder(context.Background())
+
+	var diags diag.Diagnostics
+	throttles.addWarnings(&diags, "member assignment")
+
+	if len(diags) != 0 {
+		t.Errorf("got %d diagnostics, want 0", len(diags))
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{retryAfter: "7", attempt: 0, want: 7 * time.Second},
+		{retryAfter: "", attempt: 0, want: time.Second},
+		{retryAfter: "", attempt: 2, want: 4 * time.Second},
+		{retryAfter: "3600", attempt: 0, want: maxRetryDelay},
+		{retryAfter: "", attempt: 64, want: maxRetryDelay},
+		{retryAfter: "Mon, 02 Jan 2006 15:04:05 GMT", attempt: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfterDelay(tt.retryAfter, tt.attempt); got != tt.want {
+			t.Errorf("retryAfterDelay(%q, %d) = %s, want %s", tt.retryAfter, tt.attempt, got, tt.want)
+		}
+	}
+}
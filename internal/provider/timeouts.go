@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultOperationTimeout bounds a create, update or delete whose timeout is not set in the timeouts block. Throttled
+// tenants can need several minutes for a single policy rule update.
+const defaultOperationTimeout = 30 * time.Minute
+
+// timeoutsAttributeTypes are the attributes of the timeouts block.
+var timeoutsAttributeTypes = map[string]attr.Type{
+	"create": types.StringType,
+	"update": types.StringType,
+	"delete": types.StringType,
+}
+
+// timeoutsBlock returns the timeouts block of resources, which bounds all Graph calls of an operation including their
+// retries.
+func timeoutsBlock(ctx context.Context) schema.Block {
+	return timeouts.Block(ctx, timeouts.Opts{
+		Create:            true,
+		Update:            true,
+		Delete:            true,
+		CreateDescription: "How long creating the resource may take, as a duration such as `45m`. Defaults to `30m`.",
+		UpdateDescription: "How long updating the resource may take, as a duration such as `45m`. Defaults to `30m`.",
+		DeleteDescription: "How long deleting the resource may take, as a duration such as `45m`. Defaults to `30m`.",
+	})
+}
+
+// nullTimeouts returns the value of a timeouts block which is not configured, e.g. for the state after import.
+func nullTimeouts() timeouts.Value {
+	return timeouts.Value{Object: types.ObjectNull(timeoutsAttributeTypes)}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// tlsMinVersions are the TLS versions accepted by the tls_min_version provider attribute.
+var tlsMinVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsOptions configures how the provider verifies the servers it talks to, e.g. behind a TLS intercepting proxy.
+type tlsOptions struct {
+	// caBundlePath is a PEM file with root certificates trusted in addition to the system ones.
+	caBundlePath string
+
+	// minVersion is the lowest TLS version accepted, the Go default when empty.
+	minVersion string
+}
+
+// newTLSTransport returns a copy of the default transport using o, or nil when o does not change anything.
+func newTLSTransport(o tlsOptions) (*http.Transport, error) {
+	if o == (tlsOptions{}) {
+		return nil, nil
+	}
+
+	config := &tls.Config{}
+
+	if o.caBundlePath != "" {
+		pem, err := os.ReadFile(o.caBundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %w", err)
+		}
+
+		// The bundle extends the system roots, so Graph stays reachable when the proxy is bypassed.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no PEM encoded certificates", o.caBundlePath)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.minVersion != "" {
+		version, ok := tlsMinVersions[o.minVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %q", o.minVersion)
+		}
+		config.MinVersion = version
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+
+	return transport, nil
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTLSTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	transport, err := newTLSTransport(tlsOptions{caBundlePath: bundle, minVersion: "1.2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("got min version %x, want TLS 1.2", transport.TLSClientConfig.MinVersion)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("got error %s, want the server certificate to be trusted", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewTLSTransportErrors(t *testing.T) {
+	if transport, err := newTLSTransport(tlsOptions{}); transport != nil || err != nil {
+		t.Errorf("got %v, %v, want no transport without options", transport, err)
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, o := range map[string]tlsOptions{
+		"missing bundle": {caBundlePath: filepath.Join(t.TempDir(), "missing.pem")},
+		"no pem":         {caBundlePath: notPEM},
+		"unknown tls":    {minVersion: "1.1"},
+	} {
+		if _, err := newTLSTransport(o); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
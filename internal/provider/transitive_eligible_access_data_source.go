@@ -0,0 +1,338 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// defaultTransitiveMaxDepth is how many eligibilities deep the transitive eligible access is followed by default.
+// Nesting PIM enabled groups more than a few levels deep is rare, and every level lists the eligibilities of all groups
+// reached.
+const defaultTransitiveMaxDepth = 5
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &TransitiveEligibleAccess{}
+
+func NewTransitiveEligibleAccess() datasource.DataSource {
+	return &TransitiveEligibleAccess{}
+}
+
+// TransitiveEligibleAccess defines the data source implementation.
+type TransitiveEligibleAccess struct {
+	groups *grouppim.Service
+	roles  *rolepim.Service
+	// deferredReason is set when the provider configuration is not known yet.
+	deferredReason string
+}
+
+// TransitiveEligibleAccessModel describes the data source data model.
+type TransitiveEligibleAccessModel struct {
+	PrincipalID    customtypes.GUID                             `tfsdk:"principal_id"`
+	MaxDepth       types.Int64                                  `tfsdk:"max_depth"`
+	Groups         []TransitiveEligibleAccessGroupModel         `tfsdk:"groups"`
+	DirectoryRoles []TransitiveEligibleAccessDirectoryRoleModel `tfsdk:"directory_roles"`
+	Truncated      types.Bool                                   `tfsdk:"truncated"`
+}
+
+// TransitiveEligibleAccessGroupModel describes a role in a group the principal can reach.
+type TransitiveEligibleAccessGroupModel struct {
+	Scope       customtypes.GUID    `tfsdk:"scope"`
+	Role        types.String        `tfsdk:"role"`
+	MemberType  types.String        `tfsdk:"member_type"`
+	Depth       types.Int64         `tfsdk:"depth"`
+	Via         []types.String      `tfsdk:"via"`
+	EndDateTime customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+// TransitiveEligibleAccessDirectoryRoleModel describes a Microsoft Entra role the principal can reach.
+type TransitiveEligibleAccessDirectoryRoleModel struct {
+	RoleDefinitionID types.String        `tfsdk:"role_definition_id"`
+	DirectoryScopeID types.String        `tfsdk:"directory_scope_id"`
+	MemberType       types.String        `tfsdk:"member_type"`
+	Depth            types.Int64         `tfsdk:"depth"`
+	Via              []types.String      `tfsdk:"via"`
+	EndDateTime      customtypes.RFC3339 `tfsdk:"end_date_time"`
+}
+
+// transitiveHop is a principal or group whose eligibilities are followed, with the groups the principal becomes a
+// member of to reach it.
+type transitiveHop struct {
+	id  string
+	via []string
+}
+
+// transitiveEligibilities are the eligibilities of a hop.
+type transitiveEligibilities struct {
+	groups []grouppim.EligibleAssignment
+	roles  []rolepim.Eligibility
+}
+
+func (d *TransitiveEligibleAccess) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_transitive_eligible_access"
+}
+
+func (d *TransitiveEligibleAccess) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: `
+Lists everything a principal can reach through eligibilities, for blast-radius analysis. Eligibilities for the member
+role of PIM enabled groups are followed: when the principal is eligible as member of group A, and group A is eligible as
+member of group B and for a Microsoft Entra role, the roles in group B and the Entra role are listed as well, with
+` + "`via`" + ` naming group A.
+
+Eligibilities for the owner role are listed but not followed, as owning a group does not make the principal a member.
+Each group is followed once, so cycles of nested groups end, and every role is listed with the shortest chain reaching
+it.
+
+It requires the following graph permissions:
+- PrivilegedEligibilitySchedule.Read.AzureADGroup
+- RoleEligibilitySchedule.Read.Directory
+`,
+
+		Attributes: map[string]schema.Attribute{
+			"principal_id": schema.StringAttribute{
+				MarkdownDescription: "The object ID of the user, group or service principal to follow the eligibilities of.",
+				Required:            true,
+				CustomType:          customtypes.GUIDType{},
+			},
+			"max_depth": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("How many eligibilities deep to follow, `1` lists only the eligibilities of the principal itself. Defaults to `%d`.", defaultTransitiveMaxDepth),
+				Optional:            true,
+				Validators:          []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"groups": schema.ListNestedAttribute{
+				MarkdownDescription: "The roles in groups the principal can reach, sorted by depth, group and role.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"scope": schema.StringAttribute{
+							MarkdownDescription: "The group.",
+							Computed:            true,
+							CustomType:          customtypes.GUIDType{},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "Either `member` or `owner`.",
+							Computed:            true,
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "`direct` when the last principal of the chain is eligible itself, `group` when it is eligible through a group it is permanently a member of.",
+							Computed:            true,
+						},
+						"depth": schema.Int64Attribute{
+							MarkdownDescription: "How many eligibilities the principal activates to reach the role, `1` when it is eligible itself.",
+							Computed:            true,
+						},
+						"via": schema.ListAttribute{
+							MarkdownDescription: "The groups the principal becomes a member of through eligibilities to reach the role, in order. Empty when it is eligible itself.",
+							Computed:            true,
+							ElementType:         types.StringType,
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "When the last eligibility of the chain lapses. Empty for permanent eligibilities.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+			"directory_roles": schema.ListNestedAttribute{
+				MarkdownDescription: "The Microsoft Entra roles the principal can reach, sorted by depth, role and scope.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"role_definition_id": schema.StringAttribute{
+							Computed: true,
+						},
+						"directory_scope_id": schema.StringAttribute{
+							MarkdownDescription: "The scope of the role, `/` for the whole tenant.",
+							Computed:            true,
+						},
+						"member_type": schema.StringAttribute{
+							MarkdownDescription: "`Direct` when the last principal of the chain is eligible itself, `Group` when it is eligible through a group.",
+							Computed:            true,
+						},
+						"depth": schema.Int64Attribute{
+							MarkdownDescription: "How many eligibilities the principal activates to reach the role, `1` when it is eligible itself.",
+							Computed:            true,
+						},
+						"via": schema.ListAttribute{
+							MarkdownDescription: "The groups the principal becomes a member of through eligibilities to reach the role, in order. Empty when it is eligible itself.",
+							Computed:            true,
+							ElementType:         types.StringType,
+						},
+						"end_date_time": schema.StringAttribute{
+							MarkdownDescription: "When the last eligibility of the chain lapses. Empty for permanent eligibilities.",
+							Computed:            true,
+							CustomType:          customtypes.RFC3339Type{},
+						},
+					},
+				},
+			},
+			"truncated": schema.BoolAttribute{
+				MarkdownDescription: "Whether groups were left unfollowed because they are deeper than `max_depth`, so the principal may reach more than listed.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *TransitiveEligibleAccess) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	pd, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T", req.ProviderData))
+		return
+	}
+
+	d.groups = grouppim.NewService(pd.clients.GroupPIM)
+	d.roles = rolepim.NewService(pd.clients.DirectoryRolePIM)
+	d.deferredReason = pd.deferredReason
+}
+
+func (d *TransitiveEligibleAccess) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.deferredReason != "" {
+		deferDataSourceRead(req, resp, d.deferredReason)
+		return
+	}
+
+	ctx, throttles := withThrottleRecorder(ctx)
+	defer func() { throttles.addWarnings(&resp.Diagnostics, "listing of the transitive eligible access") }()
+
+	var data TransitiveEligibleAccessModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	maxDepth := int64(defaultTransitiveMaxDepth)
+	if !data.MaxDepth.IsNull() {
+		maxDepth = data.MaxDepth.ValueInt64()
+	}
+
+	data.Groups = []TransitiveEligibleAccessGroupModel{}
+	data.DirectoryRoles = []TransitiveEligibleAccessDirectoryRoleModel{}
+	data.Truncated = types.BoolValue(false)
+
+	// The eligibilities are followed breadth first, so every role is reached by its shortest chain first.
+	followed := map[string]bool{data.PrincipalID.ValueString(): true}
+	seenGroups := map[string]bool{}
+	seenRoles := map[string]bool{}
+	hops := []transitiveHop{{id: data.PrincipalID.ValueString()}}
+	for depth := int64(1); len(hops) > 0; depth++ {
+		if depth > maxDepth {
+			data.Truncated = types.BoolValue(true)
+			break
+		}
+
+		eligibilities, err := fetchAll(ctx, hops, maxConcurrentFetches, d.listEligibilities)
+		if err != nil {
+			resp.Diagnostics.AddError("Client call failed", "Unable to list eligibilities: "+sanitizeError(err))
+			return
+		}
+
+		var next []transitiveHop
+		for i, hop := range hops {
+			via := make([]types.String, 0, len(hop.via))
+			for _, id := range hop.via {
+				via = append(via, types.StringValue(id))
+			}
+
+			for _, a := range eligibilities[i].groups {
+				if key := a.GroupID + "|" + a.Role; !seenGroups[key] {
+					seenGroups[key] = true
+					data.Groups = append(data.Groups, TransitiveEligibleAccessGroupModel{
+						Scope:       customtypes.NewGUIDValue(a.GroupID),
+						Role:        types.StringValue(a.Role),
+						MemberType:  types.StringValue(a.MemberType),
+						Depth:       types.Int64Value(depth),
+						Via:         via,
+						EndDateTime: customtypes.NewRFC3339Value(a.EndDateTime),
+					})
+				}
+
+				// Only membership grants the eligibilities of a group.
+				if a.Role == "member" && !followed[a.GroupID] {
+					followed[a.GroupID] = true
+					next = append(next, transitiveHop{id: a.GroupID, via: append(slices.Clone(hop.via), a.GroupID)})
+				}
+			}
+
+			for _, e := range eligibilities[i].roles {
+				if key := e.RoleDefinitionID + "|" + e.DirectoryScopeID; !seenRoles[key] {
+					seenRoles[key] = true
+					data.DirectoryRoles = append(data.DirectoryRoles, TransitiveEligibleAccessDirectoryRoleModel{
+						RoleDefinitionID: types.StringValue(e.RoleDefinitionID),
+						DirectoryScopeID: types.StringValue(e.DirectoryScopeID),
+						MemberType:       types.StringValue(e.MemberType),
+						Depth:            types.Int64Value(depth),
+						Via:              via,
+						EndDateTime:      customtypes.NewRFC3339Value(e.EndDateTime),
+					})
+				}
+			}
+		}
+		hops = next
+	}
+
+	sort.SliceStable(data.Groups, func(i, j int) bool {
+		a, b := data.Groups[i], data.Groups[j]
+		if a.Depth.ValueInt64() != b.Depth.ValueInt64() {
+			return a.Depth.ValueInt64() < b.Depth.ValueInt64()
+		}
+		if a.Scope.ValueString() != b.Scope.ValueString() {
+			return a.Scope.ValueString() < b.Scope.ValueString()
+		}
+		return a.Role.ValueString() < b.Role.ValueString()
+	})
+
+	sort.SliceStable(data.DirectoryRoles, func(i, j int) bool {
+		a, b := data.DirectoryRoles[i], data.DirectoryRoles[j]
+		if a.Depth.ValueInt64() != b.Depth.ValueInt64() {
+			return a.Depth.ValueInt64() < b.Depth.ValueInt64()
+		}
+		if a.RoleDefinitionID.ValueString() != b.RoleDefinitionID.ValueString() {
+			return a.RoleDefinitionID.ValueString() < b.RoleDefinitionID.ValueString()
+		}
+		return a.DirectoryScopeID.ValueString() < b.DirectoryScopeID.ValueString()
+	})
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// listEligibilities lists the eligibilities of hop in groups and for Microsoft Entra roles.
+func (d *TransitiveEligibleAccess) listEligibilities(ctx context.Context, hop transitiveHop) (transitiveEligibilities, error) {
+	groups, err := d.groups.ListEligibleAssignments(ctx, "", hop.id)
+	if err != nil {
+		return transitiveEligibilities{}, fmt.Errorf("unable to list eligible assignments in groups of %s: %w", hop.id, err)
+	}
+
+	roles, err := d.roles.ListEligibilities(ctx, hop.id)
+	if err != nil {
+		return transitiveEligibilities{}, fmt.Errorf("unable to list eligible directory roles of %s: %w", hop.id, err)
+	}
+
+	return transitiveEligibilities{groups: groups, roles: roles}, nil
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/customtypes"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/grouppim"
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/services/rolepim"
+)
+
+// fakeRoleEligibilityClient lists the role eligibility schedule instances of the principal in the filter.
+type fakeRoleEligibilityClient struct {
+	*fakeRolePIMClient
+}
+
+func (f fakeRoleEligibilityClient) ListRoleEligibilityScheduleInstances(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleEligibilityScheduleInstanceable, error) {
+	var result []graphmodels.UnifiedRoleEligibilityScheduleInstanceable
+	for _, i := range f.eligibilityInstances {
+		if filterMatches(filter, "principalId", *i.GetPrincipalId()) {
+			result = append(result, i)
+		}
+	}
+
+	return result, nil
+}
+
+func TestTransitiveEligibleAccessRead(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxDepth      types.Int64
+		wantGroups    []string
+		wantRoles     []string
+		wantTruncated bool
+	}{
+		{
+			name: "all levels",
+			// group-a is reached again through group-b, but only listed by its shortest chain.
+			wantGroups: []string{"1 group-a/member []", "1 group-x/owner []", "2 group-b/member [group-a]"},
+			wantRoles:  []string{"2 role-1 [group-a]", "3 role-2 [group-a group-b]"},
+		},
+		{
+			name:          "max depth",
+			maxDepth:      types.Int64Value(2),
+			wantGroups:    []string{"1 group-a/member []", "1 group-x/owner []", "2 group-b/member [group-a]"},
+			wantRoles:     []string{"2 role-1 [group-a]"},
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			groups := grouppim.NewService(newFakeGroupEligibilityClient())
+			for _, a := range []grouppim.EligibleAssignment{
+				{GroupID: "group-a", PrincipalID: "user-id", Role: "member"},
+				{GroupID: "group-x", PrincipalID: "user-id", Role: "owner"},
+				{GroupID: "group-b", PrincipalID: "group-a", Role: "member"},
+				{GroupID: "group-a", PrincipalID: "group-b", Role: "member"},
+			} {
+				if _, err := groups.CreateEligibleAssignment(ctx, a); err != nil {
+					t.Fatalf("unable to create eligible assignment: %v", err)
+				}
+			}
+
+			var instances []graphmodels.UnifiedRoleEligibilityScheduleInstanceable
+			for principalID, roleID := range map[string]string{"group-a": "role-1", "group-b": "role-2", "group-x": "role-3"} {
+				instance := graphmodels.NewUnifiedRoleEligibilityScheduleInstance()
+				instance.SetRoleDefinitionId(toPtr(roleID))
+				instance.SetPrincipalId(toPtr(principalID))
+				instance.SetDirectoryScopeId(toPtr("/"))
+				instance.SetMemberType(toPtr("Direct"))
+				instances = append(instances, instance)
+			}
+
+			d := &TransitiveEligibleAccess{
+				groups: groups,
+				roles:  rolepim.NewService(fakeRoleEligibilityClient{&fakeRolePIMClient{eligibilityInstances: instances}}),
+			}
+
+			var schemaResp datasource.SchemaResponse
+			d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+			if schemaResp.Diagnostics.HasError() {
+				t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+			}
+
+			configState := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			if diags := configState.Set(ctx, TransitiveEligibleAccessModel{
+				PrincipalID: customtypes.NewGUIDValue("user-id"),
+				MaxDepth:    tt.maxDepth,
+				Truncated:   types.BoolNull(),
+			}); diags.HasError() {
+				t.Fatalf("unable to set config: %v", diags)
+			}
+
+			resp := &datasource.ReadResponse{State: tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}}
+			d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configState.Raw}}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+			}
+
+			var read TransitiveEligibleAccessModel
+			resp.State.Get(ctx, &read)
+
+			var gotGroups []string
+			for _, g := range read.Groups {
+				gotGroups = append(gotGroups, g.Depth.String()+" "+g.Scope.ValueString()+"/"+g.Role.ValueString()+" "+viaString(g.Via))
+			}
+			if !slices.Equal(gotGroups, tt.wantGroups) {
+				t.Errorf("got groups %q, want %q", gotGroups, tt.wantGroups)
+			}
+
+			var gotRoles []string
+			for _, r := range read.DirectoryRoles {
+				gotRoles = append(gotRoles, r.Depth.String()+" "+r.RoleDefinitionID.ValueString()+" "+viaString(r.Via))
+			}
+			if !slices.Equal(gotRoles, tt.wantRoles) {
+				t.Errorf("got directory roles %q, want %q", gotRoles, tt.wantRoles)
+			}
+
+			if read.Truncated.ValueBool() != tt.wantTruncated {
+				t.Errorf("got truncated %s, want %t", read.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+// viaString formats the groups of a chain like fmt formats a slice of strings.
+func viaString(via []types.String) string {
+	s := "["
+	for i, id := range via {
+		if i > 0 {
+			s += " "
+		}
+		s += id.ValueString()
+	}
+
+	return s + "]"
+}
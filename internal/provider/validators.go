@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+)
+
+// durationValidator validates that a string is a positive Go duration, such as "720h".
+type durationValidator struct{}
+
+var _ validator.String = durationValidator{}
+
+func (v durationValidator) Description(_ context.Context) string {
+	return `value must be a positive duration, such as "720h"`
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	d, err := time.ParseDuration(req.ConfigValue.ValueString())
+	if err != nil || d <= 0 {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Duration", v.Description(ctx)+", got: "+req.ConfigValue.ValueString())
+	}
+}
+
+// timestampValidator validates that a string is an RFC 3339 timestamp, such as "2024-01-31T12:00:00Z".
+type timestampValidator struct{}
+
+var _ validator.String = timestampValidator{}
+
+func (v timestampValidator) Description(_ context.Context) string {
+	return `value must be an RFC 3339 timestamp, such as "2024-01-31T12:00:00Z"`
+}
+
+func (v timestampValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v timestampValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := time.Parse(time.RFC3339, req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Timestamp", v.Description(ctx)+", got: "+req.ConfigValue.ValueString())
+	}
+}
+
+// isoDurationValidator validates that a string is an ISO 8601 duration, such as "PT8H", as used by Graph policies.
+type isoDurationValidator struct{}
+
+var _ validator.String = isoDurationValidator{}
+
+func (v isoDurationValidator) Description(_ context.Context) string {
+	return `value must be an ISO 8601 duration, such as "PT8H" or "P365D"`
+}
+
+func (v isoDurationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v isoDurationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := serialization.ParseISODuration(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Duration", v.Description(ctx)+", got: "+req.ConfigValue.ValueString())
+	}
+}
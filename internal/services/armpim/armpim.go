@@ -0,0 +1,221 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package armpim maps PIM for Azure resources concepts to Azure Resource Manager calls, so data sources only translate
+// between Terraform values and the types in this package.
+package armpim
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+)
+
+// RequestTypeSelfActivate is the request type of a schedule request made by a principal to activate one of its
+// eligibilities.
+const RequestTypeSelfActivate = "SelfActivate"
+
+// Client is the set of Azure Resource Manager operations used by the service.
+type Client interface {
+	// ListRoleAssignmentScheduleRequests lists the role assignment schedule requests at and below scope matching an
+	// OData filter, following the pages of the response.
+	ListRoleAssignmentScheduleRequests(ctx context.Context, scope, filter string) ([]RoleAssignmentScheduleRequest, error)
+	// ListRoleEligibilityScheduleInstances lists the role eligibility schedule instances at and below scope matching
+	// an OData filter, following the pages of the response.
+	ListRoleEligibilityScheduleInstances(ctx context.Context, scope, filter string) ([]RoleEligibilityScheduleInstance, error)
+}
+
+// RoleAssignmentScheduleRequest is a role assignment schedule request as returned by Azure Resource Manager. The Azure
+// SDK for Go is not a dependency of the provider, so the response is decoded into this type directly.
+type RoleAssignmentScheduleRequest struct {
+	ID         string                                  `json:"id"`
+	Name       string                                  `json:"name"`
+	Properties RoleAssignmentScheduleRequestProperties `json:"properties"`
+}
+
+// RoleAssignmentScheduleRequestProperties are the properties of a role assignment schedule request.
+type RoleAssignmentScheduleRequestProperties struct {
+	Scope            string        `json:"scope"`
+	RoleDefinitionID string        `json:"roleDefinitionId"`
+	PrincipalID      string        `json:"principalId"`
+	PrincipalType    string        `json:"principalType"`
+	RequestType      string        `json:"requestType"`
+	Status           string        `json:"status"`
+	ApprovalID       string        `json:"approvalId"`
+	Justification    string        `json:"justification"`
+	RequestorID      string        `json:"requestorId"`
+	CreatedOn        *time.Time    `json:"createdOn"`
+	ScheduleInfo     *ScheduleInfo `json:"scheduleInfo"`
+}
+
+// ScheduleInfo is the schedule of a role assignment schedule request.
+type ScheduleInfo struct {
+	StartDateTime *time.Time `json:"startDateTime"`
+	Expiration    *struct {
+		EndDateTime *time.Time `json:"endDateTime"`
+	} `json:"expiration"`
+}
+
+// RoleEligibilityScheduleInstance is a role eligibility schedule instance as returned by Azure Resource Manager.
+type RoleEligibilityScheduleInstance struct {
+	ID         string                                    `json:"id"`
+	Name       string                                    `json:"name"`
+	Properties RoleEligibilityScheduleInstanceProperties `json:"properties"`
+}
+
+// RoleEligibilityScheduleInstanceProperties are the properties of a role eligibility schedule instance.
+type RoleEligibilityScheduleInstanceProperties struct {
+	Scope            string     `json:"scope"`
+	RoleDefinitionID string     `json:"roleDefinitionId"`
+	PrincipalID      string     `json:"principalId"`
+	PrincipalType    string     `json:"principalType"`
+	MemberType       string     `json:"memberType"`
+	StartDateTime    *time.Time `json:"startDateTime"`
+	EndDateTime      *time.Time `json:"endDateTime"`
+}
+
+// ScheduleRequest is a request to assign, activate or remove an Azure role at a scope.
+type ScheduleRequest struct {
+	ID string
+	// Scope is the resource the role applies to, e.g. /subscriptions/{id}.
+	Scope string
+	// RoleDefinitionID is the resource ID of the role definition.
+	RoleDefinitionID string
+	PrincipalID      string
+	// PrincipalType is e.g. User or Group.
+	PrincipalType string
+	// RequestType is e.g. AdminAssign or SelfActivate.
+	RequestType   string
+	Status        string
+	Justification string
+	// RequestorID is the object ID of the principal which made the request.
+	RequestorID string
+	// CreatedDateTime, StartDateTime and EndDateTime are formatted as RFC 3339.
+	CreatedDateTime string
+	StartDateTime   string
+	EndDateTime     string
+	// ApprovalID is the ID of the approval of the request, empty when the request needs no approval.
+	ApprovalID string
+}
+
+// Eligibility is the eligibility of a principal for an Azure role at a scope, read from its schedule instance.
+type Eligibility struct {
+	ID string
+	// Scope is the resource the role applies to, e.g. /subscriptions/{id}.
+	Scope string
+	// RoleDefinitionID is the resource ID of the role definition.
+	RoleDefinitionID string
+	PrincipalID      string
+	// MemberType is Direct when the principal is eligible itself, Group when it is eligible through a group, or
+	// Inherited when the eligibility is at a parent scope.
+	MemberType string
+	// StartDateTime and EndDateTime are formatted as RFC 3339. EndDateTime is empty for permanent eligibilities.
+	StartDateTime string
+	EndDateTime   string
+}
+
+// RequestFilter selects schedule requests at a scope. Empty fields match every request.
+type RequestFilter struct {
+	PrincipalID string
+	// CreatedAfter and CreatedBefore bound the time window the requests were made in, zero for no bound.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// Service reads PIM for Azure resources.
+type Service struct {
+	client Client
+}
+
+func NewService(client Client) *Service {
+	return &Service{client: client}
+}
+
+// ListActivationRequests returns the requests of principals to activate their eligible roles at scope, including those
+// made at child scopes, matching f. Resource Manager cannot filter requests by time, so the window is applied here.
+func (s *Service) ListActivationRequests(ctx context.Context, scope string, f RequestFilter) ([]ScheduleRequest, error) {
+	// atScope() would leave out the requests at child scopes, e.g. resource groups of a subscription.
+	var filter string
+	if f.PrincipalID != "" {
+		filter = fmt.Sprintf("principalId eq '%s'", f.PrincipalID)
+	}
+
+	requests, err := s.client.ListRoleAssignmentScheduleRequests(ctx, scope, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role assignment schedule requests at scope '%s' with filter '%s': %w", scope, filter, err)
+	}
+
+	var result []ScheduleRequest
+	for _, r := range requests {
+		if !strings.EqualFold(r.Properties.RequestType, RequestTypeSelfActivate) {
+			continue
+		}
+
+		if created := r.Properties.CreatedOn; created != nil {
+			if !f.CreatedAfter.IsZero() && created.Before(f.CreatedAfter) {
+				continue
+			}
+			if !f.CreatedBefore.IsZero() && !created.Before(f.CreatedBefore) {
+				continue
+			}
+		}
+
+		result = append(result, fromRoleAssignmentScheduleRequest(r))
+	}
+
+	return result, nil
+}
+
+// ListCallerEligibilities returns the Azure roles the caller is eligible for at and below scope, including those it is
+// eligible for through groups.
+func (s *Service) ListCallerEligibilities(ctx context.Context, scope string) ([]Eligibility, error) {
+	// asTarget() selects the instances of the caller, which needs no permission on the scope beyond being eligible.
+	filter := "asTarget()"
+	instances, err := s.client.ListRoleEligibilityScheduleInstances(ctx, scope, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role eligibility schedule instances at scope '%s': %w", scope, err)
+	}
+
+	result := make([]Eligibility, 0, len(instances))
+	for _, i := range instances {
+		result = append(result, Eligibility{
+			ID:               i.ID,
+			Scope:            i.Properties.Scope,
+			RoleDefinitionID: i.Properties.RoleDefinitionID,
+			PrincipalID:      i.Properties.PrincipalID,
+			MemberType:       i.Properties.MemberType,
+			StartDateTime:    conversions.Time(i.Properties.StartDateTime),
+			EndDateTime:      conversions.Time(i.Properties.EndDateTime),
+		})
+	}
+
+	return result, nil
+}
+
+func fromRoleAssignmentScheduleRequest(r RoleAssignmentScheduleRequest) ScheduleRequest {
+	sr := ScheduleRequest{
+		ID:               r.ID,
+		Scope:            r.Properties.Scope,
+		RoleDefinitionID: r.Properties.RoleDefinitionID,
+		PrincipalID:      r.Properties.PrincipalID,
+		PrincipalType:    r.Properties.PrincipalType,
+		RequestType:      r.Properties.RequestType,
+		Status:           r.Properties.Status,
+		Justification:    r.Properties.Justification,
+		RequestorID:      r.Properties.RequestorID,
+		CreatedDateTime:  conversions.Time(r.Properties.CreatedOn),
+		ApprovalID:       r.Properties.ApprovalID,
+	}
+
+	if scheduleInfo := r.Properties.ScheduleInfo; scheduleInfo != nil {
+		sr.StartDateTime = conversions.Time(scheduleInfo.StartDateTime)
+		if expiration := scheduleInfo.Expiration; expiration != nil {
+			sr.EndDateTime = conversions.Time(expiration.EndDateTime)
+		}
+	}
+
+	return sr
+}
@@ -0,0 +1,496 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package directory resolves Entra ID directory objects referenced by resources, independently of the Graph SDK plumbing.
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+)
+
+// Client is the subset of Microsoft Graph used to look up directory objects.
+type Client interface {
+	// GetUser gets a user by object ID or user principal name. It returns ErrNotFound if the user does not exist.
+	GetUser(ctx context.Context, idOrUserPrincipalName string) (graphmodels.Userable, error)
+	// ListUsers lists the users matching an OData filter. At most top users are returned.
+	ListUsers(ctx context.Context, filter string, top int32) ([]graphmodels.Userable, error)
+	// GetDirectoryObject gets a directory object by object ID. It returns ErrNotFound if the object does not exist.
+	GetDirectoryObject(ctx context.Context, id string) (graphmodels.DirectoryObjectable, error)
+	// ListGroups lists the groups matching an OData filter. At most top groups are returned.
+	ListGroups(ctx context.Context, filter string, top int32) ([]graphmodels.Groupable, error)
+	// CallerObjectID returns the object ID of the user or service principal the client authenticates as.
+	CallerObjectID(ctx context.Context) (string, error)
+	// ListAllGroups calls visit with each page of the groups matching an OData filter, following the pages of the
+	// response. The groups of a tenant can be many, so callers keep what they need of a page instead of the whole
+	// models. An error of visit stops the listing and is returned.
+	ListAllGroups(ctx context.Context, filter string, visit func(page []graphmodels.Groupable) error) error
+	// ListGroupMembers lists the direct members of a group.
+	ListGroupMembers(ctx context.Context, groupID string) ([]graphmodels.DirectoryObjectable, error)
+	// RemoveGroupMember removes a direct member from a group.
+	RemoveGroupMember(ctx context.Context, groupID, memberID string) error
+	// ListGroupMemberChanges lists the changes of the direct members of a group since deltaLink with a delta query,
+	// following the pages of the response, and returns the delta link of the next query. All members are listed as
+	// added when deltaLink is empty. It returns ErrDeltaExpired if Graph no longer tracks the changes since deltaLink.
+	ListGroupMemberChanges(ctx context.Context, groupID, deltaLink string) ([]MemberChange, string, error)
+	// ListGroupChanges lists the changes of the groups of the tenant since deltaLink with a delta query, following the
+	// pages of the response, and returns the delta link of the next query. All groups are listed when deltaLink is
+	// empty. It returns ErrDeltaExpired if Graph no longer tracks the changes since deltaLink.
+	ListGroupChanges(ctx context.Context, deltaLink string) ([]GroupChange, string, error)
+}
+
+// The OData types of the directory objects which can be eligible for a role in a group.
+const (
+	odataTypeUser  = "#microsoft.graph.user"
+	odataTypeGroup = "#microsoft.graph.group"
+)
+
+// UserTypeGuest is the userType of B2B guests.
+const UserTypeGuest = "Guest"
+
+// groupTypeDynamicMembership is in the groupTypes of groups whose members are determined by a membership rule.
+const groupTypeDynamicMembership = "DynamicMembership"
+
+// Principal is a user, group or service principal which can be eligible for a role.
+type Principal struct {
+	ID string
+	// OdataType is the type of the directory object, e.g. #microsoft.graph.user.
+	OdataType string
+	// UserType is Member or Guest for users, and empty for other principals.
+	UserType string
+	// HomeDomain is the domain of the email address of a guest, i.e. of the organization it was invited from. Graph
+	// does not expose the home tenant ID of guests. It is empty for other principals.
+	HomeDomain string
+	// PendingAcceptance is whether a guest has not accepted its invitation yet.
+	PendingAcceptance bool
+}
+
+// Member is a direct member of a group.
+type Member struct {
+	ID string
+	// OdataType is the type of the member, e.g. #microsoft.graph.user.
+	OdataType string
+}
+
+// MemberChange is a change of the direct members of a group returned by a delta query.
+type MemberChange struct {
+	Member
+	// Removed is whether the member was removed, rather than added.
+	Removed bool
+}
+
+// GroupChange is a change of a group returned by a delta query.
+type GroupChange struct {
+	ID string
+	// SecurityEnabled is nil when it did not change.
+	SecurityEnabled *bool
+	// Removed is whether the group was deleted.
+	Removed bool
+}
+
+// Group is a group in the tenant.
+type Group struct {
+	ID          string
+	DisplayName string
+	// MembershipRule is the rule determining the members of a group with dynamic membership. It is empty for groups
+	// whose members are assigned, and for groups listed by RoleAssignableGroups.
+	MembershipRule string
+	// DynamicMembership is whether the members of the group are determined by its membership rule.
+	DynamicMembership bool
+}
+
+// CheckPIMSupported returns an error describing why PIM for Groups cannot manage the group, or nil if it can as far as
+// the directory is concerned.
+func (g Group) CheckPIMSupported() error {
+	if !g.DynamicMembership && g.MembershipRule == "" {
+		return nil
+	}
+
+	return fmt.Errorf("group %s has dynamic membership with the rule %q, and PIM for Groups only supports groups whose members are assigned, as the rule would override the members PIM activates", g.ID, g.MembershipRule)
+}
+
+// CheckEligibleFor returns an error describing why PIM for Groups rejects making the principal eligible for role in the
+// group, or nil if it accepts it as far as the directory is concerned.
+func (p Principal) CheckEligibleFor(groupID, role string) error {
+	if strings.EqualFold(p.ID, groupID) {
+		return fmt.Errorf("group %s cannot be eligible for itself", groupID)
+	}
+
+	switch p.OdataType {
+	case odataTypeUser:
+		return nil
+	case odataTypeGroup:
+		if role == "owner" {
+			return fmt.Errorf("%s is a group, and groups cannot own groups, so only users can be eligible owners", p.ID)
+		}
+		return nil
+	case "":
+		// The type is unknown, so it is left to Graph to reject.
+		return nil
+	default:
+		return fmt.Errorf("%s is a %s, and only users and groups can be eligible for groups", p.ID, strings.TrimPrefix(p.OdataType, "#microsoft.graph."))
+	}
+}
+
+// CanBeEligible returns whether the member is a user or group, the principal types PIM for Groups supports.
+func (m Member) CanBeEligible() bool {
+	return m.OdataType == odataTypeUser || m.OdataType == odataTypeGroup
+}
+
+// ErrNotFound is returned by clients when a directory object does not exist.
+var ErrNotFound = errors.New("directory object not found")
+
+// ErrDeltaExpired is returned by clients when Graph no longer tracks the changes since a delta link, e.g. because it
+// is older than the changes Graph keeps.
+var ErrDeltaExpired = errors.New("delta link expired")
+
+// Service looks up directory objects.
+type Service struct {
+	client         Client
+	securityGroups *SecurityGroupCache
+}
+
+func NewService(client Client) *Service {
+	return &Service{client: client}
+}
+
+// UserIDByPrincipalName returns the object ID of the user with the given user principal name. Guests are also found by
+// their external email address, since their user principal name, e.g. alice_contoso.com#EXT#@fabrikam.onmicrosoft.com,
+// is rarely known.
+func (s *Service) UserIDByPrincipalName(ctx context.Context, userPrincipalName string) (string, error) {
+	user, err := s.client.GetUser(ctx, userPrincipalName)
+	if errors.Is(err, ErrNotFound) && !strings.Contains(userPrincipalName, "#EXT#") {
+		return s.guestIDByEmail(ctx, userPrincipalName)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to get user %q: %w", userPrincipalName, err)
+	}
+
+	if user == nil || user.GetId() == nil {
+		return "", fmt.Errorf("user %q has no object ID", userPrincipalName)
+	}
+
+	return *user.GetId(), nil
+}
+
+// guestIDByEmail returns the object ID of the guest with the given external email address.
+func (s *Service) guestIDByEmail(ctx context.Context, email string) (string, error) {
+	filter := fmt.Sprintf("userType eq '%s' and mail eq '%s'", UserTypeGuest, strings.ReplaceAll(email, "'", "''"))
+	users, err := s.client.ListUsers(ctx, filter, 2)
+	if err != nil {
+		return "", fmt.Errorf("unable to list users with filter '%s': %w", filter, err)
+	}
+
+	if len(users) == 0 {
+		return "", fmt.Errorf("no user has the user principal name %q, and no guest has it as email address", email)
+	}
+
+	if len(users) > 1 {
+		return "", fmt.Errorf("more than one guest has the email address %q, use the user principal name or object ID instead", email)
+	}
+
+	if users[0].GetId() == nil {
+		return "", fmt.Errorf("guest %q has no object ID", email)
+	}
+
+	return *users[0].GetId(), nil
+}
+
+// GroupIDByDisplayName returns the object ID of the group with the given display name.
+// Display names are not unique in Entra ID, so it fails unless exactly one group matches.
+func (s *Service) GroupIDByDisplayName(ctx context.Context, displayName string) (string, error) {
+	filter := fmt.Sprintf("displayName eq '%s'", strings.ReplaceAll(displayName, "'", "''"))
+	groups, err := s.client.ListGroups(ctx, filter, 2)
+	if err != nil {
+		return "", fmt.Errorf("unable to list groups with filter '%s': %w", filter, err)
+	}
+
+	if len(groups) == 0 {
+		return "", fmt.Errorf("no group has the display name %q", displayName)
+	}
+
+	if len(groups) > 1 {
+		return "", fmt.Errorf("more than one group has the display name %q, use the group object ID instead", displayName)
+	}
+
+	if groups[0].GetId() == nil {
+		return "", fmt.Errorf("group %q has no object ID", displayName)
+	}
+
+	return *groups[0].GetId(), nil
+}
+
+// GetPrincipal returns the user, group or service principal with the given object ID. It returns ErrNotFound if the
+// principal does not exist. Deleted principals do not exist, even while they can still be restored.
+func (s *Service) GetPrincipal(ctx context.Context, id string) (Principal, error) {
+	o, err := s.client.GetDirectoryObject(ctx, id)
+	if err != nil {
+		return Principal{}, fmt.Errorf("unable to get directory object %q: %w", id, err)
+	}
+
+	p := Principal{ID: id}
+	if o.GetOdataType() != nil {
+		p.OdataType = *o.GetOdataType()
+	}
+
+	user, ok := o.(graphmodels.Userable)
+	if !ok {
+		return p, nil
+	}
+
+	if user.GetUserType() != nil {
+		p.UserType = *user.GetUserType()
+	}
+
+	if p.UserType == UserTypeGuest {
+		if mail := user.GetMail(); mail != nil {
+			if _, domain, ok := strings.Cut(*mail, "@"); ok {
+				p.HomeDomain = strings.ToLower(domain)
+			}
+		}
+		p.PendingAcceptance = user.GetExternalUserState() != nil && *user.GetExternalUserState() == "PendingAcceptance"
+	}
+
+	return p, nil
+}
+
+// GetGroup returns the group with the given object ID. It returns ErrNotFound if the group does not exist. When the
+// object is not a group, only its ID is returned and it is left to Graph to reject.
+func (s *Service) GetGroup(ctx context.Context, id string) (Group, error) {
+	o, err := s.client.GetDirectoryObject(ctx, id)
+	if err != nil {
+		return Group{}, fmt.Errorf("unable to get directory object %q: %w", id, err)
+	}
+
+	group := Group{ID: id}
+	g, ok := o.(graphmodels.Groupable)
+	if !ok {
+		return group, nil
+	}
+
+	if g.GetDisplayName() != nil {
+		group.DisplayName = *g.GetDisplayName()
+	}
+	if g.GetMembershipRule() != nil {
+		group.MembershipRule = *g.GetMembershipRule()
+	}
+	group.DynamicMembership = slices.Contains(g.GetGroupTypes(), groupTypeDynamicMembership)
+
+	return group, nil
+}
+
+// GroupExists returns whether the group with the given object ID exists. A group which was deleted and recreated with
+// the same display name has a new object ID, so the old ID does not exist.
+func (s *Service) GroupExists(ctx context.Context, id string) (bool, error) {
+	return s.objectExists(ctx, id)
+}
+
+func (s *Service) objectExists(ctx context.Context, id string) (bool, error) {
+	_, err := s.client.GetDirectoryObject(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get directory object %q: %w", id, err)
+	}
+
+	return true, nil
+}
+
+// GroupMembers returns the direct members of the group with the given object ID.
+func (s *Service) GroupMembers(ctx context.Context, groupID string) ([]Member, error) {
+	objects, err := s.client.ListGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list members of group %q: %w", groupID, err)
+	}
+
+	members := make([]Member, 0, len(objects))
+	for _, o := range objects {
+		if o.GetId() == nil {
+			return nil, fmt.Errorf("member of group %q has no object ID", groupID)
+		}
+
+		m := Member{ID: *o.GetId()}
+		if o.GetOdataType() != nil {
+			m.OdataType = *o.GetOdataType()
+		}
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// GroupMembersSince returns the direct members of the group with the given object ID, and the delta link to list the
+// changes since with. members are the members as of deltaLink, and only the changes since are listed. All members are
+// listed when deltaLink is empty, or Graph no longer tracks the changes since. The members are sorted by object ID.
+func (s *Service) GroupMembersSince(ctx context.Context, groupID string, members []Member, deltaLink string) ([]Member, string, error) {
+	changes, next, err := s.client.ListGroupMemberChanges(ctx, groupID, deltaLink)
+	if deltaLink != "" && errors.Is(err, ErrDeltaExpired) {
+		deltaLink = ""
+		changes, next, err = s.client.ListGroupMemberChanges(ctx, groupID, "")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list changes of members of group %q: %w", groupID, err)
+	}
+
+	byID := map[string]Member{}
+	if deltaLink != "" {
+		for _, m := range members {
+			byID[strings.ToLower(m.ID)] = m
+		}
+	}
+	for _, c := range changes {
+		if c.Removed {
+			delete(byID, strings.ToLower(c.ID))
+		} else {
+			byID[strings.ToLower(c.ID)] = c.Member
+		}
+	}
+
+	result := make([]Member, 0, len(byID))
+	for _, m := range byID {
+		result = append(result, m)
+	}
+	slices.SortFunc(result, func(a, b Member) int { return strings.Compare(a.ID, b.ID) })
+
+	return result, next, nil
+}
+
+// RemoveGroupMember removes the direct member with the given object ID from the group.
+func (s *Service) RemoveGroupMember(ctx context.Context, groupID, memberID string) error {
+	if err := s.client.RemoveGroupMember(ctx, groupID, memberID); err != nil {
+		return fmt.Errorf("unable to remove member %q from group %q: %w", memberID, groupID, err)
+	}
+
+	return nil
+}
+
+// SetSecurityGroupCache sets the cache of the security groups of the tenant, shared by all services of a provider
+// instance.
+func (s *Service) SetSecurityGroupCache(c *SecurityGroupCache) {
+	s.securityGroups = c
+}
+
+// SecurityGroupCache caches the security groups of the tenant, so data sources listing all of them only fetch the
+// changes since the previous listing of the same plan or apply with a delta query. It is safe for concurrent use.
+type SecurityGroupCache struct {
+	mu sync.Mutex
+	// ids are the object IDs of the security groups by their lower case object ID, as of deltaLink.
+	ids       map[string]string
+	deltaLink string
+}
+
+func NewSecurityGroupCache() *SecurityGroupCache {
+	return &SecurityGroupCache{ids: map[string]string{}}
+}
+
+// refresh applies the changes since the delta link of the cache, or lists all groups again when there is none or it
+// expired, and returns the object IDs of the security groups sorted.
+func (c *SecurityGroupCache) refresh(ctx context.Context, client Client) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changes, next, err := client.ListGroupChanges(ctx, c.deltaLink)
+	if c.deltaLink != "" && errors.Is(err, ErrDeltaExpired) {
+		c.ids, c.deltaLink = map[string]string{}, ""
+		changes, next, err = client.ListGroupChanges(ctx, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range changes {
+		key := strings.ToLower(g.ID)
+		switch {
+		case g.Removed || (g.SecurityEnabled != nil && !*g.SecurityEnabled):
+			delete(c.ids, key)
+		case g.SecurityEnabled != nil:
+			c.ids[key] = g.ID
+		}
+	}
+	c.deltaLink = next
+
+	ids := make([]string, 0, len(c.ids))
+	for _, id := range c.ids {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
+// SecurityGroupIDs returns the object IDs of all security groups in the tenant. PIM can only be enabled for them. With
+// a security group cache, only the changes since the previous call are fetched, and the IDs are sorted.
+func (s *Service) SecurityGroupIDs(ctx context.Context) ([]string, error) {
+	if s.securityGroups != nil {
+		ids, err := s.securityGroups.refresh(ctx, s.client)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list changes of security groups: %w", err)
+		}
+
+		return ids, nil
+	}
+
+	var ids []string
+	err := s.client.ListAllGroups(ctx, "securityEnabled eq true", func(page []graphmodels.Groupable) error {
+		for _, g := range page {
+			if g.GetId() != nil {
+				ids = append(ids, *g.GetId())
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list security groups: %w", err)
+	}
+
+	return ids, nil
+}
+
+// RoleAssignableGroups returns the groups which can be assigned Microsoft Entra roles, i.e. with isAssignableToRole
+// set, whose display name starts with displayNamePrefix. An empty prefix returns all of them.
+func (s *Service) RoleAssignableGroups(ctx context.Context, displayNamePrefix string) ([]Group, error) {
+	filter := "isAssignableToRole eq true"
+	if displayNamePrefix != "" {
+		filter += fmt.Sprintf(" and startswith(displayName, '%s')", strings.ReplaceAll(displayNamePrefix, "'", "''"))
+	}
+
+	result := []Group{}
+	err := s.client.ListAllGroups(ctx, filter, func(page []graphmodels.Groupable) error {
+		for _, g := range page {
+			if g.GetId() == nil {
+				continue
+			}
+
+			group := Group{ID: *g.GetId()}
+			if g.GetDisplayName() != nil {
+				group.DisplayName = *g.GetDisplayName()
+			}
+			result = append(result, group)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list groups with filter '%s': %w", filter, err)
+	}
+
+	return result, nil
+}
+
+// CallerObjectID returns the object ID of the user or service principal the provider authenticates as.
+func (s *Service) CallerObjectID(ctx context.Context) (string, error) {
+	id, err := s.client.CallerObjectID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to get object ID of the caller: %w", err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,1502 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package grouppim maps PIM for groups concepts to Microsoft Graph calls, so resources only translate between
+// Terraform values and the types in this package.
+package grouppim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	jsonserialization "github.com/microsoft/kiota-serialization-json-go"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+)
+
+const (
+	// StatusProvisioned is the status of a schedule request which is in effect.
+	StatusProvisioned = "Provisioned"
+	// StatusPendingApproval is the status of a schedule request waiting for an approver.
+	StatusPendingApproval = "PendingApproval"
+)
+
+// expirationAdminEligibilityRuleID is the ID of the policy rule controlling whether eligible assignments made by
+// admins must expire.
+const expirationAdminEligibilityRuleID = "Expiration_Admin_Eligibility"
+
+// policyProvisioningTimeout bounds how long a policy update waits for the policies of a group which was just
+// onboarded to PIM, and policyRetryDelay is the first delay between attempts.
+const (
+	policyProvisioningTimeout = 2 * time.Minute
+	policyRetryDelay          = 5 * time.Second
+)
+
+// policyRollbackTimeout bounds restoring the expiration rule after a failed creation. The rollback outlives the
+// context of the creation, which may have failed because it timed out.
+const policyRollbackTimeout = time.Minute
+
+// Client is the set of Graph operations used by the service.
+type Client interface {
+	CreateEligibilityScheduleRequest(ctx context.Context, body graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable) (graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error)
+	CancelEligibilityScheduleRequest(ctx context.Context, requestID string) error
+	ListEligibilityScheduleRequests(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error)
+	// GetEligibilityScheduleRequest and GetEligibilityScheduleInstance return ErrNotFound if the ID does not exist.
+	GetEligibilityScheduleRequest(ctx context.Context, requestID string) (graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable, error)
+	GetEligibilityScheduleInstance(ctx context.Context, instanceID string) (graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable, error)
+	ListEligibilityScheduleInstances(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable, error)
+	ListAssignmentScheduleInstances(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable, error)
+	ListAssignmentScheduleRequests(ctx context.Context, filter string) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, error)
+	CreateAssignmentScheduleRequest(ctx context.Context, body graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable) (graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, error)
+	// CreateAssignmentScheduleRequests creates several assignment schedule requests in as few round trips as Graph
+	// allows. It returns the created requests and the error of each, in the order of bodies.
+	CreateAssignmentScheduleRequests(ctx context.Context, bodies []graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, []error)
+	ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error)
+	// GetRoleManagementPolicy returns a policy with its rules and effective rules expanded. It returns
+	// ErrPolicyNotFound if the policy does not exist.
+	GetRoleManagementPolicy(ctx context.Context, policyID string) (graphmodels.UnifiedRoleManagementPolicyable, error)
+	// UpdatePolicyExpirationRule returns ErrPolicyNotFound if the policy does not exist, and ErrPolicyConflict if the rule
+	// was changed by someone else while it was being updated.
+	UpdatePolicyExpirationRule(ctx context.Context, policyId string, isExpirationRequired bool) error
+}
+
+// EligibleAssignment is the eligibility of a principal to assume a role in a PIM enabled group.
+type EligibleAssignment struct {
+	// RequestID is the ID of the eligibility schedule request which created the assignment.
+	RequestID     string
+	GroupID       string
+	PrincipalID   string
+	Role          string
+	Justification string
+	Status        string
+	// StartDateTime and EndDateTime are formatted as RFC 3339.
+	StartDateTime string
+	EndDateTime   string
+	// ExpirationType is one of noExpiration, afterDateTime or afterDuration.
+	ExpirationType string
+	// ExpirationDuration is an ISO 8601 duration.
+	ExpirationDuration string
+	// MemberType is direct when the principal is eligible itself, or group when it is eligible through a group.
+	// It is read from the schedule instance, and is empty until the instance exists.
+	MemberType string
+	// CreatedBy is the object ID of the user or application which created the request.
+	CreatedBy string
+	// CreatedDateTime and CompletedDateTime of the request are formatted as RFC 3339.
+	CreatedDateTime   string
+	CompletedDateTime string
+	// TargetScheduleID is the ID of the eligibility schedule created by the request.
+	TargetScheduleID string
+	// InstanceID is the ID of the eligibility schedule instance. It is empty until the instance exists.
+	InstanceID string
+	// PolicyID is the ID of the role management policy governing the eligibility. It is only set by
+	// CreateEligibleAssignment, use EligibleExpirationPolicyID to resolve it otherwise.
+	PolicyID string
+
+	// Raw is the schedule request as returned by Graph.
+	Raw graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable
+}
+
+// ActiveAssignment is the active assignment of a principal to a role in a PIM enabled group, either activated through
+// an eligibility or assigned permanently.
+type ActiveAssignment struct {
+	GroupID     string
+	PrincipalID string
+	Role        string
+	// AssignmentType is activated or assigned.
+	AssignmentType string
+	// MemberType is direct when the principal is assigned itself, or group when it is assigned through a group.
+	MemberType string
+	// StartDateTime and EndDateTime are formatted as RFC 3339. EndDateTime is empty for assignments without expiration.
+	StartDateTime string
+	EndDateTime   string
+}
+
+// Activation is the activation of an eligibility by the principal itself.
+type Activation struct {
+	GroupID       string
+	PrincipalID   string
+	Role          string
+	Justification string
+	// Duration is how long the activation lasts, as ISO 8601 duration such as PT8H.
+	Duration string
+	// RequestID and Status are those of the assignment schedule request, set once it is created.
+	RequestID string
+	Status    string
+	// StartDateTime and EndDateTime are formatted as RFC 3339, set once the request is created. Extend keeps the start.
+	StartDateTime string
+	EndDateTime   string
+}
+
+// PolicyAssignment is the assignment of the role management policy governing a role in a group.
+type PolicyAssignment struct {
+	ID       string
+	PolicyID string
+	Rules    []PolicyRule
+}
+
+// PolicyRule is a rule of the role management policy governing a role in a group.
+type PolicyRule struct {
+	// ID identifies the rule within the policy, e.g. Expiration_Admin_Eligibility.
+	ID string
+	// Inherited is whether the rule follows the tenant defaults instead of being set for the group: it has the settings
+	// of the default rule, or a tenant wide rule takes precedence over it.
+	Inherited bool
+}
+
+// ErrNotFound is returned when a principal has no provisioned eligibility in a group.
+var ErrNotFound = errors.New("eligible assignment not found")
+
+// ErrPolicyNotFound is returned when the role management policy of a group does not exist, e.g. while the group is
+// being onboarded to PIM.
+var ErrPolicyNotFound = errors.New("role management policy not found")
+
+// ErrPolicyConflict is returned when a policy rule kept changing between reading and updating it, e.g. because another
+// Terraform run or an administrator in the portal was editing the policy at the same time.
+var ErrPolicyConflict = errors.New("role management policy rule was changed concurrently")
+
+// ErrExpirationRequired is returned in strict policy mode when the policy of a group requires eligible assignments to
+// expire, so an eligibility without expiration cannot be assigned.
+var ErrExpirationRequired = errors.New("the role management policy requires eligible assignments to expire, and strict policy mode does not allow changing it")
+
+// ErrActivated is returned when removing an eligibility which is in use by an active assignment.
+var ErrActivated = errors.New("the principal has an active assignment through the eligibility")
+
+// Service manages eligible assignments of groups.
+type Service struct {
+	client Client
+	ticket TicketInfo
+	// strictPolicy leaves the expiration policy of groups as it is.
+	strictPolicy bool
+	// policies caches the policy assignments of groups, nil to look them up every time.
+	policies *PolicyCache
+}
+
+func NewService(client Client) *Service {
+	return &Service{client: client}
+}
+
+// TicketInfo references the ticket, e.g. a change request, which schedule requests are made for.
+type TicketInfo struct {
+	System string
+	Number string
+}
+
+// SetTicketInfo sets the ticket referenced by every schedule request the service creates.
+func (s *Service) SetTicketInfo(t TicketInfo) {
+	s.ticket = t
+}
+
+// SetStrictPolicy sets whether the service leaves the expiration policy of groups as it is. Assigning an eligibility
+// without expiration then fails with ErrExpirationRequired if the policy requires expiration, instead of changing it.
+func (s *Service) SetStrictPolicy(strict bool) {
+	s.strictPolicy = strict
+}
+
+// SetPolicyCache sets the cache of the policy assignments of groups, shared by all services of a provider instance.
+func (s *Service) SetPolicyCache(c *PolicyCache) {
+	s.policies = c
+}
+
+// PolicyCache caches the assignment of the policy governing the member role of groups, so resources in the same group
+// look it up only once per plan or apply. Concurrent lookups of the same group wait for the first one. Failed lookups
+// are not cached, and the policy of a group is looked up again after the service updated it. It is safe for
+// concurrent use.
+type PolicyCache struct {
+	mu      sync.Mutex
+	entries map[string]*policyCacheEntry
+}
+
+// policyCacheEntry is a policy assignment of a group, which is being looked up until done is closed.
+type policyCacheEntry struct {
+	done       chan struct{}
+	assignment graphmodels.UnifiedRoleManagementPolicyAssignmentable
+	err        error
+}
+
+func NewPolicyCache() *PolicyCache {
+	return &PolicyCache{entries: map[string]*policyCacheEntry{}}
+}
+
+// assignment returns the cached policy assignment of groupID, or looks it up with lookup.
+func (c *PolicyCache) assignment(ctx context.Context, groupID string, lookup func() (graphmodels.UnifiedRoleManagementPolicyAssignmentable, error)) (graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	if c == nil {
+		return lookup()
+	}
+
+	key := strings.ToLower(groupID)
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &policyCacheEntry{done: make(chan struct{})}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		e.assignment, e.err = lookup()
+		if e.err != nil {
+			c.mu.Lock()
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+		}
+		close(e.done)
+
+		return e.assignment, e.err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-e.done:
+	}
+
+	// The error may be specific to the other lookup, e.g. its context being canceled.
+	if e.err != nil {
+		return lookup()
+	}
+
+	return e.assignment, nil
+}
+
+// invalidate removes the cached policy assignment of groupID.
+func (c *PolicyCache) invalidate(groupID string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, strings.ToLower(groupID))
+}
+
+// ticketInfoSetter is implemented by the eligibility and assignment schedule requests.
+type ticketInfoSetter interface {
+	SetTicketInfo(value graphmodels.TicketInfoable)
+}
+
+// setTicketInfo sets the ticket of the service on a schedule request, unless no ticket is set.
+func (s *Service) setTicketInfo(requestBody ticketInfoSetter) {
+	if s.ticket == (TicketInfo{}) {
+		return
+	}
+
+	ticket := graphmodels.NewTicketInfo()
+	if s.ticket.System != "" {
+		ticket.SetTicketSystem(&s.ticket.System)
+	}
+	if s.ticket.Number != "" {
+		ticket.SetTicketNumber(&s.ticket.Number)
+	}
+	requestBody.SetTicketInfo(ticket)
+}
+
+// CreateStep is a step of creating an eligible assignment. The steps are taken in the order of the constants.
+type CreateStep string
+
+const (
+	// CreateStepResolvePolicy resolves the policy governing the member role of the group.
+	CreateStepResolvePolicy CreateStep = "resolve policy"
+	// CreateStepPatchRule allows eligible assignments without expiration in the policy, or only checks it in strict
+	// policy mode.
+	CreateStepPatchRule CreateStep = "patch expiration rule"
+	// CreateStepSubmitRequest submits the eligibility schedule request.
+	CreateStepSubmitRequest CreateStep = "submit schedule request"
+	// CreateStepPoll looks up the schedule instance created by the request. It does not fail the creation, as the
+	// instance is created asynchronously.
+	CreateStepPoll CreateStep = "poll schedule instance"
+)
+
+// CreateError is returned by CreateEligibleAssignment when one of its steps failed.
+type CreateError struct {
+	Step CreateStep
+	// Changed describes what the steps before Step changed in the tenant, and which could not be rolled back. It is
+	// empty when nothing was changed, or everything was rolled back.
+	Changed []string
+	// RolledBack describes the changes which were rolled back after the step failed.
+	RolledBack []string
+	Err        error
+	// RollbackErr is the error rolling back the changes, nil when there was nothing to roll back or it succeeded.
+	RollbackErr error
+}
+
+func (e *CreateError) Error() string {
+	return fmt.Sprintf("step %q failed: %v", e.Step, e.Err)
+}
+
+func (e *CreateError) Unwrap() error {
+	return e.Err
+}
+
+// CreateEligibleAssignment allows eligible assignments without expiration in the group policy, and then assigns a.
+// In strict policy mode it fails with ErrExpirationRequired instead if the policy requires expiration.
+// The start date defaults to now. A failed step is returned as *CreateError. If submitting the request fails after
+// the policy was changed, the policy is restored to require expiration again, so a failed creation does not leave the
+// policy weakened.
+func (s *Service) CreateEligibleAssignment(ctx context.Context, a EligibleAssignment) (EligibleAssignment, error) {
+	if a.StartDateTime == "" {
+		a.StartDateTime = time.Now().Format(time.RFC3339)
+	}
+
+	// The request is built first, so an invalid assignment fails before anything is changed.
+	requestBody, err := newScheduleRequest(a, graphmodels.ADMINASSIGN_SCHEDULEREQUESTACTIONS)
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to create eligibility schedule request: %w", err)
+	}
+	s.setTicketInfo(requestBody)
+
+	var changed []string
+	fail := func(step CreateStep, err error) (EligibleAssignment, error) {
+		return EligibleAssignment{}, &CreateError{Step: step, Changed: changed, Err: err}
+	}
+
+	policyId, err := s.resolvePolicyID(ctx, a.GroupID)
+	if err != nil {
+		return fail(CreateStepResolvePolicy, err)
+	}
+
+	// The rule is only restored if it required expiration before, the creation must not tighten the policy either.
+	previouslyRequired := false
+	if !s.strictPolicy {
+		previouslyRequired, err = s.ExpirationRequired(ctx, a.GroupID)
+		if err != nil {
+			return fail(CreateStepPatchRule, fmt.Errorf("unable to get eligible expiration policy rule: %w", err))
+		}
+	}
+
+	patched, err := s.patchExpirationRule(ctx, a.GroupID, policyId, false)
+	if err != nil {
+		return fail(CreateStepPatchRule, err)
+	}
+	weakened := patched && previouslyRequired
+	if weakened {
+		changed = append(changed, fmt.Sprintf("policy %s of group %s was changed to allow eligible assignments without expiration", policyId, a.GroupID))
+	}
+
+	created, err := s.client.CreateEligibilityScheduleRequest(ctx, requestBody)
+	if err != nil {
+		createErr := &CreateError{Step: CreateStepSubmitRequest, Changed: changed, Err: fmt.Errorf("unable to create eligibility schedule request: %w", err)}
+		if weakened {
+			createErr.RollbackErr = s.restoreExpirationRule(ctx, a.GroupID, policyId)
+			if createErr.RollbackErr == nil {
+				createErr.Changed, createErr.RolledBack = nil, changed
+			}
+		}
+
+		return EligibleAssignment{}, createErr
+	}
+
+	result, err := fromScheduleRequest(created)
+	if err != nil {
+		return fail(CreateStepSubmitRequest, err)
+	}
+	result.PolicyID = policyId
+
+	// The instance is created asynchronously, it is picked up on the next read if it does not exist yet.
+	if err := s.setScheduleInstance(ctx, &result); err != nil {
+		tflog.Warn(ctx, "unable to get eligibility schedule instance", map[string]any{"step": string(CreateStepPoll), "error": err.Error()})
+	}
+
+	return result, nil
+}
+
+// GetEligibleAssignment returns the provisioned eligible assignment of principalID for role in groupID. A principal can
+// be eligible for both roles of a group, so an empty role only matches when the principal is eligible for one of them.
+// When several provisioned requests match, the most recently created is used if newest is set, otherwise it fails.
+func (s *Service) GetEligibleAssignment(ctx context.Context, groupID, principalID, role string, newest bool) (EligibleAssignment, error) {
+	filter := fmt.Sprintf("groupId eq '%s' and principalId eq '%s'", groupID, principalID)
+	if role != "" {
+		filter += fmt.Sprintf(" and accessId eq '%s'", role)
+	}
+	requests, err := s.client.ListEligibilityScheduleRequests(ctx, filter)
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to get eligibility schedule requests with filter '%s': %w", filter, err)
+	}
+
+	var provisioned []graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable
+	for _, r := range requests {
+		// The list can return multiple results, but we can remove old assignments which might have status like "Revoked".
+		if conversions.String(r.GetStatus()) == StatusProvisioned {
+			provisioned = append(provisioned, r)
+		}
+	}
+
+	if len(provisioned) == 0 {
+		return EligibleAssignment{}, fmt.Errorf("got 0 results, want 1: %w", ErrNotFound)
+	}
+
+	if role == "" {
+		roles := map[graphmodels.PrivilegedAccessGroupRelationships]bool{}
+		for _, r := range provisioned {
+			if r.GetAccessId() != nil {
+				roles[*r.GetAccessId()] = true
+			}
+		}
+		if len(roles) > 1 {
+			return EligibleAssignment{}, fmt.Errorf("the principal is eligible for both the owner and member role, the role must be given")
+		}
+	}
+
+	if len(provisioned) > 1 && !newest {
+		return EligibleAssignment{}, fmt.Errorf("got %d results, want 1", len(provisioned))
+	}
+
+	// Requests without a creation time sort last.
+	sort.SliceStable(provisioned, func(i, j int) bool {
+		a, b := provisioned[i].GetCreatedDateTime(), provisioned[j].GetCreatedDateTime()
+		return a != nil && (b == nil || a.After(*b))
+	})
+
+	result, err := fromScheduleRequest(provisioned[0])
+	if err != nil {
+		return EligibleAssignment{}, err
+	}
+
+	if err := s.setScheduleInstance(ctx, &result); err != nil {
+		return EligibleAssignment{}, err
+	}
+
+	return result, nil
+}
+
+// PrincipalOfSchedule returns the group, principal and role of the eligibility schedule request or instance with the
+// given ID, as shown in the portal. The role is empty if Graph did not return it.
+func (s *Service) PrincipalOfSchedule(ctx context.Context, id string) (groupID, principalID, role string, err error) {
+	request, err := s.client.GetEligibilityScheduleRequest(ctx, id)
+	if err == nil {
+		return conversions.String(request.GetGroupId()), conversions.String(request.GetPrincipalId()), accessIDRole(request.GetAccessId()), nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return "", "", "", fmt.Errorf("unable to get eligibility schedule request %q: %w", id, err)
+	}
+
+	instance, err := s.client.GetEligibilityScheduleInstance(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return "", "", "", fmt.Errorf("no eligibility schedule request or instance has the ID %q: %w", id, err)
+	}
+	if err != nil {
+		return "", "", "", fmt.Errorf("unable to get eligibility schedule instance %q: %w", id, err)
+	}
+
+	return conversions.String(instance.GetGroupId()), conversions.String(instance.GetPrincipalId()), accessIDRole(instance.GetAccessId()), nil
+}
+
+// accessIDRole returns the role of an accessId returned by Graph, or an empty string if it is missing or unknown.
+func accessIDRole(accessId *graphmodels.PrivilegedAccessGroupRelationships) string {
+	if accessId == nil {
+		return ""
+	}
+
+	role, err := conversions.AccessIDToRole(*accessId)
+	if err != nil {
+		return ""
+	}
+
+	return role
+}
+
+// ListProvisionedRequests returns the eligible assignments in groupID as described by their provisioned schedule
+// requests, which unlike the instances record who created them.
+func (s *Service) ListProvisionedRequests(ctx context.Context, groupID string) ([]EligibleAssignment, error) {
+	filter := fmt.Sprintf("groupId eq '%s'", groupID)
+	requests, err := s.client.ListEligibilityScheduleRequests(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get eligibility schedule requests with filter '%s': %w", filter, err)
+	}
+
+	var result []EligibleAssignment
+	for _, r := range requests {
+		if conversions.String(r.GetStatus()) != StatusProvisioned {
+			continue
+		}
+
+		a, err := fromScheduleRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+// ListScheduleRequests returns the eligibility schedule requests in groupID, or of principalID, or of principalID in
+// groupID, with the given status. Graph requires a group or principal. An empty status matches every request.
+func (s *Service) ListScheduleRequests(ctx context.Context, groupID, principalID, status string) ([]EligibleAssignment, error) {
+	var filters []string
+	if groupID != "" {
+		filters = append(filters, fmt.Sprintf("groupId eq '%s'", groupID))
+	}
+	if principalID != "" {
+		filters = append(filters, fmt.Sprintf("principalId eq '%s'", principalID))
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("either a group or principal is required")
+	}
+
+	filter := strings.Join(filters, " and ")
+	requests, err := s.client.ListEligibilityScheduleRequests(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get eligibility schedule requests with filter '%s': %w", filter, err)
+	}
+
+	var result []EligibleAssignment
+	for _, r := range requests {
+		// The status is matched here, as Graph does not document filtering the requests of groups by status.
+		if status != "" && conversions.String(r.GetStatus()) != status {
+			continue
+		}
+
+		a, err := fromScheduleRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+// ListEligibleAssignments returns the eligibility schedule instances in groupID, or of principalID, or of principalID
+// in groupID. Graph requires at least one of them. Only the fields available on the instance are set.
+func (s *Service) ListEligibleAssignments(ctx context.Context, groupID, principalID string) ([]EligibleAssignment, error) {
+	var filters []string
+	if groupID != "" {
+		filters = append(filters, fmt.Sprintf("groupId eq '%s'", groupID))
+	}
+	if principalID != "" {
+		filters = append(filters, fmt.Sprintf("principalId eq '%s'", principalID))
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("either a group or principal is required")
+	}
+
+	filter := strings.Join(filters, " and ")
+	instances, err := s.client.ListEligibilityScheduleInstances(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get eligibility schedule instances with filter '%s': %w", filter, err)
+	}
+
+	result := make([]EligibleAssignment, 0, len(instances))
+	for _, instance := range instances {
+		if instance.GetAccessId() == nil {
+			continue
+		}
+
+		role, err := conversions.AccessIDToRole(*instance.GetAccessId())
+		if err != nil {
+			continue
+		}
+
+		a := EligibleAssignment{
+			GroupID:          conversions.String(instance.GetGroupId()),
+			PrincipalID:      conversions.String(instance.GetPrincipalId()),
+			Role:             role,
+			StartDateTime:    conversions.Time(instance.GetStartDateTime()),
+			EndDateTime:      conversions.Time(instance.GetEndDateTime()),
+			TargetScheduleID: conversions.String(instance.GetEligibilityScheduleId()),
+			InstanceID:       conversions.String(instance.GetId()),
+		}
+		if memberType := instance.GetMemberType(); memberType != nil {
+			a.MemberType = memberType.String()
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+// setScheduleInstance sets the fields of a which are only available on the schedule instance.
+// a is left unchanged when the instance does not exist yet.
+func (s *Service) setScheduleInstance(ctx context.Context, a *EligibleAssignment) error {
+	instance, err := s.currentScheduleInstance(ctx, *a)
+	if err != nil || instance == nil {
+		return err
+	}
+
+	a.InstanceID = conversions.String(instance.GetId())
+	if memberType := instance.GetMemberType(); memberType != nil {
+		a.MemberType = memberType.String()
+	}
+
+	return nil
+}
+
+// currentScheduleInstance returns the eligibility schedule instance making the principal of a eligible for its role,
+// preferring a direct eligibility over one inherited through a group. It returns nil when there is none.
+func (s *Service) currentScheduleInstance(ctx context.Context, a EligibleAssignment) (graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable, error) {
+	filter := fmt.Sprintf("groupId eq '%s' and principalId eq '%s'", a.GroupID, a.PrincipalID)
+	instances, err := s.client.ListEligibilityScheduleInstances(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get eligibility schedule instances with filter '%s': %w", filter, err)
+	}
+
+	var current graphmodels.PrivilegedAccessGroupEligibilityScheduleInstanceable
+	for _, instance := range instances {
+		if instance.GetAccessId() == nil {
+			continue
+		}
+
+		role, err := conversions.AccessIDToRole(*instance.GetAccessId())
+		if err != nil || role != a.Role {
+			continue
+		}
+
+		memberType := instance.GetMemberType()
+		if memberType == nil || *memberType == graphmodels.DIRECT_PRIVILEGEDACCESSGROUPMEMBERTYPE {
+			return instance, nil
+		}
+
+		if current == nil {
+			current = instance
+		}
+	}
+
+	return current, nil
+}
+
+// RenewEligibleAssignment extends a, which expires at a.EndDateTime, by extension. The start of the schedule is kept,
+// so the extension is fixed rather than the length of the schedule, which would grow with every renewal.
+func (s *Service) RenewEligibleAssignment(ctx context.Context, a EligibleAssignment, extension time.Duration) (EligibleAssignment, error) {
+	end, err := time.Parse(time.RFC3339, a.EndDateTime)
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to parse endDateTime: %w", err)
+	}
+
+	a.EndDateTime = end.Add(extension).Format(time.RFC3339)
+
+	requestBody, err := newScheduleRequest(a, graphmodels.ADMINEXTEND_SCHEDULEREQUESTACTIONS)
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to create eligibility schedule request: %w", err)
+	}
+
+	s.setTicketInfo(requestBody)
+	created, err := s.client.CreateEligibilityScheduleRequest(ctx, requestBody)
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to extend eligibility schedule request: %w", err)
+	}
+
+	result, err := fromScheduleRequest(created)
+	if err != nil {
+		return EligibleAssignment{}, err
+	}
+
+	if err := s.setScheduleInstance(ctx, &result); err != nil {
+		tflog.Warn(ctx, "unable to get eligibility schedule instance", map[string]any{"error": err.Error()})
+	}
+
+	return result, nil
+}
+
+// DeleteEligibleAssignment removes a, and then requires eligible assignments in the group policy to expire again.
+// It returns ErrActivated if the principal has activated the eligibility, unless force is set, in which case
+// the activation is removed too. Requests pending approval cannot be removed, so they are canceled instead.
+func (s *Service) DeleteEligibleAssignment(ctx context.Context, a EligibleAssignment, force bool) error {
+	if err := s.removeEligibleAssignment(ctx, a, force); err != nil {
+		return err
+	}
+
+	return s.requireExpiration(ctx, a.GroupID)
+}
+
+// ReplaceEligibleAssignment assigns replacement, and only then removes old, so the principal is eligible throughout,
+// e.g. when the role changes. The group policy is left allowing eligible assignments without expiration. It returns
+// ErrActivated before assigning anything if old is activated, unless force is set.
+func (s *Service) ReplaceEligibleAssignment(ctx context.Context, old, replacement EligibleAssignment, force bool) (EligibleAssignment, error) {
+	if !force {
+		activations, err := s.activations(ctx, old)
+		if err != nil {
+			return EligibleAssignment{}, err
+		}
+		if len(activations) > 0 {
+			return EligibleAssignment{}, ErrActivated
+		}
+	}
+
+	created, err := s.CreateEligibleAssignment(ctx, replacement)
+	if err != nil {
+		return EligibleAssignment{}, err
+	}
+
+	if err := s.removeEligibleAssignment(ctx, old, force); err != nil {
+		return created, fmt.Errorf("the replacement %s eligibility was created, but the %s eligibility could not be removed: %w", replacement.Role, old.Role, err)
+	}
+
+	return created, nil
+}
+
+// UpdateEligibleAssignment changes the justification of a in place, so the eligibility does not lapse in between.
+func (s *Service) UpdateEligibleAssignment(ctx context.Context, a EligibleAssignment) (EligibleAssignment, error) {
+	requestBody, err := newScheduleRequest(a, graphmodels.ADMINUPDATE_SCHEDULEREQUESTACTIONS)
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to create eligibility schedule request: %w", err)
+	}
+
+	s.setTicketInfo(requestBody)
+	updated, err := s.client.CreateEligibilityScheduleRequest(ctx, requestBody)
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to update eligibility schedule request: %w", err)
+	}
+
+	result, err := fromScheduleRequest(updated)
+	if err != nil {
+		return EligibleAssignment{}, err
+	}
+	result.PolicyID = a.PolicyID
+
+	if err := s.setScheduleInstance(ctx, &result); err != nil {
+		tflog.Warn(ctx, "unable to get eligibility schedule instance", map[string]any{"error": err.Error()})
+	}
+
+	return result, nil
+}
+
+// removeEligibleAssignment removes a without changing the group policy.
+func (s *Service) removeEligibleAssignment(ctx context.Context, a EligibleAssignment, force bool) error {
+	status, err := s.requestStatus(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	if status == StatusPendingApproval {
+		tflog.Info(ctx, "canceling eligibility schedule request pending approval", map[string]any{"request_id": a.RequestID})
+		if err := s.client.CancelEligibilityScheduleRequest(ctx, a.RequestID); err != nil {
+			return fmt.Errorf("unable to cancel eligibility schedule request: %w", err)
+		}
+		return nil
+	}
+
+	// The request in state may have expired, or not be the one which created the eligibility, e.g. after import, so
+	// the eligibility to remove is resolved from its current schedule instance.
+	instance, err := s.currentScheduleInstance(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	if instance == nil {
+		tflog.Info(ctx, "eligibility no longer exists, nothing to remove", map[string]any{"group_id": a.GroupID, "principal_id": a.PrincipalID})
+		return nil
+	}
+
+	a.InstanceID = conversions.String(instance.GetId())
+	a.TargetScheduleID = conversions.String(instance.GetEligibilityScheduleId())
+	if instance.GetStartDateTime() != nil {
+		a.StartDateTime = conversions.Time(instance.GetStartDateTime())
+	}
+
+	activations, err := s.activations(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	if len(activations) > 0 {
+		if !force {
+			return ErrActivated
+		}
+
+		if err := s.removeActivation(ctx, a); err != nil {
+			return err
+		}
+	}
+
+	requestBody, err := newScheduleRequest(a, graphmodels.ADMINREMOVE_SCHEDULEREQUESTACTIONS)
+	if err != nil {
+		return fmt.Errorf("unable to create eligibility schedule request: %w", err)
+	}
+
+	if a.TargetScheduleID != "" {
+		requestBody.SetTargetScheduleId(&a.TargetScheduleID)
+	}
+
+	s.setTicketInfo(requestBody)
+	if _, err := s.client.CreateEligibilityScheduleRequest(ctx, requestBody); err != nil {
+		return fmt.Errorf("unable to delete eligibility schedule request: %w", err)
+	}
+
+	return nil
+}
+
+// requestStatus returns the current status of the request of a, or the status of a if the request is not found.
+func (s *Service) requestStatus(ctx context.Context, a EligibleAssignment) (string, error) {
+	filter := fmt.Sprintf("groupId eq '%s' and principalId eq '%s'", a.GroupID, a.PrincipalID)
+	requests, err := s.client.ListEligibilityScheduleRequests(ctx, filter)
+	if err != nil {
+		return "", fmt.Errorf("unable to get eligibility schedule requests with filter '%s': %w", filter, err)
+	}
+
+	for _, r := range requests {
+		if conversions.String(r.GetId()) == a.RequestID {
+			return conversions.String(r.GetStatus()), nil
+		}
+	}
+
+	return a.Status, nil
+}
+
+// activations returns the assignment instances activated through the eligibility a. When the instance of a is
+// known, activations through other eligibilities of the principal, e.g. through a nested group, are left out.
+func (s *Service) activations(ctx context.Context, a EligibleAssignment) ([]graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable, error) {
+	filter := fmt.Sprintf("groupId eq '%s' and principalId eq '%s'", a.GroupID, a.PrincipalID)
+	instances, err := s.client.ListAssignmentScheduleInstances(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get assignment schedule instances with filter '%s': %w", filter, err)
+	}
+
+	var result []graphmodels.PrivilegedAccessGroupAssignmentScheduleInstanceable
+	for _, instance := range instances {
+		if instance.GetAccessId() == nil || instance.GetAssignmentType() == nil {
+			continue
+		}
+
+		role, err := conversions.AccessIDToRole(*instance.GetAccessId())
+		if err != nil || role != a.Role {
+			continue
+		}
+
+		if *instance.GetAssignmentType() != graphmodels.ACTIVATED_PRIVILEGEDACCESSGROUPASSIGNMENTTYPE {
+			continue
+		}
+
+		if activatedUsing := instance.GetActivatedUsing(); a.InstanceID != "" && activatedUsing != nil && conversions.String(activatedUsing.GetId()) != a.InstanceID {
+			continue
+		}
+
+		result = append(result, instance)
+	}
+
+	return result, nil
+}
+
+// ActivatedMemberIDs returns the object IDs of the principals with an activated member assignment in groupID. PIM
+// adds them to the direct members of the group while the activation lasts. The IDs are in lower case.
+func (s *Service) ActivatedMemberIDs(ctx context.Context, groupID string) (map[string]bool, error) {
+	filter := fmt.Sprintf("groupId eq '%s'", groupID)
+	instances, err := s.client.ListAssignmentScheduleInstances(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get assignment schedule instances with filter '%s': %w", filter, err)
+	}
+
+	result := map[string]bool{}
+	for _, instance := range instances {
+		if instance.GetAccessId() == nil || *instance.GetAccessId() != graphmodels.MEMBER_PRIVILEGEDACCESSGROUPRELATIONSHIPS {
+			continue
+		}
+
+		if instance.GetAssignmentType() == nil || *instance.GetAssignmentType() != graphmodels.ACTIVATED_PRIVILEGEDACCESSGROUPASSIGNMENTTYPE {
+			continue
+		}
+
+		result[strings.ToLower(conversions.String(instance.GetPrincipalId()))] = true
+	}
+
+	return result, nil
+}
+
+// ListActiveAssignments returns the assignment schedule instances in groupID, or of principalID, or of principalID in
+// groupID, i.e. the owners and members which currently hold their role, whether activated or assigned. Graph requires
+// at least one of them.
+func (s *Service) ListActiveAssignments(ctx context.Context, groupID, principalID string) ([]ActiveAssignment, error) {
+	var filters []string
+	if groupID != "" {
+		filters = append(filters, fmt.Sprintf("groupId eq '%s'", groupID))
+	}
+	if principalID != "" {
+		filters = append(filters, fmt.Sprintf("principalId eq '%s'", principalID))
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("either a group or principal is required")
+	}
+
+	filter := strings.Join(filters, " and ")
+	instances, err := s.client.ListAssignmentScheduleInstances(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get assignment schedule instances with filter '%s': %w", filter, err)
+	}
+
+	result := make([]ActiveAssignment, 0, len(instances))
+	for _, instance := range instances {
+		if instance.GetAccessId() == nil {
+			continue
+		}
+
+		role, err := conversions.AccessIDToRole(*instance.GetAccessId())
+		if err != nil {
+			continue
+		}
+
+		a := ActiveAssignment{
+			GroupID:       conversions.String(instance.GetGroupId()),
+			PrincipalID:   conversions.String(instance.GetPrincipalId()),
+			Role:          role,
+			StartDateTime: conversions.Time(instance.GetStartDateTime()),
+			EndDateTime:   conversions.Time(instance.GetEndDateTime()),
+		}
+		if assignmentType := instance.GetAssignmentType(); assignmentType != nil {
+			a.AssignmentType = assignmentType.String()
+		}
+		if memberType := instance.GetMemberType(); memberType != nil {
+			a.MemberType = memberType.String()
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+// removeActivation removes the active assignment of the principal of a.
+func (s *Service) removeActivation(ctx context.Context, a EligibleAssignment) error {
+	accessId, err := conversions.RoleToAccessID(a.Role)
+	if err != nil {
+		return err
+	}
+
+	requestBody := graphmodels.NewPrivilegedAccessGroupAssignmentScheduleRequest()
+	requestBody.SetAccessId(&accessId)
+	requestBody.SetGroupId(&a.GroupID)
+	requestBody.SetPrincipalId(&a.PrincipalID)
+	requestBody.SetJustification(&a.Justification)
+	action := graphmodels.ADMINREMOVE_SCHEDULEREQUESTACTIONS
+	requestBody.SetAction(&action)
+
+	s.setTicketInfo(requestBody)
+	if _, err := s.client.CreateAssignmentScheduleRequest(ctx, requestBody); err != nil {
+		return fmt.Errorf("unable to remove activated assignment: %w", err)
+	}
+
+	return nil
+}
+
+// Activate activates the eligibilities of activations, in as few round trips to Graph as it allows. It returns the
+// activations with the fields of their request set, and the error of each, nil when its request was created.
+func (s *Service) Activate(ctx context.Context, activations []Activation) ([]Activation, []error) {
+	return s.sendActivationRequests(ctx, activations, graphmodels.SELFACTIVATE_SCHEDULEREQUESTACTIONS)
+}
+
+// Extend extends activations to last Duration from their StartDateTime, without activating them again, which could
+// require approval again. It returns the activations with the fields of their new request set, and the error of each.
+func (s *Service) Extend(ctx context.Context, activations []Activation) ([]Activation, []error) {
+	return s.sendActivationRequests(ctx, activations, graphmodels.SELFEXTEND_SCHEDULEREQUESTACTIONS)
+}
+
+// Deactivate ends activations before they expire. It returns the error of each, nil when it was ended.
+func (s *Service) Deactivate(ctx context.Context, activations []Activation) []error {
+	_, errs := s.sendActivationRequests(ctx, activations, graphmodels.SELFDEACTIVATE_SCHEDULEREQUESTACTIONS)
+	return errs
+}
+
+// sendActivationRequests creates an assignment schedule request with action for each of activations in one go.
+func (s *Service) sendActivationRequests(ctx context.Context, activations []Activation, action graphmodels.ScheduleRequestActions) ([]Activation, []error) {
+	result := slices.Clone(activations)
+	errs := make([]error, len(activations))
+
+	// The bodies which could be built, and the index of their activation.
+	var bodies []graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable
+	var indexes []int
+	for i, a := range activations {
+		body, err := s.newActivationRequest(a, action)
+		if err != nil {
+			errs[i] = fmt.Errorf("unable to create assignment schedule request: %w", err)
+			continue
+		}
+		bodies = append(bodies, body)
+		indexes = append(indexes, i)
+	}
+
+	if len(bodies) == 0 {
+		return result, errs
+	}
+
+	created, createErrs := s.client.CreateAssignmentScheduleRequests(ctx, bodies)
+	for j, i := range indexes {
+		if createErrs[j] != nil {
+			errs[i] = fmt.Errorf("unable to create assignment schedule request: %w", createErrs[j])
+			continue
+		}
+
+		r := created[j]
+		result[i].RequestID = conversions.String(r.GetId())
+		result[i].Status = conversions.String(r.GetStatus())
+		if scheduleInfo := r.GetScheduleInfo(); scheduleInfo != nil {
+			result[i].StartDateTime = conversions.Time(scheduleInfo.GetStartDateTime())
+			result[i].EndDateTime = conversions.ScheduleEnd(scheduleInfo)
+		}
+	}
+
+	return result, errs
+}
+
+// newActivationRequest returns the assignment schedule request of the principal of a with action. Only activations
+// and extensions have a schedule, which starts now unless a has a start.
+func (s *Service) newActivationRequest(a Activation, action graphmodels.ScheduleRequestActions) (graphmodels.PrivilegedAccessGroupAssignmentScheduleRequestable, error) {
+	accessId, err := conversions.RoleToAccessID(a.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	requestBody := graphmodels.NewPrivilegedAccessGroupAssignmentScheduleRequest()
+	requestBody.SetAccessId(&accessId)
+	requestBody.SetGroupId(&a.GroupID)
+	requestBody.SetPrincipalId(&a.PrincipalID)
+	requestBody.SetAction(&action)
+	if a.Justification != "" {
+		requestBody.SetJustification(&a.Justification)
+	}
+
+	if action != graphmodels.SELFDEACTIVATE_SCHEDULEREQUESTACTIONS {
+		scheduleInfo, err := conversions.DurationSchedule(a.StartDateTime, a.Duration)
+		if err != nil {
+			return nil, err
+		}
+		requestBody.SetScheduleInfo(scheduleInfo)
+	}
+
+	s.setTicketInfo(requestBody)
+
+	return requestBody, nil
+}
+
+// ActivationStatus returns the current status of the request of a, or the status of a if the request is not found.
+func (s *Service) ActivationStatus(ctx context.Context, a Activation) (string, error) {
+	filter := fmt.Sprintf("groupId eq '%s' and principalId eq '%s'", a.GroupID, a.PrincipalID)
+	requests, err := s.client.ListAssignmentScheduleRequests(ctx, filter)
+	if err != nil {
+		return "", fmt.Errorf("unable to get assignment schedule requests with filter '%s': %w", filter, err)
+	}
+
+	for _, r := range requests {
+		if conversions.String(r.GetId()) == a.RequestID {
+			return conversions.String(r.GetStatus()), nil
+		}
+	}
+
+	return a.Status, nil
+}
+
+// CancelEligibleAssignment cancels the request of an assignment which is not provisioned yet, e.g. pending approval.
+func (s *Service) CancelEligibleAssignment(ctx context.Context, a EligibleAssignment) error {
+	if err := s.client.CancelEligibilityScheduleRequest(ctx, a.RequestID); err != nil {
+		return fmt.Errorf("unable to cancel eligibility schedule request: %w", err)
+	}
+
+	return s.requireExpiration(ctx, a.GroupID)
+}
+
+// requireExpiration restores the policy of groupID to require expiration of eligible assignments.
+func (s *Service) requireExpiration(ctx context.Context, groupID string) error {
+	_, err := s.updateExpirationRule(ctx, groupID, true)
+	return err
+}
+
+// AllowNoExpiration changes the policy of groupID to allow eligible assignments without expiration, e.g. after
+// somebody required expiration again in the portal.
+func (s *Service) AllowNoExpiration(ctx context.Context, groupID string) error {
+	_, err := s.updateExpirationRule(ctx, groupID, false)
+	return err
+}
+
+// updateExpirationRule sets whether the policy of groupID requires eligible assignments made by admins to expire, and
+// returns the ID of the policy. In strict policy mode the rule is only checked.
+func (s *Service) updateExpirationRule(ctx context.Context, groupID string, required bool) (string, error) {
+	policyId, err := s.resolvePolicyID(ctx, groupID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.patchExpirationRule(ctx, groupID, policyId, required); err != nil {
+		return "", err
+	}
+
+	return policyId, nil
+}
+
+// restoreExpirationRule makes policyId of groupID require expiration of eligible assignments again, after creating an
+// eligibility failed.
+func (s *Service) restoreExpirationRule(ctx context.Context, groupID, policyId string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), policyRollbackTimeout)
+	defer cancel()
+
+	tflog.Info(ctx, "restoring the eligible expiration policy rule after the eligibility schedule request failed", map[string]any{"group_id": groupID, "policy_id": policyId})
+
+	if _, err := s.patchExpirationRule(ctx, groupID, policyId, true); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resolvePolicyID returns the ID of the policy governing the member role of groupID, waiting for the group to be
+// onboarded to PIM.
+func (s *Service) resolvePolicyID(ctx context.Context, groupID string) (string, error) {
+	var policyId string
+	err := s.retryWhilePolicyNotFound(ctx, groupID, func() error {
+		var err error
+		policyId, err = s.EligibleExpirationPolicyID(ctx, groupID)
+		if err != nil {
+			return fmt.Errorf("unable to get eligible expiration policy ID: %w", err)
+		}
+
+		return nil
+	})
+
+	return policyId, err
+}
+
+// patchExpirationRule sets whether policyId of groupID requires eligible assignments made by admins to expire, and
+// returns whether it was written. In strict policy mode the rule is only checked, and never written.
+func (s *Service) patchExpirationRule(ctx context.Context, groupID, policyId string, required bool) (bool, error) {
+	if s.strictPolicy {
+		if required {
+			return false, nil
+		}
+
+		expirationRequired, err := s.ExpirationRequired(ctx, groupID)
+		if err != nil {
+			return false, fmt.Errorf("unable to get eligible expiration policy rule: %w", err)
+		}
+		if expirationRequired {
+			return false, fmt.Errorf("policy %s of group %s: %w", policyId, groupID, ErrExpirationRequired)
+		}
+
+		return false, nil
+	}
+
+	err := s.retryWhilePolicyNotFound(ctx, groupID, func() error {
+		// The rules of the cached assignment are outdated once the rule is written, or may be if writing it failed.
+		err := s.client.UpdatePolicyExpirationRule(ctx, policyId, required)
+		s.policies.invalidate(groupID)
+		if err != nil {
+			return fmt.Errorf("unable to update unified role management policy rule: %w", err)
+		}
+
+		return nil
+	})
+
+	return err == nil, err
+}
+
+// retryWhilePolicyNotFound calls f until it does not fail with ErrPolicyNotFound. The policies of a group which was
+// just onboarded to PIM are created asynchronously, so f is called again with backoff, for at most
+// policyProvisioningTimeout.
+func (s *Service) retryWhilePolicyNotFound(ctx context.Context, groupID string, f func() error) error {
+	deadline := time.Now().Add(policyProvisioningTimeout)
+	delay := policyRetryDelay
+
+	for {
+		err := f()
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, ErrPolicyNotFound) || time.Now().Add(delay).After(deadline) {
+			return err
+		}
+
+		tflog.Info(ctx, "role management policy not found, waiting for the group to be onboarded to PIM", map[string]any{"group_id": groupID, "delay": delay.String()})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// ExpirationRequired returns whether the policy of groupID requires eligible assignments made by admins to expire.
+func (s *Service) ExpirationRequired(ctx context.Context, groupID string) (bool, error) {
+	policyAssignment, err := s.eligiblePolicyAssignment(ctx, groupID)
+	if err != nil {
+		return false, err
+	}
+
+	policy := policyAssignment.GetPolicy()
+	if policy == nil {
+		return false, fmt.Errorf("role management policy assignment has no policy")
+	}
+
+	for _, rule := range policy.GetRules() {
+		if conversions.String(rule.GetId()) != expirationAdminEligibilityRuleID {
+			continue
+		}
+
+		expirationRule, ok := rule.(graphmodels.UnifiedRoleManagementPolicyExpirationRuleable)
+		if !ok {
+			return false, fmt.Errorf("policy rule %s is not an expiration rule", expirationAdminEligibilityRuleID)
+		}
+
+		return expirationRule.GetIsExpirationRequired() != nil && *expirationRule.GetIsExpirationRequired(), nil
+	}
+
+	return false, fmt.Errorf("unable to find policy rule %s", expirationAdminEligibilityRuleID)
+}
+
+// EligibleExpirationPolicyID returns the ID of the policy governing the member role of groupID.
+func (s *Service) EligibleExpirationPolicyID(ctx context.Context, groupID string) (string, error) {
+	policyAssignment, err := s.eligiblePolicyAssignment(ctx, groupID)
+	if err != nil {
+		return "", err
+	}
+
+	return conversions.String(policyAssignment.GetPolicyId()), nil
+}
+
+// RolePolicyAssignment returns the assignment of the policy governing role in groupID, where role is member or owner.
+// It returns ErrPolicyNotFound if the group is not onboarded to PIM. The policy of the member role is taken from the
+// policy cache when set.
+func (s *Service) RolePolicyAssignment(ctx context.Context, groupID, role string) (PolicyAssignment, error) {
+	if _, err := conversions.RoleToAccessID(role); err != nil {
+		return PolicyAssignment{}, fmt.Errorf("unable to convert role to access ID: %w", err)
+	}
+
+	var policyAssignment graphmodels.UnifiedRoleManagementPolicyAssignmentable
+	var err error
+	if role == "member" {
+		policyAssignment, err = s.eligiblePolicyAssignment(ctx, groupID)
+	} else {
+		policyAssignment, err = s.lookupPolicyAssignment(ctx, groupID, role)
+	}
+	if err != nil {
+		return PolicyAssignment{}, err
+	}
+
+	result := PolicyAssignment{
+		ID:       conversions.String(policyAssignment.GetId()),
+		PolicyID: conversions.String(policyAssignment.GetPolicyId()),
+	}
+
+	// Only this lookup needs the effective rules, which the policy assignments of the cache do not expand.
+	policy, err := s.client.GetRoleManagementPolicy(ctx, result.PolicyID)
+	if err != nil {
+		return PolicyAssignment{}, fmt.Errorf("unable to get role management policy: %w", err)
+	}
+	result.Rules, err = policyRules(policy)
+	if err != nil {
+		return PolicyAssignment{}, err
+	}
+
+	return result, nil
+}
+
+// defaultExpirationRules are the settings of the expiration rules of the policies of groups onboarded to PIM.
+var defaultExpirationRules = map[string]struct {
+	isExpirationRequired bool
+	maximumDuration      time.Duration
+}{
+	"Expiration_Admin_Eligibility":  {isExpirationRequired: true, maximumDuration: 365 * 24 * time.Hour},
+	"Expiration_Admin_Assignment":   {isExpirationRequired: true, maximumDuration: 180 * 24 * time.Hour},
+	"Expiration_EndUser_Assignment": {isExpirationRequired: true, maximumDuration: 8 * time.Hour},
+}
+
+// defaultEnabledRules are the enabled rules of the enablement rules of the policies of groups onboarded to PIM.
+var defaultEnabledRules = map[string][]string{
+	"Enablement_Admin_Eligibility":  {},
+	"Enablement_Admin_Assignment":   {"Justification"},
+	"Enablement_EndUser_Assignment": {"Justification"},
+}
+
+// policyRules returns the rules of policy sorted by ID, with whether they are inherited. Each rule is compared on its
+// own: with its effective rule, which differs where a tenant wide rule takes precedence, and with the default rule
+// groups are onboarded with. Rules whose default is not known are only inherited when overridden.
+func policyRules(policy graphmodels.UnifiedRoleManagementPolicyable) ([]PolicyRule, error) {
+	effective := map[string]string{}
+	for _, rule := range policy.GetEffectiveRules() {
+		settings, err := ruleSettings(rule)
+		if err != nil {
+			return nil, err
+		}
+		effective[conversions.String(rule.GetId())] = settings
+	}
+
+	result := make([]PolicyRule, 0, len(policy.GetRules()))
+	for _, rule := range policy.GetRules() {
+		id := conversions.String(rule.GetId())
+		settings, err := ruleSettings(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		overridden := false
+		if e, ok := effective[id]; ok {
+			overridden = e != settings
+		}
+		result = append(result, PolicyRule{ID: id, Inherited: overridden || isDefaultRule(rule)})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result, nil
+}
+
+// isDefaultRule returns whether rule has the settings of the same rule of a group onboarded to PIM. It returns false
+// when the default of the rule is not known.
+func isDefaultRule(rule graphmodels.UnifiedRoleManagementPolicyRuleable) bool {
+	id := conversions.String(rule.GetId())
+
+	switch r := rule.(type) {
+	case graphmodels.UnifiedRoleManagementPolicyExpirationRuleable:
+		d, ok := defaultExpirationRules[id]
+		if !ok || r.GetMaximumDuration() == nil {
+			return false
+		}
+		maximumDuration, err := r.GetMaximumDuration().ToDuration()
+		return err == nil && conversions.Bool(r.GetIsExpirationRequired()) == d.isExpirationRequired && maximumDuration == d.maximumDuration
+	case graphmodels.UnifiedRoleManagementPolicyEnablementRuleable:
+		d, ok := defaultEnabledRules[id]
+		if !ok {
+			return false
+		}
+		enabled := slices.Clone(r.GetEnabledRules())
+		slices.Sort(enabled)
+		return slices.Equal(enabled, d)
+	case graphmodels.UnifiedRoleManagementPolicyApprovalRuleable:
+		return r.GetSetting() == nil || !conversions.Bool(r.GetSetting().GetIsApprovalRequired())
+	case graphmodels.UnifiedRoleManagementPolicyAuthenticationContextRuleable:
+		return !conversions.Bool(r.GetIsEnabled())
+	case graphmodels.UnifiedRoleManagementPolicyNotificationRuleable:
+		// Every notification goes to the default recipients only.
+		return conversions.Bool(r.GetIsDefaultRecipientsEnabled()) && conversions.String(r.GetNotificationLevel()) == "All" && len(r.GetNotificationRecipients()) == 0
+	default:
+		return false
+	}
+}
+
+// ruleSettings serializes rule to JSON, so rules of any type can be compared.
+func ruleSettings(rule graphmodels.UnifiedRoleManagementPolicyRuleable) (string, error) {
+	w := jsonserialization.NewJsonSerializationWriter()
+	defer w.Close()
+
+	if err := w.WriteObjectValue("", rule); err != nil {
+		return "", fmt.Errorf("unable to serialize policy rule %s: %w", conversions.String(rule.GetId()), err)
+	}
+
+	b, err := w.GetSerializedContent()
+	if err != nil {
+		return "", fmt.Errorf("unable to serialize policy rule %s: %w", conversions.String(rule.GetId()), err)
+	}
+
+	return string(b), nil
+}
+
+// eligiblePolicyAssignment returns the assignment of the policy governing the member role of groupID, from the policy
+// cache when set.
+func (s *Service) eligiblePolicyAssignment(ctx context.Context, groupID string) (graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	return s.policies.assignment(ctx, groupID, func() (graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+		return s.lookupPolicyAssignment(ctx, groupID, "member")
+	})
+}
+
+// lookupPolicyAssignment looks up the assignment of the policy governing role in groupID.
+func (s *Service) lookupPolicyAssignment(ctx context.Context, groupID, role string) (graphmodels.UnifiedRoleManagementPolicyAssignmentable, error) {
+	requestFilter := fmt.Sprintf("scopeId eq '%s' and scopeType eq 'Group' and roleDefinitionId eq '%s'", groupID, role)
+
+	policyAssignments, err := s.client.ListRoleManagementPolicyAssignments(ctx, requestFilter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role management policy assignments: %w", err)
+	}
+
+	// Edit the policy group assignment and allow no expiration date for PIM eligible assignment
+	if len(policyAssignments) == 0 {
+		return nil, fmt.Errorf("unable to find role management policy assignments from result: %w", ErrPolicyNotFound)
+	}
+
+	if len(policyAssignments) > 1 {
+		tflog.Warn(ctx, "found more than one role management policy assignment")
+	}
+
+	return policyAssignments[0], nil
+}
+
+func newScheduleRequest(a EligibleAssignment, action graphmodels.ScheduleRequestActions) (*graphmodels.PrivilegedAccessGroupEligibilityScheduleRequest, error) {
+	requestBody := graphmodels.NewPrivilegedAccessGroupEligibilityScheduleRequest()
+
+	accessId, err := conversions.RoleToAccessID(a.Role)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert role to access ID: %w", err)
+	}
+
+	requestBody.SetAccessId(&accessId)
+	requestBody.SetPrincipalId(&a.PrincipalID)
+	requestBody.SetGroupId(&a.GroupID)
+	requestBody.SetAction(&action)
+
+	scheduleInfo := graphmodels.NewRequestSchedule()
+	startDateTime, err := time.Parse(time.RFC3339, a.StartDateTime)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse startDateTime: %w", err)
+	}
+
+	scheduleInfo.SetStartDateTime(&startDateTime)
+	expiration := graphmodels.NewExpirationPattern()
+	typ := graphmodels.NOEXPIRATION_EXPIRATIONPATTERNTYPE
+	if a.EndDateTime != "" {
+		endDateTime, err := time.Parse(time.RFC3339, a.EndDateTime)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse endDateTime: %w", err)
+		}
+
+		typ = graphmodels.AFTERDATETIME_EXPIRATIONPATTERNTYPE
+		expiration.SetEndDateTime(&endDateTime)
+	}
+	expiration.SetTypeEscaped(&typ)
+
+	scheduleInfo.SetExpiration(expiration)
+	requestBody.SetScheduleInfo(scheduleInfo)
+	requestBody.SetJustification(&a.Justification)
+
+	return requestBody, nil
+}
+
+func fromScheduleRequest(r graphmodels.PrivilegedAccessGroupEligibilityScheduleRequestable) (EligibleAssignment, error) {
+	if r.GetStatus() == nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to get eligibility schedule request status")
+	}
+
+	if r.GetAccessId() == nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to get eligibility schedule request access ID")
+	}
+
+	role, err := conversions.AccessIDToRole(*r.GetAccessId())
+	if err != nil {
+		return EligibleAssignment{}, fmt.Errorf("unable to convert access ID to role: %w", err)
+	}
+
+	a := EligibleAssignment{
+		RequestID:         conversions.String(r.GetId()),
+		GroupID:           conversions.String(r.GetGroupId()),
+		PrincipalID:       conversions.String(r.GetPrincipalId()),
+		Role:              role,
+		Justification:     conversions.String(r.GetJustification()),
+		Status:            *r.GetStatus(),
+		CreatedBy:         conversions.IdentityID(r.GetCreatedBy()),
+		CreatedDateTime:   conversions.Time(r.GetCreatedDateTime()),
+		CompletedDateTime: conversions.Time(r.GetCompletedDateTime()),
+		TargetScheduleID:  conversions.String(r.GetTargetScheduleId()),
+		Raw:               r,
+	}
+
+	if scheduleInfo := r.GetScheduleInfo(); scheduleInfo != nil {
+		a.StartDateTime = conversions.Time(scheduleInfo.GetStartDateTime())
+
+		if expiration := scheduleInfo.GetExpiration(); expiration != nil {
+			a.EndDateTime = conversions.Time(expiration.GetEndDateTime())
+			if typ := expiration.GetTypeEscaped(); typ != nil {
+				a.ExpirationType = typ.String()
+			}
+			if duration := expiration.GetDuration(); duration != nil {
+				a.ExpirationDuration = duration.String()
+			}
+		}
+	}
+
+	return a, nil
+}
@@ -0,0 +1,365 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package pimpolicy maps the role management policies of PIM enabled groups and Microsoft Entra roles to Microsoft
+// Graph calls, so resources only translate between Terraform values and the types in this package.
+package pimpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+)
+
+// The kinds of targets a policy template applies to.
+const (
+	// TargetGroup is the member role of a PIM enabled group.
+	TargetGroup = "group"
+	// TargetGroupOwner is the owner role of a PIM enabled group. Templates do not govern it.
+	TargetGroupOwner = "groupOwner"
+	// TargetDirectoryRole is a Microsoft Entra role in the whole tenant.
+	TargetDirectoryRole = "directoryRole"
+)
+
+// The IDs of the policy rules a template manages.
+const (
+	RuleExpirationAdminEligibility  = "Expiration_Admin_Eligibility"
+	RuleExpirationEndUserAssignment = "Expiration_EndUser_Assignment"
+	RuleEnablementEndUserAssignment = "Enablement_EndUser_Assignment"
+)
+
+// RuleApprovalEndUserAssignment is the ID of the rule deciding whether activations must be approved. It is only read.
+const RuleApprovalEndUserAssignment = "Approval_EndUser_Assignment"
+
+// ErrPolicyNotFound is returned when a target has no role management policy, e.g. while a group is not PIM enabled.
+var ErrPolicyNotFound = errors.New("role management policy not found")
+
+// ErrPolicyConflict is returned when a policy rule kept changing between reading and updating it, e.g. because an
+// administrator was editing the policy in the portal at the same time.
+var ErrPolicyConflict = errors.New("role management policy rule was changed concurrently")
+
+// Client is the set of Graph operations used by the service.
+type Client interface {
+	// ListRoleManagementPolicyAssignments lists the role management policy assignments matching an OData filter, with
+	// the rules of their policy expanded.
+	ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error)
+	// UpdatePolicyRule replaces a rule of a policy. It returns ErrPolicyNotFound if the policy does not exist, and
+	// ErrPolicyConflict if the rule kept changing concurrently.
+	UpdatePolicyRule(ctx context.Context, policyID string, rule Rule) error
+}
+
+// Target is a group or directory role whose policy is governed by a template.
+type Target struct {
+	// Kind is TargetGroup or TargetDirectoryRole.
+	Kind string
+	// ID is the object ID of the group, or the role definition ID of the directory role.
+	ID string
+}
+
+func (t Target) String() string {
+	return t.Kind + "/" + t.ID
+}
+
+// filter returns the OData filter selecting the policy assignment of t.
+func (t Target) filter() (string, error) {
+	switch t.Kind {
+	case TargetGroup:
+		return fmt.Sprintf("scopeId eq '%s' and scopeType eq 'Group' and roleDefinitionId eq 'member'", t.ID), nil
+	case TargetGroupOwner:
+		return fmt.Sprintf("scopeId eq '%s' and scopeType eq 'Group' and roleDefinitionId eq 'owner'", t.ID), nil
+	case TargetDirectoryRole:
+		return fmt.Sprintf("scopeId eq '/' and scopeType eq 'Directory' and roleDefinitionId eq '%s'", t.ID), nil
+	default:
+		return "", fmt.Errorf("invalid policy target kind: %s", t.Kind)
+	}
+}
+
+// Rule is a rule of a role management policy. Only the fields of the type of the rule are used.
+type Rule struct {
+	// ID identifies the rule within the policy, e.g. Expiration_EndUser_Assignment.
+	ID string
+	// IsExpirationRequired and MaximumDuration are the fields of expiration rules. MaximumDuration is an ISO 8601
+	// duration.
+	IsExpirationRequired bool
+	MaximumDuration      string
+	// EnabledRules are the fields of enablement rules, e.g. Justification or MultiFactorAuthentication.
+	EnabledRules []string
+}
+
+// Template is the shared baseline of policy rules. Empty fields leave the rule of a target as it is.
+type Template struct {
+	// EligibilityExpirationRequired is whether eligibilities assigned by admins must expire.
+	EligibilityExpirationRequired *bool
+	// EligibilityMaximumDuration is the ISO 8601 duration eligibilities assigned by admins can last at most.
+	EligibilityMaximumDuration string
+	// ActivationMaximumDuration is the ISO 8601 duration an activation can last at most.
+	ActivationMaximumDuration string
+	// ActivationEnabledRules are what principals must provide to activate, e.g. Justification. Nil leaves the rule
+	// as it is, while an empty slice requires nothing.
+	ActivationEnabledRules []string
+}
+
+// apply returns current with the fields set in the template replaced. Rules the template does not manage are returned
+// unchanged.
+func (t Template) apply(current Rule) Rule {
+	desired := current
+	switch current.ID {
+	case RuleExpirationAdminEligibility:
+		if t.EligibilityExpirationRequired != nil {
+			desired.IsExpirationRequired = *t.EligibilityExpirationRequired
+		}
+		if t.EligibilityMaximumDuration != "" {
+			desired.MaximumDuration = t.EligibilityMaximumDuration
+		}
+	case RuleExpirationEndUserAssignment:
+		if t.ActivationMaximumDuration != "" {
+			desired.MaximumDuration = t.ActivationMaximumDuration
+		}
+	case RuleEnablementEndUserAssignment:
+		if t.ActivationEnabledRules != nil {
+			desired.EnabledRules = t.ActivationEnabledRules
+		}
+	}
+
+	return desired
+}
+
+// Equal returns whether a and b are the same rule with the same values. Durations are compared after parsing, as
+// Graph may format them differently than configured, e.g. PT480M as PT8H.
+func Equal(a, b Rule) bool {
+	if a.ID != b.ID || a.IsExpirationRequired != b.IsExpirationRequired || !sameDuration(a.MaximumDuration, b.MaximumDuration) {
+		return false
+	}
+
+	x := slices.Clone(a.EnabledRules)
+	y := slices.Clone(b.EnabledRules)
+	sort.Strings(x)
+	sort.Strings(y)
+
+	return slices.Equal(x, y)
+}
+
+func sameDuration(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	x, errA := serialization.ParseISODuration(a)
+	y, errB := serialization.ParseISODuration(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	dx, errA := x.ToDuration()
+	dy, errB := y.ToDuration()
+	if errA != nil || errB != nil {
+		return x.String() == y.String()
+	}
+
+	return dx == dy
+}
+
+// Summary is the key rule values of the policy of a role in a group, for reporting.
+type Summary struct {
+	GroupID string
+	// Role is member or owner.
+	Role     string
+	PolicyID string
+	// EligibilityExpirationRequired and EligibilityMaximumDuration are the values of the Expiration_Admin_Eligibility
+	// rule. The duration is ISO 8601.
+	EligibilityExpirationRequired bool
+	EligibilityMaximumDuration    string
+	// ActivationMaximumDuration is the value of the Expiration_EndUser_Assignment rule, as ISO 8601 duration.
+	ActivationMaximumDuration string
+	// ActivationApprovalRequired is the value of the Approval_EndUser_Assignment rule.
+	ActivationApprovalRequired bool
+}
+
+// Service applies policy templates.
+type Service struct {
+	client Client
+}
+
+func NewService(client Client) *Service {
+	return &Service{client: client}
+}
+
+// Drift returns the IDs of the rules of the policy of target which differ from the template.
+func (s *Service) Drift(ctx context.Context, target Target, t Template) ([]string, error) {
+	_, rules, err := s.policy(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, current := range rules {
+		if !Equal(current, t.apply(current)) {
+			result = append(result, current.ID)
+		}
+	}
+
+	return result, nil
+}
+
+// Apply updates the rules of the policy of target which differ from the template, and returns their IDs.
+func (s *Service) Apply(ctx context.Context, target Target, t Template) ([]string, error) {
+	policyID, rules, err := s.policy(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, current := range rules {
+		desired := t.apply(current)
+		if Equal(current, desired) {
+			continue
+		}
+
+		if err := s.client.UpdatePolicyRule(ctx, policyID, desired); err != nil {
+			return nil, fmt.Errorf("unable to update rule %s of policy %s: %w", desired.ID, policyID, err)
+		}
+		result = append(result, desired.ID)
+	}
+
+	return result, nil
+}
+
+// ActivationMaximumDuration returns the ISO 8601 duration an activation of target can last at most, i.e. the value of
+// its Expiration_EndUser_Assignment rule. It is empty when the policy has no such rule.
+func (s *Service) ActivationMaximumDuration(ctx context.Context, target Target) (string, error) {
+	_, rules, err := s.policy(ctx, target)
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range rules {
+		if r.ID == RuleExpirationEndUserAssignment {
+			return r.MaximumDuration, nil
+		}
+	}
+
+	return "", nil
+}
+
+// policy returns the ID of the policy of target and its rules managed by templates.
+func (s *Service) policy(ctx context.Context, target Target) (string, []Rule, error) {
+	filter, err := target.filter()
+	if err != nil {
+		return "", nil, err
+	}
+
+	assignments, err := s.client.ListRoleManagementPolicyAssignments(ctx, filter)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to get role management policy assignments with filter '%s': %w", filter, err)
+	}
+	if len(assignments) == 0 || assignments[0].GetPolicy() == nil {
+		return "", nil, fmt.Errorf("%s: %w", target, ErrPolicyNotFound)
+	}
+
+	policy := assignments[0].GetPolicy()
+
+	var rules []Rule
+	for _, r := range policy.GetRules() {
+		if rule, ok := ParseRule(r); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	policyID := conversions.String(assignments[0].GetPolicyId())
+	if policyID == "" {
+		policyID = conversions.String(policy.GetId())
+	}
+
+	return policyID, rules, nil
+}
+
+// ParseRule returns r as Rule, and whether r is one of the rules managed by templates.
+func ParseRule(r graphmodels.UnifiedRoleManagementPolicyRuleable) (Rule, bool) {
+	switch rule := r.(type) {
+	case graphmodels.UnifiedRoleManagementPolicyExpirationRuleable:
+		id := conversions.String(rule.GetId())
+		if id != RuleExpirationAdminEligibility && id != RuleExpirationEndUserAssignment {
+			return Rule{}, false
+		}
+
+		current := Rule{ID: id}
+		if required := rule.GetIsExpirationRequired(); required != nil {
+			current.IsExpirationRequired = *required
+		}
+		if duration := rule.GetMaximumDuration(); duration != nil {
+			current.MaximumDuration = duration.String()
+		}
+		return current, true
+	case graphmodels.UnifiedRoleManagementPolicyEnablementRuleable:
+		id := conversions.String(rule.GetId())
+		if id != RuleEnablementEndUserAssignment {
+			return Rule{}, false
+		}
+
+		return Rule{ID: id, EnabledRules: rule.GetEnabledRules()}, true
+	default:
+		return Rule{}, false
+	}
+}
+
+// GroupPolicies returns the summaries of the policies of the roles in groupID. It returns none when the group is not
+// onboarded to PIM.
+func (s *Service) GroupPolicies(ctx context.Context, groupID string) ([]Summary, error) {
+	filter := fmt.Sprintf("scopeId eq '%s' and scopeType eq 'Group'", groupID)
+	assignments, err := s.client.ListRoleManagementPolicyAssignments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role management policy assignments with filter '%s': %w", filter, err)
+	}
+
+	result := make([]Summary, 0, len(assignments))
+	for _, a := range assignments {
+		policy := a.GetPolicy()
+		if policy == nil {
+			continue
+		}
+
+		summary := Summary{
+			GroupID:  groupID,
+			Role:     conversions.String(a.GetRoleDefinitionId()),
+			PolicyID: conversions.String(a.GetPolicyId()),
+		}
+		if summary.PolicyID == "" {
+			summary.PolicyID = conversions.String(policy.GetId())
+		}
+
+		for _, r := range policy.GetRules() {
+			switch rule := r.(type) {
+			case graphmodels.UnifiedRoleManagementPolicyExpirationRuleable:
+				var duration string
+				if d := rule.GetMaximumDuration(); d != nil {
+					duration = d.String()
+				}
+
+				switch conversions.String(rule.GetId()) {
+				case RuleExpirationAdminEligibility:
+					summary.EligibilityExpirationRequired = rule.GetIsExpirationRequired() != nil && *rule.GetIsExpirationRequired()
+					summary.EligibilityMaximumDuration = duration
+				case RuleExpirationEndUserAssignment:
+					summary.ActivationMaximumDuration = duration
+				}
+			case graphmodels.UnifiedRoleManagementPolicyApprovalRuleable:
+				if conversions.String(rule.GetId()) != RuleApprovalEndUserAssignment || rule.GetSetting() == nil {
+					continue
+				}
+
+				required := rule.GetSetting().GetIsApprovalRequired()
+				summary.ActivationApprovalRequired = required != nil && *required
+			}
+		}
+		result = append(result, summary)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,426 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package rolepim maps PIM for Microsoft Entra roles concepts to Microsoft Graph calls, so data sources only translate
+// between Terraform values and the types in this package.
+package rolepim
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	graphmodels "github.com/microsoftgraph/msgraph-beta-sdk-go/models"
+
+	"github.com/TelenorNorway/terraform-provider-azurepim/internal/conversions"
+)
+
+// ActionSelfActivate is the action of a schedule request made by a principal to activate one of its eligibilities.
+const ActionSelfActivate = "selfActivate"
+
+// ActionSelfDeactivate is the action of a schedule request made by a principal to end one of its activations.
+const ActionSelfDeactivate = "selfDeactivate"
+
+// ActionSelfExtend is the action of a schedule request made by a principal to extend one of its activations.
+const ActionSelfExtend = "selfExtend"
+
+// The kinds of schedule requests of directory roles.
+const (
+	// KindEligibility requests make principals eligible for a role.
+	KindEligibility = "eligibility"
+	// KindAssignment requests assign a role to principals, or activate an eligibility.
+	KindAssignment = "assignment"
+)
+
+// Client is the set of Graph operations used by the service.
+type Client interface {
+	// ListRoleAssignmentScheduleRequests lists the assignment schedule requests of directory roles matching an OData
+	// filter, following the pages of the response.
+	ListRoleAssignmentScheduleRequests(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleAssignmentScheduleRequestable, error)
+	// ListRoleEligibilityScheduleRequests lists the eligibility schedule requests of directory roles matching an OData
+	// filter, following the pages of the response.
+	ListRoleEligibilityScheduleRequests(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleEligibilityScheduleRequestable, error)
+	// ListRoleEligibilityScheduleInstances lists the eligibility schedule instances of directory roles matching an
+	// OData filter, following the pages of the response.
+	ListRoleEligibilityScheduleInstances(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleEligibilityScheduleInstanceable, error)
+	// ListRoleAssignmentScheduleInstances lists the assignment schedule instances of directory roles matching an OData
+	// filter, following the pages of the response.
+	ListRoleAssignmentScheduleInstances(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleAssignmentScheduleInstanceable, error)
+	// ListRoleManagementPolicyAssignments lists the role management policy assignments matching an OData filter,
+	// following the pages of the response.
+	ListRoleManagementPolicyAssignments(ctx context.Context, filter string) ([]graphmodels.UnifiedRoleManagementPolicyAssignmentable, error)
+	// CreateRoleAssignmentScheduleRequests creates several assignment schedule requests of directory roles in as few
+	// round trips as Graph allows. It returns the created requests and the error of each, in the order of bodies.
+	CreateRoleAssignmentScheduleRequests(ctx context.Context, bodies []graphmodels.UnifiedRoleAssignmentScheduleRequestable) ([]graphmodels.UnifiedRoleAssignmentScheduleRequestable, []error)
+}
+
+// ScheduleRequest is a request to assign, activate or remove a directory role.
+type ScheduleRequest struct {
+	ID               string
+	RoleDefinitionID string
+	PrincipalID      string
+	// DirectoryScopeID is the scope of the role, / for the whole tenant.
+	DirectoryScopeID string
+	// Action is e.g. adminAssign or selfActivate.
+	Action        string
+	Status        string
+	Justification string
+	// CreatedBy is the object ID of the user or application which created the request.
+	CreatedBy string
+	// CreatedDateTime, CompletedDateTime, StartDateTime and EndDateTime are formatted as RFC 3339.
+	CreatedDateTime   string
+	CompletedDateTime string
+	StartDateTime     string
+	EndDateTime       string
+	// ApprovalID is the ID of the approval of the request, empty when the request needs no approval.
+	ApprovalID string
+	// TargetScheduleID is the ID of the schedule created by the request.
+	TargetScheduleID string
+
+	// Raw is the schedule request as returned by Graph.
+	Raw serialization.Parsable
+}
+
+// Eligibility is the eligibility of a principal for a directory role, read from its schedule instance.
+type Eligibility struct {
+	ID               string
+	RoleDefinitionID string
+	PrincipalID      string
+	// DirectoryScopeID is the scope of the role, / for the whole tenant.
+	DirectoryScopeID string
+	// MemberType is direct when the principal is eligible itself, or group when it is eligible through a group.
+	MemberType string
+	// StartDateTime and EndDateTime are formatted as RFC 3339. EndDateTime is empty for permanent eligibilities.
+	StartDateTime string
+	EndDateTime   string
+}
+
+// ActiveAssignment is the active assignment of a principal to a directory role, read from its schedule instance.
+type ActiveAssignment struct {
+	ID               string
+	RoleDefinitionID string
+	PrincipalID      string
+	// DirectoryScopeID is the scope of the role, / for the whole tenant.
+	DirectoryScopeID string
+	// AssignmentType is Activated when the role was activated through an eligibility, or Assigned.
+	AssignmentType string
+	// MemberType is direct when the principal is assigned itself, or group when it is assigned through a group.
+	MemberType string
+	// StartDateTime and EndDateTime are formatted as RFC 3339. EndDateTime is empty for permanent assignments.
+	StartDateTime string
+	EndDateTime   string
+}
+
+// Activation is the activation of an eligibility for a directory role by the principal itself.
+type Activation struct {
+	RoleDefinitionID string
+	PrincipalID      string
+	// DirectoryScopeID is the scope of the role, / for the whole tenant.
+	DirectoryScopeID string
+	Justification    string
+	// Duration is how long the activation lasts, as ISO 8601 duration such as PT8H.
+	Duration string
+	// RequestID and Status are those of the assignment schedule request, set once it is created.
+	RequestID string
+	Status    string
+	// StartDateTime and EndDateTime are formatted as RFC 3339, set once the request is created. Extend keeps the start.
+	StartDateTime string
+	EndDateTime   string
+}
+
+// PolicyAssignment assigns a role management policy to a directory role.
+type PolicyAssignment struct {
+	ID               string
+	PolicyID         string
+	RoleDefinitionID string
+}
+
+// scheduleRequestable is implemented by the assignment and eligibility schedule requests of directory roles.
+type scheduleRequestable interface {
+	graphmodels.Requestable
+	GetRoleDefinitionId() *string
+	GetPrincipalId() *string
+	GetDirectoryScopeId() *string
+	GetAction() *string
+	GetJustification() *string
+	GetScheduleInfo() graphmodels.RequestScheduleable
+	GetTargetScheduleId() *string
+}
+
+// RequestFilter selects schedule requests. Empty fields match every request.
+type RequestFilter struct {
+	Status           string
+	PrincipalID      string
+	RoleDefinitionID string
+}
+
+// odata returns the OData filter of f, combined with the filters in extra.
+func (f RequestFilter) odata(extra ...string) string {
+	filters := extra
+	if f.Status != "" {
+		filters = append(filters, fmt.Sprintf("status eq '%s'", f.Status))
+	}
+	if f.PrincipalID != "" {
+		filters = append(filters, fmt.Sprintf("principalId eq '%s'", f.PrincipalID))
+	}
+	if f.RoleDefinitionID != "" {
+		filters = append(filters, fmt.Sprintf("roleDefinitionId eq '%s'", f.RoleDefinitionID))
+	}
+
+	return strings.Join(filters, " and ")
+}
+
+// Service reads PIM for Microsoft Entra roles, and activates the eligibilities of the caller.
+type Service struct {
+	client Client
+}
+
+func NewService(client Client) *Service {
+	return &Service{client: client}
+}
+
+// ListActivationRequests returns the requests of principals to activate their eligible directory roles matching f.
+func (s *Service) ListActivationRequests(ctx context.Context, f RequestFilter) ([]ScheduleRequest, error) {
+	filter := f.odata(fmt.Sprintf("action eq '%s'", ActionSelfActivate))
+	requests, err := s.client.ListRoleAssignmentScheduleRequests(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role assignment schedule requests with filter '%s': %w", filter, err)
+	}
+
+	result := make([]ScheduleRequest, 0, len(requests))
+	for _, r := range requests {
+		result = append(result, fromScheduleRequest(r))
+	}
+
+	return result, nil
+}
+
+// ActivationStatus returns the current status of the request of a, or the status of a if the request is not found.
+func (s *Service) ActivationStatus(ctx context.Context, a Activation) (string, error) {
+	requests, err := s.ListActivationRequests(ctx, RequestFilter{PrincipalID: a.PrincipalID, RoleDefinitionID: a.RoleDefinitionID})
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range requests {
+		if r.ID == a.RequestID {
+			return r.Status, nil
+		}
+	}
+
+	return a.Status, nil
+}
+
+// ListScheduleRequests returns the schedule requests of the given kind matching an OData filter as is, e.g. for
+// reporting on properties the other methods do not expose. An empty filter matches every request.
+func (s *Service) ListScheduleRequests(ctx context.Context, kind, filter string) ([]ScheduleRequest, error) {
+	var result []ScheduleRequest
+	switch kind {
+	case KindEligibility:
+		requests, err := s.client.ListRoleEligibilityScheduleRequests(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get role eligibility schedule requests with filter '%s': %w", filter, err)
+		}
+		for _, r := range requests {
+			result = append(result, fromScheduleRequest(r))
+		}
+	case KindAssignment:
+		requests, err := s.client.ListRoleAssignmentScheduleRequests(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get role assignment schedule requests with filter '%s': %w", filter, err)
+		}
+		for _, r := range requests {
+			result = append(result, fromScheduleRequest(r))
+		}
+	default:
+		return nil, fmt.Errorf("invalid schedule request kind: %s", kind)
+	}
+
+	return result, nil
+}
+
+// ListEligibilities returns the directory roles principalID is eligible for.
+func (s *Service) ListEligibilities(ctx context.Context, principalID string) ([]Eligibility, error) {
+	filter := fmt.Sprintf("principalId eq '%s'", principalID)
+	instances, err := s.client.ListRoleEligibilityScheduleInstances(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role eligibility schedule instances with filter '%s': %w", filter, err)
+	}
+
+	result := make([]Eligibility, 0, len(instances))
+	for _, i := range instances {
+		result = append(result, Eligibility{
+			ID:               conversions.String(i.GetId()),
+			RoleDefinitionID: conversions.String(i.GetRoleDefinitionId()),
+			PrincipalID:      conversions.String(i.GetPrincipalId()),
+			DirectoryScopeID: conversions.String(i.GetDirectoryScopeId()),
+			MemberType:       conversions.String(i.GetMemberType()),
+			StartDateTime:    conversions.Time(i.GetStartDateTime()),
+			EndDateTime:      conversions.Time(i.GetEndDateTime()),
+		})
+	}
+
+	return result, nil
+}
+
+// ListActiveAssignments returns the directory roles principalID currently holds, whether activated or assigned.
+func (s *Service) ListActiveAssignments(ctx context.Context, principalID string) ([]ActiveAssignment, error) {
+	filter := fmt.Sprintf("principalId eq '%s'", principalID)
+	instances, err := s.client.ListRoleAssignmentScheduleInstances(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role assignment schedule instances with filter '%s': %w", filter, err)
+	}
+
+	result := make([]ActiveAssignment, 0, len(instances))
+	for _, i := range instances {
+		result = append(result, ActiveAssignment{
+			ID:               conversions.String(i.GetId()),
+			RoleDefinitionID: conversions.String(i.GetRoleDefinitionId()),
+			PrincipalID:      conversions.String(i.GetPrincipalId()),
+			DirectoryScopeID: conversions.String(i.GetDirectoryScopeId()),
+			AssignmentType:   conversions.String(i.GetAssignmentType()),
+			MemberType:       conversions.String(i.GetMemberType()),
+			StartDateTime:    conversions.Time(i.GetStartDateTime()),
+			EndDateTime:      conversions.Time(i.GetEndDateTime()),
+		})
+	}
+
+	return result, nil
+}
+
+// ListPolicyAssignments returns the assignments of role management policies to directory roles for the whole tenant.
+// Every role has exactly one policy, which is created by Graph and cannot be replaced.
+func (s *Service) ListPolicyAssignments(ctx context.Context) ([]PolicyAssignment, error) {
+	filter := "scopeId eq '/' and scopeType eq 'Directory'"
+	assignments, err := s.client.ListRoleManagementPolicyAssignments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role management policy assignments with filter '%s': %w", filter, err)
+	}
+
+	result := make([]PolicyAssignment, 0, len(assignments))
+	for _, a := range assignments {
+		result = append(result, PolicyAssignment{
+			ID:               conversions.String(a.GetId()),
+			PolicyID:         conversions.String(a.GetPolicyId()),
+			RoleDefinitionID: conversions.String(a.GetRoleDefinitionId()),
+		})
+	}
+
+	return result, nil
+}
+
+// Activate activates the eligibilities of activations, in as few round trips to Graph as it allows. It returns the
+// activations with the fields of their request set, and the error of each, nil when its request was created.
+func (s *Service) Activate(ctx context.Context, activations []Activation) ([]Activation, []error) {
+	return s.sendActivationRequests(ctx, activations, ActionSelfActivate)
+}
+
+// Extend extends activations to last Duration from their StartDateTime, without activating them again, which could
+// require approval again. It returns the activations with the fields of their new request set, and the error of each.
+func (s *Service) Extend(ctx context.Context, activations []Activation) ([]Activation, []error) {
+	return s.sendActivationRequests(ctx, activations, ActionSelfExtend)
+}
+
+// Deactivate ends activations before they expire. It returns the error of each, nil when it was ended.
+func (s *Service) Deactivate(ctx context.Context, activations []Activation) []error {
+	_, errs := s.sendActivationRequests(ctx, activations, ActionSelfDeactivate)
+	return errs
+}
+
+// sendActivationRequests creates an assignment schedule request with action for each of activations in one go.
+func (s *Service) sendActivationRequests(ctx context.Context, activations []Activation, action string) ([]Activation, []error) {
+	result := slices.Clone(activations)
+	errs := make([]error, len(activations))
+
+	// The bodies which could be built, and the index of their activation.
+	var bodies []graphmodels.UnifiedRoleAssignmentScheduleRequestable
+	var indexes []int
+	for i, a := range activations {
+		body, err := newActivationRequest(a, action)
+		if err != nil {
+			errs[i] = fmt.Errorf("unable to create role assignment schedule request: %w", err)
+			continue
+		}
+		bodies = append(bodies, body)
+		indexes = append(indexes, i)
+	}
+
+	if len(bodies) == 0 {
+		return result, errs
+	}
+
+	created, createErrs := s.client.CreateRoleAssignmentScheduleRequests(ctx, bodies)
+	for j, i := range indexes {
+		if createErrs[j] != nil {
+			errs[i] = fmt.Errorf("unable to create role assignment schedule request: %w", createErrs[j])
+			continue
+		}
+
+		r := created[j]
+		result[i].RequestID = conversions.String(r.GetId())
+		result[i].Status = conversions.String(r.GetStatus())
+		if scheduleInfo := r.GetScheduleInfo(); scheduleInfo != nil {
+			result[i].StartDateTime = conversions.Time(scheduleInfo.GetStartDateTime())
+			result[i].EndDateTime = conversions.ScheduleEnd(scheduleInfo)
+		}
+	}
+
+	return result, errs
+}
+
+// newActivationRequest returns the role assignment schedule request of the principal of a with action. Only
+// activations and extensions have a schedule, which starts now unless a has a start.
+func newActivationRequest(a Activation, action string) (graphmodels.UnifiedRoleAssignmentScheduleRequestable, error) {
+	directoryScopeID := a.DirectoryScopeID
+	if directoryScopeID == "" {
+		directoryScopeID = "/"
+	}
+
+	requestBody := graphmodels.NewUnifiedRoleAssignmentScheduleRequest()
+	requestBody.SetRoleDefinitionId(&a.RoleDefinitionID)
+	requestBody.SetPrincipalId(&a.PrincipalID)
+	requestBody.SetDirectoryScopeId(&directoryScopeID)
+	requestBody.SetAction(&action)
+	if a.Justification != "" {
+		requestBody.SetJustification(&a.Justification)
+	}
+
+	if action != ActionSelfDeactivate {
+		scheduleInfo, err := conversions.DurationSchedule(a.StartDateTime, a.Duration)
+		if err != nil {
+			return nil, err
+		}
+		requestBody.SetScheduleInfo(scheduleInfo)
+	}
+
+	return requestBody, nil
+}
+
+func fromScheduleRequest(r scheduleRequestable) ScheduleRequest {
+	sr := ScheduleRequest{
+		ID:                conversions.String(r.GetId()),
+		RoleDefinitionID:  conversions.String(r.GetRoleDefinitionId()),
+		PrincipalID:       conversions.String(r.GetPrincipalId()),
+		DirectoryScopeID:  conversions.String(r.GetDirectoryScopeId()),
+		Action:            conversions.String(r.GetAction()),
+		Status:            conversions.String(r.GetStatus()),
+		Justification:     conversions.String(r.GetJustification()),
+		CreatedBy:         conversions.IdentityID(r.GetCreatedBy()),
+		CreatedDateTime:   conversions.Time(r.GetCreatedDateTime()),
+		CompletedDateTime: conversions.Time(r.GetCompletedDateTime()),
+		ApprovalID:        conversions.String(r.GetApprovalId()),
+		TargetScheduleID:  conversions.String(r.GetTargetScheduleId()),
+		Raw:               r,
+	}
+
+	if scheduleInfo := r.GetScheduleInfo(); scheduleInfo != nil {
+		sr.StartDateTime = conversions.Time(scheduleInfo.GetStartDateTime())
+		if expiration := scheduleInfo.GetExpiration(); expiration != nil {
+			sr.EndDateTime = conversions.Time(expiration.GetEndDateTime())
+		}
+	}
+
+	return sr
+}
@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package vcr records Microsoft Graph interactions to a cassette file and replays them,
+// so acceptance tests can run without a PIM licensed tenant.
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ModeEnvironmentVariable selects the mode of acceptance tests, either "record" or "replay".
+const ModeEnvironmentVariable = "AZUREPIM_VCR_MODE"
+
+// Mode is whether a Recorder records real interactions or replays them from a cassette.
+type Mode string
+
+const (
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// ModeFromEnv returns the mode set in ModeEnvironmentVariable, or false when recording and replaying is disabled.
+func ModeFromEnv() (Mode, bool, error) {
+	switch v := os.Getenv(ModeEnvironmentVariable); Mode(v) {
+	case "":
+		return "", false, nil
+	case ModeRecord, ModeReplay:
+		return Mode(v), true, nil
+	default:
+		return "", false, fmt.Errorf("invalid %s %q, must be %q or %q", ModeEnvironmentVariable, v, ModeRecord, ModeReplay)
+	}
+}
+
+// Interaction is a single recorded request and its response. Request headers are never recorded as they hold credentials.
+type Interaction struct {
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	StatusCode      int         `json:"status_code"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+}
+
+// Cassette is the file format of recorded interactions.
+type Cassette struct {
+	// Variables hold test inputs, e.g. object IDs, which must be the same when replaying.
+	Variables    map[string]string `json:"variables"`
+	Interactions []Interaction     `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Recorder for the cassette at path. In replay mode the cassette must exist.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: http.DefaultTransport,
+		cassette:  Cassette{Variables: map[string]string{}},
+	}
+
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &r.cassette); err != nil {
+		return nil, fmt.Errorf("unable to parse cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// Mode returns whether the recorder records or replays.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Variable returns a test input stored in the cassette.
+func (r *Recorder) Variable(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cassette.Variables[name]
+}
+
+// SetVariable stores a test input in the cassette when recording.
+func (r *Recorder) SetVariable(name, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Variables[name] = value
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request body: %w", err)
+		}
+		req.Body.Close()
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+
+	return r.record(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:          req.Method,
+		URL:             req.URL.String(),
+		RequestBody:     string(body),
+		StatusCode:      resp.StatusCode,
+		ResponseHeaders: responseHeaders(resp.Header),
+		ResponseBody:    string(respBody),
+	})
+
+	return resp, nil
+}
+
+// replay returns the first unused interaction with the same method and URL.
+// Request bodies are not compared, as they contain timestamps which differ between runs.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Method != req.Method || interaction.URL != req.URL.String() {
+			continue
+		}
+
+		r.used[i] = true
+
+		header := interaction.ResponseHeaders.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+			StatusCode:    interaction.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.ResponseBody))),
+			ContentLength: int64(len(interaction.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction left for %s %s in %s", req.Method, req.URL, r.path)
+}
+
+// Save writes the recorded interactions to the cassette. It does nothing when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("unable to create cassette directory: %w", err)
+	}
+
+	return os.WriteFile(r.path, append(b, '\n'), 0o644)
+}
+
+// responseHeaders keeps the headers which affect how the provider handles a response.
+func responseHeaders(h http.Header) http.Header {
+	result := http.Header{}
+	for _, k := range []string{"Content-Type", "Retry-After", "Location"} {
+		if v := h.Values(k); len(v) > 0 {
+			result[k] = v
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,80 @@
+package vcr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "secret")
+		io.WriteString(w, `{"value":[]}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.SetVariable("group_id", "00000000-0000-0000-0000-000000000001")
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/beta/things", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	if _, err := (&http.Client{Transport: rec}).Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Close()
+
+	replay, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := replay.Variable("group_id"); got != "00000000-0000-0000-0000-000000000001" {
+		t.Errorf("got variable %q", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/beta/things", strings.NewReader(`{"a":2}`))
+	resp, err := (&http.Client{Transport: replay}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := io.ReadAll(resp.Body)
+	if string(b) != `{"value":[]}` {
+		t.Errorf("got body %q", b)
+	}
+
+	if resp.Header.Get("Set-Cookie") != "" {
+		t.Errorf("unexpected header recorded: %v", resp.Header)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/beta/things", nil)
+	if _, err := (&http.Client{Transport: replay}).Do(req); err == nil {
+		t.Error("got nil error, want error when no interactions are left")
+	}
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(ModeEnvironmentVariable, "replay")
+	if mode, ok, err := ModeFromEnv(); err != nil || !ok || mode != ModeReplay {
+		t.Errorf("got %q %t %v", mode, ok, err)
+	}
+
+	t.Setenv(ModeEnvironmentVariable, "rewind")
+	if _, _, err := ModeFromEnv(); err == nil {
+		t.Error("got nil error, want error")
+	}
+}
@@ -4,12 +4,11 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"log"
 
 	"github.com/TelenorNorway/terraform-provider-azurepim/internal/provider"
-	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
 )
 
 // Run "go generate" to format example terraform files and generate the docs for the registry/website
@@ -37,12 +36,14 @@ func main() {
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	opts := providerserver.ServeOpts{
-		Address: "registry.terraform.io/TelenorNorway/azurepim",
-		Debug:   debug,
+	var opts []tf6server.ServeOpt
+	if debug {
+		opts = append(opts, tf6server.WithManagedDebug())
 	}
 
-	err := providerserver.Serve(context.Background(), provider.New(version), opts)
+	// The provider server is served directly rather than with providerserver.Serve, so it masks sensitive values in
+	// all logs.
+	err := tf6server.Serve("registry.terraform.io/TelenorNorway/azurepim", provider.NewProtocol6(provider.New(version)()), opts...)
 
 	if err != nil {
 		log.Fatal(err.Error())